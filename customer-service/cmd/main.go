@@ -1,9 +1,11 @@
 package main
 
 import (
+	"context"
 	"log"
 	"net/http"
 	"os"
+	"time"
 
 	"github.com/oliknight1/retail-isa-investment/customer-service/event"
 	"github.com/oliknight1/retail-isa-investment/customer-service/handler"
@@ -39,6 +41,8 @@ func main() {
 	svc := service.New(repo, pub)
 	ch := handler.New(svc, logger)
 
+	go svc.StartMaturityJob(context.Background(), 24*time.Hour)
+
 	http.HandleFunc("/health", func(w http.ResponseWriter, r *http.Request) {
 		w.WriteHeader(http.StatusOK)
 		w.Write([]byte(`{"status":"ok"}`))
@@ -49,6 +53,7 @@ func main() {
 	http.HandleFunc("POST /customer", ch.CreateCustomer)
 
 	http.HandleFunc("GET /customer/", ch.GetCustomerById)
+	http.HandleFunc("GET /customer/{id}/eligibility", ch.GetEligibility)
 
 	logger.Info("Customer service running on :8080")
 	err = http.ListenAndServe(":8080", nil)
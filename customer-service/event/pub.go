@@ -10,6 +10,7 @@ import (
 
 type EventPublisher interface {
 	PublishCustomer(customer model.Customer) error
+	Publish(subject string, payload any) error
 }
 
 type NatsPublisher struct {
@@ -34,3 +35,18 @@ func (p *NatsPublisher) PublishCustomer(customer model.Customer) error {
 
 	return err
 }
+
+func (p *NatsPublisher) Publish(subject string, payload any) error {
+	msg, err := json.Marshal(payload)
+	if err != nil {
+		log.Printf("failed to marshal event for subject %s: %v", subject, err)
+		return err
+	}
+	err = p.nc.Publish(subject, msg)
+
+	if err != nil {
+		log.Printf("failed to publish %s event: %v", subject, err)
+	}
+
+	return err
+}
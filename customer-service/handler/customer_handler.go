@@ -7,8 +7,10 @@ import (
 	"fmt"
 	"net/http"
 	"strings"
+	"time"
 
 	"github.com/oliknight1/retail-isa-investment/customer-service/internal"
+	"github.com/oliknight1/retail-isa-investment/customer-service/model"
 	"github.com/oliknight1/retail-isa-investment/customer-service/service"
 	"go.uber.org/zap"
 )
@@ -31,7 +33,12 @@ func (h *CustomerHandler) CreateCustomer(w http.ResponseWriter, r *http.Request)
 	)
 	internal.CustomerRequests.WithLabelValues("/customer", "POST").Inc()
 	var req struct {
-		Name string `json:"name"`
+		Name                    string          `json:"name"`
+		DateOfBirth             model.Date      `json:"dateOfBirth"`
+		RegisteredContactId     string          `json:"registeredContactId"`
+		NationalInsuranceNumber string          `json:"nationalInsuranceNumber"`
+		Residency               model.Residency `json:"residency"`
+		Address                 *model.Address  `json:"address"`
 	}
 
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
@@ -51,11 +58,43 @@ func (h *CustomerHandler) CreateCustomer(w http.ResponseWriter, r *http.Request)
 		http.Error(w, error.Error(), http.StatusBadRequest)
 		return
 	}
+	if req.DateOfBirth.IsZero() || req.DateOfBirth.After(time.Now()) {
+		internal.CustomerCreationFailures.WithLabelValues("invalid_date_of_birth").Inc()
+		error := errors.New("valid dateOfBirth required")
+		h.Logger.Warn("Error invalid date of birth",
+			zap.Error(error),
+		)
+		http.Error(w, error.Error(), http.StatusBadRequest)
+		return
+	}
 
 	h.Logger.Info("calling RegisterCustomer",
 		zap.String("name", req.Name),
 	)
-	customer, err := h.Service.RegisterCustomer(req.Name)
+	customer, err := h.Service.RegisterCustomer(model.Customer{
+		Name:                    req.Name,
+		DateOfBirth:             req.DateOfBirth,
+		RegisteredContactId:     req.RegisteredContactId,
+		NationalInsuranceNumber: req.NationalInsuranceNumber,
+		Residency:               req.Residency,
+		Address:                 req.Address,
+	})
+	if errors.Is(err, internal.ErrMissingRegisteredContact) || errors.Is(err, internal.ErrInvalidRegisteredContact) {
+		h.Logger.Warn("Error invalid registered contact",
+			zap.Error(err),
+		)
+		internal.CustomerCreationFailures.WithLabelValues("invalid_registered_contact").Inc()
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if errors.Is(err, internal.ErrInvalidNINO) || errors.Is(err, internal.ErrInvalidResidency) {
+		h.Logger.Warn("Error invalid eligibility details",
+			zap.Error(err),
+		)
+		internal.CustomerCreationFailures.WithLabelValues("invalid_eligibility_details").Inc()
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
 	if err != nil {
 		h.Logger.Error("RegisterCustomer failed",
 			zap.Error(err),
@@ -104,7 +143,12 @@ func (h *CustomerHandler) GetCustomerById(w http.ResponseWriter, r *http.Request
 	}
 
 	customer, err := h.Service.GetCustomerById(customerId)
-
+	if errors.Is(err, internal.ErrCustomerNotFound) {
+		internal.CustomerLookupFailures.WithLabelValues("not_found").Inc()
+		h.Logger.Warn("customer not found", zap.String("customer_id", customerId))
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
 	if err != nil {
 		internal.CustomerLookupFailures.WithLabelValues("internal_server_error").Inc()
 		h.Logger.Error("internal server error")
@@ -123,3 +167,40 @@ func (h *CustomerHandler) GetCustomerById(w http.ResponseWriter, r *http.Request
 	w.WriteHeader(http.StatusOK)
 	w.Write(buf.Bytes())
 }
+
+func (h *CustomerHandler) GetEligibility(w http.ResponseWriter, r *http.Request) {
+	internal.CustomerRequests.WithLabelValues("/customer/{id}/eligibility", "GET").Inc()
+	customerId := r.PathValue("id")
+	product := model.Product(r.URL.Query().Get("product"))
+
+	eligibility, err := h.Service.CheckEligibility(customerId, product)
+	if errors.Is(err, internal.ErrInvalidProduct) {
+		internal.CustomerLookupFailures.WithLabelValues("invalid_product").Inc()
+		h.Logger.Warn("Error invalid product", zap.Error(err))
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if errors.Is(err, internal.ErrCustomerNotFound) {
+		internal.CustomerLookupFailures.WithLabelValues("not_found").Inc()
+		h.Logger.Warn("customer not found", zap.String("customer_id", customerId))
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		internal.CustomerLookupFailures.WithLabelValues("internal_server_error").Inc()
+		h.Logger.Error("CheckEligibility failed", zap.Error(err), zap.String("customer_id", customerId))
+		http.Error(w, "internal server error", http.StatusInternalServerError)
+		return
+	}
+
+	var buf bytes.Buffer
+	if err := json.NewEncoder(&buf).Encode(eligibility); err != nil {
+		h.Logger.Error("error encoding response", zap.Error(err))
+		internal.CustomerLookupFailures.WithLabelValues("encoding_error").Inc()
+		http.Error(w, "failed to encode response", http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	w.Write(buf.Bytes())
+}
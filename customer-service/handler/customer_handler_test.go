@@ -8,38 +8,46 @@ import (
 	"net/http/httptest"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/oliknight1/retail-isa-investment/customer-service/handler"
+	"github.com/oliknight1/retail-isa-investment/customer-service/internal"
 	"github.com/oliknight1/retail-isa-investment/customer-service/model"
 	"go.uber.org/zap"
 )
 
 type mockService struct {
-	registerFn func(name string) (model.Customer, error)
-	getById    func(id string) (*model.Customer, error)
+	registerFn    func(details model.Customer) (model.Customer, error)
+	getById       func(id string) (*model.Customer, error)
+	eligibilityFn func(id string, product model.Product) (*model.Eligibility, error)
 }
 
-func (s *mockService) RegisterCustomer(name string) (model.Customer, error) {
-	return s.registerFn(name)
+func (s *mockService) RegisterCustomer(details model.Customer) (model.Customer, error) {
+	return s.registerFn(details)
 }
 
 func (s *mockService) GetCustomerById(id string) (*model.Customer, error) {
 	return s.getById(id)
 }
 
+func (s *mockService) CheckEligibility(id string, product model.Product) (*model.Eligibility, error) {
+	return s.eligibilityFn(id, product)
+}
+
 func TestCreateCustomerSuccess(t *testing.T) {
 	expectedName := "Oli"
 	mockService := &mockService{
-		registerFn: func(name string) (model.Customer, error) {
-			if name != expectedName {
-				t.Fatalf("expected name %s, recieved %s", expectedName, name)
+		registerFn: func(details model.Customer) (model.Customer, error) {
+			if details.Name != expectedName {
+				t.Fatalf("expected name %s, recieved %s", expectedName, details.Name)
 			}
-			return model.Customer{Id: "1234", Name: name}, nil
+			details.Id = "1234"
+			return details, nil
 		},
 	}
 	handler := &handler.CustomerHandler{Service: mockService, Logger: zap.NewNop()}
 
-	reqBody := fmt.Sprintf(`{"name":"%s"}`, expectedName)
+	reqBody := fmt.Sprintf(`{"name":"%s","dateOfBirth":"1990-05-21"}`, expectedName)
 	req := httptest.NewRequest(http.MethodPost, "/customer", strings.NewReader(reqBody))
 	req.Header.Set("Content-Type", "application/json")
 
@@ -59,12 +67,16 @@ func TestCreateCustomerSuccess(t *testing.T) {
 	if resp.Name != expectedName {
 		t.Errorf("expected name %s, got %s", expectedName, resp.Name)
 	}
+	if resp.DateOfBirth != model.NewDate(1990, time.May, 21) {
+		t.Errorf("expected date of birth 1990-05-21, got %s", resp.DateOfBirth)
+	}
 }
 
 func TestInvalidJSON(t *testing.T) {
 	mockService := &mockService{
-		registerFn: func(name string) (model.Customer, error) {
-			return model.Customer{Id: "1234", Name: name}, nil
+		registerFn: func(details model.Customer) (model.Customer, error) {
+			details.Id = "1234"
+			return details, nil
 		},
 	}
 	handler := &handler.CustomerHandler{Service: mockService, Logger: zap.NewNop()}
@@ -86,16 +98,17 @@ func TestInvalidJSON(t *testing.T) {
 func TestInavlidPayload(t *testing.T) {
 	expectedName := "Oli"
 	mockService := &mockService{
-		registerFn: func(name string) (model.Customer, error) {
-			if name != expectedName {
-				t.Fatalf("expected name %s, recieved %s", expectedName, name)
+		registerFn: func(details model.Customer) (model.Customer, error) {
+			if details.Name != expectedName {
+				t.Fatalf("expected name %s, recieved %s", expectedName, details.Name)
 			}
-			return model.Customer{Id: "1234", Name: name}, nil
+			details.Id = "1234"
+			return details, nil
 		},
 	}
 	handler := &handler.CustomerHandler{Service: mockService, Logger: zap.NewNop()}
 
-	reqBody := `{"name":""}`
+	reqBody := `{"name":"","dateOfBirth":"1990-05-21"}`
 	req := httptest.NewRequest(http.MethodPost, "/customer", strings.NewReader(reqBody))
 	req.Header.Set("Content-Type", "application/json")
 
@@ -111,13 +124,13 @@ func TestInavlidPayload(t *testing.T) {
 
 func TestInavlidService(t *testing.T) {
 	mockService := &mockService{
-		registerFn: func(name string) (model.Customer, error) {
+		registerFn: func(details model.Customer) (model.Customer, error) {
 			return model.Customer{}, errors.New("service error")
 		},
 	}
 	handler := &handler.CustomerHandler{Service: mockService, Logger: zap.NewNop()}
 
-	reqBody := `{"name":"Oli"}`
+	reqBody := `{"name":"Oli","dateOfBirth":"1990-05-21"}`
 	req := httptest.NewRequest(http.MethodPost, "/customer", strings.NewReader(reqBody))
 	req.Header.Set("Content-Type", "application/json")
 
@@ -129,5 +142,122 @@ func TestInavlidService(t *testing.T) {
 	}
 }
 
+func TestInvalidDateOfBirth(t *testing.T) {
+	tests := []struct {
+		name    string
+		reqBody string
+	}{
+		{name: "missing date of birth", reqBody: `{"name":"Oli"}`},
+		{name: "badly formatted date of birth", reqBody: `{"name":"Oli","dateOfBirth":"21/05/1990"}`},
+		{name: "date of birth in the future", reqBody: fmt.Sprintf(`{"name":"Oli","dateOfBirth":"%d-01-01"}`, time.Now().Year()+1)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockService := &mockService{
+				registerFn: func(details model.Customer) (model.Customer, error) {
+					t.Fatal("should not register customer with invalid date of birth")
+					return model.Customer{}, nil
+				},
+			}
+			handler := &handler.CustomerHandler{Service: mockService, Logger: zap.NewNop()}
+
+			req := httptest.NewRequest(http.MethodPost, "/customer", strings.NewReader(tt.reqBody))
+			recorder := httptest.NewRecorder()
+			handler.CreateCustomer(recorder, req)
+
+			if recorder.Code != http.StatusBadRequest {
+				t.Errorf("expected status %d, got %d", http.StatusBadRequest, recorder.Code)
+			}
+		})
+	}
+}
+
 // TODO: Test if content type if we always expect JSON
 func TestInvalidContentType(t *testing.T) {}
+
+func TestCreateCustomerInvalidNINO(t *testing.T) {
+	mockService := &mockService{
+		registerFn: func(details model.Customer) (model.Customer, error) {
+			return model.Customer{}, internal.ErrInvalidNINO
+		},
+	}
+	handler := &handler.CustomerHandler{Service: mockService, Logger: zap.NewNop()}
+
+	reqBody := `{"name":"Oli","dateOfBirth":"1990-05-21","nationalInsuranceNumber":"GB123456A"}`
+	req := httptest.NewRequest(http.MethodPost, "/customer", strings.NewReader(reqBody))
+	recorder := httptest.NewRecorder()
+	handler.CreateCustomer(recorder, req)
+
+	if status := recorder.Code; status != http.StatusBadRequest {
+		t.Errorf("expected status %d, got %d", http.StatusBadRequest, status)
+	}
+}
+
+func TestGetEligibility(t *testing.T) {
+	mockService := &mockService{
+		eligibilityFn: func(id string, product model.Product) (*model.Eligibility, error) {
+			if id != "1234" || product != model.LifetimeISA {
+				t.Fatalf("unexpected eligibility request for %s %s", id, product)
+			}
+			return &model.Eligibility{
+				CustomerId: id,
+				Product:    product,
+				Reasons:    []model.IneligibilityReason{model.ReasonNotResident},
+			}, nil
+		},
+	}
+	handler := &handler.CustomerHandler{Service: mockService, Logger: zap.NewNop()}
+
+	req := httptest.NewRequest(http.MethodGet, "/customer/1234/eligibility?product=lifetime", nil)
+	req.SetPathValue("id", "1234")
+	recorder := httptest.NewRecorder()
+	handler.GetEligibility(recorder, req)
+
+	if status := recorder.Code; status != http.StatusOK {
+		t.Fatalf("expected status %d, got %d", http.StatusOK, status)
+	}
+	var resp model.Eligibility
+	if err := json.NewDecoder(recorder.Body).Decode(&resp); err != nil {
+		t.Fatalf("failed to decode response: %v", err)
+	}
+	if resp.Eligible || len(resp.Reasons) != 1 || resp.Reasons[0] != model.ReasonNotResident {
+		t.Errorf("unexpected eligibility: %+v", resp)
+	}
+}
+
+func TestGetEligibilityInvalidProduct(t *testing.T) {
+	mockService := &mockService{
+		eligibilityFn: func(id string, product model.Product) (*model.Eligibility, error) {
+			return nil, internal.ErrInvalidProduct
+		},
+	}
+	handler := &handler.CustomerHandler{Service: mockService, Logger: zap.NewNop()}
+
+	req := httptest.NewRequest(http.MethodGet, "/customer/1234/eligibility?product=pension", nil)
+	req.SetPathValue("id", "1234")
+	recorder := httptest.NewRecorder()
+	handler.GetEligibility(recorder, req)
+
+	if status := recorder.Code; status != http.StatusBadRequest {
+		t.Errorf("expected status %d, got %d", http.StatusBadRequest, status)
+	}
+}
+
+func TestGetEligibilityUnknownCustomer(t *testing.T) {
+	mockService := &mockService{
+		eligibilityFn: func(id string, product model.Product) (*model.Eligibility, error) {
+			return nil, internal.ErrCustomerNotFound
+		},
+	}
+	handler := &handler.CustomerHandler{Service: mockService, Logger: zap.NewNop()}
+
+	req := httptest.NewRequest(http.MethodGet, "/customer/1234/eligibility?product=cash", nil)
+	req.SetPathValue("id", "1234")
+	recorder := httptest.NewRecorder()
+	handler.GetEligibility(recorder, req)
+
+	if status := recorder.Code; status != http.StatusNotFound {
+		t.Errorf("expected status %d, got %d", http.StatusNotFound, status)
+	}
+}
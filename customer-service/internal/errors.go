@@ -0,0 +1,12 @@
+package internal
+
+import "errors"
+
+var (
+	ErrMissingRegisteredContact = errors.New("customers under 18 require a registered contact")
+	ErrInvalidRegisteredContact = errors.New("registered contact must be an existing customer aged 18 or over")
+	ErrInvalidNINO              = errors.New("invalid National Insurance number")
+	ErrInvalidResidency         = errors.New("residency must be uk_resident, crown_employee or non_resident")
+	ErrInvalidProduct           = errors.New("product must be stocks_and_shares, cash, lifetime or junior")
+	ErrCustomerNotFound         = errors.New("customer not found")
+)
@@ -1,6 +1,24 @@
 package model
 
+import "time"
+
+// AdultAge is the age a customer no longer needs a registered contact
+const AdultAge = 18
+
 type Customer struct {
-	Id   string `json:"id"`
-	Name string `json:"name"`
+	Id          string `json:"id"`
+	Name        string `json:"name"`
+	DateOfBirth Date   `json:"dateOfBirth"`
+	// RegisteredContactId is the parent or guardian who operates a child customer's accounts
+	RegisteredContactId     string    `json:"registeredContactId,omitempty"`
+	NationalInsuranceNumber string    `json:"nationalInsuranceNumber,omitempty"`
+	Residency               Residency `json:"residency,omitempty"`
+	Address                 *Address  `json:"address,omitempty"`
+}
+
+// JisaMatured is published when a child customer turns 18 and takes control of their Junior ISA
+type JisaMatured struct {
+	CustomerId          string    `json:"customerId"`
+	RegisteredContactId string    `json:"registeredContactId"`
+	MaturedAt           time.Time `json:"maturedAt"`
 }
@@ -0,0 +1,30 @@
+package model_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/oliknight1/retail-isa-investment/customer-service/model"
+)
+
+func TestAgeOn(t *testing.T) {
+	dob := model.NewDate(2000, time.June, 15)
+
+	tests := []struct {
+		name     string
+		on       time.Time
+		expected int
+	}{
+		{name: "day before birthday", on: time.Date(2018, time.June, 14, 23, 0, 0, 0, time.UTC), expected: 17},
+		{name: "on birthday", on: time.Date(2018, time.June, 15, 0, 0, 0, 0, time.UTC), expected: 18},
+		{name: "later in the year", on: time.Date(2018, time.December, 1, 0, 0, 0, 0, time.UTC), expected: 18},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if actual := dob.AgeOn(tt.on); actual != tt.expected {
+				t.Errorf("expected age %d, got %d", tt.expected, actual)
+			}
+		})
+	}
+}
@@ -0,0 +1,66 @@
+package model
+
+// Residency is where a customer lives for tax purposes. Crown employees serving overseas,
+// and their spouses, are treated as UK resident for ISAs
+type Residency string
+
+const (
+	UKResident    Residency = "uk_resident"
+	CrownEmployee Residency = "crown_employee"
+	NonResident   Residency = "non_resident"
+)
+
+func (r Residency) Valid() bool {
+	switch r {
+	case UKResident, CrownEmployee, NonResident:
+		return true
+	}
+	return false
+}
+
+type Address struct {
+	Line1    string `json:"line1"`
+	Line2    string `json:"line2,omitempty"`
+	City     string `json:"city"`
+	Postcode string `json:"postcode"`
+	Country  string `json:"country"`
+}
+
+// Product is the ISA product a customer's eligibility is checked for, named as in
+// investment-service
+type Product string
+
+const (
+	StocksAndSharesISA Product = "stocks_and_shares"
+	CashISA            Product = "cash"
+	LifetimeISA        Product = "lifetime"
+	JuniorISA          Product = "junior"
+)
+
+// Valid reports whether p is a known product
+func (p Product) Valid() bool {
+	switch p {
+	case StocksAndSharesISA, CashISA, LifetimeISA, JuniorISA:
+		return true
+	}
+	return false
+}
+
+// IneligibilityReason is a specific reason a customer cannot subscribe to a product
+type IneligibilityReason string
+
+const (
+	ReasonNotResident      IneligibilityReason = "not_uk_resident"
+	ReasonMissingNINO      IneligibilityReason = "missing_national_insurance_number"
+	ReasonMissingAddress   IneligibilityReason = "missing_address"
+	ReasonMissingDOB       IneligibilityReason = "missing_date_of_birth"
+	ReasonUnknownResidency IneligibilityReason = "residency_unknown"
+)
+
+// Eligibility is whether a customer can subscribe to a product, with every reason they cannot
+type Eligibility struct {
+	CustomerId string                `json:"customerId"`
+	Product    Product               `json:"product"`
+	Eligible   bool                  `json:"eligible"`
+	Reasons    []IneligibilityReason `json:"reasons"`
+}
@@ -0,0 +1,31 @@
+package model
+
+import (
+	"regexp"
+	"slices"
+	"strings"
+)
+
+var ninoFormat = regexp.MustCompile(`^[A-Z]{2}[0-9]{6}[A-D]$`)
+
+// prefixes HMRC never allocates
+var invalidNinoPrefixes = []string{"BG", "GB", "KN", "NK", "NT", "TN", "ZZ"}
+
+// NormaliseNINO upper cases a National Insurance number and strips any spaces, so
+// "qq 12 34 56 c" becomes "QQ123456C"
+func NormaliseNINO(nino string) string {
+	return strings.ToUpper(strings.ReplaceAll(nino, " ", ""))
+}
+
+// ValidNINO reports whether nino is a National Insurance number HMRC could have issued:
+// two prefix letters, six digits and a suffix of A to D. D, F, I, Q, U and V are never used
+// in the prefix, O is never the second letter, and some prefixes are never allocated
+func ValidNINO(nino string) bool {
+	if !ninoFormat.MatchString(nino) {
+		return false
+	}
+	if strings.ContainsAny(nino[0:1], "DFIQUV") || strings.ContainsAny(nino[1:2], "DFIOQUV") {
+		return false
+	}
+	return !slices.Contains(invalidNinoPrefixes, nino[:2])
+}
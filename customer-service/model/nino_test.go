@@ -0,0 +1,38 @@
+package model_test
+
+import (
+	"testing"
+
+	"github.com/oliknight1/retail-isa-investment/customer-service/model"
+)
+
+func TestValidNINO(t *testing.T) {
+	tests := []struct {
+		nino     string
+		expected bool
+	}{
+		{"AB123456C", true},
+		{"JG103759A", true},
+		{"AB123456", false},
+		{"AB123456E", false},
+		{"A1234567C", false},
+		{"DA123456A", false},
+		{"AO123456A", false},
+		{"QQ123456A", false},
+		{"BG123456A", false},
+		{"GB123456A", false},
+		{"ZZ123456A", false},
+		{"ab123456c", false},
+	}
+	for _, tt := range tests {
+		if actual := model.ValidNINO(tt.nino); actual != tt.expected {
+			t.Errorf("ValidNINO(%q) = %v, expected %v", tt.nino, actual, tt.expected)
+		}
+	}
+}
+
+func TestNormaliseNINO(t *testing.T) {
+	if actual := model.NormaliseNINO("ab 12 34 56 c"); actual != "AB123456C" {
+		t.Errorf("expected AB123456C, got %s", actual)
+	}
+}
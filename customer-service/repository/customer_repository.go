@@ -3,18 +3,23 @@ package repository
 import (
 	"fmt"
 	"log"
+	"sync"
 
 	"github.com/google/uuid"
+	"github.com/oliknight1/retail-isa-investment/customer-service/internal"
 	"github.com/oliknight1/retail-isa-investment/customer-service/model"
 )
 
 type Repository interface {
 	Create(customer model.Customer) error
 	GetById(id string) (*model.Customer, error)
+	Update(customer model.Customer) error
+	List() ([]model.Customer, error)
 }
 
 type InMemDb struct {
 	Store map[string]model.Customer
+	mu    sync.RWMutex
 }
 
 func New() *InMemDb {
@@ -23,7 +28,6 @@ func New() *InMemDb {
 	}
 }
 
-// TODO: add mutex to write lock
 func (db *InMemDb) Create(customer model.Customer) error {
 	//TODO: move this validation to the service layer
 	if customer.Id == "" {
@@ -32,11 +36,17 @@ func (db *InMemDb) Create(customer model.Customer) error {
 	if customer.Name == "" {
 		return fmt.Errorf("customer name cannot be empty")
 	}
+	if customer.DateOfBirth.IsZero() {
+		return fmt.Errorf("customer date of birth cannot be empty")
+	}
 
 	if err := uuid.Validate(customer.Id); err != nil {
 		return fmt.Errorf("invalid customer ID: %w", err)
 	}
 
+	db.mu.Lock()
+	defer db.mu.Unlock()
+
 	db.Store[customer.Id] = customer
 	return nil
 }
@@ -45,13 +55,38 @@ func (db *InMemDb) Create(customer model.Customer) error {
 func (db *InMemDb) GetById(id string) (*model.Customer, error) {
 	if err := uuid.Validate(id); err != nil {
 		log.Printf("invalid UUID provided: %s, error: %v", id, err)
-		return nil, err
+		return nil, fmt.Errorf("%w: %s is not a valid id", internal.ErrCustomerNotFound, id)
 	}
+	db.mu.RLock()
+	defer db.mu.RUnlock()
+
 	c, ok := db.Store[id]
 	if !ok {
-		err := fmt.Errorf("customer with ID %s not found", id)
+		err := fmt.Errorf("%w: %s", internal.ErrCustomerNotFound, id)
 		log.Println(err)
 		return nil, err
 	}
 	return &c, nil
 }
+
+func (db *InMemDb) Update(customer model.Customer) error {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+
+	if _, ok := db.Store[customer.Id]; !ok {
+		return fmt.Errorf("%w: %s", internal.ErrCustomerNotFound, customer.Id)
+	}
+	db.Store[customer.Id] = customer
+	return nil
+}
+
+func (db *InMemDb) List() ([]model.Customer, error) {
+	db.mu.RLock()
+	defer db.mu.RUnlock()
+
+	customers := make([]model.Customer, 0, len(db.Store))
+	for _, c := range db.Store {
+		customers = append(customers, c)
+	}
+	return customers, nil
+}
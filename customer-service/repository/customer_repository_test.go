@@ -1,10 +1,13 @@
 package repository_test
 
 import (
-	"fmt"
+	"errors"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/google/uuid"
+	"github.com/oliknight1/retail-isa-investment/customer-service/internal"
 	"github.com/oliknight1/retail-isa-investment/customer-service/model"
 	"github.com/oliknight1/retail-isa-investment/customer-service/repository"
 )
@@ -20,8 +23,9 @@ func TestCreateCustomerValidation(t *testing.T) {
 		{
 			name: "valid customer",
 			customer: model.Customer{
-				Id:   validId,
-				Name: "Oli",
+				Id:          validId,
+				Name:        "Oli",
+				DateOfBirth: model.NewDate(1990, time.May, 21),
 			},
 			expectErr: false,
 		},
@@ -41,6 +45,14 @@ func TestCreateCustomerValidation(t *testing.T) {
 			},
 			expectErr: true,
 		},
+		{
+			name: "missing date of birth",
+			customer: model.Customer{
+				Id:   validId,
+				Name: "Oli",
+			},
+			expectErr: true,
+		},
 		{
 			name: "missing name",
 			customer: model.Customer{
@@ -115,9 +127,8 @@ func TestGetByIdNotFound(t *testing.T) {
 		t.Fatal("expected error for missing customer, got nil")
 	}
 
-	expectedErr := fmt.Sprintf("customer with ID %s not found", missingID)
-	if err.Error() != expectedErr {
-		t.Errorf("unexpected error: got %v, want %v", err.Error(), expectedErr)
+	if !errors.Is(err, internal.ErrCustomerNotFound) || !strings.Contains(err.Error(), missingID) {
+		t.Errorf("expected customer not found error naming %s, got %v", missingID, err)
 	}
 	if c != nil {
 		t.Errorf("expected nil customer, got %+v", c)
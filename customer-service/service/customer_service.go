@@ -1,17 +1,23 @@
 package service
 
 import (
+	"context"
 	"errors"
+	"fmt"
+	"log"
+	"time"
 
 	"github.com/google/uuid"
 	"github.com/oliknight1/retail-isa-investment/customer-service/event"
+	"github.com/oliknight1/retail-isa-investment/customer-service/internal"
 	"github.com/oliknight1/retail-isa-investment/customer-service/model"
 	"github.com/oliknight1/retail-isa-investment/customer-service/repository"
 )
 
 type CustomerService interface {
-	RegisterCustomer(name string) (model.Customer, error)
+	RegisterCustomer(details model.Customer) (model.Customer, error)
 	GetCustomerById(id string) (*model.Customer, error)
+	CheckEligibility(id string, product model.Product) (*model.Eligibility, error)
 }
 
 type customerServiceImpl struct {
@@ -26,10 +32,24 @@ func New(repo repository.Repository, publisher event.EventPublisher) *customerSe
 	}
 }
 
-func (cs *customerServiceImpl) RegisterCustomer(name string) (model.Customer, error) {
-	customer := model.Customer{
-		Id:   uuid.New().String(),
-		Name: name,
+func (cs *customerServiceImpl) RegisterCustomer(details model.Customer) (model.Customer, error) {
+	customer := details
+	customer.Id = uuid.New().String()
+
+	if customer.NationalInsuranceNumber != "" {
+		customer.NationalInsuranceNumber = model.NormaliseNINO(customer.NationalInsuranceNumber)
+		if !model.ValidNINO(customer.NationalInsuranceNumber) {
+			return model.Customer{}, internal.ErrInvalidNINO
+		}
+	}
+	if customer.Residency != "" && !customer.Residency.Valid() {
+		return model.Customer{}, internal.ErrInvalidResidency
+	}
+
+	if customer.DateOfBirth.AgeOn(time.Now()) < model.AdultAge {
+		if err := cs.validateRegisteredContact(customer.RegisteredContactId); err != nil {
+			return model.Customer{}, err
+		}
 	}
 
 	if err := cs.repo.Create(customer); err != nil {
@@ -48,3 +68,64 @@ func (cs *customerServiceImpl) GetCustomerById(id string) (*model.Customer, erro
 	}
 	return cs.repo.GetById(id)
 }
+
+// MatureJuniorCustomers hands control to every child customer who has turned 18, publishing
+// customer.jisa.matured so their Junior ISA can be converted and then removing their registered
+// contact. The event goes first so a failed update is retried with the next run rather than
+// losing it, and one customer failing does not hold up the rest
+func (cs *customerServiceImpl) MatureJuniorCustomers(now time.Time) error {
+	customers, err := cs.repo.List()
+	if err != nil {
+		return err
+	}
+
+	var errs []error
+	for _, customer := range customers {
+		if customer.RegisteredContactId == "" || customer.DateOfBirth.AgeOn(now) < model.AdultAge {
+			continue
+		}
+
+		matured := model.JisaMatured{
+			CustomerId:          customer.Id,
+			RegisteredContactId: customer.RegisteredContactId,
+			MaturedAt:           now,
+		}
+		if err := cs.publisher.Publish("customer.jisa.matured", matured); err != nil {
+			errs = append(errs, fmt.Errorf("publishing maturity of customer %s: %w", customer.Id, err))
+			continue
+		}
+		customer.RegisteredContactId = ""
+		if err := cs.repo.Update(customer); err != nil {
+			errs = append(errs, fmt.Errorf("updating matured customer %s: %w", customer.Id, err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// StartMaturityJob runs MatureJuniorCustomers every interval until ctx is cancelled
+func (cs *customerServiceImpl) StartMaturityJob(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case now := <-ticker.C:
+			if err := cs.MatureJuniorCustomers(now); err != nil {
+				log.Printf("error maturing junior customers: %v", err)
+			}
+		}
+	}
+}
+
+func (cs *customerServiceImpl) validateRegisteredContact(contactId string) error {
+	if contactId == "" {
+		return internal.ErrMissingRegisteredContact
+	}
+	contact, err := cs.repo.GetById(contactId)
+	if err != nil || contact.DateOfBirth.AgeOn(time.Now()) < model.AdultAge {
+		return internal.ErrInvalidRegisteredContact
+	}
+	return nil
+}
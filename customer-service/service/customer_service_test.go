@@ -3,8 +3,11 @@ package service_test
 import (
 	"errors"
 	"testing"
+	"time"
 
+	"github.com/oliknight1/retail-isa-investment/customer-service/internal"
 	"github.com/oliknight1/retail-isa-investment/customer-service/model"
+	"github.com/oliknight1/retail-isa-investment/customer-service/repository"
 	"github.com/oliknight1/retail-isa-investment/customer-service/service"
 )
 
@@ -20,14 +23,27 @@ func (m *mockRepo) GetById(id string) (*model.Customer, error) {
 	return nil, nil
 }
 
+func (m *mockRepo) Update(customer model.Customer) error {
+	return nil
+}
+
+func (m *mockRepo) List() ([]model.Customer, error) {
+	return nil, nil
+}
+
 type mockPublisher struct {
 	publishFn func(customer model.Customer) error
+	publish   func(subject string, payload any) error
 }
 
 func (m *mockPublisher) PublishCustomer(c model.Customer) error {
 	return m.publishFn(c)
 }
 
+func (m *mockPublisher) Publish(subject string, payload any) error {
+	return m.publish(subject, payload)
+}
+
 func TestRegisterSuccess(t *testing.T) {
 	expectedName := "Oli"
 	repo := &mockRepo{
@@ -49,7 +65,8 @@ func TestRegisterSuccess(t *testing.T) {
 
 	svc := service.New(repo, pub)
 
-	customer, err := svc.RegisterCustomer(expectedName)
+	dateOfBirth := model.NewDate(1990, time.May, 21)
+	customer, err := svc.RegisterCustomer(model.Customer{Name: expectedName, DateOfBirth: dateOfBirth})
 
 	if err != nil {
 		t.Fatalf("unexpected error: %v", err)
@@ -60,6 +77,9 @@ func TestRegisterSuccess(t *testing.T) {
 	if customer.Id == "" {
 		t.Errorf("expected non-empty UUID")
 	}
+	if customer.DateOfBirth != dateOfBirth {
+		t.Errorf("expected date of birth %s, got %s", dateOfBirth, customer.DateOfBirth)
+	}
 }
 
 func TestRepoFails(t *testing.T) {
@@ -74,7 +94,7 @@ func TestRepoFails(t *testing.T) {
 		},
 	}
 	svc := service.New(repo, pub)
-	_, err := svc.RegisterCustomer("Oli")
+	_, err := svc.RegisterCustomer(model.Customer{Name: "Oli", DateOfBirth: model.NewDate(1990, time.May, 21)})
 	if err == nil {
 		t.Fatal("expected error, got nil")
 	}
@@ -92,9 +112,169 @@ func TestPubFails(t *testing.T) {
 		},
 	}
 	svc := service.New(repo, pub)
-	_, err := svc.RegisterCustomer("Oli")
+	_, err := svc.RegisterCustomer(model.Customer{Name: "Oli", DateOfBirth: model.NewDate(1990, time.May, 21)})
 	if err == nil {
 		t.Fatal("expected error, got nil")
 	}
 
 }
+
+func TestRegisterChildRequiresAdultRegisteredContact(t *testing.T) {
+	now := time.Now()
+	child := model.NewDate(now.Year()-10, time.January, 1)
+
+	repo := repository.New()
+	pub := &mockPublisher{
+		publishFn: func(customer model.Customer) error {
+			return nil
+		},
+	}
+	svc := service.New(repo, pub)
+
+	parent, err := svc.RegisterCustomer(model.Customer{Name: "Parent", DateOfBirth: model.NewDate(1980, time.March, 3)})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	sibling, err := svc.RegisterCustomer(model.Customer{Name: "Sibling", DateOfBirth: child, RegisteredContactId: parent.Id})
+	if err != nil {
+		t.Fatalf("unexpected error registering child with parent contact: %v", err)
+	}
+
+	tests := []struct {
+		name        string
+		contactId   string
+		expectedErr error
+	}{
+		{name: "no registered contact", contactId: "", expectedErr: internal.ErrMissingRegisteredContact},
+		{name: "unknown registered contact", contactId: "not-a-customer", expectedErr: internal.ErrInvalidRegisteredContact},
+		{name: "registered contact is a child", contactId: sibling.Id, expectedErr: internal.ErrInvalidRegisteredContact},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := svc.RegisterCustomer(model.Customer{Name: "Child", DateOfBirth: child, RegisteredContactId: tt.contactId})
+			if !errors.Is(err, tt.expectedErr) {
+				t.Errorf("expected error %v, got %v", tt.expectedErr, err)
+			}
+		})
+	}
+}
+
+func TestMatureJuniorCustomers(t *testing.T) {
+	now := time.Now()
+	repo := repository.New()
+	var published []model.JisaMatured
+	pub := &mockPublisher{
+		publishFn: func(customer model.Customer) error {
+			return nil
+		},
+		publish: func(subject string, payload any) error {
+			if subject != "customer.jisa.matured" {
+				t.Errorf("unexpected subject: %s", subject)
+			}
+			published = append(published, payload.(model.JisaMatured))
+			return nil
+		},
+	}
+	svc := service.New(repo, pub)
+
+	parent, err := svc.RegisterCustomer(model.Customer{Name: "Parent", DateOfBirth: model.NewDate(1980, time.March, 3)})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	turningEighteen, err := svc.RegisterCustomer(model.Customer{
+		Name:                "Older child",
+		DateOfBirth:         model.NewDate(now.Year()-18, now.Month(), now.Day()+1),
+		RegisteredContactId: parent.Id,
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if _, err := svc.RegisterCustomer(model.Customer{
+		Name:                "Younger child",
+		DateOfBirth:         model.NewDate(now.Year()-12, time.January, 1),
+		RegisteredContactId: parent.Id,
+	}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if err := svc.MatureJuniorCustomers(now); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(published) != 0 {
+		t.Fatalf("expected no customers to mature the day before their birthday, got %d", len(published))
+	}
+
+	if err := svc.MatureJuniorCustomers(now.AddDate(0, 0, 1)); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(published) != 1 || published[0].CustomerId != turningEighteen.Id || published[0].RegisteredContactId != parent.Id {
+		t.Fatalf("expected one matured event for the older child, got %+v", published)
+	}
+
+	matured, err := repo.GetById(turningEighteen.Id)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if matured.RegisteredContactId != "" {
+		t.Errorf("expected registered contact to be removed, got %s", matured.RegisteredContactId)
+	}
+}
+
+func TestMatureJuniorCustomersPublishFailure(t *testing.T) {
+	now := time.Now()
+	repo := repository.New()
+	var failFor string
+	var published []string
+	pub := &mockPublisher{
+		publishFn: func(customer model.Customer) error {
+			return nil
+		},
+		publish: func(subject string, payload any) error {
+			matured := payload.(model.JisaMatured)
+			if matured.CustomerId == failFor {
+				return errors.New("nats unavailable")
+			}
+			published = append(published, matured.CustomerId)
+			return nil
+		},
+	}
+	svc := service.New(repo, pub)
+
+	parent, err := svc.RegisterCustomer(model.Customer{Name: "Parent", DateOfBirth: model.NewDate(1980, time.March, 3)})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	var children []model.Customer
+	for _, name := range []string{"First twin", "Second twin"} {
+		child, err := svc.RegisterCustomer(model.Customer{
+			Name:                name,
+			DateOfBirth:         model.NewDate(now.Year()-18, now.Month(), now.Day()+1),
+			RegisteredContactId: parent.Id,
+		})
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		children = append(children, child)
+	}
+	failFor = children[0].Id
+
+	if err := svc.MatureJuniorCustomers(now.AddDate(0, 0, 1)); err == nil {
+		t.Fatal("expected the failed event to be reported")
+	}
+	if len(published) != 1 || published[0] != children[1].Id {
+		t.Fatalf("expected the second child to mature despite the first failing, got %v", published)
+	}
+	first, _ := repo.GetById(children[0].Id)
+	if first.RegisteredContactId != parent.Id {
+		t.Errorf("expected the first child to keep their contact until the event is published")
+	}
+
+	failFor = ""
+	if err := svc.MatureJuniorCustomers(now.AddDate(0, 0, 1)); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(published) != 2 || published[1] != children[0].Id {
+		t.Errorf("expected the first child to mature on the next run, got %v", published)
+	}
+}
@@ -0,0 +1,48 @@
+package service
+
+import (
+	"github.com/oliknight1/retail-isa-investment/customer-service/internal"
+	"github.com/oliknight1/retail-isa-investment/customer-service/model"
+)
+
+// CheckEligibility works out whether the customer's details let them subscribe to product,
+// collecting every reason they cannot rather than stopping at the first. How old a customer can
+// be for each product is one of investment-service's product rules, so it is checked there
+// against the date of birth rather than here
+func (cs *customerServiceImpl) CheckEligibility(id string, product model.Product) (*model.Eligibility, error) {
+	if !product.Valid() {
+		return nil, internal.ErrInvalidProduct
+	}
+	customer, err := cs.GetCustomerById(id)
+	if err != nil {
+		return nil, err
+	}
+
+	eligibility := &model.Eligibility{
+		CustomerId: customer.Id,
+		Product:    product,
+		Reasons:    []model.IneligibilityReason{},
+	}
+	if customer.DateOfBirth.IsZero() {
+		eligibility.Reasons = append(eligibility.Reasons, model.ReasonMissingDOB)
+	}
+
+	switch customer.Residency {
+	case model.UKResident, model.CrownEmployee:
+	case "":
+		eligibility.Reasons = append(eligibility.Reasons, model.ReasonUnknownResidency)
+	default:
+		eligibility.Reasons = append(eligibility.Reasons, model.ReasonNotResident)
+	}
+
+	// NOTE: children are not issued a National Insurance number until they are 16
+	if product != model.JuniorISA && customer.NationalInsuranceNumber == "" {
+		eligibility.Reasons = append(eligibility.Reasons, model.ReasonMissingNINO)
+	}
+	if customer.Address == nil {
+		eligibility.Reasons = append(eligibility.Reasons, model.ReasonMissingAddress)
+	}
+
+	eligibility.Eligible = len(eligibility.Reasons) == 0
+	return eligibility, nil
+}
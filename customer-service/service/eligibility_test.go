@@ -0,0 +1,113 @@
+package service_test
+
+import (
+	"errors"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/oliknight1/retail-isa-investment/customer-service/internal"
+	"github.com/oliknight1/retail-isa-investment/customer-service/model"
+	"github.com/oliknight1/retail-isa-investment/customer-service/repository"
+	"github.com/oliknight1/retail-isa-investment/customer-service/service"
+)
+
+func TestRegisterValidatesEligibilityDetails(t *testing.T) {
+	pub := &mockPublisher{
+		publishFn: func(customer model.Customer) error {
+			return nil
+		},
+	}
+	svc := service.New(repository.New(), pub)
+	dob := model.NewDate(1990, time.May, 21)
+
+	customer, err := svc.RegisterCustomer(model.Customer{Name: "Oli", DateOfBirth: dob, NationalInsuranceNumber: "ab 12 34 56 c"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if customer.NationalInsuranceNumber != "AB123456C" {
+		t.Errorf("expected normalised National Insurance number, got %s", customer.NationalInsuranceNumber)
+	}
+
+	if _, err := svc.RegisterCustomer(model.Customer{Name: "Oli", DateOfBirth: dob, NationalInsuranceNumber: "TN123456A"}); !errors.Is(err, internal.ErrInvalidNINO) {
+		t.Errorf("expected invalid National Insurance number error, got %v", err)
+	}
+	if _, err := svc.RegisterCustomer(model.Customer{Name: "Oli", DateOfBirth: dob, Residency: "mars"}); !errors.Is(err, internal.ErrInvalidResidency) {
+		t.Errorf("expected invalid residency error, got %v", err)
+	}
+}
+
+func TestCheckEligibility(t *testing.T) {
+	pub := &mockPublisher{
+		publishFn: func(customer model.Customer) error {
+			return nil
+		},
+	}
+	svc := service.New(repository.New(), pub)
+	now := time.Now()
+	address := &model.Address{Line1: "1 High Street", City: "London", Postcode: "SW1A 1AA", Country: "GB"}
+
+	adult, err := svc.RegisterCustomer(model.Customer{
+		Name:                    "Adult",
+		DateOfBirth:             model.NewDate(now.Year()-30, time.January, 1),
+		NationalInsuranceNumber: "AB123456C",
+		Residency:               model.UKResident,
+		Address:                 address,
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	older, err := svc.RegisterCustomer(model.Customer{
+		Name:        "Older",
+		DateOfBirth: model.NewDate(now.Year()-55, time.January, 1),
+		Residency:   model.NonResident,
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	child, err := svc.RegisterCustomer(model.Customer{
+		Name:                "Child",
+		DateOfBirth:         model.NewDate(now.Year()-8, time.January, 1),
+		RegisteredContactId: adult.Id,
+		Residency:           model.UKResident,
+		Address:             address,
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	tests := []struct {
+		name       string
+		customerId string
+		product    model.Product
+		expected   []model.IneligibilityReason
+	}{
+		{"eligible adult", adult.Id, model.StocksAndSharesISA, []model.IneligibilityReason{}},
+		{"child without NINO for junior ISA", child.Id, model.JuniorISA, []model.IneligibilityReason{}},
+		{"child for cash ISA", child.Id, model.CashISA, []model.IneligibilityReason{model.ReasonMissingNINO}},
+		{"every reason", older.Id, model.LifetimeISA, []model.IneligibilityReason{
+			model.ReasonNotResident, model.ReasonMissingNINO, model.ReasonMissingAddress,
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			eligibility, err := svc.CheckEligibility(tt.customerId, tt.product)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if !slices.Equal(tt.expected, eligibility.Reasons) {
+				t.Errorf("expected reasons %v, got %v", tt.expected, eligibility.Reasons)
+			}
+			if eligibility.Eligible != (len(tt.expected) == 0) {
+				t.Errorf("expected eligible to be %v", len(tt.expected) == 0)
+			}
+		})
+	}
+
+	if _, err := svc.CheckEligibility(adult.Id, "pension"); !errors.Is(err, internal.ErrInvalidProduct) {
+		t.Errorf("expected invalid product error, got %v", err)
+	}
+	if _, err := svc.CheckEligibility("f47ac10b-58cc-4372-a567-0e02b2c3d479", model.CashISA); !errors.Is(err, internal.ErrCustomerNotFound) {
+		t.Errorf("expected customer not found error, got %v", err)
+	}
+}
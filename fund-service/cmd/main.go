@@ -5,6 +5,7 @@ import (
 	"net/http"
 	"os"
 
+	"github.com/oliknight1/retail-isa-investment/fund-service/event"
 	"github.com/oliknight1/retail-isa-investment/fund-service/handler"
 	"github.com/oliknight1/retail-isa-investment/fund-service/internal"
 	"github.com/oliknight1/retail-isa-investment/fund-service/logger"
@@ -31,8 +32,34 @@ func main() {
 	if err != nil {
 		logger.Error("Error reading funds.json", zap.Error(err))
 	}
+	pricesFilePath := os.Getenv("PRICES_JSON_PATH")
+	if pricesFilePath == "" {
+		pricesFilePath = "./repository/prices.json"
+	}
+	priceRepo, err := repository.NewPriceClient(pricesFilePath)
+	if err != nil {
+		logger.Error("Error reading prices.json", zap.Error(err))
+		priceRepo = &repository.PriceClient{}
+	}
+
+	natsURL := os.Getenv("NATS_URL")
+	if natsURL == "" {
+		natsURL = "nats://localhost:4222"
+	}
+	pub := event.NewNatsPublisher(natsURL)
+
 	svc := service.New(repo, logger)
+	priceSvc := service.NewPriceService(repo, priceRepo, pub, logger)
+	distributionSvc := service.NewDistributionService(repo, repository.NewDistributionClient(), pub, logger)
+	unitSvc := service.NewUnitService(repository.NewUnitClient(), logger)
 	fh := handler.New(svc, logger)
+	ph := handler.NewPriceHandler(priceSvc, logger)
+	dh := handler.NewDistributionHandler(distributionSvc, logger)
+	uh := handler.NewUnitHandler(unitSvc, logger)
+
+	if err := pub.Subscribe("investment.units.moved", unitSvc.OnUnitsMoved); err != nil {
+		logger.Error("error subscribing to investment.units.moved", zap.Error(err))
+	}
 
 	http.HandleFunc("/health", func(w http.ResponseWriter, r *http.Request) {
 		w.WriteHeader(http.StatusOK)
@@ -44,6 +71,13 @@ func main() {
 	http.HandleFunc("/funds", fh.GetFundList)
 
 	http.HandleFunc("/funds/", fh.GetFundById)
+	http.HandleFunc("GET /funds/{id}/prices", ph.GetPrices)
+	http.HandleFunc("GET /funds/{id}/price/latest", ph.GetLatestPrice)
+	http.HandleFunc("POST /admin/funds/{id}/prices", ph.UpdatePrice)
+	http.HandleFunc("GET /distributions", dh.GetDistributions)
+	http.HandleFunc("GET /funds/{id}/distributions", dh.GetDistributionsByFundId)
+	http.HandleFunc("POST /admin/funds/{id}/distributions", dh.DeclareDistribution)
+	http.HandleFunc("GET /units", uh.GetUnitsInIssue)
 
 	logger.Info("fund-service listening on :8080")
 	if err := http.ListenAndServe(":8080", nil); err != nil {
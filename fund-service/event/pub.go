@@ -0,0 +1,46 @@
+package event
+
+import (
+	"encoding/json"
+	"log"
+
+	"github.com/nats-io/nats.go"
+)
+
+type EventPublisher interface {
+	Publish(subject string, payload any) error
+}
+
+type NatsPublisher struct {
+	nc *nats.Conn
+}
+
+func NewNatsPublisher(url string) *NatsPublisher {
+	nc, err := nats.Connect(url)
+	if err != nil {
+		log.Fatalf("failed to connect to NATS: %v", err)
+	}
+	return &NatsPublisher{nc}
+}
+
+func (p *NatsPublisher) Publish(subject string, payload any) error {
+	msg, err := json.Marshal(payload)
+	if err != nil {
+		log.Printf("failed to marshal event for subject %s: %v", subject, err)
+		return err
+	}
+	err = p.nc.Publish(subject, msg)
+
+	if err != nil {
+		log.Printf("failed to publish %s event: %v", subject, err)
+	}
+
+	return err
+}
+
+func (p *NatsPublisher) Subscribe(subject string, handle func(data []byte)) error {
+	_, err := p.nc.Subscribe(subject, func(msg *nats.Msg) {
+		handle(msg.Data)
+	})
+	return err
+}
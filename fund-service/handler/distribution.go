@@ -0,0 +1,115 @@
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/oliknight1/retail-isa-investment/fund-service/internal"
+	"github.com/oliknight1/retail-isa-investment/fund-service/logger"
+	"github.com/oliknight1/retail-isa-investment/fund-service/model"
+	"github.com/oliknight1/retail-isa-investment/fund-service/service"
+	"go.uber.org/zap"
+)
+
+type DistributionHandler struct {
+	Service service.DistributionService
+	Logger  logger.Logger
+}
+
+func NewDistributionHandler(service service.DistributionService, logger logger.Logger) *DistributionHandler {
+	return &DistributionHandler{service, logger}
+}
+
+func (h *DistributionHandler) writeJson(w http.ResponseWriter, status int, data interface{}) {
+	body, err := json.Marshal(data)
+	if err != nil {
+		h.Logger.Error("failed to encode JSON", zap.Error(err))
+		http.Error(w, "failed to encode response", http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	w.Write(body)
+}
+
+func (h *DistributionHandler) writeError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, internal.ErrMissingId), errors.Is(err, internal.ErrInvalidDistribution), errors.Is(err, internal.ErrInvalidDateRange):
+		internal.FundLookupFailures.WithLabelValues("invalid_request").Inc()
+		h.Logger.Error("invalid distribution request", zap.Error(err))
+		http.Error(w, err.Error(), http.StatusBadRequest)
+	case errors.Is(err, internal.ErrFundNotFound):
+		internal.FundLookupFailures.WithLabelValues("not_found").Inc()
+		h.Logger.Error("fund not found", zap.Error(err))
+		http.Error(w, err.Error(), http.StatusNotFound)
+	case errors.Is(err, internal.ErrDuplicateDistribution):
+		h.Logger.Error("duplicate distribution", zap.Error(err))
+		http.Error(w, err.Error(), http.StatusConflict)
+	default:
+		internal.FundLookupFailures.WithLabelValues("internal_error").Inc()
+		h.Logger.Error("internal server error", zap.Error(err))
+		http.Error(w, "internal server error", http.StatusInternalServerError)
+	}
+}
+
+// GetDistributions returns every fund's distributions paid between the from and to query
+// parameters
+func (h *DistributionHandler) GetDistributions(w http.ResponseWriter, r *http.Request) {
+	internal.FundRequests.WithLabelValues("/distributions", "GET").Inc()
+	from, err := parseTime(r.URL.Query().Get("from"), false)
+	if err != nil {
+		h.writeError(w, err)
+		return
+	}
+	to, err := parseTime(r.URL.Query().Get("to"), true)
+	if err != nil {
+		h.writeError(w, err)
+		return
+	}
+
+	distributions, err := h.Service.GetDistributions(from, to)
+	if err != nil {
+		h.writeError(w, err)
+		return
+	}
+	h.writeJson(w, http.StatusOK, distributions)
+}
+
+func (h *DistributionHandler) GetDistributionsByFundId(w http.ResponseWriter, r *http.Request) {
+	internal.FundRequests.WithLabelValues("/funds/{id}/distributions", "GET").Inc()
+	distributions, err := h.Service.GetDistributionsByFundId(r.PathValue("id"))
+	if err != nil {
+		h.writeError(w, err)
+		return
+	}
+	h.writeJson(w, http.StatusOK, distributions)
+}
+
+func (h *DistributionHandler) DeclareDistribution(w http.ResponseWriter, r *http.Request) {
+	internal.FundRequests.WithLabelValues("/admin/funds/{id}/distributions", "POST").Inc()
+	var req struct {
+		Rate    model.UnitPrice `json:"rate"`
+		ExDate  model.Date      `json:"exDate"`
+		PayDate model.Date      `json:"payDate"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		h.Logger.Error("failed to decode distribution request", zap.Error(err))
+		http.Error(w, fmt.Sprintf("error reading JSON: \n%s", err), http.StatusBadRequest)
+		return
+	}
+
+	distribution, err := h.Service.DeclareDistribution(model.Distribution{
+		FundId:  r.PathValue("id"),
+		Rate:    req.Rate,
+		ExDate:  req.ExDate,
+		PayDate: req.PayDate,
+	})
+	if err != nil {
+		h.writeError(w, err)
+		return
+	}
+	h.Logger.Info("distribution declared", zap.String("fund_id", distribution.FundId), zap.String("rate", distribution.Rate.String()))
+	h.writeJson(w, http.StatusCreated, distribution)
+}
@@ -0,0 +1,107 @@
+package handler_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/oliknight1/retail-isa-investment/fund-service/handler"
+	"github.com/oliknight1/retail-isa-investment/fund-service/internal"
+	"github.com/oliknight1/retail-isa-investment/fund-service/logger"
+	"github.com/oliknight1/retail-isa-investment/fund-service/model"
+)
+
+type mockDistributionService struct {
+	declareDistribution      func(distribution model.Distribution) (*model.Distribution, error)
+	getDistributionsByFundId func(fundId string) (*[]model.Distribution, error)
+	getDistributions         func(from *time.Time, to *time.Time) (*[]model.Distribution, error)
+}
+
+func (m *mockDistributionService) DeclareDistribution(distribution model.Distribution) (*model.Distribution, error) {
+	return m.declareDistribution(distribution)
+}
+func (m *mockDistributionService) GetDistributionsByFundId(fundId string) (*[]model.Distribution, error) {
+	return m.getDistributionsByFundId(fundId)
+}
+func (m *mockDistributionService) GetDistributions(from *time.Time, to *time.Time) (*[]model.Distribution, error) {
+	return m.getDistributions(from, to)
+}
+
+func TestDeclareDistribution(t *testing.T) {
+	tests := []struct {
+		name         string
+		body         string
+		err          error
+		expectedCode int
+	}{
+		{"declared", `{"rate":"0.012345","exDate":"2025-06-05","payDate":"2025-06-30"}`, nil, http.StatusCreated},
+		{"invalid date", `{"rate":"0.012345","exDate":"5 June","payDate":"2025-06-30"}`, nil, http.StatusBadRequest},
+		{"invalid distribution", `{"rate":"0","exDate":"2025-06-05","payDate":"2025-06-30"}`, internal.ErrInvalidDistribution, http.StatusBadRequest},
+		{"unknown fund", `{"rate":"0.012345","exDate":"2025-06-05","payDate":"2025-06-30"}`, internal.FundNotFoundError("fund-1"), http.StatusNotFound},
+		{"duplicate", `{"rate":"0.012345","exDate":"2025-06-05","payDate":"2025-06-30"}`, internal.ErrDuplicateDistribution, http.StatusConflict},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			mockSvc := &mockDistributionService{
+				declareDistribution: func(distribution model.Distribution) (*model.Distribution, error) {
+					if tc.err != nil {
+						return nil, tc.err
+					}
+					distribution.Id = "dist-1"
+					return &distribution, nil
+				},
+			}
+			h := handler.NewDistributionHandler(mockSvc, logger.NewMockLogger())
+
+			req := httptest.NewRequest(http.MethodPost, "/admin/funds/fund-1/distributions", strings.NewReader(tc.body))
+			req.SetPathValue("id", "fund-1")
+			w := httptest.NewRecorder()
+
+			h.DeclareDistribution(w, req)
+
+			if w.Code != tc.expectedCode {
+				t.Fatalf("expected status %d, got %d", tc.expectedCode, w.Code)
+			}
+			if tc.expectedCode != http.StatusCreated {
+				return
+			}
+			var distribution model.Distribution
+			if err := json.NewDecoder(w.Body).Decode(&distribution); err != nil {
+				t.Fatalf("decode error: %v", err)
+			}
+			if distribution.FundId != "fund-1" || distribution.Rate != 12_345 || !distribution.ExDate.Equal(model.NewDate(2025, time.June, 5).Time) {
+				t.Errorf("unexpected distribution: %+v", distribution)
+			}
+		})
+	}
+}
+
+func TestGetDistributions(t *testing.T) {
+	mockSvc := &mockDistributionService{
+		getDistributions: func(from *time.Time, to *time.Time) (*[]model.Distribution, error) {
+			if from != nil {
+				t.Errorf("expected from to be left open, got %v", from)
+			}
+			if to == nil || !to.Equal(time.Date(2025, time.July, 1, 0, 0, 0, 0, time.UTC).Add(-time.Nanosecond)) {
+				t.Errorf("expected to to be the end of 30 June, got %v", to)
+			}
+			return &[]model.Distribution{{Id: "dist-1", FundId: "fund-1", Rate: 12_345, PayDate: model.NewDate(2025, time.June, 30)}}, nil
+		},
+	}
+	h := handler.NewDistributionHandler(mockSvc, logger.NewMockLogger())
+
+	req := httptest.NewRequest(http.MethodGet, "/distributions?to=2025-06-30", nil)
+	w := httptest.NewRecorder()
+
+	h.GetDistributions(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected status %d, got %d", http.StatusOK, w.Code)
+	}
+	if !strings.Contains(w.Body.String(), `"payDate":"2025-06-30"`) {
+		t.Errorf("expected the pay date as a date, got %s", w.Body.String())
+	}
+}
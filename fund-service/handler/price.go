@@ -0,0 +1,139 @@
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/oliknight1/retail-isa-investment/fund-service/internal"
+	"github.com/oliknight1/retail-isa-investment/fund-service/logger"
+	"github.com/oliknight1/retail-isa-investment/fund-service/model"
+	"github.com/oliknight1/retail-isa-investment/fund-service/service"
+	"go.uber.org/zap"
+)
+
+type PriceHandler struct {
+	Service service.PriceService
+	Logger  logger.Logger
+}
+
+func NewPriceHandler(service service.PriceService, logger logger.Logger) *PriceHandler {
+	return &PriceHandler{service, logger}
+}
+
+func (h *PriceHandler) writeJson(w http.ResponseWriter, status int, data interface{}) {
+	body, err := json.Marshal(data)
+	if err != nil {
+		h.Logger.Error("failed to encode JSON", zap.Error(err))
+		http.Error(w, "failed to encode response", http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	w.Write(body)
+}
+
+func (h *PriceHandler) writeError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, internal.ErrMissingId), errors.Is(err, internal.ErrInvalidPrice), errors.Is(err, internal.ErrInvalidDateRange):
+		internal.FundLookupFailures.WithLabelValues("invalid_request").Inc()
+		h.Logger.Error("invalid price request", zap.Error(err))
+		http.Error(w, err.Error(), http.StatusBadRequest)
+	case errors.Is(err, internal.ErrFundNotFound), errors.Is(err, internal.ErrPriceNotFound):
+		internal.FundLookupFailures.WithLabelValues("not_found").Inc()
+		h.Logger.Error("fund price not found", zap.Error(err))
+		http.Error(w, err.Error(), http.StatusNotFound)
+	case errors.Is(err, internal.ErrDuplicatePrice):
+		h.Logger.Error("duplicate fund price", zap.Error(err))
+		http.Error(w, err.Error(), http.StatusConflict)
+	default:
+		internal.FundLookupFailures.WithLabelValues("internal_error").Inc()
+		h.Logger.Error("internal server error", zap.Error(err))
+		http.Error(w, "internal server error", http.StatusInternalServerError)
+	}
+}
+
+// parseTime reads a query parameter as either an RFC 3339 timestamp or a date. A date used as
+// the end of a range covers the whole of that day
+func parseTime(value string, endOfDay bool) (*time.Time, error) {
+	if value == "" {
+		return nil, nil
+	}
+	if t, err := time.Parse(time.RFC3339, value); err == nil {
+		return &t, nil
+	}
+	t, err := time.Parse(time.DateOnly, value)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %q is not a date or RFC 3339 time", internal.ErrInvalidDateRange, value)
+	}
+	if endOfDay {
+		t = t.AddDate(0, 0, 1).Add(-time.Nanosecond)
+	}
+	return &t, nil
+}
+
+func (h *PriceHandler) GetPrices(w http.ResponseWriter, r *http.Request) {
+	internal.FundRequests.WithLabelValues("/funds/{id}/prices", "GET").Inc()
+	fundId := r.PathValue("id")
+
+	from, err := parseTime(r.URL.Query().Get("from"), false)
+	if err != nil {
+		h.writeError(w, err)
+		return
+	}
+	to, err := parseTime(r.URL.Query().Get("to"), true)
+	if err != nil {
+		h.writeError(w, err)
+		return
+	}
+
+	prices, err := h.Service.GetPrices(fundId, from, to)
+	if err != nil {
+		h.writeError(w, err)
+		return
+	}
+	h.writeJson(w, http.StatusOK, prices)
+}
+
+func (h *PriceHandler) GetLatestPrice(w http.ResponseWriter, r *http.Request) {
+	internal.FundRequests.WithLabelValues("/funds/{id}/price/latest", "GET").Inc()
+	fundId := r.PathValue("id")
+
+	price, err := h.Service.GetLatestPrice(fundId)
+	if err != nil {
+		h.writeError(w, err)
+		return
+	}
+	h.writeJson(w, http.StatusOK, price)
+}
+
+func (h *PriceHandler) UpdatePrice(w http.ResponseWriter, r *http.Request) {
+	internal.FundRequests.WithLabelValues("/admin/funds/{id}/prices", "POST").Inc()
+	var req struct {
+		Nav      model.UnitPrice  `json:"nav"`
+		Bid      *model.UnitPrice `json:"bid"`
+		Offer    *model.UnitPrice `json:"offer"`
+		ValuedAt time.Time        `json:"valuedAt"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		h.Logger.Error("failed to decode price request", zap.Error(err))
+		http.Error(w, fmt.Sprintf("error reading JSON: \n%s", err), http.StatusBadRequest)
+		return
+	}
+
+	price, err := h.Service.UpdatePrice(model.Price{
+		FundId:   r.PathValue("id"),
+		Nav:      req.Nav,
+		Bid:      req.Bid,
+		Offer:    req.Offer,
+		ValuedAt: req.ValuedAt,
+	})
+	if err != nil {
+		h.writeError(w, err)
+		return
+	}
+	h.Logger.Info("fund price updated", zap.String("fund_id", price.FundId), zap.String("nav", price.Nav.String()))
+	h.writeJson(w, http.StatusCreated, price)
+}
@@ -0,0 +1,161 @@
+package handler_test
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/oliknight1/retail-isa-investment/fund-service/handler"
+	"github.com/oliknight1/retail-isa-investment/fund-service/internal"
+	"github.com/oliknight1/retail-isa-investment/fund-service/logger"
+	"github.com/oliknight1/retail-isa-investment/fund-service/model"
+)
+
+type mockPriceService struct {
+	getPrices      func(fundId string, from *time.Time, to *time.Time) (*[]model.Price, error)
+	getLatestPrice func(fundId string) (*model.Price, error)
+	updatePrice    func(price model.Price) (*model.Price, error)
+}
+
+func (m *mockPriceService) GetPrices(fundId string, from *time.Time, to *time.Time) (*[]model.Price, error) {
+	return m.getPrices(fundId, from, to)
+}
+func (m *mockPriceService) GetLatestPrice(fundId string) (*model.Price, error) {
+	return m.getLatestPrice(fundId)
+}
+func (m *mockPriceService) UpdatePrice(price model.Price) (*model.Price, error) {
+	return m.updatePrice(price)
+}
+
+func TestGetPrices(t *testing.T) {
+	mockSvc := &mockPriceService{
+		getPrices: func(fundId string, from *time.Time, to *time.Time) (*[]model.Price, error) {
+			if from == nil || !from.Equal(time.Date(2025, time.June, 2, 0, 0, 0, 0, time.UTC)) {
+				t.Errorf("expected from to be the start of 2 June, got %v", from)
+			}
+			if to == nil || !to.Equal(time.Date(2025, time.June, 5, 0, 0, 0, 0, time.UTC).Add(-time.Nanosecond)) {
+				t.Errorf("expected to to be the end of 4 June, got %v", to)
+			}
+			return &[]model.Price{{FundId: fundId, Nav: 1_234_500, ValuedAt: *from}}, nil
+		},
+	}
+	h := handler.NewPriceHandler(mockSvc, logger.NewMockLogger())
+
+	req := httptest.NewRequest(http.MethodGet, "/funds/fund-1/prices?from=2025-06-02&to=2025-06-04", nil)
+	req.SetPathValue("id", "fund-1")
+	w := httptest.NewRecorder()
+
+	h.GetPrices(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected status %d, got %d", http.StatusOK, w.Code)
+	}
+	if !strings.Contains(w.Body.String(), `"nav":"1.234500"`) {
+		t.Errorf("expected nav as a decimal string, got %s", w.Body.String())
+	}
+}
+
+func TestGetPricesErrors(t *testing.T) {
+	tests := []struct {
+		name         string
+		query        string
+		err          error
+		expectedCode int
+	}{
+		{"invalid from", "?from=yesterday", nil, http.StatusBadRequest},
+		{"range ends before it starts", "?from=2025-06-04&to=2025-06-02", internal.ErrInvalidDateRange, http.StatusBadRequest},
+		{"unknown fund", "", internal.FundNotFoundError("fund-1"), http.StatusNotFound},
+		{"unexpected error", "", errors.New("db failure"), http.StatusInternalServerError},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			mockSvc := &mockPriceService{
+				getPrices: func(fundId string, from *time.Time, to *time.Time) (*[]model.Price, error) {
+					return nil, tc.err
+				},
+			}
+			h := handler.NewPriceHandler(mockSvc, logger.NewMockLogger())
+
+			req := httptest.NewRequest(http.MethodGet, "/funds/fund-1/prices"+tc.query, nil)
+			req.SetPathValue("id", "fund-1")
+			w := httptest.NewRecorder()
+
+			h.GetPrices(w, req)
+
+			if w.Code != tc.expectedCode {
+				t.Fatalf("expected status %d, got %d", tc.expectedCode, w.Code)
+			}
+		})
+	}
+}
+
+func TestGetLatestPriceNotFound(t *testing.T) {
+	mockSvc := &mockPriceService{
+		getLatestPrice: func(fundId string) (*model.Price, error) {
+			return nil, internal.PriceNotFoundError(fundId)
+		},
+	}
+	h := handler.NewPriceHandler(mockSvc, logger.NewMockLogger())
+
+	req := httptest.NewRequest(http.MethodGet, "/funds/fund-1/price/latest", nil)
+	req.SetPathValue("id", "fund-1")
+	w := httptest.NewRecorder()
+
+	h.GetLatestPrice(w, req)
+
+	if w.Code != http.StatusNotFound {
+		t.Fatalf("expected status %d, got %d", http.StatusNotFound, w.Code)
+	}
+}
+
+func TestUpdatePrice(t *testing.T) {
+	tests := []struct {
+		name         string
+		body         string
+		err          error
+		expectedCode int
+	}{
+		{"single priced", `{"nav":"1.25","valuedAt":"2025-06-02T12:00:00Z"}`, nil, http.StatusCreated},
+		{"dual priced", `{"nav":"1.25","bid":"1.24","offer":"1.26","valuedAt":"2025-06-02T12:00:00Z"}`, nil, http.StatusCreated},
+		{"too many decimal places", `{"nav":"1.2345678","valuedAt":"2025-06-02T12:00:00Z"}`, nil, http.StatusBadRequest},
+		{"invalid price", `{"nav":"0","valuedAt":"2025-06-02T12:00:00Z"}`, internal.ErrInvalidPrice, http.StatusBadRequest},
+		{"duplicate", `{"nav":"1.25","valuedAt":"2025-06-02T12:00:00Z"}`, internal.ErrDuplicatePrice, http.StatusConflict},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			mockSvc := &mockPriceService{
+				updatePrice: func(price model.Price) (*model.Price, error) {
+					if tc.err != nil {
+						return nil, tc.err
+					}
+					return &price, nil
+				},
+			}
+			h := handler.NewPriceHandler(mockSvc, logger.NewMockLogger())
+
+			req := httptest.NewRequest(http.MethodPost, "/admin/funds/fund-1/prices", strings.NewReader(tc.body))
+			req.SetPathValue("id", "fund-1")
+			w := httptest.NewRecorder()
+
+			h.UpdatePrice(w, req)
+
+			if w.Code != tc.expectedCode {
+				t.Fatalf("expected status %d, got %d", tc.expectedCode, w.Code)
+			}
+			if tc.expectedCode != http.StatusCreated {
+				return
+			}
+			var price model.Price
+			if err := json.NewDecoder(w.Body).Decode(&price); err != nil {
+				t.Fatalf("decode error: %v", err)
+			}
+			if price.FundId != "fund-1" || price.Nav != 1_250_000 {
+				t.Errorf("unexpected price: %+v", price)
+			}
+		})
+	}
+}
@@ -0,0 +1,35 @@
+package handler
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/oliknight1/retail-isa-investment/fund-service/internal"
+	"github.com/oliknight1/retail-isa-investment/fund-service/logger"
+	"github.com/oliknight1/retail-isa-investment/fund-service/service"
+	"go.uber.org/zap"
+)
+
+type UnitHandler struct {
+	Service service.UnitService
+	Logger  logger.Logger
+}
+
+func NewUnitHandler(service service.UnitService, logger logger.Logger) *UnitHandler {
+	return &UnitHandler{service, logger}
+}
+
+// GetUnitsInIssue returns how many units of each fund are in issue
+func (h *UnitHandler) GetUnitsInIssue(w http.ResponseWriter, r *http.Request) {
+	internal.FundRequests.WithLabelValues("/units", "GET").Inc()
+	units, err := h.Service.GetUnitsInIssue()
+	if err != nil {
+		internal.FundLookupFailures.WithLabelValues("internal_error").Inc()
+		h.Logger.Error("failed to get units in issue", zap.Error(err))
+		http.Error(w, "internal server error", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(units)
+}
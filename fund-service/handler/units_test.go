@@ -0,0 +1,66 @@
+package handler_test
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/oliknight1/retail-isa-investment/fund-service/handler"
+	"github.com/oliknight1/retail-isa-investment/fund-service/logger"
+	"github.com/oliknight1/retail-isa-investment/fund-service/model"
+)
+
+type mockUnitService struct {
+	recordMovement  func(movement model.UnitMovement) error
+	getUnitsInIssue func() (*[]model.FundUnits, error)
+}
+
+func (m *mockUnitService) RecordMovement(movement model.UnitMovement) error {
+	return m.recordMovement(movement)
+}
+func (m *mockUnitService) GetUnitsInIssue() (*[]model.FundUnits, error) {
+	return m.getUnitsInIssue()
+}
+
+func TestGetUnitsInIssue(t *testing.T) {
+	tests := []struct {
+		name         string
+		err          error
+		expectedCode int
+	}{
+		{"units in issue", nil, http.StatusOK},
+		{"repository error", errors.New("db down"), http.StatusInternalServerError},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			mockSvc := &mockUnitService{
+				getUnitsInIssue: func() (*[]model.FundUnits, error) {
+					if tc.err != nil {
+						return nil, tc.err
+					}
+					return &[]model.FundUnits{{FundId: "fund-1", Units: 8_123_456}}, nil
+				},
+			}
+			h := handler.NewUnitHandler(mockSvc, logger.NewMockLogger())
+
+			rr := httptest.NewRecorder()
+			h.GetUnitsInIssue(rr, httptest.NewRequest(http.MethodGet, "/units", nil))
+			if rr.Code != tc.expectedCode {
+				t.Fatalf("expected status %d, got %d", tc.expectedCode, rr.Code)
+			}
+			if tc.err != nil {
+				return
+			}
+			var units []struct {
+				FundId string `json:"fundId"`
+				Units  string `json:"units"`
+			}
+			json.NewDecoder(rr.Body).Decode(&units)
+			if len(units) != 1 || units[0].Units != "812.3456" {
+				t.Errorf("expected units as a decimal string, got %+v", units)
+			}
+		})
+	}
+}
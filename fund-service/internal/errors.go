@@ -10,8 +10,22 @@ var (
 	ErrInvalidRisklevel = errors.New("invalid risk level")
 	ErrInvalidUrl       = errors.New("invalid url")
 	ErrFundNotFound     = errors.New("fund not found")
+	ErrInvalidPrice     = errors.New("invalid price")
+	ErrPriceNotFound    = errors.New("no price found")
+	ErrDuplicatePrice   = errors.New("price already exists for this valuation time")
+	ErrInvalidDateRange = errors.New("invalid date range")
+
+	ErrInvalidDistribution   = errors.New("invalid distribution")
+	ErrDuplicateDistribution = errors.New("distribution already declared for this ex-dividend date")
+
+	ErrInvalidMovement   = errors.New("invalid unit movement")
+	ErrDuplicateMovement = errors.New("unit movement already recorded")
 )
 
 func FundNotFoundError(id string) error {
 	return fmt.Errorf("%w: %s", ErrFundNotFound, id)
 }
+
+func PriceNotFoundError(fundId string) error {
+	return fmt.Errorf("%w: %s", ErrPriceNotFound, fundId)
+}
@@ -0,0 +1,55 @@
+package model
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// Date is a calendar date without a time of day, sent over the wire as "2006-01-02"
+type Date struct {
+	time.Time
+}
+
+func NewDate(year int, month time.Month, day int) Date {
+	return Date{time.Date(year, month, day, 0, 0, 0, 0, time.UTC)}
+}
+
+func (d Date) String() string {
+	return d.Format(time.DateOnly)
+}
+
+func (d Date) MarshalJSON() ([]byte, error) {
+	if d.IsZero() {
+		return []byte("null"), nil
+	}
+	return json.Marshal(d.String())
+}
+
+func (d *Date) UnmarshalJSON(data []byte) error {
+	if string(data) == "null" {
+		*d = Date{}
+		return nil
+	}
+	var s string
+	if err := json.Unmarshal(data, &s); err != nil {
+		return err
+	}
+	t, err := time.Parse(time.DateOnly, s)
+	if err != nil {
+		return err
+	}
+	*d = Date{t}
+	return nil
+}
+
+// Distribution is income a fund has declared it will pay on each of its units. Units held
+// before the ex-dividend date qualify, and the income is paid on PayDate. Rate is the amount
+// paid per unit, to the same precision as a unit price
+type Distribution struct {
+	Id         string    `json:"id"`
+	FundId     string    `json:"fundId"`
+	Rate       UnitPrice `json:"rate"`
+	ExDate     Date      `json:"exDate"`
+	PayDate    Date      `json:"payDate"`
+	DeclaredAt time.Time `json:"declaredAt"`
+}
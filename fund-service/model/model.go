@@ -1,14 +1,21 @@
 package model
 
-type Fund struct {
-	Id          string `json:"id"`
-	Name        string `json:"name"`
-	Description string `json:"description"`
-	RiskLevel   string `json:"riskLevel"`
-}
+// UnitClass is how a fund share class passes on the income its investments earn. Accumulation
+// units keep it in the fund, so holders have it reinvested, while income units pay it out
+type UnitClass string
 
-type FundAccount struct {
-	CustomerID     string
-	Balance        int64
-	ReservedAmount int64
+const (
+	UnitClassAccumulation UnitClass = "acc"
+	UnitClassIncome       UnitClass = "inc"
+)
+
+// Fund is a fund customers can invest in. OngoingCharge is the fund's ongoing charges figure,
+// the percentage of its value taken each year by the fund manager through the unit price
+type Fund struct {
+	Id            string    `json:"id"`
+	Name          string    `json:"name"`
+	Description   string    `json:"description"`
+	RiskLevel     string    `json:"riskLevel"`
+	OngoingCharge float64   `json:"ongoingCharge"`
+	UnitClass     UnitClass `json:"unitClass"`
 }
@@ -0,0 +1,89 @@
+package model
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// UnitPricePlaces is how many decimal places of a pound unit prices are held to
+const UnitPricePlaces = 6
+
+var ErrInvalidUnitPrice = errors.New("unit price must be a decimal with at most 6 decimal places")
+
+var unitPricePattern = regexp.MustCompile(`^[0-9]{1,9}(\.[0-9]{1,6})?$`)
+
+// UnitPrice is the price of one unit of a fund in millionths of a pound, so prices quoted to
+// fractions of a penny stay exact. It is written to JSON as a decimal string e.g. "1.234567"
+type UnitPrice int64
+
+func ParseUnitPrice(s string) (UnitPrice, error) {
+	if !unitPricePattern.MatchString(s) {
+		return 0, fmt.Errorf("%w: %q", ErrInvalidUnitPrice, s)
+	}
+	whole, fraction, _ := strings.Cut(s, ".")
+	pounds, err := strconv.ParseInt(whole, 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("%w: %q", ErrInvalidUnitPrice, s)
+	}
+	micros, _ := strconv.ParseInt((fraction + "000000")[:UnitPricePlaces], 10, 64)
+	return UnitPrice(pounds*1_000_000 + micros), nil
+}
+
+func (p UnitPrice) String() string {
+	return fmt.Sprintf("%d.%06d", p/1_000_000, p%1_000_000)
+}
+
+func (p UnitPrice) MarshalJSON() ([]byte, error) {
+	return json.Marshal(p.String())
+}
+
+func (p *UnitPrice) UnmarshalJSON(data []byte) error {
+	value := string(data)
+	if strings.HasPrefix(value, `"`) {
+		if err := json.Unmarshal(data, &value); err != nil {
+			return fmt.Errorf("%w: %v", ErrInvalidUnitPrice, err)
+		}
+	}
+	parsed, err := ParseUnitPrice(value)
+	if err != nil {
+		return err
+	}
+	*p = parsed
+	return nil
+}
+
+// Price is a fund's valuation at a point in time. A single priced fund only has a NAV, while
+// a dual priced fund also has a bid price it buys units back at and an offer price it sells
+// them at
+type Price struct {
+	FundId   string     `json:"fundId"`
+	Nav      UnitPrice  `json:"nav"`
+	Bid      *UnitPrice `json:"bid,omitempty"`
+	Offer    *UnitPrice `json:"offer,omitempty"`
+	ValuedAt time.Time  `json:"valuedAt"`
+}
+
+func (p Price) DualPriced() bool {
+	return p.Bid != nil && p.Offer != nil
+}
+
+// BuyPrice is what an investor pays for one unit
+func (p Price) BuyPrice() UnitPrice {
+	if p.Offer != nil {
+		return *p.Offer
+	}
+	return p.Nav
+}
+
+// SellPrice is what an investor receives for one unit
+func (p Price) SellPrice() UnitPrice {
+	if p.Bid != nil {
+		return *p.Bid
+	}
+	return p.Nav
+}
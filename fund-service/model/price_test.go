@@ -0,0 +1,51 @@
+package model_test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/oliknight1/retail-isa-investment/fund-service/model"
+)
+
+func TestParseUnitPrice(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected model.UnitPrice
+	}{
+		{"1", 1_000_000},
+		{"1.5", 1_500_000},
+		{"0.000001", 1},
+		{"245.123456", 245_123_456},
+	}
+	for _, tt := range tests {
+		actual, err := model.ParseUnitPrice(tt.input)
+		if err != nil {
+			t.Fatalf("unexpected error for %q: %v", tt.input, err)
+		}
+		if actual != tt.expected {
+			t.Errorf("expected %q to parse as %d, got %d", tt.input, tt.expected, actual)
+		}
+		if reparsed, _ := model.ParseUnitPrice(actual.String()); reparsed != actual {
+			t.Errorf("expected %s to round trip, got %s", actual, reparsed)
+		}
+	}
+
+	for _, input := range []string{"", "-1", "1.0000001", "1e2", "abc"} {
+		if _, err := model.ParseUnitPrice(input); !errors.Is(err, model.ErrInvalidUnitPrice) {
+			t.Errorf("expected invalid unit price error for %q, got: %v", input, err)
+		}
+	}
+}
+
+func TestBuyAndSellPrice(t *testing.T) {
+	bid, offer := model.UnitPrice(990_000), model.UnitPrice(1_010_000)
+	single := model.Price{Nav: 1_000_000}
+	dual := model.Price{Nav: 1_000_000, Bid: &bid, Offer: &offer}
+
+	if single.DualPriced() || single.BuyPrice() != 1_000_000 || single.SellPrice() != 1_000_000 {
+		t.Errorf("expected a single priced fund to buy and sell at nav, got %+v", single)
+	}
+	if !dual.DualPriced() || dual.BuyPrice() != offer || dual.SellPrice() != bid {
+		t.Errorf("expected a dual priced fund to buy at offer and sell at bid, got %+v", dual)
+	}
+}
@@ -0,0 +1,86 @@
+package model
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// UnitPlaces is how many decimal places units are held to
+const UnitPlaces = 4
+
+var ErrInvalidUnits = errors.New("units must be a decimal with at most 4 decimal places")
+
+var unitsPattern = regexp.MustCompile(`^-?[0-9]{1,12}(\.[0-9]{1,4})?$`)
+
+// Units is a number of a fund's units in ten-thousandths of a unit. A movement of units can be
+// negative, so unlike a price it may carry a sign. It is written to JSON as a decimal string
+// e.g. "812.3456"
+type Units int64
+
+func ParseUnits(s string) (Units, error) {
+	if !unitsPattern.MatchString(s) {
+		return 0, fmt.Errorf("%w: %q", ErrInvalidUnits, s)
+	}
+	negative := strings.HasPrefix(s, "-")
+	whole, fraction, _ := strings.Cut(strings.TrimPrefix(s, "-"), ".")
+	units, err := strconv.ParseInt(whole, 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("%w: %q", ErrInvalidUnits, s)
+	}
+	tenThousandths, _ := strconv.ParseInt((fraction + "0000")[:UnitPlaces], 10, 64)
+	parsed := Units(units*10_000 + tenThousandths)
+	if negative {
+		parsed = -parsed
+	}
+	return parsed, nil
+}
+
+func (u Units) String() string {
+	sign := ""
+	if u < 0 {
+		sign, u = "-", -u
+	}
+	return fmt.Sprintf("%s%d.%04d", sign, u/10_000, u%10_000)
+}
+
+func (u Units) MarshalJSON() ([]byte, error) {
+	return json.Marshal(u.String())
+}
+
+func (u *Units) UnmarshalJSON(data []byte) error {
+	value := string(data)
+	if strings.HasPrefix(value, `"`) {
+		if err := json.Unmarshal(data, &value); err != nil {
+			return fmt.Errorf("%w: %v", ErrInvalidUnits, err)
+		}
+	}
+	parsed, err := ParseUnits(value)
+	if err != nil {
+		return err
+	}
+	*u = parsed
+	return nil
+}
+
+// UnitMovement is a change in how many of a fund's units are in issue, reported by
+// investment-service when it deals an order. Units are created when investors buy and cancelled
+// when they sell, so a sale is a negative movement, as is undoing a purchase that was dealt
+// and then failed. Id is unique to the movement so one reported twice is only counted once
+type UnitMovement struct {
+	Id           string    `json:"id"`
+	FundId       string    `json:"fundId"`
+	InvestmentId string    `json:"investmentId"`
+	Units        Units     `json:"units"`
+	At           time.Time `json:"at"`
+}
+
+// FundUnits is how many of a fund's units are in issue
+type FundUnits struct {
+	FundId string `json:"fundId"`
+	Units  Units  `json:"units"`
+}
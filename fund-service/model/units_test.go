@@ -0,0 +1,38 @@
+package model_test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/oliknight1/retail-isa-investment/fund-service/model"
+)
+
+func TestParseUnits(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected model.Units
+	}{
+		{"1", 10_000},
+		{"812.3456", 8_123_456},
+		{"0.0001", 1},
+		{"-25.5", -255_000},
+	}
+	for _, tt := range tests {
+		actual, err := model.ParseUnits(tt.input)
+		if err != nil {
+			t.Fatalf("unexpected error for %q: %v", tt.input, err)
+		}
+		if actual != tt.expected {
+			t.Errorf("expected %q to parse as %d, got %d", tt.input, tt.expected, actual)
+		}
+		if reparsed, _ := model.ParseUnits(actual.String()); reparsed != actual {
+			t.Errorf("expected %s to round trip, got %s", actual, reparsed)
+		}
+	}
+
+	for _, input := range []string{"", "1.00001", "--1", "1e2"} {
+		if _, err := model.ParseUnits(input); !errors.Is(err, model.ErrInvalidUnits) {
+			t.Errorf("expected invalid units error for %q, got: %v", input, err)
+		}
+	}
+}
@@ -0,0 +1,70 @@
+package repository
+
+import (
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/oliknight1/retail-isa-investment/fund-service/internal"
+	"github.com/oliknight1/retail-isa-investment/fund-service/model"
+)
+
+type DistributionRepository interface {
+	AddDistribution(distribution model.Distribution) error
+	GetDistributionsByFundId(fundId string) (*[]model.Distribution, error)
+	GetDistributionsPaidBetween(from time.Time, to time.Time) (*[]model.Distribution, error)
+}
+
+// DistributionClient holds every fund's declared distributions in order of ex-dividend date
+type DistributionClient struct {
+	mu            sync.RWMutex
+	distributions []model.Distribution
+}
+
+func NewDistributionClient() *DistributionClient {
+	return &DistributionClient{}
+}
+
+func (c *DistributionClient) AddDistribution(distribution model.Distribution) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	for _, declared := range c.distributions {
+		if declared.FundId == distribution.FundId && declared.ExDate.Equal(distribution.ExDate.Time) {
+			return internal.ErrDuplicateDistribution
+		}
+	}
+	i, _ := slices.BinarySearchFunc(c.distributions, distribution.ExDate, func(d model.Distribution, exDate model.Date) int {
+		return d.ExDate.Compare(exDate.Time)
+	})
+	c.distributions = slices.Insert(c.distributions, i, distribution)
+	return nil
+}
+
+func (c *DistributionClient) GetDistributionsByFundId(fundId string) (*[]model.Distribution, error) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	distributions := []model.Distribution{}
+	for _, distribution := range c.distributions {
+		if distribution.FundId == fundId {
+			distributions = append(distributions, distribution)
+		}
+	}
+	return &distributions, nil
+}
+
+// GetDistributionsPaidBetween returns the distributions with a pay date between from and to
+// inclusive
+func (c *DistributionClient) GetDistributionsPaidBetween(from time.Time, to time.Time) (*[]model.Distribution, error) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	distributions := []model.Distribution{}
+	for _, distribution := range c.distributions {
+		if !distribution.PayDate.Before(from) && !distribution.PayDate.After(to) {
+			distributions = append(distributions, distribution)
+		}
+	}
+	return &distributions, nil
+}
@@ -0,0 +1,94 @@
+package repository
+
+import (
+	"encoding/json"
+	"log"
+	"os"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/oliknight1/retail-isa-investment/fund-service/internal"
+	"github.com/oliknight1/retail-isa-investment/fund-service/model"
+)
+
+type PriceRepository interface {
+	AddPrice(price model.Price) error
+	GetPrices(fundId string, from time.Time, to time.Time) (*[]model.Price, error)
+	GetLatestPrice(fundId string) (*model.Price, error)
+}
+
+// PriceClient holds each fund's price history in order of valuation time
+type PriceClient struct {
+	mu     sync.RWMutex
+	prices map[string][]model.Price
+}
+
+// NOTE: replace with a client that listens to the fund administrator's pricing feed
+// Currently seeded from a local file
+func NewPriceClient(path string) (*PriceClient, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		log.Printf("error reading from file: %v", err)
+		return nil, err
+	}
+	defer file.Close()
+
+	var priceList []model.Price
+	if err := json.NewDecoder(file).Decode(&priceList); err != nil {
+		log.Printf("error decoding price data: %v", err)
+		return nil, err
+	}
+
+	c := &PriceClient{prices: make(map[string][]model.Price)}
+	for _, price := range priceList {
+		if err := c.AddPrice(price); err != nil {
+			return nil, err
+		}
+	}
+	return c, nil
+}
+
+func (c *PriceClient) AddPrice(price model.Price) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.prices == nil {
+		c.prices = make(map[string][]model.Price)
+	}
+
+	history := c.prices[price.FundId]
+	i, found := slices.BinarySearchFunc(history, price.ValuedAt, func(p model.Price, t time.Time) int {
+		return p.ValuedAt.Compare(t)
+	})
+	if found {
+		return internal.ErrDuplicatePrice
+	}
+	c.prices[price.FundId] = slices.Insert(history, i, price)
+	return nil
+}
+
+// GetPrices returns the prices valued between from and to inclusive
+func (c *PriceClient) GetPrices(fundId string, from time.Time, to time.Time) (*[]model.Price, error) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	prices := []model.Price{}
+	for _, price := range c.prices[fundId] {
+		if !price.ValuedAt.Before(from) && !price.ValuedAt.After(to) {
+			prices = append(prices, price)
+		}
+	}
+	return &prices, nil
+}
+
+func (c *PriceClient) GetLatestPrice(fundId string) (*model.Price, error) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	history := c.prices[fundId]
+	if len(history) == 0 {
+		return nil, internal.PriceNotFoundError(fundId)
+	}
+	latest := history[len(history)-1]
+	return &latest, nil
+}
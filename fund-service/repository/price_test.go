@@ -0,0 +1,36 @@
+package repository_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/oliknight1/retail-isa-investment/fund-service/repository"
+)
+
+func TestNewPriceClientLoadsHistoryInOrder(t *testing.T) {
+	client, err := repository.NewPriceClient("./prices.json")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	prices, err := client.GetPrices("fund-global-bond", time.Time{}, time.Now())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(*prices) == 0 {
+		t.Fatal("expected seeded prices for fund-global-bond")
+	}
+	for i := 1; i < len(*prices); i++ {
+		if !(*prices)[i-1].ValuedAt.Before((*prices)[i].ValuedAt) {
+			t.Errorf("expected prices in valuation order, got %v before %v", (*prices)[i-1].ValuedAt, (*prices)[i].ValuedAt)
+		}
+	}
+
+	latest, err := client.GetLatestPrice("fund-global-bond")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !latest.DualPriced() || latest.ValuedAt != (*prices)[len(*prices)-1].ValuedAt {
+		t.Errorf("expected latest dual priced valuation, got %+v", latest)
+	}
+}
@@ -0,0 +1,54 @@
+package repository
+
+import (
+	"slices"
+	"strings"
+	"sync"
+
+	"github.com/oliknight1/retail-isa-investment/fund-service/internal"
+	"github.com/oliknight1/retail-isa-investment/fund-service/model"
+)
+
+type UnitRepository interface {
+	AddMovement(movement model.UnitMovement) error
+	GetUnitsInIssue() (*[]model.FundUnits, error)
+}
+
+// UnitClient is the register of each fund's units in issue, kept as the movements that make it
+// up
+type UnitClient struct {
+	mu        sync.RWMutex
+	movements map[string]model.UnitMovement
+}
+
+func NewUnitClient() *UnitClient {
+	return &UnitClient{movements: make(map[string]model.UnitMovement)}
+}
+
+func (c *UnitClient) AddMovement(movement model.UnitMovement) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if _, ok := c.movements[movement.Id]; ok {
+		return internal.ErrDuplicateMovement
+	}
+	c.movements[movement.Id] = movement
+	return nil
+}
+
+// GetUnitsInIssue totals the movements of every fund that has had any, in fund id order
+func (c *UnitClient) GetUnitsInIssue() (*[]model.FundUnits, error) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	totals := make(map[string]model.Units)
+	for _, movement := range c.movements {
+		totals[movement.FundId] += movement.Units
+	}
+	funds := []model.FundUnits{}
+	for fundId, units := range totals {
+		funds = append(funds, model.FundUnits{FundId: fundId, Units: units})
+	}
+	slices.SortFunc(funds, func(a, b model.FundUnits) int { return strings.Compare(a.FundId, b.FundId) })
+	return &funds, nil
+}
@@ -0,0 +1,96 @@
+package service
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/oliknight1/retail-isa-investment/fund-service/event"
+	"github.com/oliknight1/retail-isa-investment/fund-service/internal"
+	"github.com/oliknight1/retail-isa-investment/fund-service/logger"
+	"github.com/oliknight1/retail-isa-investment/fund-service/model"
+	"github.com/oliknight1/retail-isa-investment/fund-service/repository"
+	"go.uber.org/zap"
+)
+
+type DistributionService interface {
+	DeclareDistribution(distribution model.Distribution) (*model.Distribution, error)
+	GetDistributionsByFundId(fundId string) (*[]model.Distribution, error)
+	GetDistributions(from *time.Time, to *time.Time) (*[]model.Distribution, error)
+}
+
+type DistributionServiceImpl struct {
+	funds         repository.Repository
+	distributions repository.DistributionRepository
+	publisher     event.EventPublisher
+	Logger        logger.Logger
+}
+
+func NewDistributionService(funds repository.Repository, distributions repository.DistributionRepository, publisher event.EventPublisher, logger logger.Logger) *DistributionServiceImpl {
+	return &DistributionServiceImpl{
+		funds,
+		distributions,
+		publisher,
+		logger,
+	}
+}
+
+// DeclareDistribution records income a fund will pay per unit and publishes
+// fund.distribution.declared
+func (s *DistributionServiceImpl) DeclareDistribution(distribution model.Distribution) (*model.Distribution, error) {
+	if _, err := findFund(s.funds, s.Logger, distribution.FundId); err != nil {
+		return nil, err
+	}
+	if err := validateDistribution(distribution); err != nil {
+		s.Logger.Error("invalid distribution", zap.String("fund_id", distribution.FundId), zap.Error(err))
+		return nil, err
+	}
+
+	distribution.Id = uuid.New().String()
+	distribution.DeclaredAt = time.Now().UTC()
+	if err := s.distributions.AddDistribution(distribution); err != nil {
+		s.Logger.Error("error saving distribution", zap.String("fund_id", distribution.FundId), zap.Error(err))
+		return nil, err
+	}
+	if err := s.publisher.Publish("fund.distribution.declared", distribution); err != nil {
+		s.Logger.Error("error publishing fund.distribution.declared event", zap.Error(err))
+	}
+	return &distribution, nil
+}
+
+func (s *DistributionServiceImpl) GetDistributionsByFundId(fundId string) (*[]model.Distribution, error) {
+	if _, err := findFund(s.funds, s.Logger, fundId); err != nil {
+		return nil, err
+	}
+	return s.distributions.GetDistributionsByFundId(fundId)
+}
+
+// GetDistributions returns every fund's distributions with a pay date between from and to.
+// Either end can be left open
+func (s *DistributionServiceImpl) GetDistributions(from *time.Time, to *time.Time) (*[]model.Distribution, error) {
+	start, end := time.Time{}, time.Now()
+	if from != nil {
+		start = *from
+	}
+	if to != nil {
+		end = *to
+	}
+	if end.Before(start) {
+		s.Logger.Error("distribution range ends before it starts", zap.Error(internal.ErrInvalidDateRange))
+		return nil, internal.ErrInvalidDateRange
+	}
+	return s.distributions.GetDistributionsPaidBetween(start, end)
+}
+
+func validateDistribution(distribution model.Distribution) error {
+	if distribution.Rate <= 0 {
+		return fmt.Errorf("%w: rate must be positive", internal.ErrInvalidDistribution)
+	}
+	if distribution.ExDate.IsZero() || distribution.PayDate.IsZero() {
+		return fmt.Errorf("%w: exDate and payDate are required", internal.ErrInvalidDistribution)
+	}
+	if distribution.PayDate.Before(distribution.ExDate.Time) {
+		return fmt.Errorf("%w: payDate cannot be before exDate", internal.ErrInvalidDistribution)
+	}
+	return nil
+}
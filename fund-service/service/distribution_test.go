@@ -0,0 +1,100 @@
+package service_test
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/oliknight1/retail-isa-investment/fund-service/internal"
+	"github.com/oliknight1/retail-isa-investment/fund-service/logger"
+	"github.com/oliknight1/retail-isa-investment/fund-service/model"
+	"github.com/oliknight1/retail-isa-investment/fund-service/repository"
+	"github.com/oliknight1/retail-isa-investment/fund-service/service"
+)
+
+func TestDeclareDistribution(t *testing.T) {
+	var published []any
+	mockPub := &mockPublisher{
+		publishFn: func(subject string, payload any) error {
+			if subject != "fund.distribution.declared" {
+				t.Errorf("unexpected subject %s", subject)
+			}
+			published = append(published, payload)
+			return nil
+		},
+	}
+	svc := service.NewDistributionService(fundRepo(), repository.NewDistributionClient(), mockPub, logger.NewMockLogger())
+
+	for _, month := range []time.Month{time.September, time.March} {
+		distribution := model.Distribution{
+			FundId:  "fund-1",
+			Rate:    unitPrice(t, "0.0125"),
+			ExDate:  model.NewDate(2025, month, 1),
+			PayDate: model.NewDate(2025, month, 30),
+		}
+		declared, err := svc.DeclareDistribution(distribution)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if declared.Id == "" || declared.DeclaredAt.IsZero() {
+			t.Errorf("expected an id and declaration time, got %+v", declared)
+		}
+	}
+	if len(published) != 2 {
+		t.Errorf("expected an event for each distribution, got %d", len(published))
+	}
+
+	distributions, err := svc.GetDistributionsByFundId("fund-1")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(*distributions) != 2 || (*distributions)[0].ExDate.Month() != time.March {
+		t.Errorf("expected distributions in ex-dividend date order, got %+v", *distributions)
+	}
+
+	from, to := time.Date(2025, time.March, 30, 0, 0, 0, 0, time.UTC), time.Date(2025, time.April, 30, 0, 0, 0, 0, time.UTC)
+	paid, err := svc.GetDistributions(&from, &to)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(*paid) != 1 || (*paid)[0].PayDate.Month() != time.March {
+		t.Errorf("expected only the distribution paid in the range, got %+v", *paid)
+	}
+
+	duplicate := model.Distribution{FundId: "fund-1", Rate: 1, ExDate: model.NewDate(2025, time.March, 1), PayDate: model.NewDate(2025, time.March, 31)}
+	if _, err := svc.DeclareDistribution(duplicate); !errors.Is(err, internal.ErrDuplicateDistribution) {
+		t.Errorf("expected duplicate distribution error, got: %v", err)
+	}
+}
+
+func TestDeclareDistributionFailures(t *testing.T) {
+	valid := model.Distribution{FundId: "fund-1", Rate: 12_500, ExDate: model.NewDate(2025, time.June, 5), PayDate: model.NewDate(2025, time.June, 30)}
+	tests := []struct {
+		name        string
+		modify      func(*model.Distribution)
+		expectedErr error
+	}{
+		{"missing fund", func(d *model.Distribution) { d.FundId = "" }, internal.ErrMissingId},
+		{"unknown fund", func(d *model.Distribution) { d.FundId = "fund-unknown" }, internal.ErrFundNotFound},
+		{"zero rate", func(d *model.Distribution) { d.Rate = 0 }, internal.ErrInvalidDistribution},
+		{"missing ex-dividend date", func(d *model.Distribution) { d.ExDate = model.Date{} }, internal.ErrInvalidDistribution},
+		{"paid before going ex-dividend", func(d *model.Distribution) { d.PayDate = model.NewDate(2025, time.June, 4) }, internal.ErrInvalidDistribution},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockPub := &mockPublisher{
+				publishFn: func(subject string, payload any) error {
+					t.Fatal("no event should be published for an invalid distribution")
+					return nil
+				},
+			}
+			svc := service.NewDistributionService(fundRepo(), repository.NewDistributionClient(), mockPub, logger.NewMockLogger())
+
+			distribution := valid
+			tt.modify(&distribution)
+			if _, err := svc.DeclareDistribution(distribution); !errors.Is(err, tt.expectedErr) {
+				t.Errorf("expected %v, got: %v", tt.expectedErr, err)
+			}
+		})
+	}
+}
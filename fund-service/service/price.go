@@ -0,0 +1,125 @@
+package service
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/oliknight1/retail-isa-investment/fund-service/event"
+	"github.com/oliknight1/retail-isa-investment/fund-service/internal"
+	"github.com/oliknight1/retail-isa-investment/fund-service/logger"
+	"github.com/oliknight1/retail-isa-investment/fund-service/model"
+	"github.com/oliknight1/retail-isa-investment/fund-service/repository"
+	"go.uber.org/zap"
+)
+
+type PriceService interface {
+	GetPrices(fundId string, from *time.Time, to *time.Time) (*[]model.Price, error)
+	GetLatestPrice(fundId string) (*model.Price, error)
+	UpdatePrice(price model.Price) (*model.Price, error)
+}
+
+type PriceServiceImpl struct {
+	funds     repository.Repository
+	prices    repository.PriceRepository
+	publisher event.EventPublisher
+	Logger    logger.Logger
+}
+
+func NewPriceService(funds repository.Repository, prices repository.PriceRepository, publisher event.EventPublisher, logger logger.Logger) *PriceServiceImpl {
+	return &PriceServiceImpl{
+		funds,
+		prices,
+		publisher,
+		logger,
+	}
+}
+
+// GetPrices returns a fund's prices valued between from and to. Either end can be left open
+func (s *PriceServiceImpl) GetPrices(fundId string, from *time.Time, to *time.Time) (*[]model.Price, error) {
+	if err := s.checkFund(fundId); err != nil {
+		return nil, err
+	}
+
+	start, end := time.Time{}, time.Now()
+	if from != nil {
+		start = *from
+	}
+	if to != nil {
+		end = *to
+	}
+	if end.Before(start) {
+		s.Logger.Error("price range ends before it starts", zap.Error(internal.ErrInvalidDateRange))
+		return nil, internal.ErrInvalidDateRange
+	}
+	return s.prices.GetPrices(fundId, start, end)
+}
+
+func (s *PriceServiceImpl) GetLatestPrice(fundId string) (*model.Price, error) {
+	if err := s.checkFund(fundId); err != nil {
+		return nil, err
+	}
+	return s.prices.GetLatestPrice(fundId)
+}
+
+// UpdatePrice adds a new valuation to a fund's price history and publishes fund.price.updated
+func (s *PriceServiceImpl) UpdatePrice(price model.Price) (*model.Price, error) {
+	if err := s.checkFund(price.FundId); err != nil {
+		return nil, err
+	}
+	if err := validatePrice(price); err != nil {
+		s.Logger.Error("invalid fund price", zap.String("fund_id", price.FundId), zap.Error(err))
+		return nil, err
+	}
+
+	price.ValuedAt = price.ValuedAt.UTC()
+	if err := s.prices.AddPrice(price); err != nil {
+		s.Logger.Error("error saving fund price", zap.String("fund_id", price.FundId), zap.Error(err))
+		return nil, err
+	}
+	if err := s.publisher.Publish("fund.price.updated", price); err != nil {
+		s.Logger.Error("error publishing fund.price.updated event", zap.Error(err))
+	}
+	return &price, nil
+}
+
+func (s *PriceServiceImpl) checkFund(fundId string) error {
+	_, err := findFund(s.funds, s.Logger, fundId)
+	return err
+}
+
+// findFund looks up a fund that prices or distributions are being read or added for
+func findFund(funds repository.Repository, l logger.Logger, fundId string) (*model.Fund, error) {
+	if fundId == "" {
+		l.Error("missing fund_id", zap.Error(internal.ErrMissingId))
+		return nil, internal.ErrMissingId
+	}
+	fund, err := funds.GetFundById(fundId)
+	if err != nil {
+		l.Error("error fetching fund", zap.Error(err))
+		return nil, err
+	}
+	if fund == nil {
+		l.Error("fund not found", zap.String("fund_id", fundId))
+		return nil, internal.FundNotFoundError(fundId)
+	}
+	return fund, nil
+}
+
+func validatePrice(price model.Price) error {
+	if price.Nav <= 0 {
+		return fmt.Errorf("%w: nav must be positive", internal.ErrInvalidPrice)
+	}
+	if (price.Bid == nil) != (price.Offer == nil) {
+		return fmt.Errorf("%w: bid and offer must be given together", internal.ErrInvalidPrice)
+	}
+	if price.DualPriced() && (*price.Bid <= 0 || *price.Bid > *price.Offer) {
+		return fmt.Errorf("%w: bid must be positive and no more than offer", internal.ErrInvalidPrice)
+	}
+	if price.ValuedAt.IsZero() {
+		return fmt.Errorf("%w: valuedAt is required", internal.ErrInvalidPrice)
+	}
+	if price.ValuedAt.After(time.Now()) {
+		return fmt.Errorf("%w: valuedAt cannot be in the future", internal.ErrInvalidPrice)
+	}
+	return nil
+}
@@ -0,0 +1,140 @@
+package service_test
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/oliknight1/retail-isa-investment/fund-service/internal"
+	"github.com/oliknight1/retail-isa-investment/fund-service/logger"
+	"github.com/oliknight1/retail-isa-investment/fund-service/model"
+	"github.com/oliknight1/retail-isa-investment/fund-service/repository"
+	"github.com/oliknight1/retail-isa-investment/fund-service/service"
+)
+
+type mockPublisher struct {
+	publishFn func(subject string, payload any) error
+}
+
+func (m *mockPublisher) Publish(subject string, payload any) error {
+	return m.publishFn(subject, payload)
+}
+
+func fundRepo() *mockRepo {
+	return &mockRepo{
+		getFundByIdFn: func(id string) (*model.Fund, error) {
+			if id != "fund-1" {
+				return nil, nil
+			}
+			return &model.Fund{Id: id}, nil
+		},
+	}
+}
+
+func unitPrice(t *testing.T, s string) model.UnitPrice {
+	t.Helper()
+	price, err := model.ParseUnitPrice(s)
+	if err != nil {
+		t.Fatalf("invalid unit price %q: %v", s, err)
+	}
+	return price
+}
+
+func TestUpdatePrice(t *testing.T) {
+	var published []any
+	mockPub := &mockPublisher{
+		publishFn: func(subject string, payload any) error {
+			if subject != "fund.price.updated" {
+				t.Errorf("unexpected subject %s", subject)
+			}
+			published = append(published, payload)
+			return nil
+		},
+	}
+	svc := service.NewPriceService(fundRepo(), &repository.PriceClient{}, mockPub, logger.NewMockLogger())
+
+	monday := time.Date(2025, time.June, 2, 12, 0, 0, 0, time.UTC)
+	for i, nav := range []string{"1.000000", "1.012345", "0.998700"} {
+		price := model.Price{FundId: "fund-1", Nav: unitPrice(t, nav), ValuedAt: monday.AddDate(0, 0, i)}
+		if _, err := svc.UpdatePrice(price); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}
+	if len(published) != 3 {
+		t.Errorf("expected an event for each price, got %d", len(published))
+	}
+
+	latest, err := svc.GetLatestPrice("fund-1")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if latest.Nav != unitPrice(t, "0.9987") {
+		t.Errorf("expected latest nav 0.998700, got %s", latest.Nav)
+	}
+
+	from, to := monday.AddDate(0, 0, 1), monday.AddDate(0, 0, 2)
+	prices, err := svc.GetPrices("fund-1", &from, &to)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	expected := []model.Price{
+		{FundId: "fund-1", Nav: unitPrice(t, "1.012345"), ValuedAt: from},
+		{FundId: "fund-1", Nav: unitPrice(t, "0.9987"), ValuedAt: to},
+	}
+	if diff := cmp.Diff(expected, *prices); diff != "" {
+		t.Errorf("unexpected prices (-want +got):\n%s", diff)
+	}
+
+	if _, err := svc.UpdatePrice(model.Price{FundId: "fund-1", Nav: unitPrice(t, "1.1"), ValuedAt: monday}); !errors.Is(err, internal.ErrDuplicatePrice) {
+		t.Errorf("expected duplicate price error, got: %v", err)
+	}
+}
+
+func TestUpdatePriceFailures(t *testing.T) {
+	bid, offer := model.UnitPrice(990_000), model.UnitPrice(1_010_000)
+	valid := model.Price{FundId: "fund-1", Nav: 1_000_000, Bid: &bid, Offer: &offer, ValuedAt: time.Now().Add(-time.Hour)}
+	tests := []struct {
+		name        string
+		modify      func(*model.Price)
+		expectedErr error
+	}{
+		{"missing fund", func(p *model.Price) { p.FundId = "" }, internal.ErrMissingId},
+		{"unknown fund", func(p *model.Price) { p.FundId = "fund-unknown" }, internal.ErrFundNotFound},
+		{"zero nav", func(p *model.Price) { p.Nav = 0 }, internal.ErrInvalidPrice},
+		{"bid without offer", func(p *model.Price) { p.Offer = nil }, internal.ErrInvalidPrice},
+		{"bid above offer", func(p *model.Price) { p.Bid, p.Offer = &offer, &bid }, internal.ErrInvalidPrice},
+		{"missing valuation time", func(p *model.Price) { p.ValuedAt = time.Time{} }, internal.ErrInvalidPrice},
+		{"valued in the future", func(p *model.Price) { p.ValuedAt = time.Now().Add(time.Hour) }, internal.ErrInvalidPrice},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockPub := &mockPublisher{
+				publishFn: func(subject string, payload any) error {
+					t.Fatal("no event should be published for an invalid price")
+					return nil
+				},
+			}
+			svc := service.NewPriceService(fundRepo(), &repository.PriceClient{}, mockPub, logger.NewMockLogger())
+
+			price := valid
+			tt.modify(&price)
+			if _, err := svc.UpdatePrice(price); !errors.Is(err, tt.expectedErr) {
+				t.Errorf("expected %v, got: %v", tt.expectedErr, err)
+			}
+		})
+	}
+}
+
+func TestGetPricesInvalidRange(t *testing.T) {
+	svc := service.NewPriceService(fundRepo(), &repository.PriceClient{}, nil, logger.NewMockLogger())
+
+	from := time.Date(2025, time.June, 2, 0, 0, 0, 0, time.UTC)
+	to := from.AddDate(0, 0, -1)
+	if _, err := svc.GetPrices("fund-1", &from, &to); !errors.Is(err, internal.ErrInvalidDateRange) {
+		t.Errorf("expected invalid date range error, got: %v", err)
+	}
+	if _, err := svc.GetLatestPrice("fund-1"); !errors.Is(err, internal.ErrPriceNotFound) {
+		t.Errorf("expected price not found for a fund with no prices, got: %v", err)
+	}
+}
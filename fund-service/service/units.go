@@ -0,0 +1,61 @@
+package service
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/oliknight1/retail-isa-investment/fund-service/internal"
+	"github.com/oliknight1/retail-isa-investment/fund-service/logger"
+	"github.com/oliknight1/retail-isa-investment/fund-service/model"
+	"github.com/oliknight1/retail-isa-investment/fund-service/repository"
+	"go.uber.org/zap"
+)
+
+type UnitService interface {
+	RecordMovement(movement model.UnitMovement) error
+	GetUnitsInIssue() (*[]model.FundUnits, error)
+}
+
+type UnitServiceImpl struct {
+	units  repository.UnitRepository
+	Logger logger.Logger
+}
+
+func NewUnitService(units repository.UnitRepository, logger logger.Logger) *UnitServiceImpl {
+	return &UnitServiceImpl{units, logger}
+}
+
+// RecordMovement adds a movement to the unit register. A movement already recorded is ignored,
+// as investment-service may report the same one again
+func (s *UnitServiceImpl) RecordMovement(movement model.UnitMovement) error {
+	if movement.Id == "" || movement.FundId == "" || movement.Units == 0 {
+		err := fmt.Errorf("%w: id, fundId and units are required", internal.ErrInvalidMovement)
+		s.Logger.Error("invalid unit movement", zap.Error(err))
+		return err
+	}
+	err := s.units.AddMovement(movement)
+	if errors.Is(err, internal.ErrDuplicateMovement) {
+		s.Logger.Info("unit movement already recorded", zap.String("movement_id", movement.Id))
+		return nil
+	}
+	if err != nil {
+		s.Logger.Error("error saving unit movement", zap.String("movement_id", movement.Id), zap.Error(err))
+		return err
+	}
+	return nil
+}
+
+func (s *UnitServiceImpl) GetUnitsInIssue() (*[]model.FundUnits, error) {
+	return s.units.GetUnitsInIssue()
+}
+
+// OnUnitsMoved handles investment.units.moved events
+func (s *UnitServiceImpl) OnUnitsMoved(data []byte) {
+	var movement model.UnitMovement
+	if err := json.Unmarshal(data, &movement); err != nil {
+		s.Logger.Error("failed to decode investment.units.moved event", zap.Error(err))
+		return
+	}
+	s.RecordMovement(movement)
+}
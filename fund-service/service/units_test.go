@@ -0,0 +1,52 @@
+package service_test
+
+import (
+	"encoding/json"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/oliknight1/retail-isa-investment/fund-service/internal"
+	"github.com/oliknight1/retail-isa-investment/fund-service/logger"
+	"github.com/oliknight1/retail-isa-investment/fund-service/model"
+	"github.com/oliknight1/retail-isa-investment/fund-service/repository"
+	"github.com/oliknight1/retail-isa-investment/fund-service/service"
+)
+
+func TestRecordMovement(t *testing.T) {
+	svc := service.NewUnitService(repository.NewUnitClient(), logger.NewMockLogger())
+	now := time.Date(2025, time.June, 2, 12, 0, 0, 0, time.UTC)
+
+	movements := []model.UnitMovement{
+		{Id: "inv-1:dealt", FundId: "fund-2", InvestmentId: "inv-1", Units: 1_000_000, At: now},
+		{Id: "inv-2:dealt", FundId: "fund-1", InvestmentId: "inv-2", Units: 500_000, At: now},
+		{Id: "inv-3:dealt", FundId: "fund-1", InvestmentId: "inv-3", Units: -125_000, At: now},
+		// reported twice, so only counted once
+		{Id: "inv-3:dealt", FundId: "fund-1", InvestmentId: "inv-3", Units: -125_000, At: now},
+	}
+	for _, movement := range movements {
+		if err := svc.RecordMovement(movement); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}
+	data, _ := json.Marshal(model.UnitMovement{Id: "inv-1:failed", FundId: "fund-2", InvestmentId: "inv-1", Units: -1_000_000, At: now})
+	svc.OnUnitsMoved(data)
+
+	units, err := svc.GetUnitsInIssue()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	expected := []model.FundUnits{{FundId: "fund-1", Units: 375_000}, {FundId: "fund-2", Units: 0}}
+	if len(*units) != len(expected) {
+		t.Fatalf("expected %d funds, got %+v", len(expected), *units)
+	}
+	for i, fund := range expected {
+		if (*units)[i] != fund {
+			t.Errorf("expected %+v, got %+v", fund, (*units)[i])
+		}
+	}
+
+	if err := svc.RecordMovement(model.UnitMovement{Id: "inv-4:dealt", FundId: "fund-1"}); !errors.Is(err, internal.ErrInvalidMovement) {
+		t.Errorf("expected invalid movement error, got: %v", err)
+	}
+}
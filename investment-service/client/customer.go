@@ -0,0 +1,59 @@
+// Package client holds HTTP clients for the other services investment-service depends on
+package client
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/oliknight1/retail-isa-investment/investment-service/model"
+)
+
+type CustomerClient interface {
+	GetCustomerById(id string) (*model.Customer, error)
+	GetEligibility(id string, productType model.ProductType) (*model.Eligibility, error)
+}
+
+type CustomerHTTPClient struct {
+	baseURL    string
+	httpClient *http.Client
+}
+
+func NewCustomerClient(baseURL string) *CustomerHTTPClient {
+	return &CustomerHTTPClient{
+		baseURL:    baseURL,
+		httpClient: &http.Client{Timeout: 5 * time.Second},
+	}
+}
+
+func (c *CustomerHTTPClient) GetCustomerById(id string) (*model.Customer, error) {
+	var customer model.Customer
+	if err := c.get(fmt.Sprintf("/customer/%s", url.PathEscape(id)), &customer); err != nil {
+		return nil, fmt.Errorf("error fetching customer %s: %w", id, err)
+	}
+	return &customer, nil
+}
+
+func (c *CustomerHTTPClient) GetEligibility(id string, productType model.ProductType) (*model.Eligibility, error) {
+	var eligibility model.Eligibility
+	path := fmt.Sprintf("/customer/%s/eligibility?product=%s", url.PathEscape(id), url.QueryEscape(string(productType)))
+	if err := c.get(path, &eligibility); err != nil {
+		return nil, fmt.Errorf("error checking eligibility of customer %s: %w", id, err)
+	}
+	return &eligibility, nil
+}
+
+func (c *CustomerHTTPClient) get(path string, out any) error {
+	res, err := c.httpClient.Get(c.baseURL + path)
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode != http.StatusOK {
+		return fmt.Errorf("customer-service responded with status %d", res.StatusCode)
+	}
+	return json.NewDecoder(res.Body).Decode(out)
+}
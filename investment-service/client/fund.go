@@ -0,0 +1,89 @@
+package client
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/oliknight1/retail-isa-investment/investment-service/model"
+)
+
+type FundClient interface {
+	GetFund(fundId string) (*model.Fund, error)
+	GetLatestPrice(fundId string) (*model.FundPrice, error)
+	GetPrices(fundId string, from time.Time, to time.Time) (*[]model.FundPrice, error)
+	GetDistributions(paidBy model.Date) (*[]model.Distribution, error)
+	GetUnitsInIssue() (*[]model.FundUnits, error)
+}
+
+type FundHTTPClient struct {
+	baseURL    string
+	httpClient *http.Client
+}
+
+func NewFundClient(baseURL string) *FundHTTPClient {
+	return &FundHTTPClient{
+		baseURL:    baseURL,
+		httpClient: &http.Client{Timeout: 5 * time.Second},
+	}
+}
+
+func (c *FundHTTPClient) GetFund(fundId string) (*model.Fund, error) {
+	var fund model.Fund
+	if err := c.get(fmt.Sprintf("/funds/%s", url.PathEscape(fundId)), &fund); err != nil {
+		return nil, fmt.Errorf("error fetching fund %s: %w", fundId, err)
+	}
+	return &fund, nil
+}
+
+func (c *FundHTTPClient) GetLatestPrice(fundId string) (*model.FundPrice, error) {
+	var price model.FundPrice
+	if err := c.get(fmt.Sprintf("/funds/%s/price/latest", url.PathEscape(fundId)), &price); err != nil {
+		return nil, fmt.Errorf("error fetching latest price of fund %s: %w", fundId, err)
+	}
+	return &price, nil
+}
+
+// GetPrices returns the fund's prices valued between from and to inclusive
+func (c *FundHTTPClient) GetPrices(fundId string, from time.Time, to time.Time) (*[]model.FundPrice, error) {
+	var prices []model.FundPrice
+	query := url.Values{"from": {from.Format(time.RFC3339)}, "to": {to.Format(time.RFC3339)}}
+	if err := c.get(fmt.Sprintf("/funds/%s/prices?%s", url.PathEscape(fundId), query.Encode()), &prices); err != nil {
+		return nil, fmt.Errorf("error fetching prices of fund %s: %w", fundId, err)
+	}
+	return &prices, nil
+}
+
+// GetDistributions returns every fund's distributions with a pay date on or before paidBy
+func (c *FundHTTPClient) GetDistributions(paidBy model.Date) (*[]model.Distribution, error) {
+	var distributions []model.Distribution
+	query := url.Values{"to": {paidBy.String()}}
+	if err := c.get("/distributions?"+query.Encode(), &distributions); err != nil {
+		return nil, fmt.Errorf("error fetching distributions: %w", err)
+	}
+	return &distributions, nil
+}
+
+// GetUnitsInIssue returns how many units of each fund fund-service has in issue
+func (c *FundHTTPClient) GetUnitsInIssue() (*[]model.FundUnits, error) {
+	var units []model.FundUnits
+	if err := c.get("/units", &units); err != nil {
+		return nil, fmt.Errorf("error fetching units in issue: %w", err)
+	}
+	return &units, nil
+}
+
+func (c *FundHTTPClient) get(path string, out any) error {
+	res, err := c.httpClient.Get(c.baseURL + path)
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode != http.StatusOK {
+		return fmt.Errorf("fund-service responded with status %d", res.StatusCode)
+	}
+	return json.NewDecoder(res.Body).Decode(out)
+}
@@ -1,18 +1,24 @@
 package main
 
 import (
+	"context"
 	"fmt"
 	"log"
 	"net/http"
 	"os"
 	"strings"
+	"time"
 
+	"github.com/oliknight1/retail-isa-investment/investment-service/client"
+	"github.com/oliknight1/retail-isa-investment/investment-service/dealing"
 	"github.com/oliknight1/retail-isa-investment/investment-service/event"
+	"github.com/oliknight1/retail-isa-investment/investment-service/fee"
 	"github.com/oliknight1/retail-isa-investment/investment-service/handler"
 	"github.com/oliknight1/retail-isa-investment/investment-service/internal"
 	"github.com/oliknight1/retail-isa-investment/investment-service/logger"
 	"github.com/oliknight1/retail-isa-investment/investment-service/repository"
 	"github.com/oliknight1/retail-isa-investment/investment-service/service"
+	"github.com/oliknight1/retail-isa-investment/investment-service/taxyear"
 	"github.com/prometheus/client_golang/prometheus"
 )
 
@@ -31,6 +37,16 @@ func main() {
 	)
 
 	repo := repository.NewInvestmentClient()
+	allowanceRepo := repository.NewAllowanceClient()
+	accountRepo := repository.NewAccountClient()
+	bonusRepo := repository.NewBonusClient()
+	transferRepo := repository.NewTransferClient()
+	switchRepo := repository.NewSwitchClient()
+	planRepo := repository.NewPlanClient()
+	feeRepo := repository.NewFeeClient()
+	distributionRepo := repository.NewDistributionClient()
+	ledgerRepo := repository.NewLedgerClient()
+	reconciliationRepo := repository.NewReconciliationClient()
 	natsURL := os.Getenv("NATS_URL")
 	if natsURL == "" {
 		natsURL = "nats://localhost:4222"
@@ -38,16 +54,96 @@ func main() {
 
 	publisher, err := event.NewNatsPublisher(natsURL)
 	if err != nil {
-		log.Printf("error connecting to publisher: %v", err)
+		log.Printf("error connecting to publisher, events will not be sent or received: %v", err)
+		publisher = event.NopPublisher{}
 	}
-	svc := service.New(repo, publisher, logger)
+	limits := taxyear.DefaultLimits()
+	if limitsPath := os.Getenv("ALLOWANCE_LIMITS_PATH"); limitsPath != "" {
+		limits, err = taxyear.LoadLimits(limitsPath)
+		if err != nil {
+			log.Fatalf("failed to load allowance limits: %v", err)
+		}
+	}
+
+	allowanceSvc := service.NewAllowanceService(allowanceRepo, limits, logger)
+	customerURL := os.Getenv("CUSTOMER_SERVICE_URL")
+	if customerURL == "" {
+		customerURL = "http://localhost:8081"
+	}
+	customers := client.NewCustomerClient(customerURL)
+	fundURL := os.Getenv("FUND_SERVICE_URL")
+	if fundURL == "" {
+		fundURL = "http://localhost:8082"
+	}
+	funds := client.NewFundClient(fundURL)
+	schedules := dealing.DefaultSchedules()
+	if schedulePath := os.Getenv("DEALING_SCHEDULE_PATH"); schedulePath != "" {
+		schedules, err = dealing.LoadSchedules(schedulePath)
+		if err != nil {
+			log.Fatalf("failed to load dealing schedules: %v", err)
+		}
+	}
+	feeSchedule := fee.DefaultSchedule()
+	if feeSchedulePath := os.Getenv("FEE_SCHEDULE_PATH"); feeSchedulePath != "" {
+		feeSchedule, err = fee.LoadSchedule(feeSchedulePath)
+		if err != nil {
+			log.Fatalf("failed to load fee schedule: %v", err)
+		}
+	}
+
+	locks := service.NewLocks()
+	ledgerSvc := service.NewLedgerService(ledgerRepo, publisher, logger)
+	accountSvc := service.NewAccountService(accountRepo, customers, ledgerSvc, locks, publisher, logger)
+	bonusSvc := service.NewBonusService(bonusRepo, logger)
+	svc := service.New(repo, accountRepo, customers, funds, allowanceSvc, bonusSvc, ledgerSvc, locks, publisher, logger)
+	transferSvc := service.NewTransferService(transferRepo, accountRepo, repo, funds, allowanceSvc, ledgerSvc, locks, publisher, logger)
+	reportSvc := service.NewSubscriptionReportService(repo, accountRepo, customers, logger)
+	switchSvc := service.NewSwitchService(switchRepo, repo, accountRepo, funds, ledgerSvc, locks, publisher, logger)
+	dealingSvc := service.NewDealingService(repo, ledgerSvc, switchSvc, funds, schedules, locks, publisher, logger)
+	portfolioSvc := service.NewPortfolioService(repo, accountRepo, funds, ledgerSvc, logger)
+	performanceSvc := service.NewPerformanceService(repo, accountRepo, funds, logger)
+	planSvc := service.NewPlanService(planRepo, accountRepo, svc, allowanceSvc, publisher, logger)
+	feeSvc := service.NewFeeService(feeRepo, repo, accountRepo, funds, ledgerSvc, feeSchedule, locks, publisher, logger)
+	distributionSvc := service.NewDistributionService(distributionRepo, repo, accountRepo, funds, ledgerSvc, locks, publisher, logger)
+	reconciliationSvc := service.NewReconciliationService(reconciliationRepo, repo, ledgerRepo, funds, publisher, logger)
 	ih := handler.New(svc, logger)
+	ah := handler.NewAllowanceHandler(allowanceSvc, logger)
+	acch := handler.NewAccountHandler(accountSvc, logger)
+	bh := handler.NewBonusHandler(bonusSvc, logger)
+	th := handler.NewTransferHandler(transferSvc, logger)
+	reph := handler.NewSubscriptionReportHandler(reportSvc, logger)
+	dh := handler.NewDealingHandler(dealingSvc, logger)
+	sh := handler.NewSwitchHandler(switchSvc, logger)
+	ph := handler.NewPortfolioHandler(portfolioSvc, logger)
+	perfh := handler.NewPerformanceHandler(performanceSvc, logger)
+	plh := handler.NewPlanHandler(planSvc, logger)
+	fh := handler.NewFeeHandler(feeSvc, logger)
+	disth := handler.NewDistributionHandler(distributionSvc, logger)
+	lh := handler.NewLedgerHandler(ledgerSvc, logger)
+	rech := handler.NewReconciliationHandler(reconciliationSvc, logger)
+
+	if err := publisher.Subscribe("customer.jisa.matured", accountSvc.OnJisaMatured); err != nil {
+		log.Printf("error subscribing to customer.jisa.matured: %v", err)
+	}
+	if err := publisher.Subscribe("investment.status.*", reconciliationSvc.OnStatusChanged); err != nil {
+		log.Printf("error subscribing to investment.status.*: %v", err)
+	}
+
+	rollover := service.NewRolloverService(allowanceRepo, publisher, logger, time.Now())
+	go rollover.Start(context.Background(), time.Hour)
+	go dealingSvc.Start(context.Background(), time.Minute)
+	go planSvc.Start(context.Background(), time.Hour)
+	go feeSvc.Start(context.Background(), time.Hour)
+	go distributionSvc.Start(context.Background(), time.Hour)
+	go reconciliationSvc.Start(context.Background(), 24*time.Hour)
 
 	http.HandleFunc("/health", func(w http.ResponseWriter, r *http.Request) {
 		w.WriteHeader(http.StatusOK)
 		w.Write([]byte(`{"status":"ok"}`))
 	})
 	http.HandleFunc("POST /investments", ih.CreateInvestment)
+	http.HandleFunc("POST /investments/{id}/cancel", ih.CancelInvestment)
+	http.HandleFunc("POST /admin/investments/{id}/status", ih.UpdateInvestmentStatus)
 
 	http.HandleFunc("GET /investments/", func(w http.ResponseWriter, r *http.Request) {
 		path := r.URL.Path
@@ -60,6 +156,49 @@ func main() {
 		ih.GetInvestmentById(w, r)
 	})
 
+	http.HandleFunc("GET /customers/{id}/allowance", ah.GetAllowance)
+	http.HandleFunc("GET /customers/{id}/portfolio", ph.GetPortfolio)
+	http.HandleFunc("GET /customers/{id}/performance", perfh.GetCustomerPerformance)
+	http.HandleFunc("GET /customers/{id}/plans", plh.GetPlansByCustomerId)
+	http.HandleFunc("GET /customers/{id}/fees", fh.GetFeesByCustomerId)
+	http.HandleFunc("GET /customers/{id}/distributions", disth.GetDistributionsByCustomerId)
+	http.HandleFunc("GET /customers/{id}/ledger", lh.GetEntriesByCustomerId)
+
+	http.HandleFunc("POST /accounts", acch.OpenAccount)
+	http.HandleFunc("GET /accounts/{id}", acch.GetAccountById)
+	http.HandleFunc("GET /customers/{id}/accounts", acch.GetAccountsByCustomerId)
+	http.HandleFunc("PUT /accounts/{id}/income-preference", acch.SetIncomePreference)
+	http.HandleFunc("GET /accounts/{id}/cash", acch.GetCashBalance)
+	http.HandleFunc("GET /accounts/{id}/ledger/balances", lh.GetBalances)
+	http.HandleFunc("POST /accounts/{id}/deposits", ih.Deposit)
+	http.HandleFunc("POST /accounts/{id}/purchases", ih.Buy)
+	http.HandleFunc("POST /accounts/{id}/withdrawals", ih.Withdraw)
+	http.HandleFunc("POST /accounts/{id}/redemptions", ih.Redeem)
+	http.HandleFunc("POST /accounts/{id}/switches", sh.RequestSwitch)
+	http.HandleFunc("GET /accounts/{id}/switches", sh.GetSwitchesByAccountId)
+	http.HandleFunc("GET /accounts/{id}/performance", perfh.GetAccountPerformance)
+	http.HandleFunc("GET /switches/{id}", sh.GetSwitchById)
+	http.HandleFunc("POST /plans", plh.CreatePlan)
+	http.HandleFunc("GET /plans/{id}", plh.GetPlanById)
+	http.HandleFunc("POST /plans/{id}/pause", plh.PausePlan)
+	http.HandleFunc("POST /plans/{id}/resume", plh.ResumePlan)
+	http.HandleFunc("POST /plans/{id}/cancel", plh.CancelPlan)
+	http.HandleFunc("GET /accounts/{id}/transfers", th.GetTransfersByAccountId)
+
+	http.HandleFunc("POST /transfers", th.RequestTransfer)
+	http.HandleFunc("GET /transfers/{id}", th.GetTransferById)
+	http.HandleFunc("POST /transfers/{id}/status", th.UpdateTransferStatus)
+
+	http.HandleFunc("GET /admin/lisa/claims", bh.GetClaimFile)
+	http.HandleFunc("GET /admin/subscription-reports/{taxYear}", reph.GetReport)
+	http.HandleFunc("GET /admin/subscription-reports/{taxYear}/validation", reph.GetReportValidation)
+	http.HandleFunc("POST /admin/dealing/run", dh.RunDealing)
+	http.HandleFunc("POST /admin/fees/run", fh.RunFees)
+	http.HandleFunc("POST /admin/distributions/run", disth.RunDistributions)
+	http.HandleFunc("POST /admin/ledger/entries/{id}/reverse", lh.ReverseEntry)
+	http.HandleFunc("POST /admin/reconciliation/run", rech.RunReconciliation)
+	http.HandleFunc("GET /admin/reconciliation", rech.GetLatestReconciliation)
+
 	log.Println("Customer service running on :8080")
 	log.Fatal(http.ListenAndServe(":8080", nil))
 }
@@ -0,0 +1,216 @@
+// Package dealing decides when fund orders are priced. Orders are forward priced: each one is
+// dealt at the next valuation point whose cut-off it arrived before, never at a price already
+// known when it was placed. Weekends and bank holidays in England and Wales are not dealing days
+package dealing
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/oliknight1/retail-isa-investment/investment-service/taxyear"
+)
+
+// Clock is a time of day in London, written as "HH:MM"
+type Clock struct {
+	Hour   int
+	Minute int
+}
+
+func (c Clock) String() string {
+	return fmt.Sprintf("%02d:%02d", c.Hour, c.Minute)
+}
+
+func (c Clock) MarshalText() ([]byte, error) {
+	return []byte(c.String()), nil
+}
+
+func (c *Clock) UnmarshalText(text []byte) error {
+	t, err := time.Parse("15:04", string(text))
+	if err != nil {
+		return fmt.Errorf("invalid time of day %q: expected format HH:MM", text)
+	}
+	*c = Clock{Hour: t.Hour(), Minute: t.Minute()}
+	return nil
+}
+
+func (c Clock) on(day time.Time) time.Time {
+	return time.Date(day.Year(), day.Month(), day.Day(), c.Hour, c.Minute, 0, 0, day.Location())
+}
+
+func (c Clock) after(o Clock) bool {
+	return c.Hour > o.Hour || (c.Hour == o.Hour && c.Minute > o.Minute)
+}
+
+// Schedule is a fund's daily dealing cycle. Orders received before the cut-off on a dealing
+// day are priced at that day's valuation point, anything later waits for the next dealing day
+type Schedule struct {
+	CutOff         Clock `json:"cutOff"`
+	ValuationPoint Clock `json:"valuationPoint"`
+}
+
+// Schedules holds the dealing schedule for every fund, with Default used for any fund not
+// listed. ExtraHolidays are one-off non-dealing days such as a royal event, as "2006-01-02"
+type Schedules struct {
+	Default       Schedule            `json:"default"`
+	Funds         map[string]Schedule `json:"funds"`
+	ExtraHolidays []string            `json:"extraHolidays"`
+	extra         map[string]bool
+}
+
+// DefaultSchedules deals every fund at midday with a midday cut-off
+func DefaultSchedules() Schedules {
+	return Schedules{
+		Default: Schedule{CutOff: Clock{12, 0}, ValuationPoint: Clock{12, 0}},
+	}
+}
+
+// LoadSchedules reads schedules from a JSON file of the form
+// {"default": {"cutOff": "12:00", "valuationPoint": "12:00"}, "funds": {...}, "extraHolidays": [...]}
+func LoadSchedules(path string) (Schedules, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return Schedules{}, err
+	}
+	schedules := DefaultSchedules()
+	if err := json.Unmarshal(data, &schedules); err != nil {
+		return Schedules{}, fmt.Errorf("invalid dealing schedules in %s: %w", path, err)
+	}
+	if err := schedules.validate(); err != nil {
+		return Schedules{}, fmt.Errorf("invalid dealing schedules in %s: %w", path, err)
+	}
+	return schedules, nil
+}
+
+func (s *Schedules) validate() error {
+	check := func(name string, schedule Schedule) error {
+		if schedule.CutOff.after(schedule.ValuationPoint) {
+			return fmt.Errorf("%s cut-off %s is after its valuation point %s", name, schedule.CutOff, schedule.ValuationPoint)
+		}
+		return nil
+	}
+	if err := check("default", s.Default); err != nil {
+		return err
+	}
+	for fundId, schedule := range s.Funds {
+		if err := check(fundId, schedule); err != nil {
+			return err
+		}
+	}
+	s.extra = make(map[string]bool)
+	for _, day := range s.ExtraHolidays {
+		if _, err := time.Parse(time.DateOnly, day); err != nil {
+			return fmt.Errorf("invalid holiday %q: expected format YYYY-MM-DD", day)
+		}
+		s.extra[day] = true
+	}
+	return nil
+}
+
+// For returns the schedule a fund deals on
+func (s Schedules) For(fundId string) Schedule {
+	if schedule, ok := s.Funds[fundId]; ok {
+		return schedule
+	}
+	return s.Default
+}
+
+// IsDealingDay reports whether funds are dealt on the day t falls on in London
+func (s Schedules) IsDealingDay(t time.Time) bool {
+	local := taxyear.InLondon(t)
+	if local.Weekday() == time.Saturday || local.Weekday() == time.Sunday {
+		return false
+	}
+	day := local.Format(time.DateOnly)
+	if s.extra[day] {
+		return false
+	}
+	for _, holiday := range BankHolidays(local.Year()) {
+		if holiday.Format(time.DateOnly) == day {
+			return false
+		}
+	}
+	return true
+}
+
+// ValuationPoint returns when an order for fundId received at the given time is priced
+func (s Schedules) ValuationPoint(fundId string, received time.Time) time.Time {
+	schedule := s.For(fundId)
+	day := taxyear.InLondon(received)
+	if !s.IsDealingDay(day) || !received.Before(schedule.CutOff.on(day)) {
+		day = s.nextDealingDay(day)
+	}
+	return schedule.ValuationPoint.on(day)
+}
+
+func (s Schedules) nextDealingDay(t time.Time) time.Time {
+	day := t.AddDate(0, 0, 1)
+	for !s.IsDealingDay(day) {
+		day = day.AddDate(0, 0, 1)
+	}
+	return day
+}
+
+// BankHolidays returns the regular bank holidays in England and Wales for a year, moved to the
+// next weekday where they fall at a weekend. One-off changes such as a moved May holiday are
+// not included and should be added to ExtraHolidays
+func BankHolidays(year int) []time.Time {
+	date := func(month time.Month, day int) time.Time {
+		return taxyear.InLondon(time.Date(year, month, day, 12, 0, 0, 0, time.UTC))
+	}
+	firstMonday := func(month time.Month) time.Time {
+		d := date(month, 1)
+		return d.AddDate(0, 0, (8-int(d.Weekday()))%7)
+	}
+	lastMonday := func(month time.Month) time.Time {
+		d := date(month+1, 1).AddDate(0, 0, -1)
+		return d.AddDate(0, 0, -((int(d.Weekday()) + 6) % 7))
+	}
+
+	newYear := date(time.January, 1)
+	switch newYear.Weekday() {
+	case time.Saturday:
+		newYear = newYear.AddDate(0, 0, 2)
+	case time.Sunday:
+		newYear = newYear.AddDate(0, 0, 1)
+	}
+
+	christmas, boxingDay := date(time.December, 25), date(time.December, 26)
+	switch christmas.Weekday() {
+	case time.Friday:
+		boxingDay = boxingDay.AddDate(0, 0, 2)
+	case time.Saturday:
+		christmas, boxingDay = christmas.AddDate(0, 0, 2), boxingDay.AddDate(0, 0, 2)
+	case time.Sunday:
+		christmas = christmas.AddDate(0, 0, 2)
+	}
+
+	easter := easterSunday(year)
+	return []time.Time{
+		newYear,
+		easter.AddDate(0, 0, -2),
+		easter.AddDate(0, 0, 1),
+		firstMonday(time.May),
+		lastMonday(time.May),
+		lastMonday(time.August),
+		christmas,
+		boxingDay,
+	}
+}
+
+// easterSunday uses the anonymous Gregorian algorithm
+func easterSunday(year int) time.Time {
+	a := year % 19
+	b, c := year/100, year%100
+	d, e := b/4, b%4
+	f := (b + 8) / 25
+	g := (b - f + 1) / 3
+	h := (19*a + b - d - g + 15) % 30
+	i, k := c/4, c%4
+	l := (32 + 2*e + 2*i - h - k) % 7
+	m := (a + 11*h + 22*l) / 451
+	month := (h + l - 7*m + 114) / 31
+	day := (h+l-7*m+114)%31 + 1
+	return taxyear.InLondon(time.Date(year, time.Month(month), day, 12, 0, 0, 0, time.UTC))
+}
@@ -0,0 +1,101 @@
+package dealing_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/oliknight1/retail-isa-investment/investment-service/dealing"
+)
+
+func london(t *testing.T) *time.Location {
+	t.Helper()
+	loc, err := time.LoadLocation("Europe/London")
+	if err != nil {
+		t.Fatalf("failed to load London timezone: %v", err)
+	}
+	return loc
+}
+
+func TestBankHolidays(t *testing.T) {
+	tests := []struct {
+		year     int
+		expected []string
+	}{
+		{2025, []string{"2025-01-01", "2025-04-18", "2025-04-21", "2025-05-05", "2025-05-26", "2025-08-25", "2025-12-25", "2025-12-26"}},
+		// Boxing Day on a Saturday moves to the Monday
+		{2026, []string{"2026-01-01", "2026-04-03", "2026-04-06", "2026-05-04", "2026-05-25", "2026-08-31", "2026-12-25", "2026-12-28"}},
+		// Christmas on a Saturday moves both days to the following Monday and Tuesday
+		{2027, []string{"2027-01-01", "2027-03-26", "2027-03-29", "2027-05-03", "2027-05-31", "2027-08-30", "2027-12-27", "2027-12-28"}},
+		// Christmas on a Sunday moves to the Tuesday after Boxing Day, New Year's Day on a Saturday to the Monday
+		{2022, []string{"2022-01-03", "2022-04-15", "2022-04-18", "2022-05-02", "2022-05-30", "2022-08-29", "2022-12-27", "2022-12-26"}},
+	}
+	for _, tt := range tests {
+		var actual []string
+		for _, holiday := range dealing.BankHolidays(tt.year) {
+			actual = append(actual, holiday.Format(time.DateOnly))
+		}
+		if diff := cmp.Diff(tt.expected, actual); diff != "" {
+			t.Errorf("unexpected bank holidays for %d (-want +got):\n%s", tt.year, diff)
+		}
+	}
+}
+
+func TestValuationPoint(t *testing.T) {
+	loc := london(t)
+	schedules := dealing.DefaultSchedules()
+	tests := []struct {
+		name     string
+		received time.Time
+		expected time.Time
+	}{
+		{"before cut-off", time.Date(2025, time.June, 4, 11, 59, 0, 0, loc), time.Date(2025, time.June, 4, 12, 0, 0, 0, loc)},
+		{"at cut-off", time.Date(2025, time.June, 4, 12, 0, 0, 0, loc), time.Date(2025, time.June, 5, 12, 0, 0, 0, loc)},
+		{"friday after cut-off", time.Date(2025, time.June, 6, 15, 0, 0, 0, loc), time.Date(2025, time.June, 9, 12, 0, 0, 0, loc)},
+		{"weekend", time.Date(2025, time.June, 7, 9, 0, 0, 0, loc), time.Date(2025, time.June, 9, 12, 0, 0, 0, loc)},
+		{"before easter weekend", time.Date(2025, time.April, 17, 13, 0, 0, 0, loc), time.Date(2025, time.April, 22, 12, 0, 0, 0, loc)},
+		{"christmas eve", time.Date(2025, time.December, 24, 12, 30, 0, 0, loc), time.Date(2025, time.December, 29, 12, 0, 0, 0, loc)},
+		// 11:30 UTC is 12:30 in London during British Summer Time
+		{"cut-off judged in London", time.Date(2025, time.June, 4, 11, 30, 0, 0, time.UTC), time.Date(2025, time.June, 5, 12, 0, 0, 0, loc)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			actual := schedules.ValuationPoint("fund-1", tt.received)
+			if !actual.Equal(tt.expected) {
+				t.Errorf("expected %v, got %v", tt.expected, actual)
+			}
+		})
+	}
+}
+
+func TestLoadSchedules(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "dealing.json")
+	config := `{
+		"funds": {"fund-global-bond": {"cutOff": "10:00", "valuationPoint": "16:00"}},
+		"extraHolidays": ["2025-06-05"]
+	}`
+	if err := os.WriteFile(path, []byte(config), 0o600); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	schedules, err := dealing.LoadSchedules(path)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	loc := london(t)
+	received := time.Date(2025, time.June, 4, 11, 0, 0, 0, loc)
+	if actual := schedules.ValuationPoint("fund-global-bond", received); !actual.Equal(time.Date(2025, time.June, 6, 16, 0, 0, 0, loc)) {
+		t.Errorf("expected fund's own cut-off and the extra holiday to be used, got %v", actual)
+	}
+	if actual := schedules.ValuationPoint("fund-ftse-100", received); !actual.Equal(time.Date(2025, time.June, 4, 12, 0, 0, 0, loc)) {
+		t.Errorf("expected the default schedule for an unlisted fund, got %v", actual)
+	}
+
+	invalid := filepath.Join(t.TempDir(), "invalid.json")
+	os.WriteFile(invalid, []byte(`{"default": {"cutOff": "13:00", "valuationPoint": "12:00"}}`), 0o600)
+	if _, err := dealing.LoadSchedules(invalid); err == nil {
+		t.Error("expected a cut-off after the valuation point to be rejected")
+	}
+}
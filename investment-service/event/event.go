@@ -9,6 +9,7 @@ import (
 
 type EventHandler interface {
 	Publish(subject string, data any) error
+	Subscribe(subject string, handle func(data []byte)) error
 	Close()
 }
 
@@ -33,6 +34,27 @@ func (p *NatsPublisher) Publish(subject string, payload any) error {
 	return p.conn.Publish(subject, data)
 }
 
+func (p *NatsPublisher) Subscribe(subject string, handle func(data []byte)) error {
+	_, err := p.conn.Subscribe(subject, func(msg *nats.Msg) {
+		handle(msg.Data)
+	})
+	return err
+}
+
 func (p *NatsPublisher) Close() {
 	p.conn.Close()
 }
+
+// NopPublisher drops every event and never delivers any. It stands in for NATS when the service
+// cannot connect, so publishing does not need checking everywhere
+type NopPublisher struct{}
+
+func (NopPublisher) Publish(subject string, payload any) error {
+	return nil
+}
+
+func (NopPublisher) Subscribe(subject string, handle func(data []byte)) error {
+	return nil
+}
+
+func (NopPublisher) Close() {}
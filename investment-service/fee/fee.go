@@ -0,0 +1,118 @@
+// Package fee works out the platform fee charged on the value of an ISA's fund holdings. The
+// fee is an annual percentage charged in tiers, so each band of value is charged at its own
+// rate, and can be capped at an amount a year. It accrues daily and is collected monthly
+package fee
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"os"
+	"slices"
+	"time"
+
+	"github.com/oliknight1/retail-isa-investment/investment-service/model"
+	"github.com/oliknight1/retail-isa-investment/investment-service/money"
+	"github.com/oliknight1/retail-isa-investment/investment-service/taxyear"
+)
+
+// Tier charges Rate percent a year on the value from From up to the start of the next tier
+type Tier struct {
+	From money.Money `json:"from"`
+	Rate float64     `json:"rate"`
+}
+
+// Schedule is the platform fee. AnnualCap limits the fee charged a year on any one account,
+// with zero meaning no cap
+type Schedule struct {
+	Tiers     []Tier      `json:"tiers"`
+	AnnualCap money.Money `json:"annualCap"`
+}
+
+// DefaultSchedule charges 0.45% on the first £250,000, 0.25% up to £1,000,000 and nothing
+// above that
+func DefaultSchedule() Schedule {
+	return Schedule{
+		Tiers: []Tier{
+			{From: money.Money{}, Rate: 0.45},
+			{From: money.Pounds(250_000), Rate: 0.25},
+			{From: money.Pounds(1_000_000), Rate: 0},
+		},
+	}
+}
+
+// LoadSchedule reads a schedule from a JSON file of the form
+// {"tiers": [{"from": 0, "rate": 0.45}, {"from": 250000, "rate": 0.25}], "annualCap": 1000}
+func LoadSchedule(path string) (Schedule, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return Schedule{}, err
+	}
+	var schedule Schedule
+	if err := json.Unmarshal(data, &schedule); err != nil {
+		return Schedule{}, fmt.Errorf("invalid fee schedule in %s: %w", path, err)
+	}
+	if err := schedule.validate(); err != nil {
+		return Schedule{}, fmt.Errorf("invalid fee schedule in %s: %w", path, err)
+	}
+	return schedule, nil
+}
+
+func (s Schedule) validate() error {
+	if len(s.Tiers) == 0 || !s.Tiers[0].From.IsZero() {
+		return fmt.Errorf("the first tier must start from 0")
+	}
+	for i, tier := range s.Tiers {
+		if tier.Rate < 0 {
+			return fmt.Errorf("tier from %s has a negative rate", tier.From)
+		}
+		if i > 0 && !tier.From.GreaterThan(s.Tiers[i-1].From) {
+			return fmt.Errorf("tier from %s must start after the tier before it", tier.From)
+		}
+	}
+	if s.AnnualCap.IsNegative() {
+		return fmt.Errorf("annual cap cannot be negative")
+	}
+	return nil
+}
+
+// Annual is the fee a year on holdings worth value, charging each tier's rate on the part of
+// the value that falls in it, and no more than the cap
+func (s Schedule) Annual(value money.Money) model.Accrual {
+	tiers := slices.Clone(s.Tiers)
+	slices.SortFunc(tiers, func(a, b Tier) int { return a.From.Cmp(b.From) })
+
+	var fee model.Accrual
+	for i, tier := range tiers {
+		if !value.GreaterThan(tier.From) {
+			break
+		}
+		band := value
+		if i+1 < len(tiers) {
+			band = money.Min(value, tiers[i+1].From)
+		}
+		fee += OnValue(band.Sub(tier.From), tier.Rate)
+	}
+	if limit := OnValue(s.AnnualCap, 100); s.AnnualCap.IsPositive() && fee > limit {
+		fee = limit
+	}
+	return fee
+}
+
+// Daily is the platform fee for one day on holdings worth value
+func (s Schedule) Daily(value money.Money, day time.Time) model.Accrual {
+	return Daily(s.Annual(value), day)
+}
+
+// OnValue is rate percent of value
+func OnValue(value money.Money, rate float64) model.Accrual {
+	// millionths of a pound = pence * 10,000 * rate / 100
+	return model.Accrual(math.Round(float64(value.Minor) * 100 * rate))
+}
+
+// Daily spreads a fee a year evenly over the days of the year day falls in, judged in London
+func Daily(annual model.Accrual, day time.Time) model.Accrual {
+	year := taxyear.InLondon(day).Year()
+	days := time.Date(year+1, time.January, 1, 0, 0, 0, 0, time.UTC).Sub(time.Date(year, time.January, 1, 0, 0, 0, 0, time.UTC)).Hours() / 24
+	return model.Accrual(math.Round(float64(annual) / days))
+}
@@ -0,0 +1,80 @@
+package fee_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/oliknight1/retail-isa-investment/investment-service/fee"
+	"github.com/oliknight1/retail-isa-investment/investment-service/model"
+	"github.com/oliknight1/retail-isa-investment/investment-service/money"
+)
+
+func TestAnnual(t *testing.T) {
+	schedule := fee.DefaultSchedule()
+	capped := schedule
+	capped.AnnualCap = money.Pounds(1000)
+	tests := []struct {
+		name     string
+		schedule fee.Schedule
+		value    money.Money
+		expected string
+	}{
+		{"nothing held", schedule, money.Money{}, "0.000000"},
+		{"first tier", schedule, money.Pounds(10_000), "45.000000"},
+		// 0.45% of £250,000 plus 0.25% of the next £50,000
+		{"across tiers", schedule, money.Pounds(300_000), "1250.000000"},
+		{"above the top tier", schedule, money.Pounds(2_000_000), "3000.000000"},
+		{"fraction of a penny", schedule, money.Pence(1), "0.000045"},
+		{"capped", capped, money.Pounds(300_000), "1000.000000"},
+		{"under the cap", capped, money.Pounds(10_000), "45.000000"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if actual := tt.schedule.Annual(tt.value).String(); actual != tt.expected {
+				t.Errorf("expected %s, got %s", tt.expected, actual)
+			}
+		})
+	}
+}
+
+func TestDaily(t *testing.T) {
+	annual := fee.OnValue(money.Pounds(10_000), 0.45)
+	if actual := fee.Daily(annual, time.Date(2025, time.June, 4, 12, 0, 0, 0, time.UTC)).String(); actual != "0.123288" {
+		t.Errorf("expected £45 over 365 days, got %s", actual)
+	}
+	if actual := fee.Daily(annual, time.Date(2028, time.June, 4, 12, 0, 0, 0, time.UTC)).String(); actual != "0.122951" {
+		t.Errorf("expected £45 over 366 days in a leap year, got %s", actual)
+	}
+	if actual := model.Accrual(123_288 * 30).Money(); !actual.Equal(money.MustParse("3.70")) {
+		t.Errorf("expected 30 days to round to £3.70, got %s", actual)
+	}
+}
+
+func TestLoadSchedule(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "fees.json")
+	config := `{"tiers": [{"from": 0, "rate": 0.35}, {"from": "100000", "rate": 0.15}], "annualCap": 250}`
+	if err := os.WriteFile(path, []byte(config), 0o600); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	schedule, err := fee.LoadSchedule(path)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if actual := schedule.Annual(money.Pounds(200_000)).String(); actual != "250.000000" {
+		t.Errorf("expected the cap to apply, got %s", actual)
+	}
+
+	for _, invalid := range []string{
+		`{"tiers": [{"from": 100, "rate": 0.35}]}`,
+		`{"tiers": [{"from": 0, "rate": 0.35}, {"from": 0, "rate": 0.15}]}`,
+		`{"tiers": [{"from": 0, "rate": -0.35}]}`,
+	} {
+		path := filepath.Join(t.TempDir(), "invalid.json")
+		os.WriteFile(path, []byte(invalid), 0o600)
+		if _, err := fee.LoadSchedule(path); err == nil {
+			t.Errorf("expected %s to be rejected", invalid)
+		}
+	}
+}
@@ -0,0 +1,168 @@
+package handler
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/oliknight1/retail-isa-investment/investment-service/internal"
+	"github.com/oliknight1/retail-isa-investment/investment-service/logger"
+	"github.com/oliknight1/retail-isa-investment/investment-service/model"
+	"github.com/oliknight1/retail-isa-investment/investment-service/service"
+	"go.uber.org/zap"
+)
+
+type AccountHandler struct {
+	Service service.AccountService
+	Logger  logger.Logger
+}
+
+func NewAccountHandler(service service.AccountService, logger logger.Logger) *AccountHandler {
+	return &AccountHandler{service, logger}
+}
+
+func (h *AccountHandler) OpenAccount(w http.ResponseWriter, r *http.Request) {
+	internal.InvestmentRequests.WithLabelValues("/accounts", "POST").Inc()
+	var req struct {
+		CustomerId  string            `json:"customerId"`
+		ProductType model.ProductType `json:"productType"`
+	}
+
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		h.Logger.Error("failed to decode account opening request", zap.Error(err))
+		http.Error(w, fmt.Sprintf("error reading JSON: \n%s", err), http.StatusBadRequest)
+		return
+	}
+
+	account, err := h.Service.OpenAccount(req.CustomerId, req.ProductType)
+	if errors.Is(err, internal.ErrMissingCustomerId) || errors.Is(err, internal.ErrInvalidProductType) {
+		h.Logger.Error("invalid account opening request", zap.Error(err))
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if errors.Is(err, internal.ErrIneligibleAge) || errors.Is(err, internal.ErrMissingContact) {
+		h.Logger.Error("customer not eligible for account", zap.Error(err))
+		http.Error(w, err.Error(), http.StatusUnprocessableEntity)
+		return
+	}
+	if err != nil {
+		h.Logger.Error("internal service error", zap.Error(err))
+		http.Error(w, "failed to open account", http.StatusInternalServerError)
+		return
+	}
+
+	var buf bytes.Buffer
+	if err := json.NewEncoder(&buf).Encode(account); err != nil {
+		h.Logger.Error("failed to write account to JSON", zap.Error(err))
+		http.Error(w, "failed to encode response", http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	h.Logger.Info("account successfully opened", zap.String("account_id", account.Id))
+	w.Write(buf.Bytes())
+}
+
+func (h *AccountHandler) GetAccountById(w http.ResponseWriter, r *http.Request) {
+	internal.InvestmentRequests.WithLabelValues("/accounts/{id}", "GET").Inc()
+	id := r.PathValue("id")
+	if id == "" {
+		h.Logger.Error("missing account_id when requesting account", zap.Error(internal.ErrMissingAccountId))
+		http.Error(w, internal.ErrMissingAccountId.Error(), http.StatusBadRequest)
+		return
+	}
+
+	account, err := h.Service.GetAccountById(id)
+	if errors.Is(err, internal.ErrAccountNotFound) {
+		h.Logger.Error("account not found", zap.Error(err))
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		h.Logger.Error("failed to get account", zap.Error(err))
+		http.Error(w, "failed to get account", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(account)
+}
+
+func (h *AccountHandler) GetAccountsByCustomerId(w http.ResponseWriter, r *http.Request) {
+	internal.InvestmentRequests.WithLabelValues("/customers/{id}/accounts", "GET").Inc()
+	customerId := r.PathValue("id")
+	if customerId == "" {
+		h.Logger.Error("missing customer_id when requesting accounts", zap.Error(internal.ErrMissingCustomerId))
+		http.Error(w, internal.ErrMissingCustomerId.Error(), http.StatusBadRequest)
+		return
+	}
+
+	accounts, err := h.Service.GetAccountsByCustomerId(customerId)
+	if err != nil {
+		h.Logger.Error("failed to get accounts", zap.Error(err))
+		http.Error(w, "failed to get accounts", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(accounts)
+}
+
+// SetIncomePreference chooses whether income from the account's income units is paid into its
+// cash or reinvested
+func (h *AccountHandler) SetIncomePreference(w http.ResponseWriter, r *http.Request) {
+	internal.InvestmentRequests.WithLabelValues("/accounts/{id}/income-preference", "PUT").Inc()
+	var req struct {
+		IncomePreference model.IncomePreference `json:"incomePreference"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		h.Logger.Error("failed to decode income preference request", zap.Error(err))
+		http.Error(w, fmt.Sprintf("error reading JSON: \n%s", err), http.StatusBadRequest)
+		return
+	}
+
+	account, err := h.Service.SetIncomePreference(r.PathValue("id"), req.IncomePreference)
+	if errors.Is(err, internal.ErrMissingAccountId) || errors.Is(err, internal.ErrInvalidPreference) {
+		h.Logger.Error("invalid income preference request", zap.Error(err))
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if errors.Is(err, internal.ErrAccountNotFound) {
+		h.Logger.Error("account not found", zap.Error(err))
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		h.Logger.Error("failed to set income preference", zap.Error(err))
+		http.Error(w, "failed to set income preference", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(account)
+}
+
+func (h *AccountHandler) GetCashBalance(w http.ResponseWriter, r *http.Request) {
+	internal.InvestmentRequests.WithLabelValues("/accounts/{id}/cash", "GET").Inc()
+	balance, err := h.Service.GetCashBalance(r.PathValue("id"))
+	if errors.Is(err, internal.ErrMissingAccountId) {
+		h.Logger.Error("missing account_id when requesting cash balance", zap.Error(err))
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if errors.Is(err, internal.ErrAccountNotFound) {
+		h.Logger.Error("account not found", zap.Error(err))
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		h.Logger.Error("failed to get cash balance", zap.Error(err))
+		http.Error(w, "failed to get cash balance", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(balance)
+}
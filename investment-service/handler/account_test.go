@@ -0,0 +1,204 @@
+package handler_test
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/oliknight1/retail-isa-investment/investment-service/handler"
+	"github.com/oliknight1/retail-isa-investment/investment-service/internal"
+	"github.com/oliknight1/retail-isa-investment/investment-service/logger"
+	"github.com/oliknight1/retail-isa-investment/investment-service/model"
+	"github.com/oliknight1/retail-isa-investment/investment-service/money"
+)
+
+type mockAccountService struct {
+	openAccount             func(customerId string, productType model.ProductType) (*model.Account, error)
+	getAccountById          func(string) (*model.Account, error)
+	getAccountsByCustomerId func(string) (*[]model.Account, error)
+	setIncomePreference     func(id string, preference model.IncomePreference) (*model.Account, error)
+	getCashBalance          func(id string) (*model.CashBalance, error)
+}
+
+func (m *mockAccountService) OpenAccount(customerId string, productType model.ProductType) (*model.Account, error) {
+	return m.openAccount(customerId, productType)
+}
+func (m *mockAccountService) GetAccountById(id string) (*model.Account, error) {
+	return m.getAccountById(id)
+}
+func (m *mockAccountService) GetAccountsByCustomerId(id string) (*[]model.Account, error) {
+	return m.getAccountsByCustomerId(id)
+}
+func (m *mockAccountService) SetIncomePreference(id string, preference model.IncomePreference) (*model.Account, error) {
+	return m.setIncomePreference(id, preference)
+}
+func (m *mockAccountService) GetCashBalance(id string) (*model.CashBalance, error) {
+	return m.getCashBalance(id)
+}
+
+func TestOpenAccountSuccess(t *testing.T) {
+	mockSvc := &mockAccountService{
+		openAccount: func(customerId string, productType model.ProductType) (*model.Account, error) {
+			return &model.Account{
+				Id:          "acc-123",
+				CustomerId:  customerId,
+				ProductType: productType,
+				Status:      model.AccountOpen,
+			}, nil
+		},
+	}
+	logger := logger.NewMockLogger()
+	h := handler.NewAccountHandler(mockSvc, logger)
+
+	req := httptest.NewRequest(http.MethodPost, "/accounts", strings.NewReader(`{"customerId":"cust-123","productType":"lifetime"}`))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+
+	h.OpenAccount(w, req)
+
+	if w.Code != http.StatusCreated {
+		t.Fatalf("expected status %d, got %d", http.StatusCreated, w.Code)
+	}
+	var account model.Account
+	if err := json.NewDecoder(w.Body).Decode(&account); err != nil {
+		t.Fatalf("decode error: %v", err)
+	}
+	if account.ProductType != model.LifetimeISA || account.CustomerId != "cust-123" {
+		t.Errorf("unexpected account: %+v", account)
+	}
+}
+
+func TestOpenAccountInvalidProduct(t *testing.T) {
+	mockSvc := &mockAccountService{
+		openAccount: func(customerId string, productType model.ProductType) (*model.Account, error) {
+			return nil, internal.ErrInvalidProductType
+		},
+	}
+	logger := logger.NewMockLogger()
+	h := handler.NewAccountHandler(mockSvc, logger)
+
+	req := httptest.NewRequest(http.MethodPost, "/accounts", strings.NewReader(`{"customerId":"cust-123","productType":"pension"}`))
+	w := httptest.NewRecorder()
+
+	h.OpenAccount(w, req)
+
+	if w.Code != http.StatusBadRequest {
+		t.Fatalf("expected status %d, got %d", http.StatusBadRequest, w.Code)
+	}
+}
+
+func TestGetAccountByIdNotFound(t *testing.T) {
+	mockSvc := &mockAccountService{
+		getAccountById: func(id string) (*model.Account, error) {
+			return nil, internal.AccountNotFoundError(id)
+		},
+	}
+	logger := logger.NewMockLogger()
+	h := handler.NewAccountHandler(mockSvc, logger)
+
+	req := httptest.NewRequest(http.MethodGet, "/accounts/acc-123", nil)
+	req.SetPathValue("id", "acc-123")
+	w := httptest.NewRecorder()
+
+	h.GetAccountById(w, req)
+
+	if w.Code != http.StatusNotFound {
+		t.Fatalf("expected status %d, got %d", http.StatusNotFound, w.Code)
+	}
+}
+
+func TestSetIncomePreference(t *testing.T) {
+	tests := []struct {
+		name     string
+		body     string
+		err      error
+		expected int
+	}{
+		{"reinvest", `{"incomePreference":"reinvest"}`, nil, http.StatusOK},
+		{"invalid JSON", `{`, nil, http.StatusBadRequest},
+		{"invalid preference", `{"incomePreference":"spend"}`, internal.ErrInvalidPreference, http.StatusBadRequest},
+		{"unknown account", `{"incomePreference":"pay_out"}`, internal.AccountNotFoundError("acc-123"), http.StatusNotFound},
+		{"service error", `{"incomePreference":"pay_out"}`, errors.New("db down"), http.StatusInternalServerError},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockSvc := &mockAccountService{
+				setIncomePreference: func(id string, preference model.IncomePreference) (*model.Account, error) {
+					if tt.err != nil {
+						return nil, tt.err
+					}
+					return &model.Account{Id: id, IncomePreference: preference}, nil
+				},
+			}
+			h := handler.NewAccountHandler(mockSvc, logger.NewMockLogger())
+
+			req := httptest.NewRequest(http.MethodPut, "/accounts/acc-123/income-preference", strings.NewReader(tt.body))
+			req.SetPathValue("id", "acc-123")
+			w := httptest.NewRecorder()
+
+			h.SetIncomePreference(w, req)
+
+			if w.Code != tt.expected {
+				t.Fatalf("expected status %d, got %d", tt.expected, w.Code)
+			}
+			if tt.expected != http.StatusOK {
+				return
+			}
+			var account model.Account
+			if err := json.NewDecoder(w.Body).Decode(&account); err != nil {
+				t.Fatalf("decode error: %v", err)
+			}
+			if account.Id != "acc-123" || account.IncomePreference != model.IncomeReinvest {
+				t.Errorf("unexpected account %+v", account)
+			}
+		})
+	}
+}
+
+func TestGetCashBalance(t *testing.T) {
+	tests := []struct {
+		name     string
+		err      error
+		expected int
+	}{
+		{"found", nil, http.StatusOK},
+		{"unknown account", internal.AccountNotFoundError("acc-123"), http.StatusNotFound},
+		{"service error", errors.New("db down"), http.StatusInternalServerError},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockSvc := &mockAccountService{
+				getCashBalance: func(id string) (*model.CashBalance, error) {
+					if tt.err != nil {
+						return nil, tt.err
+					}
+					return &model.CashBalance{AccountId: id, Balance: money.Pounds(100), Reserved: money.Pounds(40), Available: money.Pounds(60)}, nil
+				},
+			}
+			h := handler.NewAccountHandler(mockSvc, logger.NewMockLogger())
+
+			req := httptest.NewRequest(http.MethodGet, "/accounts/acc-123/cash", nil)
+			req.SetPathValue("id", "acc-123")
+			w := httptest.NewRecorder()
+
+			h.GetCashBalance(w, req)
+
+			if w.Code != tt.expected {
+				t.Fatalf("expected status %d, got %d", tt.expected, w.Code)
+			}
+			if tt.err != nil {
+				return
+			}
+			var balance model.CashBalance
+			if err := json.NewDecoder(w.Body).Decode(&balance); err != nil {
+				t.Fatalf("decode error: %v", err)
+			}
+			if !balance.Available.Equal(money.Pounds(60)) {
+				t.Errorf("expected £60 available, got %s", balance.Available)
+			}
+		})
+	}
+}
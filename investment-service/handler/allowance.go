@@ -0,0 +1,40 @@
+package handler
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/oliknight1/retail-isa-investment/investment-service/internal"
+	"github.com/oliknight1/retail-isa-investment/investment-service/logger"
+	"github.com/oliknight1/retail-isa-investment/investment-service/service"
+	"go.uber.org/zap"
+)
+
+type AllowanceHandler struct {
+	Service service.AllowanceService
+	Logger  logger.Logger
+}
+
+func NewAllowanceHandler(service service.AllowanceService, logger logger.Logger) *AllowanceHandler {
+	return &AllowanceHandler{service, logger}
+}
+
+func (h *AllowanceHandler) GetAllowance(w http.ResponseWriter, r *http.Request) {
+	internal.InvestmentRequests.WithLabelValues("/customers/{id}/allowance", "GET").Inc()
+	customerId := r.PathValue("id")
+	if customerId == "" {
+		h.Logger.Error("missing customer_id when requesting allowance", zap.Error(internal.ErrMissingCustomerId))
+		http.Error(w, internal.ErrMissingCustomerId.Error(), http.StatusBadRequest)
+		return
+	}
+
+	allowance, err := h.Service.GetAllowance(customerId)
+	if err != nil {
+		h.Logger.Error("failed to get allowance", zap.Error(err))
+		http.Error(w, "failed to get allowance", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(allowance)
+}
@@ -0,0 +1,105 @@
+package handler_test
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/oliknight1/retail-isa-investment/investment-service/handler"
+	"github.com/oliknight1/retail-isa-investment/investment-service/logger"
+	"github.com/oliknight1/retail-isa-investment/investment-service/model"
+	"github.com/oliknight1/retail-isa-investment/investment-service/money"
+	"github.com/oliknight1/retail-isa-investment/investment-service/product"
+	"github.com/oliknight1/retail-isa-investment/investment-service/taxyear"
+)
+
+type mockAllowanceService struct {
+	getAllowance func(customerId string) (*model.Allowance, error)
+}
+
+func (m *mockAllowanceService) GetAllowance(customerId string) (*model.Allowance, error) {
+	return m.getAllowance(customerId)
+}
+func (m *mockAllowanceService) Subscribe(customerId string, taxYear taxyear.TaxYear, rules product.Rules, amount money.Money) (model.AllowanceUse, error) {
+	return model.AllowanceUse{}, nil
+}
+func (m *mockAllowanceService) Release(customerId string, taxYear taxyear.TaxYear, rules product.Rules, use model.AllowanceUse) error {
+	return nil
+}
+func (m *mockAllowanceService) Withdraw(customerId string, taxYear taxyear.TaxYear, rules product.Rules, amount money.Money) error {
+	return nil
+}
+func (m *mockAllowanceService) TransferIn(customerId string, transferId string, taxYear taxyear.TaxYear, rules product.Rules, amount money.Money) error {
+	return nil
+}
+
+func TestGetAllowanceSuccess(t *testing.T) {
+	mockSvc := &mockAllowanceService{
+		getAllowance: func(customerId string) (*model.Allowance, error) {
+			return &model.Allowance{
+				CustomerId: customerId,
+				TaxYear:    2025,
+				Limit:      money.Pounds(20000),
+				Used:       money.Pounds(5000),
+				Remaining:  money.Pounds(15000),
+			}, nil
+		},
+	}
+	logger := logger.NewMockLogger()
+	h := handler.NewAllowanceHandler(mockSvc, logger)
+
+	req := httptest.NewRequest(http.MethodGet, "/customers/cust-123/allowance", nil)
+	req.SetPathValue("id", "cust-123")
+	w := httptest.NewRecorder()
+
+	h.GetAllowance(w, req)
+	res := w.Result()
+	defer res.Body.Close()
+
+	if res.StatusCode != http.StatusOK {
+		t.Fatalf("expected 200 OK, got %d", res.StatusCode)
+	}
+	var allowance model.Allowance
+	if err := json.NewDecoder(res.Body).Decode(&allowance); err != nil {
+		t.Fatalf("decode error: %v", err)
+	}
+	if allowance.CustomerId != "cust-123" || !allowance.Remaining.Equal(money.Pounds(15000)) {
+		t.Errorf("unexpected allowance: %+v", allowance)
+	}
+}
+
+func TestGetAllowanceMissingId(t *testing.T) {
+	logger := logger.NewMockLogger()
+	h := handler.NewAllowanceHandler(&mockAllowanceService{}, logger)
+
+	req := httptest.NewRequest(http.MethodGet, "/customers//allowance", nil)
+	w := httptest.NewRecorder()
+
+	h.GetAllowance(w, req)
+
+	if w.Code != http.StatusBadRequest {
+		t.Fatalf("expected 400 Bad Request, got %d", w.Code)
+	}
+}
+
+func TestGetAllowanceServiceError(t *testing.T) {
+	mockSvc := &mockAllowanceService{
+		getAllowance: func(customerId string) (*model.Allowance, error) {
+			return nil, errors.New("db failure")
+		},
+	}
+	logger := logger.NewMockLogger()
+	h := handler.NewAllowanceHandler(mockSvc, logger)
+
+	req := httptest.NewRequest(http.MethodGet, "/customers/cust-123/allowance", nil)
+	req.SetPathValue("id", "cust-123")
+	w := httptest.NewRecorder()
+
+	h.GetAllowance(w, req)
+
+	if w.Code != http.StatusInternalServerError {
+		t.Fatalf("expected 500 Internal Server Error, got %d", w.Code)
+	}
+}
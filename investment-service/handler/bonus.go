@@ -0,0 +1,43 @@
+package handler
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/oliknight1/retail-isa-investment/investment-service/internal"
+	"github.com/oliknight1/retail-isa-investment/investment-service/logger"
+	"github.com/oliknight1/retail-isa-investment/investment-service/service"
+	"go.uber.org/zap"
+)
+
+type BonusHandler struct {
+	Service service.BonusService
+	Logger  logger.Logger
+}
+
+func NewBonusHandler(service service.BonusService, logger logger.Logger) *BonusHandler {
+	return &BonusHandler{service, logger}
+}
+
+// GetClaimFile returns the monthly Lifetime ISA bonus claim file as CSV
+func (h *BonusHandler) GetClaimFile(w http.ResponseWriter, r *http.Request) {
+	internal.InvestmentRequests.WithLabelValues("/admin/lisa/claims", "GET").Inc()
+	period := r.URL.Query().Get("period")
+
+	file, err := h.Service.GenerateClaimFile(period)
+	if errors.Is(err, internal.ErrInvalidClaimPeriod) {
+		h.Logger.Error("invalid claim period", zap.Error(err))
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if err != nil {
+		h.Logger.Error("failed to generate claim file", zap.Error(err))
+		http.Error(w, "failed to generate claim file", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/csv")
+	w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=lisa-bonus-claims-%s.csv", period))
+	w.Write(file)
+}
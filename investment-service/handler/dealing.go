@@ -0,0 +1,36 @@
+package handler
+
+import (
+	"encoding/json"
+	"net/http"
+	"time"
+
+	"github.com/oliknight1/retail-isa-investment/investment-service/internal"
+	"github.com/oliknight1/retail-isa-investment/investment-service/logger"
+	"github.com/oliknight1/retail-isa-investment/investment-service/service"
+	"go.uber.org/zap"
+)
+
+type DealingHandler struct {
+	Service service.DealingService
+	Logger  logger.Logger
+}
+
+func NewDealingHandler(service service.DealingService, logger logger.Logger) *DealingHandler {
+	return &DealingHandler{service, logger}
+}
+
+// RunDealing deals every pending order whose valuation point has passed without waiting for
+// the scheduler, and reports what was done
+func (h *DealingHandler) RunDealing(w http.ResponseWriter, r *http.Request) {
+	internal.InvestmentRequests.WithLabelValues("/admin/dealing/run", "POST").Inc()
+	run, err := h.Service.Run(time.Now())
+	if err != nil {
+		h.Logger.Error("failed to run dealing", zap.Error(err))
+		http.Error(w, "failed to run dealing", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(run)
+}
@@ -0,0 +1,73 @@
+package handler_test
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/oliknight1/retail-isa-investment/investment-service/handler"
+	"github.com/oliknight1/retail-isa-investment/investment-service/logger"
+	"github.com/oliknight1/retail-isa-investment/investment-service/model"
+)
+
+type mockDealingService struct {
+	run func(now time.Time) (*model.DealingRun, error)
+}
+
+func (m *mockDealingService) Run(now time.Time) (*model.DealingRun, error) {
+	return m.run(now)
+}
+
+func TestRunDealing(t *testing.T) {
+	runAt := time.Date(2025, time.June, 4, 12, 5, 0, 0, time.UTC)
+	tests := []struct {
+		name         string
+		run          func(now time.Time) (*model.DealingRun, error)
+		expectedCode int
+		expectedRun  *model.DealingRun
+	}{
+		{
+			name: "reports the run",
+			run: func(now time.Time) (*model.DealingRun, error) {
+				return &model.DealingRun{RunAt: runAt, Dealt: 2, NotYetDue: 1, AwaitingPrice: 1}, nil
+			},
+			expectedCode: http.StatusOK,
+			expectedRun:  &model.DealingRun{RunAt: runAt, Dealt: 2, NotYetDue: 1, AwaitingPrice: 1},
+		},
+		{
+			name: "repository failure",
+			run: func(now time.Time) (*model.DealingRun, error) {
+				return nil, errors.New("db down")
+			},
+			expectedCode: http.StatusInternalServerError,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h := handler.NewDealingHandler(&mockDealingService{run: tt.run}, logger.NewMockLogger())
+			req := httptest.NewRequest(http.MethodPost, "/admin/dealing/run", nil)
+			w := httptest.NewRecorder()
+
+			h.RunDealing(w, req)
+
+			if w.Code != tt.expectedCode {
+				t.Fatalf("expected status %d, got %d", tt.expectedCode, w.Code)
+			}
+			if tt.expectedRun == nil {
+				return
+			}
+			var actual model.DealingRun
+			if err := json.NewDecoder(w.Body).Decode(&actual); err != nil {
+				t.Fatalf("failed to decode response: %v", err)
+			}
+			if diff := cmp.Diff(*tt.expectedRun, actual); diff != "" {
+				t.Errorf("unexpected run (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
@@ -0,0 +1,58 @@
+package handler
+
+import (
+	"encoding/json"
+	"net/http"
+	"time"
+
+	"github.com/oliknight1/retail-isa-investment/investment-service/internal"
+	"github.com/oliknight1/retail-isa-investment/investment-service/logger"
+	"github.com/oliknight1/retail-isa-investment/investment-service/service"
+	"go.uber.org/zap"
+)
+
+type DistributionHandler struct {
+	Service service.DistributionService
+	Logger  logger.Logger
+}
+
+func NewDistributionHandler(service service.DistributionService, logger logger.Logger) *DistributionHandler {
+	return &DistributionHandler{service, logger}
+}
+
+// GetDistributionsByCustomerId returns the fund income paid or reinvested in a customer's
+// accounts
+func (h *DistributionHandler) GetDistributionsByCustomerId(w http.ResponseWriter, r *http.Request) {
+	internal.InvestmentRequests.WithLabelValues("/customers/{id}/distributions", "GET").Inc()
+	customerId := r.PathValue("id")
+	if customerId == "" {
+		h.Logger.Error("missing customer_id when requesting distributions", zap.Error(internal.ErrMissingCustomerId))
+		http.Error(w, internal.ErrMissingCustomerId.Error(), http.StatusBadRequest)
+		return
+	}
+
+	payments, err := h.Service.GetDistributionsByCustomerId(customerId)
+	if err != nil {
+		h.Logger.Error("failed to get distributions", zap.Error(err))
+		http.Error(w, "failed to get distributions", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(payments)
+}
+
+// RunDistributions pays every distribution that has reached its pay date without waiting for
+// the scheduler, and reports what was done
+func (h *DistributionHandler) RunDistributions(w http.ResponseWriter, r *http.Request) {
+	internal.InvestmentRequests.WithLabelValues("/admin/distributions/run", "POST").Inc()
+	run, err := h.Service.Run(time.Now())
+	if err != nil {
+		h.Logger.Error("failed to run distributions", zap.Error(err))
+		http.Error(w, "failed to run distributions", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(run)
+}
@@ -0,0 +1,136 @@
+package handler_test
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/oliknight1/retail-isa-investment/investment-service/handler"
+	"github.com/oliknight1/retail-isa-investment/investment-service/logger"
+	"github.com/oliknight1/retail-isa-investment/investment-service/model"
+	"github.com/oliknight1/retail-isa-investment/investment-service/money"
+)
+
+type mockDistributionService struct {
+	getDistributionsByCustomerId func(id string) (*[]model.DistributionPayment, error)
+	run                          func(now time.Time) (*model.DistributionRun, error)
+}
+
+func (m *mockDistributionService) GetDistributionsByCustomerId(id string) (*[]model.DistributionPayment, error) {
+	return m.getDistributionsByCustomerId(id)
+}
+
+func (m *mockDistributionService) Run(now time.Time) (*model.DistributionRun, error) {
+	return m.run(now)
+}
+
+func TestGetDistributionsByCustomerId(t *testing.T) {
+	tests := []struct {
+		name       string
+		customerId string
+		err        error
+		expected   int
+	}{
+		{"success", "cust-123", nil, http.StatusOK},
+		{"missing id", "", nil, http.StatusBadRequest},
+		{"service error", "cust-123", errors.New("db down"), http.StatusInternalServerError},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockSvc := &mockDistributionService{
+				getDistributionsByCustomerId: func(id string) (*[]model.DistributionPayment, error) {
+					if tt.err != nil {
+						return nil, tt.err
+					}
+					return &[]model.DistributionPayment{{
+						Id:             "pay-1",
+						DistributionId: "dist-1",
+						AccountId:      "acc-1",
+						CustomerId:     id,
+						FundId:         "fund-1",
+						UnitClass:      model.UnitClassIncome,
+						Units:          1_000_000,
+						Rate:           12_345,
+						Amount:         money.MustParse("1.23"),
+						Outcome:        model.DistributionPaid,
+					}}, nil
+				},
+			}
+			h := handler.NewDistributionHandler(mockSvc, logger.NewMockLogger())
+
+			req := httptest.NewRequest(http.MethodGet, "/customers/"+tt.customerId+"/distributions", nil)
+			req.SetPathValue("id", tt.customerId)
+			w := httptest.NewRecorder()
+
+			h.GetDistributionsByCustomerId(w, req)
+
+			if w.Code != tt.expected {
+				t.Fatalf("expected %d, got %d", tt.expected, w.Code)
+			}
+			if tt.expected != http.StatusOK {
+				return
+			}
+			var payments []model.DistributionPayment
+			if err := json.NewDecoder(w.Body).Decode(&payments); err != nil {
+				t.Fatalf("decode error: %v", err)
+			}
+			if len(payments) != 1 || payments[0].Units != 1_000_000 || !payments[0].Amount.Equal(money.MustParse("1.23")) {
+				t.Errorf("unexpected payments %+v", payments)
+			}
+		})
+	}
+}
+
+func TestRunDistributions(t *testing.T) {
+	runAt := time.Date(2025, time.June, 30, 9, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name         string
+		run          func(now time.Time) (*model.DistributionRun, error)
+		expectedCode int
+		expectedRun  *model.DistributionRun
+	}{
+		{
+			name: "reports the run",
+			run: func(now time.Time) (*model.DistributionRun, error) {
+				return &model.DistributionRun{RunAt: runAt, Distributions: 1, Paid: 2, Reinvested: 1}, nil
+			},
+			expectedCode: http.StatusOK,
+			expectedRun:  &model.DistributionRun{RunAt: runAt, Distributions: 1, Paid: 2, Reinvested: 1},
+		},
+		{
+			name: "fund-service failure",
+			run: func(now time.Time) (*model.DistributionRun, error) {
+				return nil, errors.New("fund-service unavailable")
+			},
+			expectedCode: http.StatusInternalServerError,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h := handler.NewDistributionHandler(&mockDistributionService{run: tt.run}, logger.NewMockLogger())
+			req := httptest.NewRequest(http.MethodPost, "/admin/distributions/run", nil)
+			w := httptest.NewRecorder()
+
+			h.RunDistributions(w, req)
+
+			if w.Code != tt.expectedCode {
+				t.Fatalf("expected status %d, got %d", tt.expectedCode, w.Code)
+			}
+			if tt.expectedRun == nil {
+				return
+			}
+			var actual model.DistributionRun
+			if err := json.NewDecoder(w.Body).Decode(&actual); err != nil {
+				t.Fatalf("failed to decode response: %v", err)
+			}
+			if diff := cmp.Diff(*tt.expectedRun, actual); diff != "" {
+				t.Errorf("unexpected run (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
@@ -0,0 +1,57 @@
+package handler
+
+import (
+	"encoding/json"
+	"net/http"
+	"time"
+
+	"github.com/oliknight1/retail-isa-investment/investment-service/internal"
+	"github.com/oliknight1/retail-isa-investment/investment-service/logger"
+	"github.com/oliknight1/retail-isa-investment/investment-service/service"
+	"go.uber.org/zap"
+)
+
+type FeeHandler struct {
+	Service service.FeeService
+	Logger  logger.Logger
+}
+
+func NewFeeHandler(service service.FeeService, logger logger.Logger) *FeeHandler {
+	return &FeeHandler{service, logger}
+}
+
+// GetFeesByCustomerId returns every monthly fee charge raised on a customer's accounts
+func (h *FeeHandler) GetFeesByCustomerId(w http.ResponseWriter, r *http.Request) {
+	internal.InvestmentRequests.WithLabelValues("/customers/{id}/fees", "GET").Inc()
+	customerId := r.PathValue("id")
+	if customerId == "" {
+		h.Logger.Error("missing customer_id when requesting fees", zap.Error(internal.ErrMissingCustomerId))
+		http.Error(w, internal.ErrMissingCustomerId.Error(), http.StatusBadRequest)
+		return
+	}
+
+	charges, err := h.Service.GetFeesByCustomerId(customerId)
+	if err != nil {
+		h.Logger.Error("failed to get fees", zap.Error(err))
+		http.Error(w, "failed to get fees", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(charges)
+}
+
+// RunFees accrues, bills and collects fees without waiting for the scheduler, and reports what
+// was done
+func (h *FeeHandler) RunFees(w http.ResponseWriter, r *http.Request) {
+	internal.InvestmentRequests.WithLabelValues("/admin/fees/run", "POST").Inc()
+	run, err := h.Service.Run(time.Now())
+	if err != nil {
+		h.Logger.Error("failed to run fees", zap.Error(err))
+		http.Error(w, "failed to run fees", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(run)
+}
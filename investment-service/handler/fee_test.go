@@ -0,0 +1,134 @@
+package handler_test
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/oliknight1/retail-isa-investment/investment-service/handler"
+	"github.com/oliknight1/retail-isa-investment/investment-service/logger"
+	"github.com/oliknight1/retail-isa-investment/investment-service/model"
+	"github.com/oliknight1/retail-isa-investment/investment-service/money"
+)
+
+type mockFeeService struct {
+	getFeesByCustomerId func(id string) (*[]model.FeeCharge, error)
+	run                 func(now time.Time) (*model.FeeRun, error)
+}
+
+func (m *mockFeeService) GetFeesByCustomerId(id string) (*[]model.FeeCharge, error) {
+	return m.getFeesByCustomerId(id)
+}
+
+func (m *mockFeeService) Run(now time.Time) (*model.FeeRun, error) {
+	return m.run(now)
+}
+
+func TestGetFeesByCustomerId(t *testing.T) {
+	tests := []struct {
+		name       string
+		customerId string
+		err        error
+		expected   int
+	}{
+		{"success", "cust-123", nil, http.StatusOK},
+		{"missing id", "", nil, http.StatusBadRequest},
+		{"service error", "cust-123", errors.New("db down"), http.StatusInternalServerError},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockSvc := &mockFeeService{
+				getFeesByCustomerId: func(id string) (*[]model.FeeCharge, error) {
+					if tt.err != nil {
+						return nil, tt.err
+					}
+					return &[]model.FeeCharge{{
+						Id:         "fee-1",
+						AccountId:  "acc-1",
+						CustomerId: id,
+						Month:      "2025-06",
+						Accrued:    3_698_630,
+						Amount:     money.Pence(370),
+						Status:     model.FeeChargeCharged,
+						Collection: model.CollectedFromCash,
+					}}, nil
+				},
+			}
+			h := handler.NewFeeHandler(mockSvc, logger.NewMockLogger())
+
+			req := httptest.NewRequest(http.MethodGet, "/customers/"+tt.customerId+"/fees", nil)
+			req.SetPathValue("id", tt.customerId)
+			w := httptest.NewRecorder()
+
+			h.GetFeesByCustomerId(w, req)
+
+			if w.Code != tt.expected {
+				t.Fatalf("expected %d, got %d", tt.expected, w.Code)
+			}
+			if tt.expected != http.StatusOK {
+				return
+			}
+			var charges []model.FeeCharge
+			if err := json.NewDecoder(w.Body).Decode(&charges); err != nil {
+				t.Fatalf("decode error: %v", err)
+			}
+			if len(charges) != 1 || charges[0].Accrued != 3_698_630 || !charges[0].Amount.Equal(money.Pence(370)) {
+				t.Errorf("unexpected charges %+v", charges)
+			}
+		})
+	}
+}
+
+func TestRunFees(t *testing.T) {
+	runAt := time.Date(2025, time.July, 1, 1, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name         string
+		run          func(now time.Time) (*model.FeeRun, error)
+		expectedCode int
+		expectedRun  *model.FeeRun
+	}{
+		{
+			name: "reports the run",
+			run: func(now time.Time) (*model.FeeRun, error) {
+				return &model.FeeRun{RunAt: runAt, Accrued: 3, Billed: 3, Charged: 2, AwaitingSale: 1}, nil
+			},
+			expectedCode: http.StatusOK,
+			expectedRun:  &model.FeeRun{RunAt: runAt, Accrued: 3, Billed: 3, Charged: 2, AwaitingSale: 1},
+		},
+		{
+			name: "repository failure",
+			run: func(now time.Time) (*model.FeeRun, error) {
+				return nil, errors.New("db down")
+			},
+			expectedCode: http.StatusInternalServerError,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h := handler.NewFeeHandler(&mockFeeService{run: tt.run}, logger.NewMockLogger())
+			req := httptest.NewRequest(http.MethodPost, "/admin/fees/run", nil)
+			w := httptest.NewRecorder()
+
+			h.RunFees(w, req)
+
+			if w.Code != tt.expectedCode {
+				t.Fatalf("expected status %d, got %d", tt.expectedCode, w.Code)
+			}
+			if tt.expectedRun == nil {
+				return
+			}
+			var actual model.FeeRun
+			if err := json.NewDecoder(w.Body).Decode(&actual); err != nil {
+				t.Fatalf("failed to decode response: %v", err)
+			}
+			if diff := cmp.Diff(*tt.expectedRun, actual); diff != "" {
+				t.Errorf("unexpected run (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
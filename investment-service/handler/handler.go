@@ -3,12 +3,15 @@ package handler
 import (
 	"bytes"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"net/http"
 	"strings"
 
 	"github.com/oliknight1/retail-isa-investment/investment-service/internal"
 	"github.com/oliknight1/retail-isa-investment/investment-service/logger"
+	"github.com/oliknight1/retail-isa-investment/investment-service/model"
+	"github.com/oliknight1/retail-isa-investment/investment-service/money"
 	"github.com/oliknight1/retail-isa-investment/investment-service/service"
 	"go.uber.org/zap"
 )
@@ -25,9 +28,9 @@ func New(service service.InvestmentService, logger logger.Logger) *InvestmentHan
 func (h *InvestmentHandler) CreateInvestment(w http.ResponseWriter, r *http.Request) {
 	internal.InvestmentRequests.WithLabelValues("/investments", "POST").Inc()
 	var req struct {
-		CustomerId string  `json:"customerId"`
-		FundId     string  `json:"fundId"`
-		Amount     float64 `json:"amount"`
+		AccountId string      `json:"accountId"`
+		FundId    string      `json:"fundId"`
+		Amount    money.Money `json:"amount"`
 	}
 
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
@@ -37,10 +40,10 @@ func (h *InvestmentHandler) CreateInvestment(w http.ResponseWriter, r *http.Requ
 		return
 	}
 
-	if req.CustomerId == "" {
-		h.Logger.Error("missing customer_id in creation request", internal.ErrMissingCustomerId)
-		internal.InvestmentCreationFailures.WithLabelValues("missing_customer_id").Inc()
-		http.Error(w, internal.ErrMissingCustomerId.Error(), http.StatusBadRequest)
+	if req.AccountId == "" {
+		h.Logger.Error("missing account_id in creation request", zap.Error(internal.ErrMissingAccountId))
+		internal.InvestmentCreationFailures.WithLabelValues("missing_account_id").Inc()
+		http.Error(w, internal.ErrMissingAccountId.Error(), http.StatusBadRequest)
 		return
 	}
 	if req.FundId == "" {
@@ -49,15 +52,39 @@ func (h *InvestmentHandler) CreateInvestment(w http.ResponseWriter, r *http.Requ
 		http.Error(w, internal.ErrMissingFundId.Error(), http.StatusBadRequest)
 		return
 	}
-	if req.Amount <= 0 {
+	if !req.Amount.IsPositive() {
 		h.Logger.Error("invalid transaction amount in creation request", internal.ErrZeroTransactionAmount)
 		internal.InvestmentCreationFailures.WithLabelValues("invalid_amount").Inc()
 		http.Error(w, internal.ErrZeroTransactionAmount.Error(), http.StatusBadRequest)
 		return
 	}
 
-	transaction, err := h.Service.CreateInvestment(req.CustomerId, req.FundId, req.Amount)
+	transaction, err := h.Service.CreateInvestment(req.AccountId, req.FundId, req.Amount)
 
+	if errors.Is(err, internal.ErrAccountNotFound) {
+		h.Logger.Error("investment into unknown account", zap.Error(err))
+		internal.InvestmentCreationFailures.WithLabelValues("account_not_found").Inc()
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+	if errors.Is(err, internal.ErrAccountClosed) || errors.Is(err, internal.ErrFundNotEligible) || errors.Is(err, internal.ErrIneligibleAge) {
+		h.Logger.Error("investment rejected by account rules", zap.Error(err))
+		internal.InvestmentCreationFailures.WithLabelValues("account_rules").Inc()
+		http.Error(w, err.Error(), http.StatusUnprocessableEntity)
+		return
+	}
+	if errors.Is(err, internal.ErrCustomerIneligible) {
+		h.Logger.Error("investment rejected for ineligible customer", zap.Error(err))
+		internal.InvestmentCreationFailures.WithLabelValues("customer_ineligible").Inc()
+		http.Error(w, err.Error(), http.StatusUnprocessableEntity)
+		return
+	}
+	if errors.Is(err, internal.ErrAllowanceExceeded) {
+		h.Logger.Error("investment exceeds annual allowance", zap.Error(err))
+		internal.InvestmentCreationFailures.WithLabelValues("allowance_exceeded").Inc()
+		http.Error(w, err.Error(), http.StatusUnprocessableEntity)
+		return
+	}
 	if err != nil {
 		h.Logger.Error("internal service error", zap.Error(err))
 		internal.InvestmentCreationFailures.WithLabelValues("service_error").Inc()
@@ -89,6 +116,11 @@ func (h *InvestmentHandler) GetInvestmentById(w http.ResponseWriter, r *http.Req
 	id := parts[2]
 
 	investment, err := h.Service.GetInvestmentById(id)
+	if errors.Is(err, internal.ErrInvestmentNotFound) {
+		h.Logger.Error("investment not found", zap.Error(err))
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
 	if err != nil {
 		h.Logger.Error("failed to get investment", zap.Error(err))
 		http.Error(w, "failed to get investment", http.StatusInternalServerError)
@@ -121,3 +153,289 @@ func (h *InvestmentHandler) GetInvestmentsByCustomerId(w http.ResponseWriter, r
 	h.Logger.Info("investment found", len(*investments))
 	json.NewEncoder(w).Encode(investments)
 }
+
+func (h *InvestmentHandler) Withdraw(w http.ResponseWriter, r *http.Request) {
+	internal.InvestmentRequests.WithLabelValues("/accounts/{id}/withdrawals", "POST").Inc()
+	accountId := r.PathValue("id")
+	if accountId == "" {
+		h.Logger.Error("missing account_id in withdrawal request", zap.Error(internal.ErrMissingAccountId))
+		http.Error(w, internal.ErrMissingAccountId.Error(), http.StatusBadRequest)
+		return
+	}
+	var req struct {
+		Amount money.Money            `json:"amount"`
+		Reason model.WithdrawalReason `json:"reason"`
+	}
+
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		h.Logger.Error("failed to decode withdrawal request", zap.Error(err))
+		http.Error(w, fmt.Sprintf("error reading JSON: \n%s", err), http.StatusBadRequest)
+		return
+	}
+
+	withdrawal, err := h.Service.Withdraw(accountId, req.Amount, req.Reason)
+	if errors.Is(err, internal.ErrZeroTransactionAmount) || errors.Is(err, internal.ErrInvalidReason) {
+		h.Logger.Error("invalid withdrawal request", zap.Error(err))
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if errors.Is(err, internal.ErrAccountNotFound) {
+		h.Logger.Error("withdrawal from unknown account", zap.Error(err))
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+	if errors.Is(err, internal.ErrAccountClosed) || errors.Is(err, internal.ErrWithdrawalNotAllowed) ||
+		errors.Is(err, internal.ErrIneligibleAge) || errors.Is(err, internal.ErrInsufficientCash) {
+		h.Logger.Error("withdrawal rejected by account rules", zap.Error(err))
+		http.Error(w, err.Error(), http.StatusUnprocessableEntity)
+		return
+	}
+	if err != nil {
+		h.Logger.Error("internal service error", zap.Error(err))
+		http.Error(w, "failed to create withdrawal", http.StatusInternalServerError)
+		return
+	}
+
+	var buf bytes.Buffer
+	if err := json.NewEncoder(&buf).Encode(withdrawal); err != nil {
+		h.Logger.Error("failed to write withdrawal to JSON", zap.Error(err))
+		http.Error(w, "failed to encode response", http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	h.Logger.Info("withdrawal successfully created", zap.String("investment_id", withdrawal.Id))
+	w.Write(buf.Bytes())
+}
+
+// Deposit pays cash into an account
+func (h *InvestmentHandler) Deposit(w http.ResponseWriter, r *http.Request) {
+	internal.InvestmentRequests.WithLabelValues("/accounts/{id}/deposits", "POST").Inc()
+	var req struct {
+		Amount money.Money `json:"amount"`
+	}
+
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		h.Logger.Error("failed to decode deposit request", zap.Error(err))
+		http.Error(w, fmt.Sprintf("error reading JSON: \n%s", err), http.StatusBadRequest)
+		return
+	}
+
+	deposit, err := h.Service.Deposit(r.PathValue("id"), req.Amount)
+	if errors.Is(err, internal.ErrMissingAccountId) || errors.Is(err, internal.ErrZeroTransactionAmount) {
+		h.Logger.Error("invalid deposit request", zap.Error(err))
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if errors.Is(err, internal.ErrAccountNotFound) {
+		h.Logger.Error("deposit into unknown account", zap.Error(err))
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+	if errors.Is(err, internal.ErrAccountClosed) || errors.Is(err, internal.ErrIneligibleAge) ||
+		errors.Is(err, internal.ErrCustomerIneligible) || errors.Is(err, internal.ErrAllowanceExceeded) {
+		h.Logger.Error("deposit rejected by account rules", zap.Error(err))
+		http.Error(w, err.Error(), http.StatusUnprocessableEntity)
+		return
+	}
+	if err != nil {
+		h.Logger.Error("internal service error", zap.Error(err))
+		http.Error(w, "failed to create deposit", http.StatusInternalServerError)
+		return
+	}
+
+	var buf bytes.Buffer
+	if err := json.NewEncoder(&buf).Encode(deposit); err != nil {
+		h.Logger.Error("failed to write deposit to JSON", zap.Error(err))
+		http.Error(w, "failed to encode response", http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	h.Logger.Info("deposit successfully created", zap.String("investment_id", deposit.Id))
+	w.Write(buf.Bytes())
+}
+
+// Buy places an order for a fund paid for with cash already in an account
+func (h *InvestmentHandler) Buy(w http.ResponseWriter, r *http.Request) {
+	internal.InvestmentRequests.WithLabelValues("/accounts/{id}/purchases", "POST").Inc()
+	var req struct {
+		FundId string      `json:"fundId"`
+		Amount money.Money `json:"amount"`
+	}
+
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		h.Logger.Error("failed to decode purchase request", zap.Error(err))
+		http.Error(w, fmt.Sprintf("error reading JSON: \n%s", err), http.StatusBadRequest)
+		return
+	}
+
+	purchase, err := h.Service.Buy(r.PathValue("id"), req.FundId, req.Amount)
+	if errors.Is(err, internal.ErrMissingAccountId) || errors.Is(err, internal.ErrMissingFundId) ||
+		errors.Is(err, internal.ErrZeroTransactionAmount) {
+		h.Logger.Error("invalid purchase request", zap.Error(err))
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if errors.Is(err, internal.ErrAccountNotFound) {
+		h.Logger.Error("purchase in unknown account", zap.Error(err))
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+	if errors.Is(err, internal.ErrAccountClosed) || errors.Is(err, internal.ErrFundNotEligible) ||
+		errors.Is(err, internal.ErrInsufficientCash) {
+		h.Logger.Error("purchase rejected by account checks", zap.Error(err))
+		http.Error(w, err.Error(), http.StatusUnprocessableEntity)
+		return
+	}
+	if err != nil {
+		h.Logger.Error("internal service error", zap.Error(err))
+		http.Error(w, "failed to create purchase", http.StatusInternalServerError)
+		return
+	}
+
+	var buf bytes.Buffer
+	if err := json.NewEncoder(&buf).Encode(purchase); err != nil {
+		h.Logger.Error("failed to write purchase to JSON", zap.Error(err))
+		http.Error(w, "failed to encode response", http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	h.Logger.Info("purchase successfully created", zap.String("investment_id", purchase.Id))
+	w.Write(buf.Bytes())
+}
+
+// Redeem places a sell order for a fund held in an account, by amount, by units or for the
+// whole holding
+func (h *InvestmentHandler) Redeem(w http.ResponseWriter, r *http.Request) {
+	internal.InvestmentRequests.WithLabelValues("/accounts/{id}/redemptions", "POST").Inc()
+	accountId := r.PathValue("id")
+	var req struct {
+		FundId string      `json:"fundId"`
+		Amount money.Money `json:"amount"`
+		Units  model.Units `json:"units"`
+		All    bool        `json:"all"`
+	}
+
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		h.Logger.Error("failed to decode redemption request", zap.Error(err))
+		http.Error(w, fmt.Sprintf("error reading JSON: \n%s", err), http.StatusBadRequest)
+		return
+	}
+
+	order := model.RedemptionOrder{Amount: req.Amount, Units: req.Units, All: req.All}
+	redemption, err := h.Service.Redeem(accountId, req.FundId, order)
+	if errors.Is(err, internal.ErrMissingAccountId) || errors.Is(err, internal.ErrMissingFundId) ||
+		errors.Is(err, internal.ErrInvalidRedemption) {
+		h.Logger.Error("invalid redemption request", zap.Error(err))
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if errors.Is(err, internal.ErrAccountNotFound) {
+		h.Logger.Error("redemption from unknown account", zap.Error(err))
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+	if errors.Is(err, internal.ErrAccountClosed) || errors.Is(err, internal.ErrInsufficientHolding) {
+		h.Logger.Error("redemption rejected by holding checks", zap.Error(err))
+		http.Error(w, err.Error(), http.StatusUnprocessableEntity)
+		return
+	}
+	if err != nil {
+		h.Logger.Error("internal service error", zap.Error(err))
+		http.Error(w, "failed to create redemption", http.StatusInternalServerError)
+		return
+	}
+
+	var buf bytes.Buffer
+	if err := json.NewEncoder(&buf).Encode(redemption); err != nil {
+		h.Logger.Error("failed to write redemption to JSON", zap.Error(err))
+		http.Error(w, "failed to encode response", http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	h.Logger.Info("redemption successfully created", zap.String("investment_id", redemption.Id))
+	w.Write(buf.Bytes())
+}
+
+func (h *InvestmentHandler) CancelInvestment(w http.ResponseWriter, r *http.Request) {
+	internal.InvestmentRequests.WithLabelValues("/investments/{id}/cancel", "POST").Inc()
+	id := r.PathValue("id")
+
+	investment, err := h.Service.CancelInvestment(id)
+	if errors.Is(err, internal.ErrInvestmentNotFound) {
+		h.Logger.Error("cancellation of unknown investment", zap.Error(err))
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+	if errors.Is(err, internal.ErrCannotCancel) {
+		h.Logger.Error("investment cannot be cancelled", zap.Error(err))
+		http.Error(w, err.Error(), http.StatusConflict)
+		return
+	}
+	if errors.Is(err, internal.ErrCoolingOffExpired) {
+		h.Logger.Error("cancellation after cooling-off period", zap.Error(err))
+		http.Error(w, err.Error(), http.StatusUnprocessableEntity)
+		return
+	}
+	if err != nil {
+		h.Logger.Error("internal service error", zap.Error(err))
+		http.Error(w, "failed to cancel investment", http.StatusInternalServerError)
+		return
+	}
+
+	h.Logger.Info("investment cancelled", zap.String("investment_id", investment.Id))
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(investment)
+}
+
+// UpdateInvestmentStatus settles or fails an investment on behalf of operations. The actor is
+// recorded in the investment's history alongside the reason
+func (h *InvestmentHandler) UpdateInvestmentStatus(w http.ResponseWriter, r *http.Request) {
+	internal.InvestmentRequests.WithLabelValues("/admin/investments/{id}/status", "POST").Inc()
+	id := r.PathValue("id")
+	var req struct {
+		Status model.InvestmentStatus `json:"status"`
+		Reason string                 `json:"reason"`
+		Actor  string                 `json:"actor"`
+	}
+
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		h.Logger.Error("failed to decode investment status request", zap.Error(err))
+		http.Error(w, fmt.Sprintf("error reading JSON: \n%s", err), http.StatusBadRequest)
+		return
+	}
+
+	investment, err := h.Service.UpdateInvestmentStatus(id, req.Status, req.Reason, req.Actor)
+	if errors.Is(err, internal.ErrMissingActor) {
+		h.Logger.Error("missing actor in investment status request", zap.Error(err))
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if errors.Is(err, internal.ErrInvestmentNotFound) {
+		h.Logger.Error("status change of unknown investment", zap.Error(err))
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+	if errors.Is(err, internal.ErrInvalidTransition) {
+		h.Logger.Error("invalid investment status change", zap.Error(err))
+		http.Error(w, err.Error(), http.StatusConflict)
+		return
+	}
+	if errors.Is(err, internal.ErrInsufficientCash) {
+		h.Logger.Error("investment status change would overdraw account", zap.Error(err))
+		http.Error(w, err.Error(), http.StatusConflict)
+		return
+	}
+	if err != nil {
+		h.Logger.Error("internal service error", zap.Error(err))
+		http.Error(w, "failed to update investment", http.StatusInternalServerError)
+		return
+	}
+
+	h.Logger.Info("investment status updated", zap.String("investment_id", investment.Id), zap.String("status", string(investment.Status)))
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(investment)
+}
@@ -10,19 +10,28 @@ import (
 	"testing"
 	"time"
 
+	"github.com/google/go-cmp/cmp"
 	"github.com/oliknight1/retail-isa-investment/investment-service/handler"
+	"github.com/oliknight1/retail-isa-investment/investment-service/internal"
 	"github.com/oliknight1/retail-isa-investment/investment-service/logger"
 	"github.com/oliknight1/retail-isa-investment/investment-service/model"
+	"github.com/oliknight1/retail-isa-investment/investment-service/money"
 )
 
 type mockService struct {
-	createInvestment           func(customerId string, fundId string, amount float64) (*model.Investment, error)
+	createInvestment           func(accountId string, fundId string, amount money.Money) (*model.Investment, error)
 	getInvestmentById          func(string) (*model.Investment, error)
 	getInvestmentsByCustomerId func(string) (*[]model.Investment, error)
+	deposit                    func(accountId string, amount money.Money) (*model.Investment, error)
+	buy                        func(accountId string, fundId string, amount money.Money) (*model.Investment, error)
+	withdraw                   func(accountId string, amount money.Money, reason model.WithdrawalReason) (*model.Investment, error)
+	redeem                     func(accountId string, fundId string, order model.RedemptionOrder) (*model.Investment, error)
+	cancelInvestment           func(id string) (*model.Investment, error)
+	updateInvestmentStatus     func(id string, status model.InvestmentStatus, reason, actor string) (*model.Investment, error)
 }
 
-func (m *mockService) CreateInvestment(customerId string, fundId string, amount float64) (*model.Investment, error) {
-	return m.createInvestment(customerId, fundId, amount)
+func (m *mockService) CreateInvestment(accountId string, fundId string, amount money.Money) (*model.Investment, error) {
+	return m.createInvestment(accountId, fundId, amount)
 }
 func (m *mockService) GetInvestmentById(id string) (*model.Investment, error) {
 	return m.getInvestmentById(id)
@@ -30,16 +39,35 @@ func (m *mockService) GetInvestmentById(id string) (*model.Investment, error) {
 func (m *mockService) GetInvestmentsByCustomerId(id string) (*[]model.Investment, error) {
 	return m.getInvestmentsByCustomerId(id)
 }
+func (m *mockService) Deposit(accountId string, amount money.Money) (*model.Investment, error) {
+	return m.deposit(accountId, amount)
+}
+func (m *mockService) Buy(accountId string, fundId string, amount money.Money) (*model.Investment, error) {
+	return m.buy(accountId, fundId, amount)
+}
+func (m *mockService) Withdraw(accountId string, amount money.Money, reason model.WithdrawalReason) (*model.Investment, error) {
+	return m.withdraw(accountId, amount, reason)
+}
+func (m *mockService) Redeem(accountId string, fundId string, order model.RedemptionOrder) (*model.Investment, error) {
+	return m.redeem(accountId, fundId, order)
+}
+func (m *mockService) CancelInvestment(id string) (*model.Investment, error) {
+	return m.cancelInvestment(id)
+}
+func (m *mockService) UpdateInvestmentStatus(id string, status model.InvestmentStatus, reason, actor string) (*model.Investment, error) {
+	return m.updateInvestmentStatus(id, status, reason, actor)
+}
 func TestCreateInvestment(t *testing.T) {
-	customerId := "cust-123"
+	accountId := "acc-123"
 	fundId := "fund-456"
-	amount := 100.0
+	amount := money.Pounds(100)
 
 	mockService := &mockService{
-		createInvestment: func(customerId string, fundId string, amount float64) (*model.Investment, error) {
+		createInvestment: func(accountId string, fundId string, amount money.Money) (*model.Investment, error) {
 			return &model.Investment{
 				Id:         "test-id",
-				CustomerId: customerId,
+				AccountId:  accountId,
+				CustomerId: "cust-123",
 				FundId:     fundId,
 				Amount:     amount,
 				Status:     "pending",
@@ -51,7 +79,7 @@ func TestCreateInvestment(t *testing.T) {
 	logger := logger.NewMockLogger()
 	handler := handler.New(mockService, logger)
 
-	reqBody := fmt.Sprintf(`{"customerId":"%s","fundId":"%s","amount":%f}`, customerId, fundId, amount)
+	reqBody := fmt.Sprintf(`{"accountId":"%s","fundId":"%s","amount":%s}`, accountId, fundId, amount)
 	req := httptest.NewRequest(http.MethodPost, "/investments", strings.NewReader(reqBody))
 	req.Header.Set("Content-Type", "application/json")
 	w := httptest.NewRecorder()
@@ -70,7 +98,7 @@ func TestCreateInvestment(t *testing.T) {
 		t.Fatalf("error decoding response: %v", err)
 	}
 
-	if inv.CustomerId != customerId || inv.FundId != fundId || inv.Amount != amount {
+	if inv.AccountId != accountId || inv.FundId != fundId || inv.Amount != amount {
 		t.Fatalf("unexpected investment returned: %+v", inv)
 	}
 }
@@ -82,23 +110,33 @@ func TestCreateInvestmentFailures(t *testing.T) {
 		expectedCode int
 	}{
 		{
-			name:         "missing customerId",
+			name:         "missing accountId",
 			body:         `{"fundId":"fund-456","amount":100}`,
 			expectedCode: http.StatusBadRequest,
 		},
 		{
 			name:         "missing fundId",
-			body:         `{"customerId":"cust-123","amount":100}`,
+			body:         `{"accountId":"acc-123","amount":100}`,
 			expectedCode: http.StatusBadRequest,
 		},
 		{
 			name:         "zero amount",
-			body:         `{"customerId":"cust-123","fundId":"fund-456","amount":0}`,
+			body:         `{"accountId":"acc-123","fundId":"fund-456","amount":0}`,
+			expectedCode: http.StatusBadRequest,
+		},
+		{
+			name:         "more than two decimal places",
+			body:         `{"accountId":"acc-123","fundId":"fund-456","amount":100.005}`,
+			expectedCode: http.StatusBadRequest,
+		},
+		{
+			name:         "unsupported currency",
+			body:         `{"accountId":"acc-123","fundId":"fund-456","amount":{"value":"100.00","currency":"USD"}}`,
 			expectedCode: http.StatusBadRequest,
 		},
 		{
 			name:         "invalid json",
-			body:         `{"customerId":"abc",`,
+			body:         `{"accountId":"abc",`,
 			expectedCode: http.StatusBadRequest,
 		},
 	}
@@ -124,6 +162,67 @@ func TestCreateInvestmentFailures(t *testing.T) {
 	}
 }
 
+func TestCreateInvestmentServiceErrors(t *testing.T) {
+	tests := []struct {
+		name         string
+		err          error
+		expectedCode int
+	}{
+		{
+			name:         "allowance exceeded",
+			err:          internal.AllowanceExceededError(money.Pounds(50)),
+			expectedCode: http.StatusUnprocessableEntity,
+		},
+		{
+			name:         "account not found",
+			err:          internal.AccountNotFoundError("acc-123"),
+			expectedCode: http.StatusNotFound,
+		},
+		{
+			name:         "account closed",
+			err:          internal.ErrAccountClosed,
+			expectedCode: http.StatusUnprocessableEntity,
+		},
+		{
+			name:         "fund not eligible",
+			err:          internal.ErrFundNotEligible,
+			expectedCode: http.StatusUnprocessableEntity,
+		},
+		{
+			name:         "customer ineligible",
+			err:          internal.CustomerIneligibleError([]string{"not_uk_resident"}),
+			expectedCode: http.StatusUnprocessableEntity,
+		},
+		{
+			name:         "unexpected error",
+			err:          errors.New("db failure"),
+			expectedCode: http.StatusInternalServerError,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			mockSvc := &mockService{
+				createInvestment: func(accountId string, fundId string, amount money.Money) (*model.Investment, error) {
+					return nil, tc.err
+				},
+			}
+			logger := logger.NewMockLogger()
+			h := handler.New(mockSvc, logger)
+
+			req := httptest.NewRequest(http.MethodPost, "/investments", strings.NewReader(`{"accountId":"acc-123","fundId":"fund-456","amount":100}`))
+			req.Header.Set("Content-Type", "application/json")
+			w := httptest.NewRecorder()
+
+			h.CreateInvestment(w, req)
+
+			if w.Code != tc.expectedCode {
+				t.Fatalf("expected status %d, got %d", tc.expectedCode, w.Code)
+			}
+		})
+	}
+}
+
 func TestGetInvestmentByIdSuccess(t *testing.T) {
 	mockSvc := &mockService{
 		getInvestmentById: func(id string) (*model.Investment, error) {
@@ -131,9 +230,13 @@ func TestGetInvestmentByIdSuccess(t *testing.T) {
 				Id:         id,
 				CustomerId: "cust-123",
 				FundId:     "fund-456",
-				Amount:     100.0,
-				Status:     "pending",
-				CreatedAt:  time.Now(),
+				Amount:     money.Pounds(100),
+				Status:     model.InvestmentValidated,
+				History: []model.InvestmentStatusChange{
+					{Status: model.InvestmentPending, Reason: "order received", Actor: model.ActorCustomer},
+					{From: model.InvestmentPending, Status: model.InvestmentValidated, Reason: "eligibility and allowance checks passed", Actor: model.ActorSystem},
+				},
+				CreatedAt: time.Now(),
 			}, nil
 		},
 	}
@@ -157,6 +260,31 @@ func TestGetInvestmentByIdSuccess(t *testing.T) {
 	if inv.Id != "inv-123" {
 		t.Errorf("expected id inv-123, got %s", inv.Id)
 	}
+	if len(inv.History) != 2 || inv.History[1].From != model.InvestmentPending {
+		t.Errorf("expected status history in response, got %+v", inv.History)
+	}
+}
+
+func TestGetInvestmentByIdNotFound(t *testing.T) {
+	mockSvc := &mockService{
+		getInvestmentById: func(id string) (*model.Investment, error) {
+			return nil, internal.InvestmentNotFoundError(id)
+		},
+	}
+	logger := logger.NewMockLogger()
+	handler := handler.New(mockSvc, logger)
+
+	req := httptest.NewRequest(http.MethodGet, "/investments/inv-unknown", nil)
+	w := httptest.NewRecorder()
+
+	handler.GetInvestmentById(w, req)
+
+	res := w.Result()
+	defer res.Body.Close()
+
+	if res.StatusCode != http.StatusNotFound {
+		t.Fatalf("expected 404 Not Found, got %d", res.StatusCode)
+	}
 }
 
 func TestGetInvestmentByIdMissingId(t *testing.T) {
@@ -205,7 +333,7 @@ func TestGetInvestmentsByCustomerIdSuccess(t *testing.T) {
 					Id:         "inv-1",
 					CustomerId: id,
 					FundId:     "fund-1",
-					Amount:     100.0,
+					Amount:     money.Pounds(100),
 					Status:     "pending",
 					CreatedAt:  time.Now(),
 				},
@@ -271,3 +399,290 @@ func TestGetInvestmentsByCustomerIdServiceError(t *testing.T) {
 		t.Fatalf("expected 500 Internal Server Error, got %d", res.StatusCode)
 	}
 }
+
+func TestWithdraw(t *testing.T) {
+	mockSvc := &mockService{
+		withdraw: func(accountId string, amount money.Money, reason model.WithdrawalReason) (*model.Investment, error) {
+			if accountId != "acc-123" || !amount.Equal(money.Pounds(250)) || reason != model.WithdrawalOther {
+				t.Errorf("unexpected withdrawal request: %s %s %s", accountId, amount, reason)
+			}
+			return &model.Investment{
+				Id:         "inv-1",
+				AccountId:  accountId,
+				Type:       model.Withdrawal,
+				Amount:     amount,
+				Status:     "pending",
+				Withdrawal: &model.WithdrawalDetails{Reason: reason, NetAmount: amount},
+			}, nil
+		},
+	}
+	logger := logger.NewMockLogger()
+	h := handler.New(mockSvc, logger)
+
+	req := httptest.NewRequest(http.MethodPost, "/accounts/acc-123/withdrawals", strings.NewReader(`{"amount":250,"reason":"other"}`))
+	req.SetPathValue("id", "acc-123")
+	w := httptest.NewRecorder()
+
+	h.Withdraw(w, req)
+
+	if w.Code != http.StatusCreated {
+		t.Fatalf("expected 201 Created, got %d", w.Code)
+	}
+	var withdrawal model.Investment
+	if err := json.NewDecoder(w.Body).Decode(&withdrawal); err != nil {
+		t.Fatalf("decode error: %v", err)
+	}
+	if withdrawal.Type != model.Withdrawal || withdrawal.Withdrawal == nil || !withdrawal.Withdrawal.NetAmount.Equal(money.Pounds(250)) {
+		t.Errorf("unexpected withdrawal: %+v", withdrawal)
+	}
+}
+
+func TestWithdrawServiceErrors(t *testing.T) {
+	tests := []struct {
+		name         string
+		err          error
+		expectedCode int
+	}{
+		{"invalid reason", internal.ErrInvalidReason, http.StatusBadRequest},
+		{"account not found", internal.AccountNotFoundError("acc-123"), http.StatusNotFound},
+		{"insufficient cash", internal.InsufficientCashError(money.Pounds(100)), http.StatusUnprocessableEntity},
+		{"withdrawal not allowed", internal.ErrWithdrawalNotAllowed, http.StatusUnprocessableEntity},
+		{"unexpected error", errors.New("db failure"), http.StatusInternalServerError},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			mockSvc := &mockService{
+				withdraw: func(accountId string, amount money.Money, reason model.WithdrawalReason) (*model.Investment, error) {
+					return nil, tc.err
+				},
+			}
+			logger := logger.NewMockLogger()
+			h := handler.New(mockSvc, logger)
+
+			req := httptest.NewRequest(http.MethodPost, "/accounts/acc-123/withdrawals", strings.NewReader(`{"amount":250}`))
+			req.SetPathValue("id", "acc-123")
+			w := httptest.NewRecorder()
+
+			h.Withdraw(w, req)
+
+			if w.Code != tc.expectedCode {
+				t.Fatalf("expected status %d, got %d", tc.expectedCode, w.Code)
+			}
+		})
+	}
+}
+
+func TestDeposit(t *testing.T) {
+	tests := []struct {
+		name         string
+		body         string
+		err          error
+		expectedCode int
+	}{
+		{"deposited", `{"amount":500}`, nil, http.StatusCreated},
+		{"invalid JSON", `{`, nil, http.StatusBadRequest},
+		{"zero amount", `{"amount":0}`, internal.ErrZeroTransactionAmount, http.StatusBadRequest},
+		{"account not found", `{"amount":500}`, internal.AccountNotFoundError("acc-123"), http.StatusNotFound},
+		{"allowance exceeded", `{"amount":500}`, internal.AllowanceExceededError(money.Pounds(100)), http.StatusUnprocessableEntity},
+		{"unexpected error", `{"amount":500}`, errors.New("db failure"), http.StatusInternalServerError},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			mockSvc := &mockService{
+				deposit: func(accountId string, amount money.Money) (*model.Investment, error) {
+					if tc.err != nil {
+						return nil, tc.err
+					}
+					return &model.Investment{Id: "inv-1", AccountId: accountId, Type: model.Deposit, Amount: amount, Status: model.InvestmentSettled}, nil
+				},
+			}
+			h := handler.New(mockSvc, logger.NewMockLogger())
+
+			req := httptest.NewRequest(http.MethodPost, "/accounts/acc-123/deposits", strings.NewReader(tc.body))
+			req.SetPathValue("id", "acc-123")
+			w := httptest.NewRecorder()
+
+			h.Deposit(w, req)
+
+			if w.Code != tc.expectedCode {
+				t.Fatalf("expected status %d, got %d", tc.expectedCode, w.Code)
+			}
+		})
+	}
+}
+
+func TestBuy(t *testing.T) {
+	tests := []struct {
+		name         string
+		body         string
+		err          error
+		expectedCode int
+	}{
+		{"reserved", `{"fundId":"fund-1","amount":200}`, nil, http.StatusCreated},
+		{"invalid JSON", `{`, nil, http.StatusBadRequest},
+		{"missing fund", `{"amount":200}`, internal.ErrMissingFundId, http.StatusBadRequest},
+		{"account not found", `{"fundId":"fund-1","amount":200}`, internal.AccountNotFoundError("acc-123"), http.StatusNotFound},
+		{"insufficient cash", `{"fundId":"fund-1","amount":200}`, internal.InsufficientCashError(money.Pounds(50)), http.StatusUnprocessableEntity},
+		{"unexpected error", `{"fundId":"fund-1","amount":200}`, errors.New("db failure"), http.StatusInternalServerError},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			mockSvc := &mockService{
+				buy: func(accountId string, fundId string, amount money.Money) (*model.Investment, error) {
+					if tc.err != nil {
+						return nil, tc.err
+					}
+					if fundId != "fund-1" || !amount.Equal(money.Pounds(200)) {
+						t.Errorf("unexpected purchase request: %s %s", fundId, amount)
+					}
+					return &model.Investment{Id: "inv-1", AccountId: accountId, FundId: fundId, Type: model.Purchase, Amount: amount, Status: model.InvestmentValidated}, nil
+				},
+			}
+			h := handler.New(mockSvc, logger.NewMockLogger())
+
+			req := httptest.NewRequest(http.MethodPost, "/accounts/acc-123/purchases", strings.NewReader(tc.body))
+			req.SetPathValue("id", "acc-123")
+			w := httptest.NewRecorder()
+
+			h.Buy(w, req)
+
+			if w.Code != tc.expectedCode {
+				t.Fatalf("expected status %d, got %d", tc.expectedCode, w.Code)
+			}
+		})
+	}
+}
+
+func TestCancelInvestment(t *testing.T) {
+	tests := []struct {
+		name         string
+		err          error
+		expectedCode int
+	}{
+		{"success", nil, http.StatusOK},
+		{"investment not found", internal.InvestmentNotFoundError("inv-1"), http.StatusNotFound},
+		{"cannot cancel", internal.ErrCannotCancel, http.StatusConflict},
+		{"cooling-off expired", internal.ErrCoolingOffExpired, http.StatusUnprocessableEntity},
+		{"unexpected error", errors.New("db failure"), http.StatusInternalServerError},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			mockSvc := &mockService{
+				cancelInvestment: func(id string) (*model.Investment, error) {
+					if tc.err != nil {
+						return nil, tc.err
+					}
+					return &model.Investment{
+						Id:           id,
+						Status:       "cancelled",
+						Cancellation: &model.Cancellation{RefundAmount: money.Pounds(100)},
+					}, nil
+				},
+			}
+			logger := logger.NewMockLogger()
+			h := handler.New(mockSvc, logger)
+
+			req := httptest.NewRequest(http.MethodPost, "/investments/inv-1/cancel", nil)
+			req.SetPathValue("id", "inv-1")
+			w := httptest.NewRecorder()
+
+			h.CancelInvestment(w, req)
+
+			if w.Code != tc.expectedCode {
+				t.Fatalf("expected status %d, got %d", tc.expectedCode, w.Code)
+			}
+		})
+	}
+}
+
+func TestUpdateInvestmentStatus(t *testing.T) {
+	tests := []struct {
+		name         string
+		body         string
+		err          error
+		expectedCode int
+	}{
+		{"success", `{"status": "settled", "actor": "ops:jo"}`, nil, http.StatusOK},
+		{"invalid JSON", `{"status":`, nil, http.StatusBadRequest},
+		{"missing actor", `{"status": "failed"}`, internal.ErrMissingActor, http.StatusBadRequest},
+		{"investment not found", `{"status": "failed", "actor": "ops:jo"}`, internal.InvestmentNotFoundError("inv-1"), http.StatusNotFound},
+		{"invalid transition", `{"status": "settled", "actor": "ops:jo"}`, internal.ErrInvalidTransition, http.StatusConflict},
+		{"unexpected error", `{"status": "failed", "actor": "ops:jo"}`, errors.New("db failure"), http.StatusInternalServerError},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			mockSvc := &mockService{
+				updateInvestmentStatus: func(id string, status model.InvestmentStatus, reason, actor string) (*model.Investment, error) {
+					if tc.err != nil {
+						return nil, tc.err
+					}
+					return &model.Investment{Id: id, Status: status}, nil
+				},
+			}
+			logger := logger.NewMockLogger()
+			h := handler.New(mockSvc, logger)
+
+			req := httptest.NewRequest(http.MethodPost, "/admin/investments/inv-1/status", strings.NewReader(tc.body))
+			req.SetPathValue("id", "inv-1")
+			w := httptest.NewRecorder()
+
+			h.UpdateInvestmentStatus(w, req)
+
+			if w.Code != tc.expectedCode {
+				t.Fatalf("expected status %d, got %d", tc.expectedCode, w.Code)
+			}
+		})
+	}
+}
+
+func TestRedeem(t *testing.T) {
+	tests := []struct {
+		name          string
+		body          string
+		err           error
+		expectedCode  int
+		expectedOrder model.RedemptionOrder
+	}{
+		{"by amount", `{"fundId": "fund-1", "amount": "250.00"}`, nil, http.StatusCreated, model.RedemptionOrder{Amount: money.Pounds(250)}},
+		{"by units", `{"fundId": "fund-1", "units": "12.5"}`, nil, http.StatusCreated, model.RedemptionOrder{Units: 125_000}},
+		{"whole holding", `{"fundId": "fund-1", "all": true}`, nil, http.StatusCreated, model.RedemptionOrder{All: true}},
+		{"units with too many decimal places", `{"fundId": "fund-1", "units": "1.23456"}`, nil, http.StatusBadRequest, model.RedemptionOrder{}},
+		{"invalid order", `{"fundId": "fund-1"}`, internal.ErrInvalidRedemption, http.StatusBadRequest, model.RedemptionOrder{}},
+		{"unknown account", `{"fundId": "fund-1", "all": true}`, internal.AccountNotFoundError("acc-1"), http.StatusNotFound, model.RedemptionOrder{All: true}},
+		{"more than held", `{"fundId": "fund-1", "units": "10"}`, internal.ErrInsufficientHolding, http.StatusUnprocessableEntity, model.RedemptionOrder{Units: 100_000}},
+		{"unexpected error", `{"fundId": "fund-1", "all": true}`, errors.New("db failure"), http.StatusInternalServerError, model.RedemptionOrder{All: true}},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			mockSvc := &mockService{
+				redeem: func(accountId string, fundId string, order model.RedemptionOrder) (*model.Investment, error) {
+					if diff := cmp.Diff(tc.expectedOrder, order); diff != "" {
+						t.Errorf("unexpected order (-want +got):\n%s", diff)
+					}
+					if tc.err != nil {
+						return nil, tc.err
+					}
+					return &model.Investment{Id: "inv-1", AccountId: accountId, FundId: fundId, Type: model.Redemption}, nil
+				},
+			}
+			logger := logger.NewMockLogger()
+			h := handler.New(mockSvc, logger)
+
+			req := httptest.NewRequest(http.MethodPost, "/accounts/acc-1/redemptions", strings.NewReader(tc.body))
+			req.SetPathValue("id", "acc-1")
+			w := httptest.NewRecorder()
+
+			h.Redeem(w, req)
+
+			if w.Code != tc.expectedCode {
+				t.Fatalf("expected status %d, got %d", tc.expectedCode, w.Code)
+			}
+		})
+	}
+}
@@ -0,0 +1,109 @@
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/oliknight1/retail-isa-investment/investment-service/internal"
+	"github.com/oliknight1/retail-isa-investment/investment-service/logger"
+	"github.com/oliknight1/retail-isa-investment/investment-service/service"
+	"go.uber.org/zap"
+)
+
+type LedgerHandler struct {
+	Service service.LedgerService
+	Logger  logger.Logger
+}
+
+func NewLedgerHandler(service service.LedgerService, logger logger.Logger) *LedgerHandler {
+	return &LedgerHandler{service, logger}
+}
+
+// GetEntriesByCustomerId returns the journal entries posted for a customer, optionally between
+// from and to given as RFC 3339 times or London dates
+func (h *LedgerHandler) GetEntriesByCustomerId(w http.ResponseWriter, r *http.Request) {
+	internal.InvestmentRequests.WithLabelValues("/customers/{id}/ledger", "GET").Inc()
+	query := r.URL.Query()
+	from, fromErr := parseTime(query.Get("from"), false)
+	to, toErr := parseTime(query.Get("to"), true)
+	if err := errors.Join(fromErr, toErr); err != nil {
+		h.Logger.Error("invalid ledger date range", zap.Error(err))
+		http.Error(w, "from and to must be dates or RFC 3339 times", http.StatusBadRequest)
+		return
+	}
+
+	entries, err := h.Service.GetEntriesByCustomerId(r.PathValue("id"), from, to)
+	if errors.Is(err, internal.ErrMissingCustomerId) {
+		h.Logger.Error("missing customer_id when requesting ledger", zap.Error(err))
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if err != nil {
+		h.Logger.Error("failed to get ledger entries", zap.Error(err))
+		http.Error(w, "failed to get ledger entries", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(entries)
+}
+
+// GetBalances returns the balance of every ledger account an ISA account has posted to
+func (h *LedgerHandler) GetBalances(w http.ResponseWriter, r *http.Request) {
+	internal.InvestmentRequests.WithLabelValues("/accounts/{id}/ledger/balances", "GET").Inc()
+	balances, err := h.Service.GetBalances(r.PathValue("id"))
+	if errors.Is(err, internal.ErrMissingAccountId) {
+		h.Logger.Error("missing account_id when requesting ledger balances", zap.Error(err))
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if err != nil {
+		h.Logger.Error("failed to get ledger balances", zap.Error(err))
+		http.Error(w, "failed to get ledger balances", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(balances)
+}
+
+// ReverseEntry corrects a journal entry by posting its reversal
+func (h *LedgerHandler) ReverseEntry(w http.ResponseWriter, r *http.Request) {
+	internal.InvestmentRequests.WithLabelValues("/admin/ledger/entries/{id}/reverse", "POST").Inc()
+	var req struct {
+		Reason string `json:"reason"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		h.Logger.Error("failed to decode ledger reversal request", zap.Error(err))
+		http.Error(w, fmt.Sprintf("error reading JSON: \n%s", err), http.StatusBadRequest)
+		return
+	}
+
+	reversal, err := h.Service.Reverse(r.PathValue("id"), req.Reason)
+	if errors.Is(err, internal.ErrEntryNotFound) {
+		h.Logger.Error("reversal of unknown journal entry", zap.Error(err))
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+	if errors.Is(err, internal.ErrDuplicateEntry) {
+		h.Logger.Error("journal entry already reversed", zap.Error(err))
+		http.Error(w, err.Error(), http.StatusConflict)
+		return
+	}
+	if errors.Is(err, internal.ErrInsufficientCash) {
+		h.Logger.Error("reversal would overdraw account", zap.Error(err))
+		http.Error(w, err.Error(), http.StatusConflict)
+		return
+	}
+	if err != nil {
+		h.Logger.Error("failed to reverse journal entry", zap.Error(err))
+		http.Error(w, "failed to reverse journal entry", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(reversal)
+}
@@ -0,0 +1,158 @@
+package handler_test
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/oliknight1/retail-isa-investment/investment-service/handler"
+	"github.com/oliknight1/retail-isa-investment/investment-service/internal"
+	"github.com/oliknight1/retail-isa-investment/investment-service/logger"
+	"github.com/oliknight1/retail-isa-investment/investment-service/model"
+	"github.com/oliknight1/retail-isa-investment/investment-service/money"
+)
+
+type mockLedgerService struct {
+	getEntriesByCustomerId func(id string, from, to time.Time) (*[]model.JournalEntry, error)
+	getBalances            func(accountId string) (*[]model.LedgerBalance, error)
+	reverse                func(id string, reason string) (*model.JournalEntry, error)
+}
+
+func (m *mockLedgerService) GetEntriesByCustomerId(id string, from, to time.Time) (*[]model.JournalEntry, error) {
+	return m.getEntriesByCustomerId(id, from, to)
+}
+
+func (m *mockLedgerService) GetBalances(accountId string) (*[]model.LedgerBalance, error) {
+	return m.getBalances(accountId)
+}
+
+func (m *mockLedgerService) Reverse(id string, reason string) (*model.JournalEntry, error) {
+	return m.reverse(id, reason)
+}
+
+func (m *mockLedgerService) GetCashBalance(accountId string) (*model.CashBalance, error) {
+	return &model.CashBalance{AccountId: accountId}, nil
+}
+
+func (m *mockLedgerService) RecordInvestment(model.Investment, model.InvestmentStatusChange) error {
+	return nil
+}
+
+func (m *mockLedgerService) RecordSwitch(model.Switch) error {
+	return nil
+}
+
+func (m *mockLedgerService) RecordFee(model.FeeCharge) error {
+	return nil
+}
+
+func (m *mockLedgerService) RecordDistribution(model.DistributionPayment) error {
+	return nil
+}
+
+func TestGetLedgerEntries(t *testing.T) {
+	tests := []struct {
+		name     string
+		query    string
+		err      error
+		expected int
+	}{
+		{"all", "", nil, http.StatusOK},
+		{"date range", "?from=2025-06-01&to=2025-06-30", nil, http.StatusOK},
+		{"invalid date", "?from=June", nil, http.StatusBadRequest},
+		{"service error", "", errors.New("db down"), http.StatusInternalServerError},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockSvc := &mockLedgerService{
+				getEntriesByCustomerId: func(id string, from, to time.Time) (*[]model.JournalEntry, error) {
+					if tt.err != nil {
+						return nil, tt.err
+					}
+					if tt.query != "" && (from.IsZero() || to.IsZero() || !to.After(from)) {
+						t.Errorf("expected the date range to be passed on, got %v to %v", from, to)
+					}
+					return &[]model.JournalEntry{{Id: "entry-1", CustomerId: id, Reference: "fee:fee-1"}}, nil
+				},
+			}
+			h := handler.NewLedgerHandler(mockSvc, logger.NewMockLogger())
+
+			req := httptest.NewRequest(http.MethodGet, "/customers/cust-1/ledger"+tt.query, nil)
+			req.SetPathValue("id", "cust-1")
+			w := httptest.NewRecorder()
+
+			h.GetEntriesByCustomerId(w, req)
+
+			if w.Code != tt.expected {
+				t.Fatalf("expected status %d, got %d", tt.expected, w.Code)
+			}
+		})
+	}
+}
+
+func TestGetLedgerBalances(t *testing.T) {
+	mockSvc := &mockLedgerService{
+		getBalances: func(accountId string) (*[]model.LedgerBalance, error) {
+			cash := model.LedgerAccount{Kind: model.LedgerCash, AccountId: accountId}
+			return &[]model.LedgerBalance{{Account: cash, Code: cash.String(), Balance: money.Pounds(155)}}, nil
+		},
+	}
+	h := handler.NewLedgerHandler(mockSvc, logger.NewMockLogger())
+
+	req := httptest.NewRequest(http.MethodGet, "/accounts/acc-1/ledger/balances", nil)
+	req.SetPathValue("id", "acc-1")
+	w := httptest.NewRecorder()
+
+	h.GetBalances(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200 OK, got %d", w.Code)
+	}
+	var balances []model.LedgerBalance
+	if err := json.NewDecoder(w.Body).Decode(&balances); err != nil {
+		t.Fatalf("decode error: %v", err)
+	}
+	if len(balances) != 1 || balances[0].Code != "cash:acc-1" || !balances[0].Balance.Equal(money.Pounds(155)) {
+		t.Errorf("unexpected balances: %+v", balances)
+	}
+}
+
+func TestReverseEntry(t *testing.T) {
+	tests := []struct {
+		name     string
+		err      error
+		expected int
+	}{
+		{"reversed", nil, http.StatusCreated},
+		{"unknown entry", internal.EntryNotFoundError("entry-1"), http.StatusNotFound},
+		{"already reversed", internal.DuplicateEntryError("reversal:fee:fee-1"), http.StatusConflict},
+		{"service error", errors.New("db down"), http.StatusInternalServerError},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockSvc := &mockLedgerService{
+				reverse: func(id string, reason string) (*model.JournalEntry, error) {
+					if tt.err != nil {
+						return nil, tt.err
+					}
+					return &model.JournalEntry{Id: "entry-2", ReversalOf: &id, Description: reason}, nil
+				},
+			}
+			h := handler.NewLedgerHandler(mockSvc, logger.NewMockLogger())
+
+			req := httptest.NewRequest(http.MethodPost, "/admin/ledger/entries/entry-1/reverse", strings.NewReader(`{"reason":"charged in error"}`))
+			req.SetPathValue("id", "entry-1")
+			w := httptest.NewRecorder()
+
+			h.ReverseEntry(w, req)
+
+			if w.Code != tt.expected {
+				t.Fatalf("expected status %d, got %d", tt.expected, w.Code)
+			}
+		})
+	}
+}
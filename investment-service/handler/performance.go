@@ -0,0 +1,107 @@
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/oliknight1/retail-isa-investment/investment-service/internal"
+	"github.com/oliknight1/retail-isa-investment/investment-service/logger"
+	"github.com/oliknight1/retail-isa-investment/investment-service/model"
+	"github.com/oliknight1/retail-isa-investment/investment-service/service"
+	"github.com/oliknight1/retail-isa-investment/investment-service/taxyear"
+	"go.uber.org/zap"
+)
+
+type PerformanceHandler struct {
+	Service service.PerformanceService
+	Logger  logger.Logger
+}
+
+func NewPerformanceHandler(service service.PerformanceService, logger logger.Logger) *PerformanceHandler {
+	return &PerformanceHandler{service, logger}
+}
+
+// GetCustomerPerformance returns the time-weighted and money-weighted returns of everything a
+// customer holds, broken down by account and fund
+func (h *PerformanceHandler) GetCustomerPerformance(w http.ResponseWriter, r *http.Request) {
+	internal.InvestmentRequests.WithLabelValues("/customers/{id}/performance", "GET").Inc()
+	h.respond(w, r, h.Service.GetCustomerPerformance)
+}
+
+// GetAccountPerformance returns the returns of the funds held in one account
+func (h *PerformanceHandler) GetAccountPerformance(w http.ResponseWriter, r *http.Request) {
+	internal.InvestmentRequests.WithLabelValues("/accounts/{id}/performance", "GET").Inc()
+	h.respond(w, r, h.Service.GetAccountPerformance)
+}
+
+type performanceFunc func(id string, period model.Period, from, to time.Time) (*model.Performance, error)
+
+func (h *PerformanceHandler) respond(w http.ResponseWriter, r *http.Request, get performanceFunc) {
+	period, from, to, err := parsePeriod(r)
+	if err != nil {
+		h.Logger.Error("invalid performance period", zap.Error(err))
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	performance, err := get(r.PathValue("id"), period, from, to)
+	if errors.Is(err, internal.ErrMissingCustomerId) || errors.Is(err, internal.ErrMissingAccountId) ||
+		errors.Is(err, internal.ErrInvalidPeriod) {
+		h.Logger.Error("invalid performance request", zap.Error(err))
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if errors.Is(err, internal.ErrAccountNotFound) {
+		h.Logger.Error("performance of unknown account", zap.Error(err))
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		h.Logger.Error("failed to measure performance", zap.Error(err))
+		http.Error(w, "failed to measure performance", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(performance)
+}
+
+// parsePeriod reads the period query parameter, defaulting to since inception. A custom period
+// takes from and to as RFC 3339 times or London dates, with a date used as the end of the range
+// covering the whole of that day
+func parsePeriod(r *http.Request) (model.Period, time.Time, time.Time, error) {
+	query := r.URL.Query()
+	period := model.Period(query.Get("period"))
+	if period == "" {
+		period = model.PeriodInception
+	}
+	from, err := parseTime(query.Get("from"), false)
+	if err != nil {
+		return period, time.Time{}, time.Time{}, err
+	}
+	to, err := parseTime(query.Get("to"), true)
+	if err != nil {
+		return period, time.Time{}, time.Time{}, err
+	}
+	return period, from, to, nil
+}
+
+func parseTime(value string, endOfDay bool) (time.Time, error) {
+	if value == "" {
+		return time.Time{}, nil
+	}
+	if t, err := time.Parse(time.RFC3339, value); err == nil {
+		return t, nil
+	}
+	t, err := time.ParseInLocation(time.DateOnly, value, taxyear.InLondon(time.Now()).Location())
+	if err != nil {
+		return time.Time{}, fmt.Errorf("%w: %q is not a date or RFC 3339 time", internal.ErrInvalidPeriod, value)
+	}
+	if endOfDay {
+		t = t.AddDate(0, 0, 1).Add(-time.Nanosecond)
+	}
+	return t, nil
+}
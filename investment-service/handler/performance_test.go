@@ -0,0 +1,106 @@
+package handler_test
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/oliknight1/retail-isa-investment/investment-service/handler"
+	"github.com/oliknight1/retail-isa-investment/investment-service/internal"
+	"github.com/oliknight1/retail-isa-investment/investment-service/logger"
+	"github.com/oliknight1/retail-isa-investment/investment-service/model"
+)
+
+type mockPerformanceService struct {
+	getCustomerPerformance func(customerId string, period model.Period, from, to time.Time) (*model.Performance, error)
+	getAccountPerformance  func(accountId string, period model.Period, from, to time.Time) (*model.Performance, error)
+}
+
+func (m *mockPerformanceService) GetCustomerPerformance(customerId string, period model.Period, from, to time.Time) (*model.Performance, error) {
+	return m.getCustomerPerformance(customerId, period, from, to)
+}
+
+func (m *mockPerformanceService) GetAccountPerformance(accountId string, period model.Period, from, to time.Time) (*model.Performance, error) {
+	return m.getAccountPerformance(accountId, period, from, to)
+}
+
+func TestGetCustomerPerformance(t *testing.T) {
+	tests := []struct {
+		name     string
+		query    string
+		err      error
+		expected int
+	}{
+		{"defaults to since inception", "", nil, http.StatusOK},
+		{"custom dates", "?period=custom&from=2025-01-01&to=2025-06-30", nil, http.StatusOK},
+		{"invalid date", "?period=custom&from=01/01/2025&to=2025-06-30", nil, http.StatusBadRequest},
+		{"invalid period", "?period=5y", internal.ErrInvalidPeriod, http.StatusBadRequest},
+		{"service error", "?period=1y", errors.New("fund-service unavailable"), http.StatusInternalServerError},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var requested model.Period
+			var requestedTo time.Time
+			mockSvc := &mockPerformanceService{
+				getCustomerPerformance: func(customerId string, period model.Period, from, to time.Time) (*model.Performance, error) {
+					requested, requestedTo = period, to
+					if tt.err != nil {
+						return nil, tt.err
+					}
+					twr := 5.25
+					return &model.Performance{CustomerId: customerId, Period: period, From: from, To: to, Return: model.Return{TimeWeighted: &twr}}, nil
+				},
+			}
+			h := handler.NewPerformanceHandler(mockSvc, logger.NewMockLogger())
+
+			req := httptest.NewRequest(http.MethodGet, "/customers/cust-123/performance"+tt.query, nil)
+			req.SetPathValue("id", "cust-123")
+			w := httptest.NewRecorder()
+
+			h.GetCustomerPerformance(w, req)
+
+			if w.Code != tt.expected {
+				t.Fatalf("expected %d, got %d", tt.expected, w.Code)
+			}
+			if tt.expected != http.StatusOK {
+				return
+			}
+			var performance model.Performance
+			if err := json.NewDecoder(w.Body).Decode(&performance); err != nil {
+				t.Fatalf("decode error: %v", err)
+			}
+			if performance.CustomerId != "cust-123" || performance.TimeWeighted == nil || *performance.TimeWeighted != 5.25 {
+				t.Errorf("unexpected performance: %+v", performance)
+			}
+			if tt.query == "" && requested != model.PeriodInception {
+				t.Errorf("expected since inception by default, got %q", requested)
+			}
+			// a date as the end of a range covers the whole day
+			if tt.query != "" && requestedTo.Format(time.DateTime) != "2025-06-30 23:59:59" {
+				t.Errorf("expected the range to end at the end of 30 June, got %v", requestedTo)
+			}
+		})
+	}
+}
+
+func TestGetAccountPerformanceNotFound(t *testing.T) {
+	mockSvc := &mockPerformanceService{
+		getAccountPerformance: func(accountId string, period model.Period, from, to time.Time) (*model.Performance, error) {
+			return nil, internal.AccountNotFoundError(accountId)
+		},
+	}
+	h := handler.NewPerformanceHandler(mockSvc, logger.NewMockLogger())
+
+	req := httptest.NewRequest(http.MethodGet, "/accounts/acc-missing/performance?period=3m", nil)
+	req.SetPathValue("id", "acc-missing")
+	w := httptest.NewRecorder()
+
+	h.GetAccountPerformance(w, req)
+
+	if w.Code != http.StatusNotFound {
+		t.Fatalf("expected 404 Not Found, got %d", w.Code)
+	}
+}
@@ -0,0 +1,156 @@
+package handler
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/oliknight1/retail-isa-investment/investment-service/internal"
+	"github.com/oliknight1/retail-isa-investment/investment-service/logger"
+	"github.com/oliknight1/retail-isa-investment/investment-service/model"
+	"github.com/oliknight1/retail-isa-investment/investment-service/money"
+	"github.com/oliknight1/retail-isa-investment/investment-service/service"
+	"go.uber.org/zap"
+)
+
+type PlanHandler struct {
+	Service service.PlanService
+	Logger  logger.Logger
+}
+
+func NewPlanHandler(service service.PlanService, logger logger.Logger) *PlanHandler {
+	return &PlanHandler{service, logger}
+}
+
+// CreatePlan sets up a monthly investment into an account split between funds
+func (h *PlanHandler) CreatePlan(w http.ResponseWriter, r *http.Request) {
+	internal.InvestmentRequests.WithLabelValues("/plans", "POST").Inc()
+	var req struct {
+		AccountId   string `json:"accountId"`
+		Allocations []struct {
+			FundId  string `json:"fundId"`
+			Percent int    `json:"percent"`
+		} `json:"allocations"`
+		Amount     money.Money `json:"amount"`
+		DayOfMonth int         `json:"dayOfMonth"`
+		StartDate  model.Date  `json:"startDate"`
+		EndDate    *model.Date `json:"endDate"`
+	}
+
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		h.Logger.Error("failed to decode plan request", zap.Error(err))
+		http.Error(w, fmt.Sprintf("error reading JSON: \n%s", err), http.StatusBadRequest)
+		return
+	}
+
+	plan := model.Plan{
+		AccountId:  req.AccountId,
+		Amount:     req.Amount,
+		DayOfMonth: req.DayOfMonth,
+		StartDate:  req.StartDate,
+		EndDate:    req.EndDate,
+	}
+	for _, allocation := range req.Allocations {
+		plan.Allocations = append(plan.Allocations, model.FundAllocation{FundId: allocation.FundId, Percent: allocation.Percent})
+	}
+	created, err := h.Service.CreatePlan(plan)
+	if errors.Is(err, internal.ErrMissingAccountId) || errors.Is(err, internal.ErrMissingFundId) ||
+		errors.Is(err, internal.ErrZeroTransactionAmount) || errors.Is(err, internal.ErrInvalidPlan) {
+		h.Logger.Error("invalid plan request", zap.Error(err))
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if errors.Is(err, internal.ErrAccountNotFound) {
+		h.Logger.Error("plan for unknown account", zap.Error(err))
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+	if errors.Is(err, internal.ErrAccountClosed) || errors.Is(err, internal.ErrFundNotEligible) {
+		h.Logger.Error("plan rejected by account rules", zap.Error(err))
+		http.Error(w, err.Error(), http.StatusUnprocessableEntity)
+		return
+	}
+	if err != nil {
+		h.Logger.Error("internal service error", zap.Error(err))
+		http.Error(w, "failed to create plan", http.StatusInternalServerError)
+		return
+	}
+
+	var buf bytes.Buffer
+	if err := json.NewEncoder(&buf).Encode(created); err != nil {
+		h.Logger.Error("failed to write plan to JSON", zap.Error(err))
+		http.Error(w, "failed to encode response", http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	h.Logger.Info("plan successfully created", zap.String("plan_id", created.Id))
+	w.Write(buf.Bytes())
+}
+
+func (h *PlanHandler) GetPlanById(w http.ResponseWriter, r *http.Request) {
+	internal.InvestmentRequests.WithLabelValues("/plans/{id}", "GET").Inc()
+	plan, err := h.Service.GetPlanById(r.PathValue("id"))
+	h.writePlan(w, plan, err, "failed to get plan")
+}
+
+func (h *PlanHandler) GetPlansByCustomerId(w http.ResponseWriter, r *http.Request) {
+	internal.InvestmentRequests.WithLabelValues("/customers/{id}/plans", "GET").Inc()
+	customerId := r.PathValue("id")
+	if customerId == "" {
+		h.Logger.Error("missing customer_id when requesting plans", zap.Error(internal.ErrMissingCustomerId))
+		http.Error(w, internal.ErrMissingCustomerId.Error(), http.StatusBadRequest)
+		return
+	}
+
+	plans, err := h.Service.GetPlansByCustomerId(customerId)
+	if err != nil {
+		h.Logger.Error("failed to get plans", zap.Error(err))
+		http.Error(w, "failed to get plans", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(plans)
+}
+
+func (h *PlanHandler) PausePlan(w http.ResponseWriter, r *http.Request) {
+	internal.InvestmentRequests.WithLabelValues("/plans/{id}/pause", "POST").Inc()
+	plan, err := h.Service.PausePlan(r.PathValue("id"))
+	h.writePlan(w, plan, err, "failed to update plan")
+}
+
+func (h *PlanHandler) ResumePlan(w http.ResponseWriter, r *http.Request) {
+	internal.InvestmentRequests.WithLabelValues("/plans/{id}/resume", "POST").Inc()
+	plan, err := h.Service.ResumePlan(r.PathValue("id"))
+	h.writePlan(w, plan, err, "failed to update plan")
+}
+
+func (h *PlanHandler) CancelPlan(w http.ResponseWriter, r *http.Request) {
+	internal.InvestmentRequests.WithLabelValues("/plans/{id}/cancel", "POST").Inc()
+	plan, err := h.Service.CancelPlan(r.PathValue("id"))
+	h.writePlan(w, plan, err, "failed to update plan")
+}
+
+func (h *PlanHandler) writePlan(w http.ResponseWriter, plan *model.Plan, err error, failure string) {
+	if errors.Is(err, internal.ErrPlanNotFound) {
+		h.Logger.Error("plan not found", zap.Error(err))
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+	if errors.Is(err, internal.ErrInvalidTransition) {
+		h.Logger.Error("invalid plan status change", zap.Error(err))
+		http.Error(w, err.Error(), http.StatusConflict)
+		return
+	}
+	if err != nil {
+		h.Logger.Error(failure, zap.Error(err))
+		http.Error(w, failure, http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(plan)
+}
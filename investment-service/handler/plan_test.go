@@ -0,0 +1,152 @@
+package handler_test
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/oliknight1/retail-isa-investment/investment-service/handler"
+	"github.com/oliknight1/retail-isa-investment/investment-service/internal"
+	"github.com/oliknight1/retail-isa-investment/investment-service/logger"
+	"github.com/oliknight1/retail-isa-investment/investment-service/model"
+	"github.com/oliknight1/retail-isa-investment/investment-service/money"
+)
+
+type mockPlanService struct {
+	createPlan           func(plan model.Plan) (*model.Plan, error)
+	getPlanById          func(id string) (*model.Plan, error)
+	getPlansByCustomerId func(id string) (*[]model.Plan, error)
+	changeStatus         func(id string, status model.PlanStatus) (*model.Plan, error)
+}
+
+func (m *mockPlanService) CreatePlan(plan model.Plan) (*model.Plan, error) {
+	return m.createPlan(plan)
+}
+func (m *mockPlanService) GetPlanById(id string) (*model.Plan, error) {
+	return m.getPlanById(id)
+}
+func (m *mockPlanService) GetPlansByCustomerId(id string) (*[]model.Plan, error) {
+	return m.getPlansByCustomerId(id)
+}
+func (m *mockPlanService) PausePlan(id string) (*model.Plan, error) {
+	return m.changeStatus(id, model.PlanPaused)
+}
+func (m *mockPlanService) ResumePlan(id string) (*model.Plan, error) {
+	return m.changeStatus(id, model.PlanActive)
+}
+func (m *mockPlanService) CancelPlan(id string) (*model.Plan, error) {
+	return m.changeStatus(id, model.PlanCancelled)
+}
+func (m *mockPlanService) Run(now time.Time) (*model.PlanSchedulerRun, error) {
+	return &model.PlanSchedulerRun{RunAt: now}, nil
+}
+
+func TestCreatePlan(t *testing.T) {
+	body := `{"accountId": "acc-1", "allocations": [{"fundId": "fund-1", "percent": 100}], "amount": "250.00", "dayOfMonth": 15, "startDate": "2025-07-01"}`
+	tests := []struct {
+		name         string
+		body         string
+		err          error
+		expectedCode int
+	}{
+		{"success", body, nil, http.StatusCreated},
+		{"invalid JSON", `{"accountId":`, nil, http.StatusBadRequest},
+		{"invalid plan", body, internal.ErrInvalidPlan, http.StatusBadRequest},
+		{"unknown account", body, internal.AccountNotFoundError("acc-1"), http.StatusNotFound},
+		{"ineligible fund", body, internal.ErrFundNotEligible, http.StatusUnprocessableEntity},
+		{"unexpected error", body, errors.New("db failure"), http.StatusInternalServerError},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			var requested model.Plan
+			mockSvc := &mockPlanService{
+				createPlan: func(plan model.Plan) (*model.Plan, error) {
+					requested = plan
+					if tc.err != nil {
+						return nil, tc.err
+					}
+					plan.Id = "plan-1"
+					plan.Status = model.PlanActive
+					return &plan, nil
+				},
+			}
+			h := handler.NewPlanHandler(mockSvc, logger.NewMockLogger())
+
+			req := httptest.NewRequest(http.MethodPost, "/plans", strings.NewReader(tc.body))
+			w := httptest.NewRecorder()
+
+			h.CreatePlan(w, req)
+
+			if w.Code != tc.expectedCode {
+				t.Fatalf("expected %d, got %d", tc.expectedCode, w.Code)
+			}
+			if tc.expectedCode != http.StatusCreated {
+				return
+			}
+			var plan model.Plan
+			if err := json.NewDecoder(w.Body).Decode(&plan); err != nil {
+				t.Fatalf("decode error: %v", err)
+			}
+			if plan.Id != "plan-1" || len(requested.Allocations) != 1 || requested.Allocations[0].Percent != 100 ||
+				!requested.Amount.Equal(money.Pounds(250)) || requested.StartDate.String() != "2025-07-01" {
+				t.Errorf("unexpected plan: %+v", requested)
+			}
+		})
+	}
+}
+
+func TestChangePlanStatus(t *testing.T) {
+	tests := []struct {
+		name         string
+		handle       func(h *handler.PlanHandler) http.HandlerFunc
+		err          error
+		expectedCode int
+		expected     model.PlanStatus
+	}{
+		{"pause", func(h *handler.PlanHandler) http.HandlerFunc { return h.PausePlan }, nil, http.StatusOK, model.PlanPaused},
+		{"resume", func(h *handler.PlanHandler) http.HandlerFunc { return h.ResumePlan }, nil, http.StatusOK, model.PlanActive},
+		{"cancel", func(h *handler.PlanHandler) http.HandlerFunc { return h.CancelPlan }, nil, http.StatusOK, model.PlanCancelled},
+		{"not found", func(h *handler.PlanHandler) http.HandlerFunc { return h.PausePlan }, internal.PlanNotFoundError("plan-1"), http.StatusNotFound, ""},
+		{"already cancelled", func(h *handler.PlanHandler) http.HandlerFunc { return h.ResumePlan }, internal.ErrInvalidTransition, http.StatusConflict, ""},
+		{"unexpected error", func(h *handler.PlanHandler) http.HandlerFunc { return h.CancelPlan }, errors.New("db failure"), http.StatusInternalServerError, ""},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			mockSvc := &mockPlanService{
+				changeStatus: func(id string, status model.PlanStatus) (*model.Plan, error) {
+					if tc.err != nil {
+						return nil, tc.err
+					}
+					return &model.Plan{Id: id, Status: status}, nil
+				},
+			}
+			h := handler.NewPlanHandler(mockSvc, logger.NewMockLogger())
+
+			req := httptest.NewRequest(http.MethodPost, "/plans/plan-1", nil)
+			req.SetPathValue("id", "plan-1")
+			w := httptest.NewRecorder()
+
+			tc.handle(h)(w, req)
+
+			if w.Code != tc.expectedCode {
+				t.Fatalf("expected %d, got %d", tc.expectedCode, w.Code)
+			}
+			if tc.expectedCode != http.StatusOK {
+				return
+			}
+			var plan model.Plan
+			if err := json.NewDecoder(w.Body).Decode(&plan); err != nil {
+				t.Fatalf("decode error: %v", err)
+			}
+			if plan.Status != tc.expected {
+				t.Errorf("expected plan to be %s, got %s", tc.expected, plan.Status)
+			}
+		})
+	}
+}
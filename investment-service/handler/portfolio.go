@@ -0,0 +1,41 @@
+package handler
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/oliknight1/retail-isa-investment/investment-service/internal"
+	"github.com/oliknight1/retail-isa-investment/investment-service/logger"
+	"github.com/oliknight1/retail-isa-investment/investment-service/service"
+	"go.uber.org/zap"
+)
+
+type PortfolioHandler struct {
+	Service service.PortfolioService
+	Logger  logger.Logger
+}
+
+func NewPortfolioHandler(service service.PortfolioService, logger logger.Logger) *PortfolioHandler {
+	return &PortfolioHandler{service, logger}
+}
+
+// GetPortfolio returns a customer's holdings in every ISA valued at the latest fund prices
+func (h *PortfolioHandler) GetPortfolio(w http.ResponseWriter, r *http.Request) {
+	internal.InvestmentRequests.WithLabelValues("/customers/{id}/portfolio", "GET").Inc()
+	customerId := r.PathValue("id")
+	if customerId == "" {
+		h.Logger.Error("missing customer_id when requesting portfolio", zap.Error(internal.ErrMissingCustomerId))
+		http.Error(w, internal.ErrMissingCustomerId.Error(), http.StatusBadRequest)
+		return
+	}
+
+	portfolio, err := h.Service.GetPortfolio(customerId)
+	if err != nil {
+		h.Logger.Error("failed to get portfolio", zap.Error(err))
+		http.Error(w, "failed to get portfolio", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(portfolio)
+}
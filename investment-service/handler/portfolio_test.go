@@ -0,0 +1,85 @@
+package handler_test
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/oliknight1/retail-isa-investment/investment-service/handler"
+	"github.com/oliknight1/retail-isa-investment/investment-service/logger"
+	"github.com/oliknight1/retail-isa-investment/investment-service/model"
+	"github.com/oliknight1/retail-isa-investment/investment-service/money"
+)
+
+type mockPortfolioService struct {
+	getPortfolio func(customerId string) (*model.Portfolio, error)
+}
+
+func (m *mockPortfolioService) GetPortfolio(customerId string) (*model.Portfolio, error) {
+	return m.getPortfolio(customerId)
+}
+
+func TestGetPortfolio(t *testing.T) {
+	tests := []struct {
+		name       string
+		customerId string
+		err        error
+		expected   int
+	}{
+		{"success", "cust-123", nil, http.StatusOK},
+		{"missing id", "", nil, http.StatusBadRequest},
+		{"service error", "cust-123", errors.New("fund-service unavailable"), http.StatusInternalServerError},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockSvc := &mockPortfolioService{
+				getPortfolio: func(customerId string) (*model.Portfolio, error) {
+					if tt.err != nil {
+						return nil, tt.err
+					}
+					return &model.Portfolio{
+						CustomerId: customerId,
+						Accounts: []model.AccountValuation{{
+							AccountId: "acc-1",
+							Holdings: []model.Holding{{
+								FundId:     "fund-1",
+								Units:      1000000,
+								BookCost:   money.Pounds(100),
+								Price:      1100000,
+								Value:      money.Pounds(110),
+								Gain:       money.Pounds(10),
+								Allocation: 100,
+							}},
+							Value: money.Pounds(110),
+						}},
+						Value: money.Pounds(110),
+					}, nil
+				},
+			}
+			h := handler.NewPortfolioHandler(mockSvc, logger.NewMockLogger())
+
+			req := httptest.NewRequest(http.MethodGet, "/customers/"+tt.customerId+"/portfolio", nil)
+			req.SetPathValue("id", tt.customerId)
+			w := httptest.NewRecorder()
+
+			h.GetPortfolio(w, req)
+
+			if w.Code != tt.expected {
+				t.Fatalf("expected %d, got %d", tt.expected, w.Code)
+			}
+			if tt.expected != http.StatusOK {
+				return
+			}
+			var portfolio model.Portfolio
+			if err := json.NewDecoder(w.Body).Decode(&portfolio); err != nil {
+				t.Fatalf("decode error: %v", err)
+			}
+			holding := portfolio.Accounts[0].Holdings[0]
+			if portfolio.CustomerId != "cust-123" || holding.Units != 1000000 || holding.Price != 1100000 {
+				t.Errorf("unexpected portfolio: %+v", portfolio)
+			}
+		})
+	}
+}
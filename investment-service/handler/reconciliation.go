@@ -0,0 +1,56 @@
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/oliknight1/retail-isa-investment/investment-service/internal"
+	"github.com/oliknight1/retail-isa-investment/investment-service/logger"
+	"github.com/oliknight1/retail-isa-investment/investment-service/service"
+	"go.uber.org/zap"
+)
+
+type ReconciliationHandler struct {
+	Service service.ReconciliationService
+	Logger  logger.Logger
+}
+
+func NewReconciliationHandler(service service.ReconciliationService, logger logger.Logger) *ReconciliationHandler {
+	return &ReconciliationHandler{service, logger}
+}
+
+// RunReconciliation reconciles everything without waiting for the daily run, and reports every
+// break found
+func (h *ReconciliationHandler) RunReconciliation(w http.ResponseWriter, r *http.Request) {
+	internal.InvestmentRequests.WithLabelValues("/admin/reconciliation/run", "POST").Inc()
+	run, err := h.Service.Run(time.Now())
+	if err != nil {
+		h.Logger.Error("failed to run reconciliation", zap.Error(err))
+		http.Error(w, "failed to run reconciliation", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(run)
+}
+
+// GetLatestReconciliation returns the break report from the most recent reconciliation run
+func (h *ReconciliationHandler) GetLatestReconciliation(w http.ResponseWriter, r *http.Request) {
+	internal.InvestmentRequests.WithLabelValues("/admin/reconciliation", "GET").Inc()
+	run, err := h.Service.GetLatestRun()
+	if errors.Is(err, internal.ErrNoReconciliationRun) {
+		h.Logger.Error("no reconciliation run to report", zap.Error(err))
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		h.Logger.Error("failed to get reconciliation run", zap.Error(err))
+		http.Error(w, "failed to get reconciliation run", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(run)
+}
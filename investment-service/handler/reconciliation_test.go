@@ -0,0 +1,121 @@
+package handler_test
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/oliknight1/retail-isa-investment/investment-service/handler"
+	"github.com/oliknight1/retail-isa-investment/investment-service/internal"
+	"github.com/oliknight1/retail-isa-investment/investment-service/logger"
+	"github.com/oliknight1/retail-isa-investment/investment-service/model"
+	"github.com/oliknight1/retail-isa-investment/investment-service/money"
+)
+
+type mockReconciliationService struct {
+	run          func(now time.Time) (*model.ReconciliationRun, error)
+	getLatestRun func() (*model.ReconciliationRun, error)
+}
+
+func (m *mockReconciliationService) Run(now time.Time) (*model.ReconciliationRun, error) {
+	return m.run(now)
+}
+func (m *mockReconciliationService) GetLatestRun() (*model.ReconciliationRun, error) {
+	return m.getLatestRun()
+}
+
+func reconciliationWithBreak() *model.ReconciliationRun {
+	return &model.ReconciliationRun{
+		Id:          "rec-1",
+		RunAt:       time.Date(2025, time.June, 4, 2, 0, 0, 0, time.UTC),
+		Investments: 3,
+		Breaks: []model.ReconciliationBreak{{
+			Type:    model.BreakCashMismatch,
+			Amount:  money.Pounds(100),
+			Records: []string{"acc-1"},
+			Detail:  "account holds 600.00 cash but the ledger has 500.00",
+		}},
+	}
+}
+
+func TestRunReconciliation(t *testing.T) {
+	tests := []struct {
+		name         string
+		err          error
+		expectedCode int
+	}{
+		{"reports the breaks", nil, http.StatusOK},
+		{"fund-service unavailable", errors.New("fund-service responded with status 503"), http.StatusInternalServerError},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockSvc := &mockReconciliationService{
+				run: func(now time.Time) (*model.ReconciliationRun, error) {
+					if tt.err != nil {
+						return nil, tt.err
+					}
+					return reconciliationWithBreak(), nil
+				},
+			}
+			h := handler.NewReconciliationHandler(mockSvc, logger.NewMockLogger())
+			w := httptest.NewRecorder()
+
+			h.RunReconciliation(w, httptest.NewRequest(http.MethodPost, "/admin/reconciliation/run", nil))
+
+			if w.Code != tt.expectedCode {
+				t.Fatalf("expected status %d, got %d", tt.expectedCode, w.Code)
+			}
+			if tt.err != nil {
+				return
+			}
+			var run model.ReconciliationRun
+			json.NewDecoder(w.Body).Decode(&run)
+			if len(run.Breaks) != 1 || run.Breaks[0].Type != model.BreakCashMismatch || !run.Breaks[0].Amount.Equal(money.Pounds(100)) || run.Breaks[0].Records[0] != "acc-1" {
+				t.Errorf("unexpected break report: %+v", run)
+			}
+		})
+	}
+}
+
+func TestGetLatestReconciliation(t *testing.T) {
+	tests := []struct {
+		name         string
+		err          error
+		expectedCode int
+	}{
+		{"latest run", nil, http.StatusOK},
+		{"never run", internal.ErrNoReconciliationRun, http.StatusNotFound},
+		{"repository failure", errors.New("db down"), http.StatusInternalServerError},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockSvc := &mockReconciliationService{
+				getLatestRun: func() (*model.ReconciliationRun, error) {
+					if tt.err != nil {
+						return nil, tt.err
+					}
+					return reconciliationWithBreak(), nil
+				},
+			}
+			h := handler.NewReconciliationHandler(mockSvc, logger.NewMockLogger())
+			w := httptest.NewRecorder()
+
+			h.GetLatestReconciliation(w, httptest.NewRequest(http.MethodGet, "/admin/reconciliation", nil))
+
+			if w.Code != tt.expectedCode {
+				t.Fatalf("expected status %d, got %d", tt.expectedCode, w.Code)
+			}
+			if tt.err != nil {
+				return
+			}
+			var run model.ReconciliationRun
+			json.NewDecoder(w.Body).Decode(&run)
+			if run.Id != "rec-1" || len(run.Breaks) != 1 {
+				t.Errorf("unexpected run: %+v", run)
+			}
+		})
+	}
+}
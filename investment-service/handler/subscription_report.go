@@ -0,0 +1,75 @@
+package handler
+
+import (
+	"encoding/json"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/oliknight1/retail-isa-investment/investment-service/internal"
+	"github.com/oliknight1/retail-isa-investment/investment-service/logger"
+	"github.com/oliknight1/retail-isa-investment/investment-service/model"
+	"github.com/oliknight1/retail-isa-investment/investment-service/service"
+	"go.uber.org/zap"
+)
+
+type SubscriptionReportHandler struct {
+	Service service.SubscriptionReportService
+	Logger  logger.Logger
+}
+
+func NewSubscriptionReportHandler(service service.SubscriptionReportService, logger logger.Logger) *SubscriptionReportHandler {
+	return &SubscriptionReportHandler{service, logger}
+}
+
+// GetReport returns the subscription report for a tax year as XML. Subscribers
+// with missing data are left out, and how many there were is given in the X-Validation-Errors
+// header
+func (h *SubscriptionReportHandler) GetReport(w http.ResponseWriter, r *http.Request) {
+	internal.InvestmentRequests.WithLabelValues("/admin/subscription-reports/{taxYear}", "GET").Inc()
+	report, ok := h.generate(w, r)
+	if !ok {
+		return
+	}
+
+	out, err := xml.MarshalIndent(report, "", "  ")
+	if err != nil {
+		h.Logger.Error("failed to write subscription report to XML", zap.Error(err))
+		http.Error(w, "failed to encode response", http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/xml")
+	w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=isa-subscriptions-%s.xml", report.TaxYear))
+	w.Header().Set("X-Validation-Errors", strconv.Itoa(len(report.Errors)))
+	w.Write([]byte(xml.Header))
+	w.Write(out)
+}
+
+// GetReportValidation lists the subscriber records that cannot go in a tax year's report
+func (h *SubscriptionReportHandler) GetReportValidation(w http.ResponseWriter, r *http.Request) {
+	internal.InvestmentRequests.WithLabelValues("/admin/subscription-reports/{taxYear}/validation", "GET").Inc()
+	report, ok := h.generate(w, r)
+	if !ok {
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(report.Errors)
+}
+
+func (h *SubscriptionReportHandler) generate(w http.ResponseWriter, r *http.Request) (*model.SubscriptionReport, bool) {
+	report, err := h.Service.GenerateReport(r.PathValue("taxYear"))
+	if errors.Is(err, internal.ErrInvalidTaxYear) || errors.Is(err, internal.ErrTaxYearNotEnded) {
+		h.Logger.Error("invalid tax year", zap.Error(err))
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return nil, false
+	}
+	if err != nil {
+		h.Logger.Error("failed to generate subscription report", zap.Error(err))
+		http.Error(w, "failed to generate subscription report", http.StatusInternalServerError)
+		return nil, false
+	}
+	return report, true
+}
@@ -0,0 +1,110 @@
+package handler_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/oliknight1/retail-isa-investment/investment-service/handler"
+	"github.com/oliknight1/retail-isa-investment/investment-service/internal"
+	"github.com/oliknight1/retail-isa-investment/investment-service/logger"
+	"github.com/oliknight1/retail-isa-investment/investment-service/model"
+	"github.com/oliknight1/retail-isa-investment/investment-service/money"
+)
+
+type mockSubscriptionReportService struct {
+	generateReport func(taxYear string) (*model.SubscriptionReport, error)
+}
+
+func (m *mockSubscriptionReportService) GenerateReport(taxYear string) (*model.SubscriptionReport, error) {
+	return m.generateReport(taxYear)
+}
+
+func sampleReport(taxYear string) (*model.SubscriptionReport, error) {
+	return &model.SubscriptionReport{
+		TaxYear: 2024,
+		Subscribers: []model.ReportSubscriber{{
+			CustomerId:              "cust-1",
+			Name:                    "Oli",
+			NationalInsuranceNumber: "AB123456C",
+			DateOfBirth:             "1990-05-21",
+			Subscriptions:           []model.ReportSubscription{{ProductType: model.StocksAndSharesISA, Amount: money.Pounds(5000)}},
+			TotalSubscribed:         money.Pounds(5000),
+		}},
+		Errors: []model.ReportError{{CustomerId: "cust-2", Field: "name", Message: "name is required"}},
+	}, nil
+}
+
+func TestGetReport(t *testing.T) {
+	logger := logger.NewMockLogger()
+	h := handler.NewSubscriptionReportHandler(&mockSubscriptionReportService{generateReport: sampleReport}, logger)
+
+	req := httptest.NewRequest(http.MethodGet, "/admin/subscription-reports/2024-25", nil)
+	req.SetPathValue("taxYear", "2024-25")
+	w := httptest.NewRecorder()
+
+	h.GetReport(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200 OK, got %d", w.Code)
+	}
+	if w.Header().Get("Content-Type") != "application/xml" || w.Header().Get("X-Validation-Errors") != "1" {
+		t.Errorf("unexpected headers: %v", w.Header())
+	}
+	body := w.Body.String()
+	for _, expected := range []string{
+		`<IsaSubscriptionReport TaxYear="2024-25"`,
+		`<NINO>AB123456C</NINO>`,
+		`<Product Type="stocks_and_shares">5000.00</Product>`,
+	} {
+		if !strings.Contains(body, expected) {
+			t.Errorf("expected report to contain %s, got:\n%s", expected, body)
+		}
+	}
+	if strings.Contains(body, "cust-2") {
+		t.Errorf("expected invalid records to be left out of the report, got:\n%s", body)
+	}
+}
+
+func TestGetReportValidation(t *testing.T) {
+	logger := logger.NewMockLogger()
+	h := handler.NewSubscriptionReportHandler(&mockSubscriptionReportService{generateReport: sampleReport}, logger)
+
+	req := httptest.NewRequest(http.MethodGet, "/admin/subscription-reports/2024-25/validation", nil)
+	req.SetPathValue("taxYear", "2024-25")
+	w := httptest.NewRecorder()
+
+	h.GetReportValidation(w, req)
+
+	var errs []model.ReportError
+	if err := json.NewDecoder(w.Body).Decode(&errs); err != nil {
+		t.Fatalf("decode error: %v", err)
+	}
+	if len(errs) != 1 || errs[0].CustomerId != "cust-2" || errs[0].Field != "name" {
+		t.Errorf("unexpected validation errors: %+v", errs)
+	}
+}
+
+func TestGetReportInvalidTaxYear(t *testing.T) {
+	for _, expectedErr := range []error{internal.ErrInvalidTaxYear, internal.ErrTaxYearNotEnded} {
+		mockSvc := &mockSubscriptionReportService{
+			generateReport: func(taxYear string) (*model.SubscriptionReport, error) {
+				return nil, expectedErr
+			},
+		}
+		logger := logger.NewMockLogger()
+		h := handler.NewSubscriptionReportHandler(mockSvc, logger)
+
+		req := httptest.NewRequest(http.MethodGet, "/admin/subscription-reports/2024", nil)
+		req.SetPathValue("taxYear", "2024")
+		w := httptest.NewRecorder()
+
+		h.GetReport(w, req)
+
+		if w.Code != http.StatusBadRequest {
+			t.Fatalf("expected 400 Bad Request for %v, got %d", expectedErr, w.Code)
+		}
+	}
+}
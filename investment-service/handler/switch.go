@@ -0,0 +1,121 @@
+package handler
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/oliknight1/retail-isa-investment/investment-service/internal"
+	"github.com/oliknight1/retail-isa-investment/investment-service/logger"
+	"github.com/oliknight1/retail-isa-investment/investment-service/model"
+	"github.com/oliknight1/retail-isa-investment/investment-service/money"
+	"github.com/oliknight1/retail-isa-investment/investment-service/service"
+	"go.uber.org/zap"
+)
+
+type SwitchHandler struct {
+	Service service.SwitchService
+	Logger  logger.Logger
+}
+
+func NewSwitchHandler(service service.SwitchService, logger logger.Logger) *SwitchHandler {
+	return &SwitchHandler{service, logger}
+}
+
+// RequestSwitch moves units worth an amount, a number of units or the whole holding of one
+// fund into another fund in the same account
+func (h *SwitchHandler) RequestSwitch(w http.ResponseWriter, r *http.Request) {
+	internal.InvestmentRequests.WithLabelValues("/accounts/{id}/switches", "POST").Inc()
+	accountId := r.PathValue("id")
+	var req struct {
+		FromFundId string      `json:"fromFundId"`
+		ToFundId   string      `json:"toFundId"`
+		Amount     money.Money `json:"amount"`
+		Units      model.Units `json:"units"`
+		All        bool        `json:"all"`
+	}
+
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		h.Logger.Error("failed to decode switch request", zap.Error(err))
+		http.Error(w, fmt.Sprintf("error reading JSON: \n%s", err), http.StatusBadRequest)
+		return
+	}
+
+	order := model.RedemptionOrder{Amount: req.Amount, Units: req.Units, All: req.All}
+	fundSwitch, err := h.Service.RequestSwitch(accountId, req.FromFundId, req.ToFundId, order)
+	if errors.Is(err, internal.ErrMissingAccountId) || errors.Is(err, internal.ErrMissingFundId) ||
+		errors.Is(err, internal.ErrInvalidSwitch) || errors.Is(err, internal.ErrInvalidRedemption) {
+		h.Logger.Error("invalid switch request", zap.Error(err))
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if errors.Is(err, internal.ErrAccountNotFound) {
+		h.Logger.Error("switch in unknown account", zap.Error(err))
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+	if errors.Is(err, internal.ErrAccountClosed) || errors.Is(err, internal.ErrFundNotEligible) ||
+		errors.Is(err, internal.ErrInsufficientHolding) {
+		h.Logger.Error("switch rejected by account rules", zap.Error(err))
+		http.Error(w, err.Error(), http.StatusUnprocessableEntity)
+		return
+	}
+	if err != nil {
+		h.Logger.Error("internal service error", zap.Error(err))
+		http.Error(w, "failed to create switch", http.StatusInternalServerError)
+		return
+	}
+
+	var buf bytes.Buffer
+	if err := json.NewEncoder(&buf).Encode(fundSwitch); err != nil {
+		h.Logger.Error("failed to write switch to JSON", zap.Error(err))
+		http.Error(w, "failed to encode response", http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	h.Logger.Info("switch successfully requested", zap.String("switch_id", fundSwitch.Id))
+	w.Write(buf.Bytes())
+}
+
+func (h *SwitchHandler) GetSwitchById(w http.ResponseWriter, r *http.Request) {
+	internal.InvestmentRequests.WithLabelValues("/switches/{id}", "GET").Inc()
+	id := r.PathValue("id")
+
+	fundSwitch, err := h.Service.GetSwitchById(id)
+	if errors.Is(err, internal.ErrSwitchNotFound) {
+		h.Logger.Error("switch not found", zap.Error(err))
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		h.Logger.Error("failed to get switch", zap.Error(err))
+		http.Error(w, "failed to get switch", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(fundSwitch)
+}
+
+func (h *SwitchHandler) GetSwitchesByAccountId(w http.ResponseWriter, r *http.Request) {
+	internal.InvestmentRequests.WithLabelValues("/accounts/{id}/switches", "GET").Inc()
+	accountId := r.PathValue("id")
+	if accountId == "" {
+		h.Logger.Error("missing account_id when requesting switches", zap.Error(internal.ErrMissingAccountId))
+		http.Error(w, internal.ErrMissingAccountId.Error(), http.StatusBadRequest)
+		return
+	}
+
+	switches, err := h.Service.GetSwitchesByAccountId(accountId)
+	if err != nil {
+		h.Logger.Error("failed to get switches", zap.Error(err))
+		http.Error(w, "failed to get switches", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(switches)
+}
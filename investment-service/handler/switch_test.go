@@ -0,0 +1,98 @@
+package handler_test
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/oliknight1/retail-isa-investment/investment-service/handler"
+	"github.com/oliknight1/retail-isa-investment/investment-service/internal"
+	"github.com/oliknight1/retail-isa-investment/investment-service/logger"
+	"github.com/oliknight1/retail-isa-investment/investment-service/model"
+)
+
+type mockSwitchService struct {
+	requestSwitch          func(accountId string, fromFundId string, toFundId string, order model.RedemptionOrder) (*model.Switch, error)
+	getSwitchById          func(string) (*model.Switch, error)
+	getSwitchesByAccountId func(string) (*[]model.Switch, error)
+}
+
+func (m *mockSwitchService) RequestSwitch(accountId string, fromFundId string, toFundId string, order model.RedemptionOrder) (*model.Switch, error) {
+	return m.requestSwitch(accountId, fromFundId, toFundId, order)
+}
+func (m *mockSwitchService) GetSwitchById(id string) (*model.Switch, error) {
+	return m.getSwitchById(id)
+}
+func (m *mockSwitchService) GetSwitchesByAccountId(id string) (*[]model.Switch, error) {
+	return m.getSwitchesByAccountId(id)
+}
+func (m *mockSwitchService) Progress(leg model.Investment) error {
+	return nil
+}
+
+func TestRequestSwitch(t *testing.T) {
+	tests := []struct {
+		name         string
+		body         string
+		err          error
+		expectedCode int
+	}{
+		{"success", `{"fromFundId": "fund-1", "toFundId": "fund-2", "all": true}`, nil, http.StatusCreated},
+		{"invalid JSON", `{"fromFundId":`, nil, http.StatusBadRequest},
+		{"same fund", `{"fromFundId": "fund-1", "toFundId": "fund-1", "all": true}`, internal.ErrInvalidSwitch, http.StatusBadRequest},
+		{"unknown account", `{"fromFundId": "fund-1", "toFundId": "fund-2", "all": true}`, internal.AccountNotFoundError("acc-1"), http.StatusNotFound},
+		{"ineligible fund", `{"fromFundId": "fund-1", "toFundId": "fund-2", "all": true}`, internal.ErrFundNotEligible, http.StatusUnprocessableEntity},
+		{"more than held", `{"fromFundId": "fund-1", "toFundId": "fund-2", "units": "10"}`, internal.ErrInsufficientHolding, http.StatusUnprocessableEntity},
+		{"unexpected error", `{"fromFundId": "fund-1", "toFundId": "fund-2", "all": true}`, errors.New("db failure"), http.StatusInternalServerError},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			mockSvc := &mockSwitchService{
+				requestSwitch: func(accountId string, fromFundId string, toFundId string, order model.RedemptionOrder) (*model.Switch, error) {
+					if tc.err != nil {
+						return nil, tc.err
+					}
+					return &model.Switch{Id: "sw-1", AccountId: accountId, FromFundId: fromFundId, ToFundId: toFundId, Status: model.SwitchSelling}, nil
+				},
+			}
+			h := handler.NewSwitchHandler(mockSvc, logger.NewMockLogger())
+
+			req := httptest.NewRequest(http.MethodPost, "/accounts/acc-1/switches", strings.NewReader(tc.body))
+			req.SetPathValue("id", "acc-1")
+			w := httptest.NewRecorder()
+
+			h.RequestSwitch(w, req)
+
+			if w.Code != tc.expectedCode {
+				t.Fatalf("expected status %d, got %d", tc.expectedCode, w.Code)
+			}
+		})
+	}
+}
+
+func TestGetSwitchById(t *testing.T) {
+	mockSvc := &mockSwitchService{
+		getSwitchById: func(id string) (*model.Switch, error) {
+			if id != "sw-1" {
+				return nil, internal.SwitchNotFoundError(id)
+			}
+			return &model.Switch{Id: id, Status: model.SwitchBuying}, nil
+		},
+	}
+	h := handler.NewSwitchHandler(mockSvc, logger.NewMockLogger())
+
+	for id, expectedCode := range map[string]int{"sw-1": http.StatusOK, "sw-unknown": http.StatusNotFound} {
+		req := httptest.NewRequest(http.MethodGet, "/switches/"+id, nil)
+		req.SetPathValue("id", id)
+		w := httptest.NewRecorder()
+
+		h.GetSwitchById(w, req)
+
+		if w.Code != expectedCode {
+			t.Errorf("expected status %d for %s, got %d", expectedCode, id, w.Code)
+		}
+	}
+}
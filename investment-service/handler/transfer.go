@@ -0,0 +1,161 @@
+package handler
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/oliknight1/retail-isa-investment/investment-service/internal"
+	"github.com/oliknight1/retail-isa-investment/investment-service/logger"
+	"github.com/oliknight1/retail-isa-investment/investment-service/model"
+	"github.com/oliknight1/retail-isa-investment/investment-service/money"
+	"github.com/oliknight1/retail-isa-investment/investment-service/service"
+	"go.uber.org/zap"
+)
+
+type TransferHandler struct {
+	Service service.TransferService
+	Logger  logger.Logger
+}
+
+func NewTransferHandler(service service.TransferService, logger logger.Logger) *TransferHandler {
+	return &TransferHandler{service, logger}
+}
+
+func (h *TransferHandler) RequestTransfer(w http.ResponseWriter, r *http.Request) {
+	internal.InvestmentRequests.WithLabelValues("/transfers", "POST").Inc()
+	var req struct {
+		AccountId         string                  `json:"accountId"`
+		Direction         model.TransferDirection `json:"direction"`
+		Method            model.TransferMethod    `json:"method"`
+		Provider          string                  `json:"provider"`
+		CurrentYearAmount money.Money             `json:"currentYearAmount"`
+		PriorYearAmount   money.Money             `json:"priorYearAmount"`
+		Holdings          []model.TransferHolding `json:"holdings"`
+	}
+
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		h.Logger.Error("failed to decode transfer request", zap.Error(err))
+		http.Error(w, fmt.Sprintf("error reading JSON: \n%s", err), http.StatusBadRequest)
+		return
+	}
+
+	transfer, err := h.Service.RequestTransfer(model.Transfer{
+		AccountId:         req.AccountId,
+		Direction:         req.Direction,
+		Method:            req.Method,
+		Provider:          req.Provider,
+		CurrentYearAmount: req.CurrentYearAmount,
+		PriorYearAmount:   req.PriorYearAmount,
+		Holdings:          req.Holdings,
+	})
+	if errors.Is(err, internal.ErrMissingAccountId) || errors.Is(err, internal.ErrInvalidTransfer) || errors.Is(err, internal.ErrZeroTransactionAmount) {
+		h.Logger.Error("invalid transfer request", zap.Error(err))
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if errors.Is(err, internal.ErrAccountNotFound) {
+		h.Logger.Error("transfer for unknown account", zap.Error(err))
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+	if errors.Is(err, internal.ErrAccountClosed) || errors.Is(err, internal.ErrInsufficientHolding) || errors.Is(err, internal.ErrInsufficientCash) {
+		h.Logger.Error("transfer rejected by account rules", zap.Error(err))
+		http.Error(w, err.Error(), http.StatusUnprocessableEntity)
+		return
+	}
+	if err != nil {
+		h.Logger.Error("internal service error", zap.Error(err))
+		http.Error(w, "failed to request transfer", http.StatusInternalServerError)
+		return
+	}
+
+	var buf bytes.Buffer
+	if err := json.NewEncoder(&buf).Encode(transfer); err != nil {
+		h.Logger.Error("failed to write transfer to JSON", zap.Error(err))
+		http.Error(w, "failed to encode response", http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	h.Logger.Info("transfer successfully requested", zap.String("transfer_id", transfer.Id))
+	w.Write(buf.Bytes())
+}
+
+func (h *TransferHandler) UpdateTransferStatus(w http.ResponseWriter, r *http.Request) {
+	internal.InvestmentRequests.WithLabelValues("/transfers/{id}/status", "POST").Inc()
+	id := r.PathValue("id")
+	var req struct {
+		Status model.TransferStatus `json:"status"`
+		Reason string               `json:"reason"`
+	}
+
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		h.Logger.Error("failed to decode transfer status request", zap.Error(err))
+		http.Error(w, fmt.Sprintf("error reading JSON: \n%s", err), http.StatusBadRequest)
+		return
+	}
+
+	transfer, err := h.Service.UpdateTransferStatus(id, req.Status, req.Reason)
+	if errors.Is(err, internal.ErrTransferNotFound) {
+		h.Logger.Error("transfer not found", zap.Error(err))
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+	if errors.Is(err, internal.ErrInvalidTransition) {
+		h.Logger.Error("invalid transfer status change", zap.Error(err))
+		http.Error(w, err.Error(), http.StatusConflict)
+		return
+	}
+	if err != nil {
+		h.Logger.Error("internal service error", zap.Error(err))
+		http.Error(w, "failed to update transfer", http.StatusInternalServerError)
+		return
+	}
+
+	h.Logger.Info("transfer status updated", zap.String("transfer_id", transfer.Id), zap.String("status", string(transfer.Status)))
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(transfer)
+}
+
+func (h *TransferHandler) GetTransferById(w http.ResponseWriter, r *http.Request) {
+	internal.InvestmentRequests.WithLabelValues("/transfers/{id}", "GET").Inc()
+	id := r.PathValue("id")
+
+	transfer, err := h.Service.GetTransferById(id)
+	if errors.Is(err, internal.ErrTransferNotFound) {
+		h.Logger.Error("transfer not found", zap.Error(err))
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		h.Logger.Error("failed to get transfer", zap.Error(err))
+		http.Error(w, "failed to get transfer", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(transfer)
+}
+
+func (h *TransferHandler) GetTransfersByAccountId(w http.ResponseWriter, r *http.Request) {
+	internal.InvestmentRequests.WithLabelValues("/accounts/{id}/transfers", "GET").Inc()
+	accountId := r.PathValue("id")
+	if accountId == "" {
+		h.Logger.Error("missing account_id when requesting transfers", zap.Error(internal.ErrMissingAccountId))
+		http.Error(w, internal.ErrMissingAccountId.Error(), http.StatusBadRequest)
+		return
+	}
+
+	transfers, err := h.Service.GetTransfersByAccountId(accountId)
+	if err != nil {
+		h.Logger.Error("failed to get transfers", zap.Error(err))
+		http.Error(w, "failed to get transfers", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(transfers)
+}
@@ -0,0 +1,137 @@
+package handler_test
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/oliknight1/retail-isa-investment/investment-service/handler"
+	"github.com/oliknight1/retail-isa-investment/investment-service/internal"
+	"github.com/oliknight1/retail-isa-investment/investment-service/logger"
+	"github.com/oliknight1/retail-isa-investment/investment-service/model"
+	"github.com/oliknight1/retail-isa-investment/investment-service/money"
+)
+
+type mockTransferService struct {
+	requestTransfer         func(details model.Transfer) (*model.Transfer, error)
+	updateTransferStatus    func(id string, status model.TransferStatus, reason string) (*model.Transfer, error)
+	getTransferById         func(string) (*model.Transfer, error)
+	getTransfersByAccountId func(string) (*[]model.Transfer, error)
+}
+
+func (m *mockTransferService) RequestTransfer(details model.Transfer) (*model.Transfer, error) {
+	return m.requestTransfer(details)
+}
+func (m *mockTransferService) UpdateTransferStatus(id string, status model.TransferStatus, reason string) (*model.Transfer, error) {
+	return m.updateTransferStatus(id, status, reason)
+}
+func (m *mockTransferService) GetTransferById(id string) (*model.Transfer, error) {
+	return m.getTransferById(id)
+}
+func (m *mockTransferService) GetTransfersByAccountId(id string) (*[]model.Transfer, error) {
+	return m.getTransfersByAccountId(id)
+}
+
+func TestRequestTransferSuccess(t *testing.T) {
+	mockSvc := &mockTransferService{
+		requestTransfer: func(details model.Transfer) (*model.Transfer, error) {
+			details.Id = "tr-1"
+			details.Status = model.TransferRequested
+			return &details, nil
+		},
+	}
+	logger := logger.NewMockLogger()
+	h := handler.NewTransferHandler(mockSvc, logger)
+
+	body := `{"accountId":"acc-123","direction":"in","method":"cash","provider":"Other Bank","currentYearAmount":500,"priorYearAmount":1500}`
+	req := httptest.NewRequest(http.MethodPost, "/transfers", strings.NewReader(body))
+	w := httptest.NewRecorder()
+
+	h.RequestTransfer(w, req)
+
+	if w.Code != http.StatusCreated {
+		t.Fatalf("expected status %d, got %d", http.StatusCreated, w.Code)
+	}
+	var transfer model.Transfer
+	if err := json.NewDecoder(w.Body).Decode(&transfer); err != nil {
+		t.Fatalf("decode error: %v", err)
+	}
+	if transfer.Direction != model.TransferIn || !transfer.CurrentYearAmount.Equal(money.Pounds(500)) || !transfer.PriorYearAmount.Equal(money.Pounds(1500)) {
+		t.Errorf("unexpected transfer: %+v", transfer)
+	}
+}
+
+func TestRequestTransferServiceErrors(t *testing.T) {
+	tests := []struct {
+		name         string
+		err          error
+		expectedCode int
+	}{
+		{"invalid transfer", internal.ErrInvalidTransfer, http.StatusBadRequest},
+		{"account not found", internal.AccountNotFoundError("acc-123"), http.StatusNotFound},
+		{"insufficient holding", internal.InsufficientHoldingError("10.0000 units of fund-1"), http.StatusUnprocessableEntity},
+		{"insufficient cash", internal.InsufficientCashError(money.Pounds(10)), http.StatusUnprocessableEntity},
+		{"unexpected error", errors.New("db failure"), http.StatusInternalServerError},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			mockSvc := &mockTransferService{
+				requestTransfer: func(details model.Transfer) (*model.Transfer, error) {
+					return nil, tc.err
+				},
+			}
+			logger := logger.NewMockLogger()
+			h := handler.NewTransferHandler(mockSvc, logger)
+
+			req := httptest.NewRequest(http.MethodPost, "/transfers", strings.NewReader(`{"accountId":"acc-123"}`))
+			w := httptest.NewRecorder()
+
+			h.RequestTransfer(w, req)
+
+			if w.Code != tc.expectedCode {
+				t.Fatalf("expected status %d, got %d", tc.expectedCode, w.Code)
+			}
+		})
+	}
+}
+
+func TestUpdateTransferStatus(t *testing.T) {
+	tests := []struct {
+		name         string
+		err          error
+		expectedCode int
+	}{
+		{"success", nil, http.StatusOK},
+		{"transfer not found", internal.TransferNotFoundError("tr-1"), http.StatusNotFound},
+		{"invalid transition", internal.ErrInvalidTransition, http.StatusConflict},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			mockSvc := &mockTransferService{
+				updateTransferStatus: func(id string, status model.TransferStatus, reason string) (*model.Transfer, error) {
+					if tc.err != nil {
+						return nil, tc.err
+					}
+					return &model.Transfer{Id: id, Status: status}, nil
+				},
+			}
+			logger := logger.NewMockLogger()
+			h := handler.NewTransferHandler(mockSvc, logger)
+
+			req := httptest.NewRequest(http.MethodPost, "/transfers/tr-1/status", strings.NewReader(`{"status":"awaiting_ceding_provider"}`))
+			req.SetPathValue("id", "tr-1")
+			w := httptest.NewRecorder()
+
+			h.UpdateTransferStatus(w, req)
+
+			if w.Code != tc.expectedCode {
+				t.Fatalf("expected status %d, got %d", tc.expectedCode, w.Code)
+			}
+		})
+	}
+}
@@ -1,9 +1,102 @@
 package internal
 
-import "errors"
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/oliknight1/retail-isa-investment/investment-service/money"
+)
 
 var (
 	ErrMissingCustomerId     = errors.New("customer id is required")
 	ErrMissingFundId         = errors.New("fund id is required")
+	ErrMissingAccountId      = errors.New("account id is required")
 	ErrZeroTransactionAmount = errors.New("transaction amount must be greater than 0")
+	ErrAllowanceExceeded     = errors.New("annual ISA allowance exceeded")
+	ErrInvalidProductType    = errors.New("invalid ISA product type")
+	ErrAccountNotFound       = errors.New("account not found")
+	ErrAccountClosed         = errors.New("account is closed")
+	ErrFundNotEligible       = errors.New("fund is not eligible for this ISA")
+	ErrIneligibleAge         = errors.New("customer age is not eligible for this ISA")
+	ErrInvalidClaimPeriod    = errors.New("claim period must be formatted YYYY-MM")
+	ErrMissingContact        = errors.New("junior ISA requires the customer to have a registered contact")
+	ErrWithdrawalNotAllowed  = errors.New("withdrawals are not allowed from this ISA")
+	ErrInvalidReason         = errors.New("invalid withdrawal reason")
+	ErrInvalidTransfer       = errors.New("invalid transfer request")
+	ErrTransferNotFound      = errors.New("transfer not found")
+	ErrInvalidTransition     = errors.New("invalid status change")
+	ErrCustomerIneligible    = errors.New("customer is not eligible for this ISA")
+	ErrInvalidTaxYear        = errors.New("tax year must be formatted YYYY-YY")
+	ErrTaxYearNotEnded       = errors.New("tax year has not ended yet")
+	ErrInvestmentNotFound    = errors.New("investment not found")
+	ErrCoolingOffExpired     = errors.New("the 30 day cooling-off period has ended")
+	ErrCannotCancel          = errors.New("investment cannot be cancelled")
+	ErrMissingActor          = errors.New("actor is required")
+	ErrInvalidRedemption     = errors.New("redemption must give exactly one of amount, units or all")
+	ErrInsufficientHolding   = errors.New("redemption is more than the holding")
+	ErrInsufficientCash      = errors.New("amount is more than the cash available")
+	ErrInvalidSwitch         = errors.New("invalid switch request")
+	ErrSwitchNotFound        = errors.New("switch not found")
+	ErrInvalidPlan           = errors.New("invalid regular investment plan")
+	ErrPlanNotFound          = errors.New("plan not found")
+	ErrFeeChargeNotFound     = errors.New("fee charge not found")
+	ErrInvalidPreference     = errors.New("income preference must be pay_out or reinvest")
+	ErrUnbalancedEntry       = errors.New("journal entry debits must equal its credits")
+	ErrDuplicateEntry        = errors.New("journal entry has already been posted")
+	ErrEntryNotFound         = errors.New("journal entry not found")
+	ErrNoReconciliationRun   = errors.New("reconciliation has not been run")
+	ErrInvalidPeriod         = errors.New("period must be 1m, 3m, ytd, 1y, inception or custom with a from date before its to date")
 )
+
+func AllowanceExceededError(remaining money.Money) error {
+	return fmt.Errorf("%w: %s remaining this tax year", ErrAllowanceExceeded, remaining)
+}
+
+func ProductLimitExceededError(productType string, remaining money.Money) error {
+	return fmt.Errorf("%w: %s remaining for %s ISA this tax year", ErrAllowanceExceeded, remaining, productType)
+}
+
+func AccountNotFoundError(id string) error {
+	return fmt.Errorf("%w: %s", ErrAccountNotFound, id)
+}
+
+func InsufficientCashError(available money.Money) error {
+	return fmt.Errorf("%w: %s available", ErrInsufficientCash, available)
+}
+
+func TransferNotFoundError(id string) error {
+	return fmt.Errorf("%w: %s", ErrTransferNotFound, id)
+}
+
+func CustomerIneligibleError(reasons []string) error {
+	return fmt.Errorf("%w: %s", ErrCustomerIneligible, strings.Join(reasons, ", "))
+}
+
+func InvestmentNotFoundError(id string) error {
+	return fmt.Errorf("%w: %s", ErrInvestmentNotFound, id)
+}
+
+func InsufficientHoldingError(available string) error {
+	return fmt.Errorf("%w: %s available to sell", ErrInsufficientHolding, available)
+}
+
+func SwitchNotFoundError(id string) error {
+	return fmt.Errorf("%w: %s", ErrSwitchNotFound, id)
+}
+
+func PlanNotFoundError(id string) error {
+	return fmt.Errorf("%w: %s", ErrPlanNotFound, id)
+}
+
+func FeeChargeNotFoundError(id string) error {
+	return fmt.Errorf("%w: %s", ErrFeeChargeNotFound, id)
+}
+
+func EntryNotFoundError(id string) error {
+	return fmt.Errorf("%w: %s", ErrEntryNotFound, id)
+}
+
+func DuplicateEntryError(reference string) error {
+	return fmt.Errorf("%w: %s", ErrDuplicateEntry, reference)
+}
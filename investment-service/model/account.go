@@ -0,0 +1,56 @@
+package model
+
+import (
+	"time"
+
+	"github.com/oliknight1/retail-isa-investment/investment-service/money"
+)
+
+// ProductType is the kind of ISA an account wraps
+type ProductType string
+
+const (
+	StocksAndSharesISA ProductType = "stocks_and_shares"
+	CashISA            ProductType = "cash"
+	LifetimeISA        ProductType = "lifetime"
+	JuniorISA          ProductType = "junior"
+)
+
+type AccountStatus string
+
+const (
+	AccountOpen   AccountStatus = "open"
+	AccountClosed AccountStatus = "closed"
+)
+
+// IncomePreference is what a customer wants done with income paid on income units
+type IncomePreference string
+
+const (
+	IncomePayOut   IncomePreference = "pay_out"
+	IncomeReinvest IncomePreference = "reinvest"
+)
+
+// Account is an ISA wrapper held by a customer, which investments are made into.
+// OperatorId is the customer who runs the account, which for a Junior ISA is the
+// child's registered contact rather than the child. The account's cash is kept in
+// the ledger. Income paid on income units goes into cash unless IncomePreference is
+// IncomeReinvest
+type Account struct {
+	Id               string
+	CustomerId       string
+	OperatorId       string
+	ProductType      ProductType
+	Status           AccountStatus
+	IncomePreference IncomePreference
+	OpenedAt         time.Time
+	ClosedAt         *time.Time
+}
+
+// CashBalance is the cash held in an account
+type CashBalance struct {
+	AccountId string
+	Balance   money.Money
+	Reserved  money.Money
+	Available money.Money
+}
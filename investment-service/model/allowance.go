@@ -0,0 +1,59 @@
+package model
+
+import (
+	"time"
+
+	"github.com/oliknight1/retail-isa-investment/investment-service/money"
+	"github.com/oliknight1/retail-isa-investment/investment-service/taxyear"
+)
+
+// AllowanceUsage is the running total of ISA subscriptions a customer has made in a tax year.
+// Subscribed only includes products that count towards the overall allowance, while Products
+// breaks down every product type. TransfersIn holds the ids of transfers whose current year
+// subscriptions have been counted, so each is only counted once
+type AllowanceUsage struct {
+	CustomerId  string
+	TaxYear     taxyear.TaxYear
+	Subscribed  money.Money
+	Products    map[ProductType]ProductUsage
+	TransfersIn map[string]bool
+}
+
+// ProductUsage tracks money in and out of one product type during a tax year. Replaced is money
+// paid back in after a withdrawal from a flexible ISA, which does not use any allowance.
+// TransferredIn is the part of Subscribed that was paid in with another provider this tax year
+type ProductUsage struct {
+	Subscribed    money.Money
+	Withdrawn     money.Money
+	Replaced      money.Money
+	TransferredIn money.Money
+}
+
+// AllowanceUse is how a single subscription was counted against the allowance, kept so it can be
+// reversed exactly if the subscription is undone
+type AllowanceUse struct {
+	Subscribed money.Money
+	Replaced   money.Money
+}
+
+// Allowance is the view of a customer's allowance returned by the API. Replaceable is how much
+// withdrawn from flexible ISAs this tax year can be paid back in without using allowance
+type Allowance struct {
+	CustomerId  string
+	TaxYear     taxyear.TaxYear
+	Limit       money.Money
+	Used        money.Money
+	Remaining   money.Money
+	Withdrawn   money.Money
+	Replaced    money.Money
+	Replaceable money.Money
+	Products    map[ProductType]ProductUsage
+}
+
+// TaxYearClosed is published once a tax year has ended and its allowance counters are reset
+type TaxYearClosed struct {
+	TaxYear         taxyear.TaxYear
+	ClosedAt        time.Time
+	Subscribers     int
+	TotalSubscribed money.Money
+}
@@ -0,0 +1,29 @@
+package model
+
+import (
+	"time"
+
+	"github.com/oliknight1/retail-isa-investment/investment-service/money"
+)
+
+type BonusClaimStatus string
+
+const (
+	BonusClaimPending   BonusClaimStatus = "pending"
+	BonusClaimSubmitted BonusClaimStatus = "submitted"
+	BonusClaimCancelled BonusClaimStatus = "cancelled"
+)
+
+// BonusClaim is the government bonus due on a single Lifetime ISA subscription.
+// ClaimPeriod is the month the claim is made in, formatted "2006-01"
+type BonusClaim struct {
+	Id                 string
+	AccountId          string
+	CustomerId         string
+	InvestmentId       string
+	SubscriptionAmount money.Money
+	BonusAmount        money.Money
+	ClaimPeriod        string
+	Status             BonusClaimStatus
+	CreatedAt          time.Time
+}
@@ -0,0 +1,76 @@
+package model
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// Customer is the view of a customer held by customer-service
+type Customer struct {
+	Id                      string `json:"id"`
+	Name                    string `json:"name"`
+	DateOfBirth             Date   `json:"dateOfBirth"`
+	RegisteredContactId     string `json:"registeredContactId,omitempty"`
+	NationalInsuranceNumber string `json:"nationalInsuranceNumber,omitempty"`
+}
+
+// JisaMatured is published by customer-service when a child customer turns 18
+type JisaMatured struct {
+	CustomerId          string    `json:"customerId"`
+	RegisteredContactId string    `json:"registeredContactId"`
+	MaturedAt           time.Time `json:"maturedAt"`
+}
+
+// Date is a calendar date without a time of day, sent over the wire as "2006-01-02"
+type Date struct {
+	time.Time
+}
+
+func NewDate(year int, month time.Month, day int) Date {
+	return Date{time.Date(year, month, day, 0, 0, 0, 0, time.UTC)}
+}
+
+func (d Date) String() string {
+	return d.Format("2006-01-02")
+}
+
+func (d Date) MarshalJSON() ([]byte, error) {
+	if d.IsZero() {
+		return []byte("null"), nil
+	}
+	return json.Marshal(d.Format("2006-01-02"))
+}
+
+func (d *Date) UnmarshalJSON(data []byte) error {
+	if string(data) == "null" {
+		*d = Date{}
+		return nil
+	}
+	var s string
+	if err := json.Unmarshal(data, &s); err != nil {
+		return err
+	}
+	t, err := time.Parse("2006-01-02", s)
+	if err != nil {
+		return err
+	}
+	*d = Date{t}
+	return nil
+}
+
+// AgeOn returns the age in whole years of someone born on d, on the day of t
+func (d Date) AgeOn(t time.Time) int {
+	age := t.Year() - d.Year()
+	if t.Month() < d.Month() || (t.Month() == d.Month() && t.Day() < d.Day()) {
+		age--
+	}
+	return age
+}
+
+// Eligibility mirrors customer-service's answer to whether a customer can subscribe to a product
+type Eligibility struct {
+	CustomerId string      `json:"customerId"`
+	Product    ProductType `json:"product"`
+	Eligible   bool        `json:"eligible"`
+	Reasons    []string    `json:"reasons"`
+}
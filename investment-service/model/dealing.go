@@ -0,0 +1,26 @@
+package model
+
+import (
+	"time"
+
+	"github.com/oliknight1/retail-isa-investment/investment-service/money"
+)
+
+// Dealing records the units a subscription bought at its valuation point
+type Dealing struct {
+	Price    UnitPrice
+	Units    Units
+	Residual money.Money
+	ValuedAt time.Time
+	DealtAt  time.Time
+}
+
+// DealingRun summarises one run of the dealing scheduler. Orders whose valuation point has not
+// been reached, or whose fund has not been priced for it yet, are left for a later run
+type DealingRun struct {
+	RunAt         time.Time `json:"runAt"`
+	Dealt         int       `json:"dealt"`
+	NotYetDue     int       `json:"notYetDue"`
+	AwaitingPrice int       `json:"awaitingPrice"`
+	Failed        int       `json:"failed"`
+}
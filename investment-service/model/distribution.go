@@ -0,0 +1,60 @@
+package model
+
+import (
+	"time"
+
+	"github.com/oliknight1/retail-isa-investment/investment-service/money"
+)
+
+// UnitClass mirrors fund-service's unit classes. Accumulation units have their income
+// reinvested, income units pay it out
+type UnitClass string
+
+const (
+	UnitClassAccumulation UnitClass = "acc"
+	UnitClassIncome       UnitClass = "inc"
+)
+
+// Distribution mirrors fund-service's declaration of income paid per unit to holders of units
+// bought before the ex-dividend date
+type Distribution struct {
+	Id      string    `json:"id"`
+	FundId  string    `json:"fundId"`
+	Rate    UnitPrice `json:"rate"`
+	ExDate  Date      `json:"exDate"`
+	PayDate Date      `json:"payDate"`
+}
+
+type DistributionOutcome string
+
+const (
+	DistributionPaid       DistributionOutcome = "paid"
+	DistributionReinvested DistributionOutcome = "reinvested"
+)
+
+// DistributionPayment is one account's share of a distribution: the units it held at the
+// ex-dividend date times the rate. Paid income goes to the account's cash and reinvested income
+// buys more units through the reinvestment InvestmentId
+type DistributionPayment struct {
+	Id             string
+	DistributionId string
+	AccountId      string
+	CustomerId     string
+	FundId         string
+	UnitClass      UnitClass
+	Units          Units
+	Rate           UnitPrice
+	Amount         money.Money
+	Outcome        DistributionOutcome
+	InvestmentId   *string `json:",omitempty"`
+	PaidAt         time.Time
+}
+
+// DistributionRun summarises one run of distribution processing
+type DistributionRun struct {
+	RunAt         time.Time
+	Distributions int
+	Paid          int
+	Reinvested    int
+	Failed        int
+}
@@ -0,0 +1,83 @@
+package model
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/oliknight1/retail-isa-investment/investment-service/money"
+)
+
+// Accrual is a fee in millionths of a pound, so a day's fee on a small holding can build up a
+// fraction of a penny at a time. It is written to JSON as a decimal string e.g. "0.123287"
+type Accrual int64
+
+func (a Accrual) String() string { return formatDecimal(int64(a), UnitPricePlaces) }
+
+func (a Accrual) MarshalJSON() ([]byte, error) { return json.Marshal(a.String()) }
+
+func (a *Accrual) UnmarshalJSON(data []byte) error {
+	v, err := unmarshalDecimal(data, UnitPricePlaces)
+	*a = Accrual(v)
+	return err
+}
+
+// Money rounds the accrual to the nearest penny
+func (a Accrual) Money() money.Money {
+	const perPenny = unitPriceScale / 100
+	return money.Pence((int64(a) + perPenny/2) / perPenny)
+}
+
+// FeeAccrual is one day's fees on an account, worked out from the value of its holdings that
+// day. Platform is the platform fee that will be charged at the end of the month. FundCharges
+// is what the funds' ongoing charges cost over the day, which fund managers take through the
+// unit price rather than being charged to the account, and is kept to report the full cost
+type FeeAccrual struct {
+	AccountId   string
+	CustomerId  string
+	Date        Date
+	Value       money.Money
+	Platform    Accrual
+	FundCharges Accrual
+}
+
+type FeeChargeStatus string
+
+const (
+	FeeChargePending FeeChargeStatus = "pending"
+	FeeChargeCharged FeeChargeStatus = "charged"
+)
+
+// FeeCollection is how a fee charge was paid
+type FeeCollection string
+
+const (
+	CollectedFromCash  FeeCollection = "cash"
+	CollectedBySelling FeeCollection = "units"
+)
+
+// FeeCharge is a month's platform fee for an account. Accrued is the exact total of the month's
+// daily accruals and Amount is what is charged, rounded to the penny. A charge is pending until
+// the account's cash covers it, with SaleId set if units had to be sold to raise the cash
+type FeeCharge struct {
+	Id          string
+	AccountId   string
+	CustomerId  string
+	Month       string
+	Accrued     Accrual
+	Amount      money.Money
+	FundCharges Accrual
+	Status      FeeChargeStatus
+	Collection  FeeCollection `json:",omitempty"`
+	SaleId      *string       `json:",omitempty"`
+	CreatedAt   time.Time
+	ChargedAt   *time.Time `json:",omitempty"`
+}
+
+// FeeRun summarises one run of the fee engine
+type FeeRun struct {
+	RunAt        time.Time
+	Accrued      int
+	Billed       int
+	Charged      int
+	AwaitingSale int
+}
@@ -0,0 +1,121 @@
+package model
+
+import (
+	"time"
+
+	"github.com/oliknight1/retail-isa-investment/investment-service/money"
+)
+
+// LedgerAccountKind is what a ledger account holds. Accounts are kept from the customer's side,
+// so money they own is a debit balance and money that came from outside or was earned is a
+// credit balance
+type LedgerAccountKind string
+
+const (
+	// LedgerCash is uninvested cash in an ISA that is free to spend
+	LedgerCash LedgerAccountKind = "cash"
+	// LedgerReserved is cash set aside for purchases, withdrawals and transfers out until they
+	// are dealt or settled
+	LedgerReserved LedgerAccountKind = "reserved"
+	// LedgerHoldings is money invested in one fund in an ISA, less what sales of it raised
+	LedgerHoldings LedgerAccountKind = "holdings"
+	// LedgerSwitching holds the proceeds of a switch sale until its purchase is dealt
+	LedgerSwitching LedgerAccountKind = "switching"
+	// LedgerExternal is money paid into an ISA from outside it, or paid out of it
+	LedgerExternal LedgerAccountKind = "external"
+	// LedgerFees is platform fees an ISA has paid
+	LedgerFees LedgerAccountKind = "fees"
+	// LedgerCharges is withdrawal charges kept back from what an ISA paid out
+	LedgerCharges LedgerAccountKind = "charges"
+	// LedgerMarketLoss is the fall in value kept back from the refund of a subscription cancelled
+	// after it was dealt
+	LedgerMarketLoss LedgerAccountKind = "market_loss"
+	// LedgerIncome is income a fund has distributed to an ISA
+	LedgerIncome LedgerAccountKind = "income"
+)
+
+// LedgerAccount is one account in the ledger. Every kind is kept per ISA account, and holdings
+// and income per fund as well
+type LedgerAccount struct {
+	Kind      LedgerAccountKind
+	AccountId string
+	FundId    string `json:",omitempty"`
+}
+
+// String is the account's code e.g. "holdings:acc-1:fund-1"
+func (a LedgerAccount) String() string {
+	code := string(a.Kind) + ":" + a.AccountId
+	if a.FundId != "" {
+		code += ":" + a.FundId
+	}
+	return code
+}
+
+type PostingSide string
+
+const (
+	Debit  PostingSide = "debit"
+	Credit PostingSide = "credit"
+)
+
+func (s PostingSide) Opposite() PostingSide {
+	if s == Debit {
+		return Credit
+	}
+	return Debit
+}
+
+// Posting is one side of a journal entry, always for a positive amount
+type Posting struct {
+	Account LedgerAccount
+	Side    PostingSide
+	Amount  money.Money
+}
+
+// Signed is the posting's effect on its account's balance, positive for a debit
+func (p Posting) Signed() money.Money {
+	if p.Side == Credit {
+		return p.Amount.Neg()
+	}
+	return p.Amount
+}
+
+// JournalEntry records one movement of money. Its postings must balance, with debits equal to
+// credits, and once posted it is never changed: a mistake is put right by posting a reversal,
+// which has the same postings on the opposite sides and ReversalOf set to the entry it undoes.
+// Reference is what the entry records, such as an investment being dealt, and is unique so the
+// same movement is never posted twice. At is when the money moved and PostedAt when it was
+// written to the ledger
+type JournalEntry struct {
+	Id          string
+	CustomerId  string
+	AccountId   string
+	Reference   string
+	Description string
+	Postings    []Posting
+	ReversalOf  *string `json:",omitempty"`
+	At          time.Time
+	PostedAt    time.Time
+}
+
+// Balanced reports whether the entry's debits equal its credits and every posting is positive
+func (e JournalEntry) Balanced() bool {
+	if len(e.Postings) < 2 {
+		return false
+	}
+	var total money.Money
+	for _, posting := range e.Postings {
+		if !posting.Amount.IsPositive() {
+			return false
+		}
+		total = total.Add(posting.Signed())
+	}
+	return total.IsZero()
+}
+
+// LedgerBalance is a ledger account's balance, its debits less its credits
+type LedgerBalance struct {
+	Account LedgerAccount
+	Code    string
+	Balance money.Money
+}
@@ -1,15 +1,87 @@
 package model
 
-import "time"
+import (
+	"time"
+
+	"github.com/oliknight1/retail-isa-investment/investment-service/money"
+	"github.com/oliknight1/retail-isa-investment/investment-service/taxyear"
+)
+
+// InvestmentType separates money paid into an ISA from money taken out of it
+type InvestmentType string
+
+const (
+	Subscription InvestmentType = "subscription"
+	Withdrawal   InvestmentType = "withdrawal"
+	// TransferredIn and TransferredOut move an ISA between us and another provider. A cash
+	// transfer is one investment for its amount, a stock transfer is one for each fund it
+	// re-registers, with the units given in its Dealing
+	TransferredIn  InvestmentType = "transfer_in"
+	TransferredOut InvestmentType = "transfer_out"
+	// Redemption sells units of a fund inside the ISA. The proceeds stay in the ISA as cash
+	Redemption InvestmentType = "redemption"
+	// SwitchSell and SwitchBuy are the two legs of a Switch, moving money between funds
+	// inside the ISA without it being subscribed or withdrawn
+	SwitchSell InvestmentType = "switch_sell"
+	SwitchBuy  InvestmentType = "switch_buy"
+	// Reinvestment buys units with income a fund has distributed, which is already inside the
+	// ISA so uses no allowance
+	Reinvestment InvestmentType = "reinvestment"
+	// Deposit pays money into the ISA as cash, using allowance like a subscription. Purchase
+	// then buys units of a fund with that cash
+	Deposit  InvestmentType = "deposit"
+	Purchase InvestmentType = "purchase"
+)
+
+// Buys reports whether investments of type t buy units of a fund
+func (t InvestmentType) Buys() bool {
+	return t == Subscription || t == SwitchBuy || t == Reinvestment || t == Purchase
+}
+
+// Sells reports whether investments of type t sell units of a fund
+func (t InvestmentType) Sells() bool {
+	return t == Redemption || t == SwitchSell
+}
+
+// AddsUnits reports whether the investment adds units of a fund to the account, by buying them
+// or by a stock transfer in
+func (i Investment) AddsUnits() bool {
+	return i.Type.Buys() || i.Type == TransferredIn && i.FundId != ""
+}
+
+// RemovesUnits reports whether the investment takes units of a fund out of the account, by
+// selling them or by a stock transfer out
+func (i Investment) RemovesUnits() bool {
+	return i.Type.Sells() || i.Type == TransferredOut && i.FundId != ""
+}
 
 type Investment struct {
 	Id         string
+	AccountId  string
 	CustomerId string
 	FundId     string
-	Amount     float64
-	// "pending", "completed", "failed"
-	Status        string
+	Type       InvestmentType
+	Amount     money.Money
+	Status     InvestmentStatus
+	// History holds every status the investment has been in, oldest first
+	History       []InvestmentStatusChange
+	TaxYear       taxyear.TaxYear
+	AllowanceUse  AllowanceUse
+	Withdrawal    *WithdrawalDetails
+	TransferId    *string
+	Cancellation  *Cancellation
+	Dealing       *Dealing
+	Redemption    *RedemptionDetails
+	SwitchId      *string
 	CreatedAt     time.Time
 	CompletedAt   *time.Time
 	FailureReason *string
 }
+
+// Cancellation is recorded when a subscription is cancelled in its cooling-off period.
+// The customer is refunded what they paid in less any fall in the value of units bought
+type Cancellation struct {
+	RefundAmount money.Money
+	MarketLoss   money.Money
+	CancelledAt  time.Time
+}
@@ -0,0 +1,56 @@
+package model
+
+import (
+	"time"
+
+	"github.com/oliknight1/retail-isa-investment/investment-service/money"
+)
+
+// Period is a standard range that performance is measured over, ending now. A custom period
+// runs between dates given with the request
+type Period string
+
+const (
+	PeriodOneMonth    Period = "1m"
+	PeriodThreeMonths Period = "3m"
+	PeriodYearToDate  Period = "ytd"
+	PeriodOneYear     Period = "1y"
+	PeriodInception   Period = "inception"
+	PeriodCustom      Period = "custom"
+)
+
+// Return is how a set of holdings performed between From and To. Money paid into funds
+// and sale proceeds taken out count as cash flows rather than growth, so Gain is what the
+// holdings made after NetInvested. TimeWeighted strips out the effect of when money went in
+// and out, MoneyWeighted is the rate the money invested actually earned. Both are percentages
+// for the whole period and are left out when nothing was invested during it
+type Return struct {
+	StartValue    money.Money
+	EndValue      money.Money
+	NetInvested   money.Money
+	Gain          money.Money
+	TimeWeighted  *float64 `json:",omitempty"`
+	MoneyWeighted *float64 `json:",omitempty"`
+}
+
+// Performance is the return of everything a customer holds over a period, broken down by
+// account and by fund
+type Performance struct {
+	CustomerId string
+	Period     Period
+	From       time.Time
+	To         time.Time
+	Return
+	Accounts []AccountPerformance
+}
+
+type AccountPerformance struct {
+	AccountId string
+	Return
+	Funds []FundPerformance
+}
+
+type FundPerformance struct {
+	FundId string
+	Return
+}
@@ -0,0 +1,91 @@
+package model
+
+import (
+	"slices"
+	"time"
+
+	"github.com/oliknight1/retail-isa-investment/investment-service/money"
+)
+
+type PlanStatus string
+
+const (
+	PlanActive    PlanStatus = "active"
+	PlanPaused    PlanStatus = "paused"
+	PlanCancelled PlanStatus = "cancelled"
+	PlanEnded     PlanStatus = "ended"
+)
+
+var planTransitions = map[PlanStatus][]PlanStatus{
+	PlanActive: {PlanPaused, PlanCancelled, PlanEnded},
+	PlanPaused: {PlanActive, PlanCancelled, PlanEnded},
+}
+
+// CanTransition reports whether a plan in status s can move to next
+func (s PlanStatus) CanTransition(next PlanStatus) bool {
+	return slices.Contains(planTransitions[s], next)
+}
+
+// MaxPlanDay is the latest day of the month a plan can invest on, so it falls in every month
+const MaxPlanDay = 28
+
+// FundAllocation is the whole percentage of each payment invested in a fund
+type FundAllocation struct {
+	FundId  string
+	Percent int
+}
+
+// Plan invests Amount into an account every month on DayOfMonth, split between funds by
+// Allocations, from StartDate until it is cancelled or passes EndDate. NextDueDate is the
+// next day it will invest, and Runs records what happened on every day it was due
+type Plan struct {
+	Id          string
+	AccountId   string
+	CustomerId  string
+	Allocations []FundAllocation
+	Amount      money.Money
+	DayOfMonth  int
+	StartDate   Date
+	EndDate     *Date `json:",omitempty"`
+	NextDueDate Date
+	Status      PlanStatus
+	Runs        []PlanRun
+	CreatedAt   time.Time
+}
+
+type PlanRunOutcome string
+
+const (
+	PlanRunInvested PlanRunOutcome = "invested"
+	PlanRunSkipped  PlanRunOutcome = "skipped"
+	PlanRunFailed   PlanRunOutcome = "failed"
+)
+
+// PlanRun is one monthly payment of a plan. A skipped run invested nothing because the customer
+// did not have enough allowance left, while a failed run was rejected part way through and
+// InvestmentIds lists any funds that had already been bought
+type PlanRun struct {
+	DueDate       Date
+	Outcome       PlanRunOutcome
+	InvestmentIds []string
+	Reason        string `json:",omitempty"`
+	RanAt         time.Time
+}
+
+// PlanRunEvent is published on plan.run.<outcome> after every run
+type PlanRunEvent struct {
+	PlanId     string
+	AccountId  string
+	CustomerId string
+	Amount     money.Money
+	PlanRun
+}
+
+// PlanSchedulerRun summarises one pass of the plan scheduler
+type PlanSchedulerRun struct {
+	RunAt    time.Time
+	Invested int
+	Skipped  int
+	Failed   int
+	Ended    int
+}
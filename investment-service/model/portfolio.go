@@ -0,0 +1,46 @@
+package model
+
+import (
+	"time"
+
+	"github.com/oliknight1/retail-isa-investment/investment-service/money"
+)
+
+// Portfolio is what a customer holds across their ISAs, valued at each fund's latest price
+type Portfolio struct {
+	CustomerId string
+	Accounts   []AccountValuation
+	BookCost   money.Money
+	Value      money.Money
+	Gain       money.Money
+	ValuedAt   time.Time
+}
+
+// AccountValuation totals the holdings and cash in one ISA. Cash counts towards Value at face
+// value, so it has no book cost or gain
+type AccountValuation struct {
+	AccountId   string
+	ProductType ProductType
+	Holdings    []Holding
+	Cash        money.Money
+	BookCost    money.Money
+	Value       money.Money
+	Gain        money.Money
+}
+
+// Holding is a position in one fund within an account. BookCost is what the units still held
+// cost, with sales taking out their share at the average cost. Units are valued at the price
+// they could be sold for. GainPercent is the unrealised gain against book cost and Allocation
+// is the holding's share of the account's value, both as percentages
+type Holding struct {
+	FundId      string
+	Units       Units
+	BookCost    money.Money
+	AverageCost UnitPrice
+	Price       UnitPrice
+	PricedAt    time.Time
+	Value       money.Money
+	Gain        money.Money
+	GainPercent float64
+	Allocation  float64
+}
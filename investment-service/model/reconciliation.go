@@ -0,0 +1,62 @@
+package model
+
+import (
+	"time"
+
+	"github.com/oliknight1/retail-isa-investment/investment-service/money"
+)
+
+// BreakType is the kind of mismatch reconciliation found
+type BreakType string
+
+const (
+	// BreakLedgerMissing is an investment that moved money with no journal entry for it
+	BreakLedgerMissing BreakType = "ledger_missing"
+	// BreakLedgerMismatch is a journal entry whose postings differ from what its investment moved
+	BreakLedgerMismatch BreakType = "ledger_mismatch"
+	// BreakLedgerNotReversed is a journal entry still standing for an investment that failed or
+	// was cancelled
+	BreakLedgerNotReversed BreakType = "ledger_not_reversed"
+	// BreakCashMismatch is an account whose cash reserved in the ledger differs from what its
+	// purchases, withdrawals and transfers out are waiting on, or that has spent more than it holds
+	BreakCashMismatch BreakType = "cash_mismatch"
+	// BreakUnitsMismatch is a fund whose units held across every account differ from the units
+	// fund-service has in issue
+	BreakUnitsMismatch BreakType = "units_mismatch"
+	// BreakEventMissing is a status change that was never received as an event
+	BreakEventMissing BreakType = "event_missing"
+	// BreakEventUnexpected is an event received for a status change no investment has
+	BreakEventUnexpected BreakType = "event_unexpected"
+)
+
+// ReconciliationCheck is one of the checks a reconciliation run makes that depends on another
+// service, and so can fail without stopping the rest of the run
+type ReconciliationCheck string
+
+const (
+	// CheckUnits compares each fund's units against fund-service's units in issue
+	CheckUnits ReconciliationCheck = "units"
+)
+
+// ReconciliationBreak is one mismatch. Amount is how far the money is out and Units how far a
+// fund's units are, each as what investment-service's records say less what the other side does
+type ReconciliationBreak struct {
+	Type    BreakType
+	Amount  money.Money
+	Units   Units `json:",omitempty"`
+	Records []string
+	Detail  string
+}
+
+// ReconciliationRun is one run of reconciliation, with how much it checked, every break it found
+// and any check it could not make
+type ReconciliationRun struct {
+	Id          string
+	RunAt       time.Time
+	Investments int
+	Accounts    int
+	Funds       int
+	Events      int
+	Breaks      []ReconciliationBreak
+	Failed      []ReconciliationCheck `json:",omitempty"`
+}
@@ -0,0 +1,21 @@
+package model
+
+import "github.com/oliknight1/retail-isa-investment/investment-service/money"
+
+// RedemptionOrder is what a customer asks to sell: units worth Amount, a number of Units, or
+// their whole holding if All is set. Exactly one of the three is given
+type RedemptionOrder struct {
+	Amount money.Money
+	Units  Units
+	All    bool
+}
+
+// RedemptionDetails records a sell order. Reserved is how many units are held back for the
+// order until it is dealt, estimated at the latest price when selling by amount. Full is set
+// when the sale emptied the holding
+type RedemptionDetails struct {
+	RedemptionOrder
+	Reserved Units
+	Proceeds money.Money
+	Full     bool
+}
@@ -0,0 +1,64 @@
+package model
+
+import (
+	"slices"
+	"time"
+)
+
+// InvestmentStatus is where an investment is in its lifecycle. A subscription is validated once
+// it has passed the eligibility and allowance checks, dealt once units have been bought at its
+// valuation point and settled once the money has changed hands. Cash movements such as
+// withdrawals are settled without being dealt
+type InvestmentStatus string
+
+const (
+	InvestmentPending   InvestmentStatus = "pending"
+	InvestmentValidated InvestmentStatus = "validated"
+	InvestmentDealt     InvestmentStatus = "dealt"
+	InvestmentSettled   InvestmentStatus = "settled"
+	InvestmentFailed    InvestmentStatus = "failed"
+	InvestmentCancelled InvestmentStatus = "cancelled"
+)
+
+var investmentTransitions = map[InvestmentStatus][]InvestmentStatus{
+	InvestmentPending:   {InvestmentValidated, InvestmentFailed, InvestmentCancelled},
+	InvestmentValidated: {InvestmentDealt, InvestmentSettled, InvestmentFailed, InvestmentCancelled},
+	InvestmentDealt:     {InvestmentSettled, InvestmentFailed, InvestmentCancelled},
+	InvestmentSettled:   {InvestmentCancelled},
+}
+
+// CanTransition reports whether an investment in status s can move to next
+func (s InvestmentStatus) CanTransition(next InvestmentStatus) bool {
+	return slices.Contains(investmentTransitions[s], next)
+}
+
+// Live reports whether an investment in status s still counts towards an account
+func (s InvestmentStatus) Live() bool {
+	return s != InvestmentFailed && s != InvestmentCancelled
+}
+
+// Actors that move investments between statuses, besides operations staff who are recorded by name
+const (
+	ActorCustomer   = "customer"
+	ActorDealingRun = "dealing-run"
+	ActorSystem     = "system"
+)
+
+// InvestmentStatusChange records an investment moving from one status to another. The first
+// change in an investment's history has no From status
+type InvestmentStatusChange struct {
+	From   InvestmentStatus `json:",omitempty"`
+	Status InvestmentStatus
+	Reason string
+	Actor  string
+	At     time.Time
+}
+
+// InvestmentStatusChanged is published every time an investment changes status
+type InvestmentStatusChanged struct {
+	InvestmentId string
+	AccountId    string
+	CustomerId   string
+	Type         InvestmentType
+	InvestmentStatusChange
+}
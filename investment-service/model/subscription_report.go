@@ -0,0 +1,43 @@
+package model
+
+import (
+	"encoding/xml"
+	"time"
+
+	"github.com/oliknight1/retail-isa-investment/investment-service/money"
+	"github.com/oliknight1/retail-isa-investment/investment-service/taxyear"
+)
+
+// SubscriptionReport lists every customer who subscribed during a tax year with their totals
+// per product, which is the data the ISA return to HMRC is prepared from. Subscribers with
+// missing data are left out of the report and listed in Errors so they can be fixed first.
+// The layout is our own and is not the file submitted to HMRC
+type SubscriptionReport struct {
+	XMLName     xml.Name           `xml:"IsaSubscriptionReport"`
+	TaxYear     taxyear.TaxYear    `xml:"TaxYear,attr"`
+	GeneratedAt time.Time          `xml:"GeneratedAt,attr"`
+	Subscribers []ReportSubscriber `xml:"Subscriber"`
+	Errors      []ReportError      `xml:"-"`
+}
+
+type ReportSubscriber struct {
+	CustomerId              string               `xml:"CustomerId"`
+	Name                    string               `xml:"Name"`
+	NationalInsuranceNumber string               `xml:"NINO,omitempty"`
+	DateOfBirth             string               `xml:"DateOfBirth"`
+	Subscriptions           []ReportSubscription `xml:"Subscriptions>Product"`
+	TotalSubscribed         money.Money          `xml:"TotalSubscribed"`
+}
+
+// ReportSubscription is the total a subscriber paid into one product type during the year
+type ReportSubscription struct {
+	ProductType ProductType `xml:"Type,attr"`
+	Amount      money.Money `xml:",chardata"`
+}
+
+// ReportError is a subscriber record that could not go in the report
+type ReportError struct {
+	CustomerId string `json:"customerId"`
+	Field      string `json:"field"`
+	Message    string `json:"message"`
+}
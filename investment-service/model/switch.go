@@ -0,0 +1,54 @@
+package model
+
+import (
+	"slices"
+	"time"
+
+	"github.com/oliknight1/retail-isa-investment/investment-service/money"
+)
+
+type SwitchStatus string
+
+const (
+	SwitchSelling   SwitchStatus = "selling"
+	SwitchBuying    SwitchStatus = "buying"
+	SwitchCompleted SwitchStatus = "completed"
+	SwitchFailed    SwitchStatus = "failed"
+)
+
+var switchTransitions = map[SwitchStatus][]SwitchStatus{
+	SwitchSelling: {SwitchBuying, SwitchFailed},
+	SwitchBuying:  {SwitchCompleted, SwitchFailed},
+}
+
+// CanTransition reports whether a switch in status s can move to next
+func (s SwitchStatus) CanTransition(next SwitchStatus) bool {
+	return slices.Contains(switchTransitions[s], next)
+}
+
+// Switch moves money from one fund to another inside an ISA. Units of FromFundId are sold
+// first, and once the sale is priced its proceeds buy ToFundId at the next valuation point.
+// Neither leg uses allowance. If the purchase cannot be placed once the sale is priced, the
+// switch fails and the proceeds are kept as cash in the account, recorded in Uninvested
+type Switch struct {
+	Id            string
+	AccountId     string
+	CustomerId    string
+	FromFundId    string
+	ToFundId      string
+	Order         RedemptionOrder
+	SellId        string
+	BuyId         *string
+	Status        SwitchStatus
+	History       []SwitchStatusChange
+	FailureReason *string
+	Uninvested    money.Money
+	CreatedAt     time.Time
+	CompletedAt   *time.Time
+}
+
+// SwitchStatusChange records when a switch moved into a status
+type SwitchStatusChange struct {
+	Status SwitchStatus
+	At     time.Time
+}
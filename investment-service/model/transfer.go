@@ -0,0 +1,86 @@
+package model
+
+import (
+	"slices"
+	"time"
+
+	"github.com/oliknight1/retail-isa-investment/investment-service/money"
+	"github.com/oliknight1/retail-isa-investment/investment-service/taxyear"
+)
+
+// TransferDirection is whether an ISA is moving to us from another provider or away from us
+type TransferDirection string
+
+const (
+	TransferIn  TransferDirection = "in"
+	TransferOut TransferDirection = "out"
+)
+
+// TransferMethod is whether the ceding provider sells the holdings and sends cash, or
+// re-registers the stock with the acquiring provider
+type TransferMethod string
+
+const (
+	TransferCash  TransferMethod = "cash"
+	TransferStock TransferMethod = "stock"
+)
+
+type TransferStatus string
+
+const (
+	TransferRequested        TransferStatus = "requested"
+	TransferAwaitingProvider TransferStatus = "awaiting_ceding_provider"
+	TransferReceived         TransferStatus = "received"
+	TransferCompleted        TransferStatus = "completed"
+	TransferRejected         TransferStatus = "rejected"
+)
+
+var transferTransitions = map[TransferStatus][]TransferStatus{
+	TransferRequested:        {TransferAwaitingProvider, TransferRejected},
+	TransferAwaitingProvider: {TransferReceived, TransferRejected},
+	TransferReceived:         {TransferCompleted, TransferRejected},
+}
+
+// CanTransition reports whether a transfer in status s can move to next
+func (s TransferStatus) CanTransition(next TransferStatus) bool {
+	return slices.Contains(transferTransitions[s], next)
+}
+
+// Transfer moves an ISA between us and another provider. Money subscribed in the current tax
+// year is kept apart from money from earlier years, since only the former has used this year's
+// allowance and it must only be counted once. A stock transfer lists the holdings it moves
+type Transfer struct {
+	Id                string
+	AccountId         string
+	CustomerId        string
+	Direction         TransferDirection
+	Method            TransferMethod
+	Provider          string
+	TaxYear           taxyear.TaxYear
+	CurrentYearAmount money.Money
+	PriorYearAmount   money.Money
+	Holdings          []TransferHolding
+	Status            TransferStatus
+	RejectionReason   *string
+	History           []TransferStatusChange
+	CreatedAt         time.Time
+	CompletedAt       *time.Time
+}
+
+// TransferHolding is the units of a fund a stock transfer re-registers. The book cost is carried
+// over from the ceding provider on a transfer in and left empty on a transfer out
+type TransferHolding struct {
+	FundId   string
+	Units    Units
+	BookCost money.Money
+}
+
+// TransferStatusChange records when a transfer moved into a status
+type TransferStatusChange struct {
+	Status TransferStatus
+	At     time.Time
+}
+
+func (t Transfer) Amount() money.Money {
+	return t.CurrentYearAmount.Add(t.PriorYearAmount)
+}
@@ -0,0 +1,210 @@
+package model
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/oliknight1/retail-isa-investment/investment-service/money"
+)
+
+const (
+	// UnitPricePlaces is how many decimal places of a pound fund-service quotes unit prices to
+	UnitPricePlaces = 6
+	// UnitPlaces is how many decimal places units are allocated to
+	UnitPlaces = 4
+
+	unitPriceScale = 1_000_000
+	unitScale      = 10_000
+)
+
+var ErrInvalidDecimal = errors.New("invalid decimal")
+
+// UnitPrice is the price of one unit of a fund in millionths of a pound, mirroring fund-service.
+// It is written to JSON as a decimal string e.g. "1.234567"
+type UnitPrice int64
+
+// Units is a holding of a fund in ten-thousandths of a unit, written to JSON as a decimal string
+// e.g. "812.3456". A movement of units can be negative
+type Units int64
+
+func ParseUnitPrice(s string) (UnitPrice, error) {
+	v, err := parseDecimal(s, UnitPricePlaces)
+	return UnitPrice(v), err
+}
+
+func ParseUnits(s string) (Units, error) {
+	v, err := parseDecimal(s, UnitPlaces)
+	return Units(v), err
+}
+
+func (p UnitPrice) String() string { return formatDecimal(int64(p), UnitPricePlaces) }
+func (u Units) String() string     { return formatDecimal(int64(u), UnitPlaces) }
+
+func (p UnitPrice) MarshalJSON() ([]byte, error) { return json.Marshal(p.String()) }
+func (u Units) MarshalJSON() ([]byte, error)     { return json.Marshal(u.String()) }
+
+func (p *UnitPrice) UnmarshalJSON(data []byte) error {
+	v, err := unmarshalDecimal(data, UnitPricePlaces)
+	*p = UnitPrice(v)
+	return err
+}
+
+func (u *Units) UnmarshalJSON(data []byte) error {
+	v, err := unmarshalDecimal(data, UnitPlaces)
+	*u = Units(v)
+	return err
+}
+
+// AllocateUnits works out how many units amount buys at price. Units are rounded down to
+// UnitPlaces so a customer is never allocated more than they paid for, and the value of the
+// fraction left over is returned as residual cash
+func AllocateUnits(amount money.Money, price UnitPrice) (Units, money.Money) {
+	if price <= 0 {
+		return 0, amount
+	}
+	// units = pence / 100 * unitScale * unitPriceScale / price
+	n := new(big.Int).Mul(big.NewInt(amount.Minor), big.NewInt(unitScale*unitPriceScale/100))
+	n.Quo(n, big.NewInt(int64(price)))
+	units := Units(n.Int64())
+	return units, amount.Sub(units.Value(price))
+}
+
+// UnitsFor works out how many units must be sold at price to raise amount. Units are rounded
+// up to UnitPlaces so the sale raises at least amount
+func UnitsFor(amount money.Money, price UnitPrice) Units {
+	if price <= 0 {
+		return 0
+	}
+	n := new(big.Int).Mul(big.NewInt(amount.Minor), big.NewInt(unitScale*unitPriceScale/100))
+	n.Add(n, big.NewInt(int64(price)-1))
+	n.Quo(n, big.NewInt(int64(price)))
+	return Units(n.Int64())
+}
+
+// PricePerUnit is what one unit cost when units were bought for amount, rounded to the nearest
+// millionth of a pound
+func PricePerUnit(amount money.Money, units Units) UnitPrice {
+	if units <= 0 {
+		return 0
+	}
+	// price = pence / 100 * unitPriceScale * unitScale / units, rounded half up
+	n := new(big.Int).Mul(big.NewInt(amount.Minor), big.NewInt(unitPriceScale*unitScale/100))
+	n.Add(n, big.NewInt(int64(units)/2))
+	n.Div(n, big.NewInt(int64(units)))
+	return UnitPrice(n.Int64())
+}
+
+// Value is what the units are worth at price, rounded to the nearest penny
+func (u Units) Value(price UnitPrice) money.Money {
+	// pence = units * price * 100 / (unitScale * unitPriceScale), rounded half up
+	n := new(big.Int).Mul(big.NewInt(int64(u)), big.NewInt(int64(price)))
+	n.Mul(n, big.NewInt(100))
+	divisor := big.NewInt(unitScale * unitPriceScale)
+	n.Add(n, new(big.Int).Quo(divisor, big.NewInt(2)))
+	n.Div(n, divisor)
+	return money.Pence(n.Int64())
+}
+
+// Fund is a fund's details from fund-service. OngoingCharge is the percentage of the fund's
+// value its manager takes each year through the unit price
+type Fund struct {
+	Id            string    `json:"id"`
+	Name          string    `json:"name"`
+	OngoingCharge float64   `json:"ongoingCharge"`
+	UnitClass     UnitClass `json:"unitClass"`
+}
+
+// FundPrice is a fund's valuation from fund-service. A dual priced fund sells units at its
+// offer price and buys them back at its bid price, a single priced fund uses its NAV for both
+type FundPrice struct {
+	FundId   string     `json:"fundId"`
+	Nav      UnitPrice  `json:"nav"`
+	Bid      *UnitPrice `json:"bid,omitempty"`
+	Offer    *UnitPrice `json:"offer,omitempty"`
+	ValuedAt time.Time  `json:"valuedAt"`
+}
+
+// BuyPrice is what an investor pays for one unit
+func (p FundPrice) BuyPrice() UnitPrice {
+	if p.Offer != nil {
+		return *p.Offer
+	}
+	return p.Nav
+}
+
+// SellPrice is what an investor receives for one unit
+func (p FundPrice) SellPrice() UnitPrice {
+	if p.Bid != nil {
+		return *p.Bid
+	}
+	return p.Nav
+}
+
+// UnitMovement is a change in how many of a fund's units are in issue, mirroring fund-service.
+// Dealing a buy creates units and dealing a sell cancels them, and an order that fails or is
+// cancelled after it was dealt moves them back. Id is unique to the movement
+type UnitMovement struct {
+	Id           string    `json:"id"`
+	FundId       string    `json:"fundId"`
+	InvestmentId string    `json:"investmentId"`
+	Units        Units     `json:"units"`
+	At           time.Time `json:"at"`
+}
+
+// FundUnits is how many of a fund's units fund-service has in issue
+type FundUnits struct {
+	FundId string `json:"fundId"`
+	Units  Units  `json:"units"`
+}
+
+var decimalPattern = regexp.MustCompile(`^-?[0-9]{1,12}(\.[0-9]+)?$`)
+
+func parseDecimal(s string, places int) (int64, error) {
+	negative := strings.HasPrefix(s, "-")
+	whole, fraction, _ := strings.Cut(strings.TrimPrefix(s, "-"), ".")
+	if !decimalPattern.MatchString(s) || len(fraction) > places {
+		return 0, fmt.Errorf("%w: %q must have at most %d decimal places", ErrInvalidDecimal, s, places)
+	}
+	units, err := strconv.ParseInt(whole, 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("%w: %q", ErrInvalidDecimal, s)
+	}
+	fraction += strings.Repeat("0", places-len(fraction))
+	frac, _ := strconv.ParseInt(fraction, 10, 64)
+	scale := int64(1)
+	for range places {
+		scale *= 10
+	}
+	if negative {
+		return -(units*scale + frac), nil
+	}
+	return units*scale + frac, nil
+}
+
+func formatDecimal(v int64, places int) string {
+	sign := ""
+	if v < 0 {
+		sign, v = "-", -v
+	}
+	scale := int64(1)
+	for range places {
+		scale *= 10
+	}
+	return fmt.Sprintf("%s%d.%0*d", sign, v/scale, places, v%scale)
+}
+
+func unmarshalDecimal(data []byte, places int) (int64, error) {
+	value := string(data)
+	if strings.HasPrefix(value, `"`) {
+		if err := json.Unmarshal(data, &value); err != nil {
+			return 0, fmt.Errorf("%w: %v", ErrInvalidDecimal, err)
+		}
+	}
+	return parseDecimal(value, places)
+}
@@ -0,0 +1,93 @@
+package model_test
+
+import (
+	"encoding/json"
+	"errors"
+	"testing"
+
+	"github.com/oliknight1/retail-isa-investment/investment-service/model"
+	"github.com/oliknight1/retail-isa-investment/investment-service/money"
+)
+
+func TestAllocateUnits(t *testing.T) {
+	tests := []struct {
+		amount   money.Money
+		price    string
+		units    string
+		residual money.Money
+	}{
+		{money.Pounds(1000), "2.000000", "500.0000", money.Pence(0)},
+		{money.Pounds(1000), "3.000000", "333.3333", money.Pence(0)},
+		{money.MustParse("0.10"), "7.000000", "0.0142", money.Pence(0)},
+		{money.MustParse("0.05"), "0.060000", "0.8333", money.Pence(0)},
+		{money.MustParse("0.01"), "250.000000", "0.0000", money.Pence(1)},
+	}
+	for _, tt := range tests {
+		price, err := model.ParseUnitPrice(tt.price)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		units, residual := model.AllocateUnits(tt.amount, price)
+		if units.String() != tt.units || !residual.Equal(tt.residual) {
+			t.Errorf("expected %s at %s to buy %s units leaving %s, got %s leaving %s", tt.amount, tt.price, tt.units, tt.residual, units, residual)
+		}
+		if value := units.Value(price).Add(residual); value.GreaterThan(tt.amount) {
+			t.Errorf("expected allocation never to be worth more than %s, got %s", tt.amount, value)
+		}
+	}
+}
+
+func TestUnitsFor(t *testing.T) {
+	tests := []struct {
+		amount money.Money
+		price  string
+		units  string
+	}{
+		{money.Pounds(1000), "2.000000", "500.0000"},
+		{money.Pounds(1000), "3.000000", "333.3334"},
+		{money.MustParse("0.01"), "250.000000", "0.0001"},
+	}
+	for _, tt := range tests {
+		price, err := model.ParseUnitPrice(tt.price)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		units := model.UnitsFor(tt.amount, price)
+		if units.String() != tt.units {
+			t.Errorf("expected %s at %s to need %s units, got %s", tt.amount, tt.price, tt.units, units)
+		}
+		if value := units.Value(price); value.LessThan(tt.amount) {
+			t.Errorf("expected sale to raise at least %s, got %s", tt.amount, value)
+		}
+	}
+}
+
+func TestPricePerUnit(t *testing.T) {
+	units, _ := model.ParseUnits("333.3333")
+	if actual := model.PricePerUnit(money.Pounds(1000), units); actual.String() != "3.000000" {
+		t.Errorf("expected 3.000000 per unit, got %s", actual)
+	}
+	if actual := model.PricePerUnit(money.Pounds(1000), 0); actual != 0 {
+		t.Errorf("expected no price for no units, got %s", actual)
+	}
+}
+
+func TestUnitsJSON(t *testing.T) {
+	data, err := json.Marshal(model.Dealing{Price: 1_234_567, Units: 81_000_000})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	var decoded struct {
+		Price string
+		Units string
+	}
+	json.Unmarshal(data, &decoded)
+	if decoded.Price != "1.234567" || decoded.Units != "8100.0000" {
+		t.Errorf("expected decimal strings, got %s", data)
+	}
+
+	var units model.Units
+	if err := json.Unmarshal([]byte(`"1.23456"`), &units); !errors.Is(err, model.ErrInvalidDecimal) {
+		t.Errorf("expected units with 5 decimal places to be rejected, got: %v", err)
+	}
+}
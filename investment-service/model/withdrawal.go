@@ -0,0 +1,31 @@
+package model
+
+import "github.com/oliknight1/retail-isa-investment/investment-service/money"
+
+// WithdrawalReason is why money is being taken out of an ISA, which decides whether a
+// withdrawal charge applies
+type WithdrawalReason string
+
+const (
+	WithdrawalFirstHome       WithdrawalReason = "first_home"
+	WithdrawalAgeSixty        WithdrawalReason = "age_60"
+	WithdrawalTerminalIllness WithdrawalReason = "terminal_illness"
+	WithdrawalOther           WithdrawalReason = "other"
+)
+
+// Valid reports whether r is one of the known withdrawal reasons
+func (r WithdrawalReason) Valid() bool {
+	switch r {
+	case WithdrawalFirstHome, WithdrawalAgeSixty, WithdrawalTerminalIllness, WithdrawalOther:
+		return true
+	}
+	return false
+}
+
+// WithdrawalDetails is stored on a withdrawal investment. NetAmount is what is paid out to
+// the customer once any withdrawal charge has been taken
+type WithdrawalDetails struct {
+	Reason    WithdrawalReason
+	Charge    money.Money
+	NetAmount money.Money
+}
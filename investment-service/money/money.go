@@ -0,0 +1,228 @@
+// Package money holds exact monetary amounts as a whole number of minor units, such as pence,
+// so that sums of subscriptions, charges and fees never pick up floating point rounding errors.
+//
+// On the wire an amount is a JSON object with the value as a decimal string and an ISO 4217
+// currency code:
+//
+//	{"value": "1250.50", "currency": "GBP"}
+//
+// When decoding, a bare JSON number or string such as 1250.5 or "1250.50" is also accepted and
+// read as GBP. Values may have at most two decimal places and only GBP is supported, anything
+// else is rejected rather than rounded or converted.
+package money
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+type Currency string
+
+const GBP Currency = "GBP"
+
+// supported is every currency amounts can be held in
+var supported = map[Currency]bool{
+	GBP: true,
+}
+
+var (
+	ErrInvalidAmount       = errors.New("amount must be a decimal with at most two decimal places")
+	ErrUnsupportedCurrency = errors.New("unsupported currency")
+)
+
+// decimal is an optional minus sign, up to 15 digits and up to two decimal places
+var decimal = regexp.MustCompile(`^-?[0-9]{1,15}(\.[0-9]{1,2})?$`)
+
+// Money is an amount in minor units of a currency. The zero value is zero pounds, and a blank
+// currency is treated as GBP
+type Money struct {
+	Minor    int64
+	Currency Currency
+}
+
+// Pence returns an amount of GBP in pence
+func Pence(p int64) Money {
+	return Money{Minor: p, Currency: GBP}
+}
+
+// Pounds returns a whole number of pounds
+func Pounds(p int64) Money {
+	return Pence(p * 100)
+}
+
+// Parse reads a decimal amount such as "1250.50" in the given currency
+func Parse(s string, currency Currency) (Money, error) {
+	if currency == "" {
+		currency = GBP
+	}
+	if !supported[currency] {
+		return Money{}, fmt.Errorf("%w: %s", ErrUnsupportedCurrency, currency)
+	}
+	if !decimal.MatchString(s) {
+		return Money{}, fmt.Errorf("%w: %q", ErrInvalidAmount, s)
+	}
+
+	negative := strings.HasPrefix(s, "-")
+	whole, fraction, _ := strings.Cut(strings.TrimPrefix(s, "-"), ".")
+	units, err := strconv.ParseInt(whole, 10, 64)
+	if err != nil {
+		return Money{}, fmt.Errorf("%w: %q", ErrInvalidAmount, s)
+	}
+	minor := units * 100
+	if fraction != "" {
+		f, _ := strconv.ParseInt((fraction + "0")[:2], 10, 64)
+		minor += f
+	}
+	if negative {
+		minor = -minor
+	}
+	return Money{Minor: minor, Currency: currency}, nil
+}
+
+// MustParse is Parse for amounts known to be valid, such as constants
+func MustParse(s string) Money {
+	m, err := Parse(s, GBP)
+	if err != nil {
+		panic(err)
+	}
+	return m
+}
+
+func (m Money) currency() Currency {
+	if m.Currency == "" {
+		return GBP
+	}
+	return m.Currency
+}
+
+// same panics if m and o are in different currencies, since adding or comparing them is a bug
+func (m Money) same(o Money) Currency {
+	if m.currency() != o.currency() {
+		panic(fmt.Sprintf("money: mismatched currencies %s and %s", m.currency(), o.currency()))
+	}
+	return m.currency()
+}
+
+func (m Money) Add(o Money) Money {
+	return Money{Minor: m.Minor + o.Minor, Currency: m.same(o)}
+}
+
+func (m Money) Sub(o Money) Money {
+	return Money{Minor: m.Minor - o.Minor, Currency: m.same(o)}
+}
+
+func (m Money) Neg() Money {
+	return Money{Minor: -m.Minor, Currency: m.currency()}
+}
+
+// MulRate multiplies m by a rate such as 0.25, rounding half away from zero to the nearest
+// minor unit
+func (m Money) MulRate(rate float64) Money {
+	return Money{Minor: int64(math.Round(float64(m.Minor) * rate)), Currency: m.currency()}
+}
+
+// Cmp returns -1, 0 or +1 as m is less than, equal to or greater than o
+func (m Money) Cmp(o Money) int {
+	m.same(o)
+	switch {
+	case m.Minor < o.Minor:
+		return -1
+	case m.Minor > o.Minor:
+		return 1
+	}
+	return 0
+}
+
+// Equal reports whether m and o are the same amount of the same currency
+func (m Money) Equal(o Money) bool {
+	return m.Minor == o.Minor && m.currency() == o.currency()
+}
+
+func (m Money) LessThan(o Money) bool    { return m.Cmp(o) < 0 }
+func (m Money) GreaterThan(o Money) bool { return m.Cmp(o) > 0 }
+func (m Money) IsZero() bool             { return m.Minor == 0 }
+func (m Money) IsPositive() bool         { return m.Minor > 0 }
+func (m Money) IsNegative() bool         { return m.Minor < 0 }
+
+func Min(a, b Money) Money {
+	if a.LessThan(b) {
+		return a
+	}
+	return b
+}
+
+func Max(a, b Money) Money {
+	if a.GreaterThan(b) {
+		return a
+	}
+	return b
+}
+
+// Float64 is the amount in major units, only for places that need an approximate number
+// such as metrics or performance ratios
+func (m Money) Float64() float64 {
+	return float64(m.Minor) / 100
+}
+
+// String formats the value with two decimal places e.g. "1250.50"
+func (m Money) String() string {
+	minor := m.Minor
+	sign := ""
+	if minor < 0 {
+		sign = "-"
+		minor = -minor
+	}
+	return fmt.Sprintf("%s%d.%02d", sign, minor/100, minor%100)
+}
+
+// MarshalText writes the value alone, for formats like XML where the currency is implied
+func (m Money) MarshalText() ([]byte, error) {
+	return []byte(m.String()), nil
+}
+
+type wire struct {
+	Value    json.RawMessage `json:"value"`
+	Currency Currency        `json:"currency"`
+}
+
+func (m Money) MarshalJSON() ([]byte, error) {
+	value, _ := json.Marshal(m.String())
+	return json.Marshal(wire{Value: value, Currency: m.currency()})
+}
+
+func (m *Money) UnmarshalJSON(data []byte) error {
+	data = bytes.TrimSpace(data)
+	if string(data) == "null" {
+		return nil
+	}
+
+	w := wire{Value: data}
+	if len(data) > 0 && data[0] == '{' {
+		w = wire{}
+		dec := json.NewDecoder(bytes.NewReader(data))
+		dec.DisallowUnknownFields()
+		if err := dec.Decode(&w); err != nil {
+			return fmt.Errorf("%w: %v", ErrInvalidAmount, err)
+		}
+	}
+
+	// a number is read from its literal text rather than through a float64, so 0.1 stays exact
+	value := string(w.Value)
+	if len(w.Value) > 0 && w.Value[0] == '"' {
+		if err := json.Unmarshal(w.Value, &value); err != nil {
+			return fmt.Errorf("%w: %v", ErrInvalidAmount, err)
+		}
+	}
+	parsed, err := Parse(value, w.Currency)
+	if err != nil {
+		return err
+	}
+	*m = parsed
+	return nil
+}
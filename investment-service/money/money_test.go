@@ -0,0 +1,138 @@
+package money_test
+
+import (
+	"encoding/json"
+	"errors"
+	"testing"
+
+	"github.com/oliknight1/retail-isa-investment/investment-service/money"
+)
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected money.Money
+	}{
+		{"0", money.Pence(0)},
+		{"100", money.Pounds(100)},
+		{"1250.5", money.Pence(125050)},
+		{"1250.50", money.Pence(125050)},
+		{"0.01", money.Pence(1)},
+		{"-3.07", money.Pence(-307)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			actual, err := money.Parse(tt.input, money.GBP)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if !actual.Equal(tt.expected) {
+				t.Errorf("expected %s, got %s", tt.expected, actual)
+			}
+		})
+	}
+}
+
+func TestParseInvalid(t *testing.T) {
+	for _, input := range []string{"", "1.005", "1e3", "1,000", ".50", "12.", "abc"} {
+		if _, err := money.Parse(input, money.GBP); !errors.Is(err, money.ErrInvalidAmount) {
+			t.Errorf("expected invalid amount error for %q, got: %v", input, err)
+		}
+	}
+	if _, err := money.Parse("1.00", "USD"); !errors.Is(err, money.ErrUnsupportedCurrency) {
+		t.Errorf("expected unsupported currency error, got: %v", err)
+	}
+}
+
+func TestMarshalJSON(t *testing.T) {
+	data, err := json.Marshal(money.Pence(125050))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if string(data) != `{"value":"1250.50","currency":"GBP"}` {
+		t.Errorf("unexpected JSON: %s", data)
+	}
+
+	data, _ = json.Marshal(money.Money{})
+	if string(data) != `{"value":"0.00","currency":"GBP"}` {
+		t.Errorf("expected zero value to marshal as GBP, got: %s", data)
+	}
+}
+
+func TestUnmarshalJSON(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected money.Money
+	}{
+		{`{"value":"1250.50","currency":"GBP"}`, money.Pence(125050)},
+		{`{"value":"0.10"}`, money.Pence(10)},
+		{`1250.5`, money.Pence(125050)},
+		{`0.1`, money.Pence(10)},
+		{`"99.99"`, money.Pence(9999)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			var actual money.Money
+			if err := json.Unmarshal([]byte(tt.input), &actual); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if !actual.Equal(tt.expected) {
+				t.Errorf("expected %s, got %s", tt.expected, actual)
+			}
+		})
+	}
+}
+
+func TestUnmarshalJSONStrict(t *testing.T) {
+	tests := []struct {
+		input       string
+		expectedErr error
+	}{
+		{`100.005`, money.ErrInvalidAmount},
+		{`1e2`, money.ErrInvalidAmount},
+		{`{"value":"1.001","currency":"GBP"}`, money.ErrInvalidAmount},
+		{`{"value":"10.00","currency":"GBP","rate":1}`, money.ErrInvalidAmount},
+		{`true`, money.ErrInvalidAmount},
+		{`{"value":"10.00","currency":"USD"}`, money.ErrUnsupportedCurrency},
+	}
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			var actual money.Money
+			if err := json.Unmarshal([]byte(tt.input), &actual); !errors.Is(err, tt.expectedErr) {
+				t.Errorf("expected %v, got: %v", tt.expectedErr, err)
+			}
+		})
+	}
+}
+
+func TestMulRate(t *testing.T) {
+	tests := []struct {
+		amount   money.Money
+		rate     float64
+		expected money.Money
+	}{
+		{money.Pounds(4000), 0.25, money.Pounds(1000)},
+		{money.MustParse("333.33"), 0.25, money.MustParse("83.33")},
+		{money.MustParse("0.02"), 0.25, money.MustParse("0.01")},
+		{money.MustParse("0.06"), 0.25, money.MustParse("0.02")},
+	}
+	for _, tt := range tests {
+		if actual := tt.amount.MulRate(tt.rate); !actual.Equal(tt.expected) {
+			t.Errorf("expected %s * %v to be %s, got %s", tt.amount, tt.rate, tt.expected, actual)
+		}
+	}
+}
+
+func TestArithmetic(t *testing.T) {
+	// 0.1 + 0.2 is the classic case float64 gets wrong
+	sum := money.MustParse("0.10").Add(money.MustParse("0.20"))
+	if !sum.Equal(money.MustParse("0.30")) {
+		t.Errorf("expected 0.30, got %s", sum)
+	}
+	if diff := money.Pounds(5).Sub(money.Pounds(7)); diff.String() != "-2.00" {
+		t.Errorf("expected -2.00, got %s", diff)
+	}
+	if !money.Min(money.Pounds(1), money.Pounds(2)).Equal(money.Pounds(1)) || !money.Max(money.Pounds(1), money.Pounds(2)).Equal(money.Pounds(2)) {
+		t.Errorf("unexpected min or max")
+	}
+}
@@ -0,0 +1,132 @@
+// Package product holds the rules that differ between the ISA product types
+package product
+
+import (
+	"fmt"
+	"slices"
+
+	"github.com/oliknight1/retail-isa-investment/investment-service/internal"
+	"github.com/oliknight1/retail-isa-investment/investment-service/model"
+	"github.com/oliknight1/retail-isa-investment/investment-service/money"
+)
+
+type Rules struct {
+	Type model.ProductType
+	// SubscriptionLimit caps subscriptions into the product each tax year. Zero means only the
+	// overall ISA allowance applies
+	SubscriptionLimit money.Money
+	// CountsTowardsAllowance is false for products with an allowance of their own, separate from
+	// the overall adult ISA allowance
+	CountsTowardsAllowance bool
+	// Flexible products let money withdrawn during a tax year be paid back in that year
+	// without using any more allowance
+	Flexible           bool
+	WithdrawalsAllowed bool
+	// MinAge and MaxOpeningAge bound the age of the customer when the account is opened.
+	// A MaxOpeningAge of zero means there is no upper limit
+	MinAge        int
+	MaxOpeningAge int
+	// MaxSubscriptionAge is the oldest a customer can be and still subscribe. Zero means no limit
+	MaxSubscriptionAge int
+	// EligibleFunds restricts which funds can be held. A nil list allows any fund
+	EligibleFunds []string
+	// BonusRate is the government bonus claimed on each subscription, as a fraction of it
+	BonusRate float64
+	// WithdrawalChargeRate is charged on any withdrawal made for a reason not in AuthorisedWithdrawals
+	WithdrawalChargeRate  float64
+	AuthorisedWithdrawals []model.WithdrawalReason
+}
+
+var rules = map[model.ProductType]Rules{
+	model.StocksAndSharesISA: {
+		Type:                   model.StocksAndSharesISA,
+		CountsTowardsAllowance: true,
+		Flexible:               true,
+		WithdrawalsAllowed:     true,
+		MinAge:                 18,
+	},
+	// NOTE: a cash ISA holds deposits rather than funds, so no fund is eligible
+	model.CashISA: {
+		Type:                   model.CashISA,
+		CountsTowardsAllowance: true,
+		Flexible:               true,
+		WithdrawalsAllowed:     true,
+		MinAge:                 18,
+		EligibleFunds:          []string{},
+	},
+	model.LifetimeISA: {
+		Type:                   model.LifetimeISA,
+		SubscriptionLimit:      money.Pounds(4000),
+		CountsTowardsAllowance: true,
+		WithdrawalsAllowed:     true,
+		MinAge:                 18,
+		MaxOpeningAge:          39,
+		MaxSubscriptionAge:     49,
+		BonusRate:              0.25,
+		WithdrawalChargeRate:   0.25,
+		AuthorisedWithdrawals: []model.WithdrawalReason{
+			model.WithdrawalFirstHome,
+			model.WithdrawalAgeSixty,
+			model.WithdrawalTerminalIllness,
+		},
+	},
+	// NOTE: a Junior ISA is converted when customer-service reports the child has turned 18, so
+	// subscriptions are refused from then on in case that event has not arrived yet
+	model.JuniorISA: {
+		Type:               model.JuniorISA,
+		SubscriptionLimit:  money.Pounds(9000),
+		MinAge:             0,
+		MaxOpeningAge:      17,
+		MaxSubscriptionAge: 17,
+	},
+}
+
+// For returns the rules for a product type
+func For(productType model.ProductType) (Rules, error) {
+	r, ok := rules[productType]
+	if !ok {
+		return Rules{}, fmt.Errorf("%w: %s", internal.ErrInvalidProductType, productType)
+	}
+	return r, nil
+}
+
+func (r Rules) FundEligible(fundId string) bool {
+	if r.EligibleFunds == nil {
+		return true
+	}
+	return slices.Contains(r.EligibleFunds, fundId)
+}
+
+func (r Rules) CheckOpeningAge(age int) error {
+	if age < r.MinAge || (r.MaxOpeningAge > 0 && age > r.MaxOpeningAge) {
+		return fmt.Errorf("%w: %s ISA requires age %s, customer is %d", internal.ErrIneligibleAge, r.Type, r.ageRange(), age)
+	}
+	return nil
+}
+
+func (r Rules) CheckSubscriptionAge(age int) error {
+	if r.MaxSubscriptionAge > 0 && age > r.MaxSubscriptionAge {
+		return fmt.Errorf("%w: %s ISA subscriptions stop after age %d, customer is %d", internal.ErrIneligibleAge, r.Type, r.MaxSubscriptionAge, age)
+	}
+	return nil
+}
+
+// Bonus is the government bonus due on a subscription, rounded to the nearest penny
+func (r Rules) Bonus(amount money.Money) money.Money {
+	return amount.MulRate(r.BonusRate)
+}
+
+// WithdrawalCharge is the charge deducted from a withdrawal, rounded to the nearest penny
+func (r Rules) WithdrawalCharge(amount money.Money, reason model.WithdrawalReason) money.Money {
+	if slices.Contains(r.AuthorisedWithdrawals, reason) {
+		return money.Money{}
+	}
+	return amount.MulRate(r.WithdrawalChargeRate)
+}
+
+func (r Rules) ageRange() string {
+	if r.MaxOpeningAge == 0 {
+		return fmt.Sprintf("%d or over", r.MinAge)
+	}
+	return fmt.Sprintf("%d to %d", r.MinAge, r.MaxOpeningAge)
+}
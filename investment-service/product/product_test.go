@@ -0,0 +1,96 @@
+package product_test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/oliknight1/retail-isa-investment/investment-service/internal"
+	"github.com/oliknight1/retail-isa-investment/investment-service/model"
+	"github.com/oliknight1/retail-isa-investment/investment-service/money"
+	"github.com/oliknight1/retail-isa-investment/investment-service/product"
+)
+
+func TestFundEligible(t *testing.T) {
+	stocks, _ := product.For(model.StocksAndSharesISA)
+	cash, _ := product.For(model.CashISA)
+
+	if !stocks.FundEligible("fund-ftse-100") {
+		t.Error("expected stocks and shares ISA to allow any fund")
+	}
+	if cash.FundEligible("fund-ftse-100") {
+		t.Error("expected cash ISA not to allow funds")
+	}
+}
+
+func TestCheckOpeningAge(t *testing.T) {
+	tests := []struct {
+		productType model.ProductType
+		age         int
+		expectErr   bool
+	}{
+		{productType: model.StocksAndSharesISA, age: 17, expectErr: true},
+		{productType: model.StocksAndSharesISA, age: 80, expectErr: false},
+		{productType: model.LifetimeISA, age: 18, expectErr: false},
+		{productType: model.LifetimeISA, age: 39, expectErr: false},
+		{productType: model.LifetimeISA, age: 40, expectErr: true},
+		{productType: model.JuniorISA, age: 0, expectErr: false},
+		{productType: model.JuniorISA, age: 18, expectErr: true},
+	}
+
+	for _, tt := range tests {
+		rules, err := product.For(tt.productType)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		err = rules.CheckOpeningAge(tt.age)
+		if tt.expectErr && !errors.Is(err, internal.ErrIneligibleAge) {
+			t.Errorf("%s at age %d: expected ineligible age error, got %v", tt.productType, tt.age, err)
+		}
+		if !tt.expectErr && err != nil {
+			t.Errorf("%s at age %d: unexpected error: %v", tt.productType, tt.age, err)
+		}
+	}
+}
+
+func TestForUnknownProduct(t *testing.T) {
+	if _, err := product.For("pension"); !errors.Is(err, internal.ErrInvalidProductType) {
+		t.Errorf("expected invalid product type error, got %v", err)
+	}
+}
+
+func TestLifetimeISABonusAndWithdrawalCharge(t *testing.T) {
+	lifetime, _ := product.For(model.LifetimeISA)
+	stocks, _ := product.For(model.StocksAndSharesISA)
+
+	if bonus := lifetime.Bonus(money.Pounds(4000)); !bonus.Equal(money.Pounds(1000)) {
+		t.Errorf("expected bonus of 1000 on 4000, got %s", bonus)
+	}
+	if bonus := stocks.Bonus(money.Pounds(4000)); !bonus.Equal(money.Pounds(0)) {
+		t.Errorf("expected no bonus on a stocks and shares ISA, got %s", bonus)
+	}
+	if charge := lifetime.WithdrawalCharge(money.Pounds(1000), model.WithdrawalOther); !charge.Equal(money.Pounds(250)) {
+		t.Errorf("expected charge of 250 on an unauthorised withdrawal, got %s", charge)
+	}
+	if charge := lifetime.WithdrawalCharge(money.Pounds(1000), model.WithdrawalFirstHome); !charge.Equal(money.Pounds(0)) {
+		t.Errorf("expected no charge on a first home withdrawal, got %s", charge)
+	}
+	if charge := stocks.WithdrawalCharge(money.Pounds(1000), model.WithdrawalOther); !charge.Equal(money.Pounds(0)) {
+		t.Errorf("expected no charge on a stocks and shares ISA, got %s", charge)
+	}
+}
+
+func TestCheckSubscriptionAge(t *testing.T) {
+	lifetime, _ := product.For(model.LifetimeISA)
+
+	if err := lifetime.CheckSubscriptionAge(49); err != nil {
+		t.Errorf("unexpected error at 49: %v", err)
+	}
+	if err := lifetime.CheckSubscriptionAge(50); !errors.Is(err, internal.ErrIneligibleAge) {
+		t.Errorf("expected ineligible age error at 50, got %v", err)
+	}
+
+	junior, _ := product.For(model.JuniorISA)
+	if err := junior.CheckSubscriptionAge(18); !errors.Is(err, internal.ErrIneligibleAge) {
+		t.Errorf("expected ineligible age error at 18, got %v", err)
+	}
+}
@@ -0,0 +1,69 @@
+package repository
+
+import (
+	"sync"
+
+	"github.com/oliknight1/retail-isa-investment/investment-service/internal"
+	"github.com/oliknight1/retail-isa-investment/investment-service/model"
+)
+
+type AccountRepository interface {
+	CreateAccount(account model.Account) error
+	UpdateAccount(account model.Account) error
+	GetAccountById(id string) (*model.Account, error)
+	GetAccountsByCustomerId(id string) (*[]model.Account, error)
+}
+
+type AccountClient struct {
+	Accounts map[string]model.Account
+	mu       sync.Mutex
+}
+
+func NewAccountClient() *AccountClient {
+	return &AccountClient{
+		Accounts: make(map[string]model.Account),
+	}
+}
+
+func (c *AccountClient) CreateAccount(account model.Account) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.Accounts[account.Id] = account
+	return nil
+}
+
+func (c *AccountClient) UpdateAccount(account model.Account) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if _, ok := c.Accounts[account.Id]; !ok {
+		return internal.AccountNotFoundError(account.Id)
+	}
+	c.Accounts[account.Id] = account
+	return nil
+}
+
+func (c *AccountClient) GetAccountById(id string) (*model.Account, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	account, ok := c.Accounts[id]
+	if !ok {
+		return nil, internal.AccountNotFoundError(id)
+	}
+	return &account, nil
+}
+
+func (c *AccountClient) GetAccountsByCustomerId(id string) (*[]model.Account, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	foundAccounts := []model.Account{}
+	for _, account := range c.Accounts {
+		if account.CustomerId == id {
+			foundAccounts = append(foundAccounts, account)
+		}
+	}
+	return &foundAccounts, nil
+}
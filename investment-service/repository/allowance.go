@@ -0,0 +1,94 @@
+package repository
+
+import (
+	"fmt"
+	"maps"
+	"sync"
+
+	"github.com/oliknight1/retail-isa-investment/investment-service/model"
+	"github.com/oliknight1/retail-isa-investment/investment-service/taxyear"
+)
+
+type AllowanceRepository interface {
+	GetUsage(customerId string, taxYear taxyear.TaxYear) (*model.AllowanceUsage, error)
+	UpdateUsage(customerId string, taxYear taxyear.TaxYear, update func(usage *model.AllowanceUsage) error) error
+	CloseTaxYear(taxYear taxyear.TaxYear) ([]model.AllowanceUsage, error)
+}
+
+type allowanceKey struct {
+	customerId string
+	taxYear    taxyear.TaxYear
+}
+
+type AllowanceClient struct {
+	usage  map[allowanceKey]model.AllowanceUsage
+	closed map[taxyear.TaxYear]bool
+	mu     sync.Mutex
+}
+
+func NewAllowanceClient() *AllowanceClient {
+	return &AllowanceClient{
+		usage:  make(map[allowanceKey]model.AllowanceUsage),
+		closed: make(map[taxyear.TaxYear]bool),
+	}
+}
+
+func (c *AllowanceClient) GetUsage(customerId string, taxYear taxyear.TaxYear) (*model.AllowanceUsage, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	usage := c.load(customerId, taxYear)
+	return &usage, nil
+}
+
+// UpdateUsage applies update to the customer's usage while holding the lock, so checks against
+// the allowance and the change to it happen atomically. If update returns an error nothing is saved
+func (c *AllowanceClient) UpdateUsage(customerId string, taxYear taxyear.TaxYear, update func(usage *model.AllowanceUsage) error) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.closed[taxYear] {
+		return fmt.Errorf("tax year %s is closed", taxYear)
+	}
+
+	usage := c.load(customerId, taxYear)
+	if err := update(&usage); err != nil {
+		return err
+	}
+	c.usage[allowanceKey{customerId, taxYear}] = usage
+	return nil
+}
+
+// CloseTaxYear resets every counter for the tax year, returning their final values.
+// Once closed a tax year can no longer be updated
+func (c *AllowanceClient) CloseTaxYear(taxYear taxyear.TaxYear) ([]model.AllowanceUsage, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	var final []model.AllowanceUsage
+	for key, usage := range c.usage {
+		if key.taxYear == taxYear {
+			final = append(final, usage)
+			delete(c.usage, key)
+		}
+	}
+	c.closed[taxYear] = true
+	return final, nil
+}
+
+func (c *AllowanceClient) load(customerId string, taxYear taxyear.TaxYear) model.AllowanceUsage {
+	usage, ok := c.usage[allowanceKey{customerId, taxYear}]
+	if !ok {
+		usage = model.AllowanceUsage{CustomerId: customerId, TaxYear: taxYear}
+	}
+	// copy so a rejected update cannot leak into the stored map
+	usage.Products = maps.Clone(usage.Products)
+	if usage.Products == nil {
+		usage.Products = make(map[model.ProductType]model.ProductUsage)
+	}
+	usage.TransfersIn = maps.Clone(usage.TransfersIn)
+	if usage.TransfersIn == nil {
+		usage.TransfersIn = make(map[string]bool)
+	}
+	return usage
+}
@@ -0,0 +1,74 @@
+package repository_test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/oliknight1/retail-isa-investment/investment-service/model"
+	"github.com/oliknight1/retail-isa-investment/investment-service/money"
+	"github.com/oliknight1/retail-isa-investment/investment-service/repository"
+)
+
+func TestUpdateUsageDiscardsChangesOnError(t *testing.T) {
+	db := repository.NewAllowanceClient()
+
+	err := db.UpdateUsage("cust-1", 2025, func(usage *model.AllowanceUsage) error {
+		usage.Subscribed = usage.Subscribed.Add(money.Pounds(100))
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	err = db.UpdateUsage("cust-1", 2025, func(usage *model.AllowanceUsage) error {
+		usage.Subscribed = usage.Subscribed.Add(money.Pounds(500))
+		return errors.New("rejected")
+	})
+	if err == nil {
+		t.Fatal("expected error, got nil")
+	}
+
+	usage, err := db.GetUsage("cust-1", 2025)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !usage.Subscribed.Equal(money.Pounds(100)) {
+		t.Errorf("expected subscribed 100, got %s", usage.Subscribed)
+	}
+}
+
+func TestCloseTaxYear(t *testing.T) {
+	db := repository.NewAllowanceClient()
+	for _, customerId := range []string{"cust-1", "cust-2"} {
+		err := db.UpdateUsage(customerId, 2025, func(usage *model.AllowanceUsage) error {
+			usage.Subscribed = usage.Subscribed.Add(money.Pounds(1000))
+			return nil
+		})
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}
+
+	final, err := db.CloseTaxYear(2025)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(final) != 2 {
+		t.Fatalf("expected 2 closed usage records, got %d", len(final))
+	}
+
+	usage, err := db.GetUsage("cust-1", 2025)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !usage.Subscribed.Equal(money.Pounds(0)) {
+		t.Errorf("expected counter to be reset, got %s", usage.Subscribed)
+	}
+
+	err = db.UpdateUsage("cust-1", 2025, func(usage *model.AllowanceUsage) error {
+		return nil
+	})
+	if err == nil {
+		t.Error("expected error updating a closed tax year, got nil")
+	}
+}
@@ -0,0 +1,71 @@
+package repository
+
+import (
+	"fmt"
+	"sync"
+
+	"github.com/oliknight1/retail-isa-investment/investment-service/model"
+)
+
+type BonusRepository interface {
+	CreateClaim(claim model.BonusClaim) error
+	UpdateClaim(claim model.BonusClaim) error
+	GetClaimsByPeriod(period string) (*[]model.BonusClaim, error)
+	GetClaimByInvestmentId(id string) (*model.BonusClaim, error)
+}
+
+type BonusClient struct {
+	Claims map[string]model.BonusClaim
+	mu     sync.Mutex
+}
+
+func NewBonusClient() *BonusClient {
+	return &BonusClient{
+		Claims: make(map[string]model.BonusClaim),
+	}
+}
+
+func (c *BonusClient) CreateClaim(claim model.BonusClaim) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.Claims[claim.Id] = claim
+	return nil
+}
+
+func (c *BonusClient) UpdateClaim(claim model.BonusClaim) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if _, ok := c.Claims[claim.Id]; !ok {
+		return fmt.Errorf("bonus claim with id %s not found", claim.Id)
+	}
+	c.Claims[claim.Id] = claim
+	return nil
+}
+
+func (c *BonusClient) GetClaimsByPeriod(period string) (*[]model.BonusClaim, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	foundClaims := []model.BonusClaim{}
+	for _, claim := range c.Claims {
+		if claim.ClaimPeriod == period {
+			foundClaims = append(foundClaims, claim)
+		}
+	}
+	return &foundClaims, nil
+}
+
+// GetClaimByInvestmentId returns nil when the investment has no bonus claim
+func (c *BonusClient) GetClaimByInvestmentId(id string) (*model.BonusClaim, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	for _, claim := range c.Claims {
+		if claim.InvestmentId == id {
+			return &claim, nil
+		}
+	}
+	return nil, nil
+}
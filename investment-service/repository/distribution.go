@@ -0,0 +1,77 @@
+package repository
+
+import (
+	"sync"
+
+	"github.com/oliknight1/retail-isa-investment/investment-service/model"
+)
+
+type DistributionRepository interface {
+	AddPaidDistribution(distribution model.Distribution) error
+	IsPaid(distributionId string) (bool, error)
+	CreatePayment(payment model.DistributionPayment) error
+	GetPayment(distributionId, accountId string) (*model.DistributionPayment, error)
+	GetPaymentsByCustomerId(id string) (*[]model.DistributionPayment, error)
+}
+
+type DistributionClient struct {
+	Paid     map[string]model.Distribution
+	Payments []model.DistributionPayment
+	mu       sync.Mutex
+}
+
+func NewDistributionClient() *DistributionClient {
+	return &DistributionClient{
+		Paid: make(map[string]model.Distribution),
+	}
+}
+
+func (c *DistributionClient) AddPaidDistribution(distribution model.Distribution) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.Paid[distribution.Id] = distribution
+	return nil
+}
+
+func (c *DistributionClient) IsPaid(distributionId string) (bool, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	_, ok := c.Paid[distributionId]
+	return ok, nil
+}
+
+func (c *DistributionClient) CreatePayment(payment model.DistributionPayment) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.Payments = append(c.Payments, payment)
+	return nil
+}
+
+// GetPayment returns the payment of a distribution to an account, or nil if it has not been paid
+func (c *DistributionClient) GetPayment(distributionId, accountId string) (*model.DistributionPayment, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	for _, payment := range c.Payments {
+		if payment.DistributionId == distributionId && payment.AccountId == accountId {
+			return &payment, nil
+		}
+	}
+	return nil, nil
+}
+
+func (c *DistributionClient) GetPaymentsByCustomerId(id string) (*[]model.DistributionPayment, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	foundPayments := []model.DistributionPayment{}
+	for _, payment := range c.Payments {
+		if payment.CustomerId == id {
+			foundPayments = append(foundPayments, payment)
+		}
+	}
+	return &foundPayments, nil
+}
@@ -0,0 +1,103 @@
+package repository
+
+import (
+	"sync"
+
+	"github.com/oliknight1/retail-isa-investment/investment-service/internal"
+	"github.com/oliknight1/retail-isa-investment/investment-service/model"
+)
+
+type FeeRepository interface {
+	AddAccrual(accrual model.FeeAccrual) error
+	GetAccrualsByAccountId(id string) (*[]model.FeeAccrual, error)
+	GetAccruedAccountIds() (*[]string, error)
+	CreateCharge(charge model.FeeCharge) error
+	UpdateCharge(charge model.FeeCharge) error
+	GetChargesByAccountId(id string) (*[]model.FeeCharge, error)
+	GetChargesByCustomerId(id string) (*[]model.FeeCharge, error)
+	GetChargesByStatus(status model.FeeChargeStatus) (*[]model.FeeCharge, error)
+}
+
+type FeeClient struct {
+	Accruals map[string][]model.FeeAccrual
+	Charges  map[string]model.FeeCharge
+	mu       sync.Mutex
+}
+
+func NewFeeClient() *FeeClient {
+	return &FeeClient{
+		Accruals: make(map[string][]model.FeeAccrual),
+		Charges:  make(map[string]model.FeeCharge),
+	}
+}
+
+func (c *FeeClient) AddAccrual(accrual model.FeeAccrual) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.Accruals[accrual.AccountId] = append(c.Accruals[accrual.AccountId], accrual)
+	return nil
+}
+
+func (c *FeeClient) GetAccrualsByAccountId(id string) (*[]model.FeeAccrual, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	foundAccruals := append([]model.FeeAccrual{}, c.Accruals[id]...)
+	return &foundAccruals, nil
+}
+
+func (c *FeeClient) GetAccruedAccountIds() (*[]string, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	foundIds := []string{}
+	for id := range c.Accruals {
+		foundIds = append(foundIds, id)
+	}
+	return &foundIds, nil
+}
+
+func (c *FeeClient) CreateCharge(charge model.FeeCharge) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.Charges[charge.Id] = charge
+	return nil
+}
+
+func (c *FeeClient) UpdateCharge(charge model.FeeCharge) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if _, ok := c.Charges[charge.Id]; !ok {
+		return internal.FeeChargeNotFoundError(charge.Id)
+	}
+	c.Charges[charge.Id] = charge
+	return nil
+}
+
+func (c *FeeClient) GetChargesByAccountId(id string) (*[]model.FeeCharge, error) {
+	return c.findCharges(func(charge model.FeeCharge) bool { return charge.AccountId == id })
+}
+
+func (c *FeeClient) GetChargesByCustomerId(id string) (*[]model.FeeCharge, error) {
+	return c.findCharges(func(charge model.FeeCharge) bool { return charge.CustomerId == id })
+}
+
+func (c *FeeClient) GetChargesByStatus(status model.FeeChargeStatus) (*[]model.FeeCharge, error) {
+	return c.findCharges(func(charge model.FeeCharge) bool { return charge.Status == status })
+}
+
+func (c *FeeClient) findCharges(match func(model.FeeCharge) bool) (*[]model.FeeCharge, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	foundCharges := []model.FeeCharge{}
+	for _, charge := range c.Charges {
+		if match(charge) {
+			foundCharges = append(foundCharges, charge)
+		}
+	}
+	return &foundCharges, nil
+}
@@ -0,0 +1,95 @@
+package repository
+
+import (
+	"slices"
+	"sync"
+
+	"github.com/oliknight1/retail-isa-investment/investment-service/internal"
+	"github.com/oliknight1/retail-isa-investment/investment-service/model"
+)
+
+// LedgerRepository is append only: entries can be posted and read but never updated or deleted
+type LedgerRepository interface {
+	Post(entry model.JournalEntry) error
+	GetEntryById(id string) (*model.JournalEntry, error)
+	GetEntryByReference(reference string) (*model.JournalEntry, error)
+	GetEntriesByCustomerId(id string) (*[]model.JournalEntry, error)
+	GetEntriesByAccountId(id string) (*[]model.JournalEntry, error)
+}
+
+type LedgerClient struct {
+	Entries    []model.JournalEntry
+	references map[string]int
+	mu         sync.Mutex
+}
+
+func NewLedgerClient() *LedgerClient {
+	return &LedgerClient{
+		references: make(map[string]int),
+	}
+}
+
+// Post appends entry to the ledger, refusing a second entry with the same reference
+func (c *LedgerClient) Post(entry model.JournalEntry) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if _, ok := c.references[entry.Reference]; ok {
+		return internal.DuplicateEntryError(entry.Reference)
+	}
+	entry.Postings = slices.Clone(entry.Postings)
+	c.references[entry.Reference] = len(c.Entries)
+	c.Entries = append(c.Entries, entry)
+	return nil
+}
+
+func (c *LedgerClient) GetEntryById(id string) (*model.JournalEntry, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	for _, entry := range c.Entries {
+		if entry.Id == id {
+			return copyEntry(entry), nil
+		}
+	}
+	return nil, internal.EntryNotFoundError(id)
+}
+
+func (c *LedgerClient) GetEntryByReference(reference string) (*model.JournalEntry, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	i, ok := c.references[reference]
+	if !ok {
+		return nil, internal.EntryNotFoundError(reference)
+	}
+	return copyEntry(c.Entries[i]), nil
+}
+
+func (c *LedgerClient) GetEntriesByCustomerId(id string) (*[]model.JournalEntry, error) {
+	return c.find(func(entry model.JournalEntry) bool { return entry.CustomerId == id }), nil
+}
+
+func (c *LedgerClient) GetEntriesByAccountId(id string) (*[]model.JournalEntry, error) {
+	return c.find(func(entry model.JournalEntry) bool { return entry.AccountId == id }), nil
+}
+
+// find returns matching entries in the order they were posted
+func (c *LedgerClient) find(match func(model.JournalEntry) bool) *[]model.JournalEntry {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	foundEntries := []model.JournalEntry{}
+	for _, entry := range c.Entries {
+		if match(entry) {
+			foundEntries = append(foundEntries, *copyEntry(entry))
+		}
+	}
+	return &foundEntries
+}
+
+// copyEntry gives callers their own postings so a posted entry cannot be changed through them
+func copyEntry(entry model.JournalEntry) *model.JournalEntry {
+	entry.Postings = slices.Clone(entry.Postings)
+	return &entry
+}
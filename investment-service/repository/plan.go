@@ -0,0 +1,83 @@
+package repository
+
+import (
+	"sync"
+
+	"github.com/oliknight1/retail-isa-investment/investment-service/internal"
+	"github.com/oliknight1/retail-isa-investment/investment-service/model"
+)
+
+type PlanRepository interface {
+	CreatePlan(plan model.Plan) error
+	UpdatePlan(plan model.Plan) error
+	GetPlanById(id string) (*model.Plan, error)
+	GetPlansByCustomerId(id string) (*[]model.Plan, error)
+	GetPlansByStatus(status model.PlanStatus) (*[]model.Plan, error)
+}
+
+type PlanClient struct {
+	Plans map[string]model.Plan
+	mu    sync.Mutex
+}
+
+func NewPlanClient() *PlanClient {
+	return &PlanClient{
+		Plans: make(map[string]model.Plan),
+	}
+}
+
+func (c *PlanClient) CreatePlan(plan model.Plan) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.Plans[plan.Id] = plan
+	return nil
+}
+
+func (c *PlanClient) UpdatePlan(plan model.Plan) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if _, ok := c.Plans[plan.Id]; !ok {
+		return internal.PlanNotFoundError(plan.Id)
+	}
+	c.Plans[plan.Id] = plan
+	return nil
+}
+
+func (c *PlanClient) GetPlanById(id string) (*model.Plan, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	plan, ok := c.Plans[id]
+	if !ok {
+		return nil, internal.PlanNotFoundError(id)
+	}
+	return &plan, nil
+}
+
+func (c *PlanClient) GetPlansByCustomerId(id string) (*[]model.Plan, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	foundPlans := []model.Plan{}
+	for _, plan := range c.Plans {
+		if plan.CustomerId == id {
+			foundPlans = append(foundPlans, plan)
+		}
+	}
+	return &foundPlans, nil
+}
+
+func (c *PlanClient) GetPlansByStatus(status model.PlanStatus) (*[]model.Plan, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	foundPlans := []model.Plan{}
+	for _, plan := range c.Plans {
+		if plan.Status == status {
+			foundPlans = append(foundPlans, plan)
+		}
+	}
+	return &foundPlans, nil
+}
@@ -0,0 +1,68 @@
+package repository
+
+import (
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/oliknight1/retail-isa-investment/investment-service/internal"
+	"github.com/oliknight1/retail-isa-investment/investment-service/model"
+)
+
+type ReconciliationRepository interface {
+	AddStatusEvent(event model.InvestmentStatusChanged, since time.Time) error
+	GetStatusEvents() (*[]model.InvestmentStatusChanged, error)
+	AddRun(run model.ReconciliationRun) error
+	GetLatestRun() (*model.ReconciliationRun, error)
+}
+
+// ReconciliationClient keeps the investment status events received from NATS, so they can be
+// checked against the investments' histories, and every reconciliation run in the order they ran
+type ReconciliationClient struct {
+	Events []model.InvestmentStatusChanged
+	Runs   []model.ReconciliationRun
+	mu     sync.Mutex
+}
+
+func NewReconciliationClient() *ReconciliationClient {
+	return &ReconciliationClient{}
+}
+
+// AddStatusEvent keeps event and drops any kept from before since, which are too old to check
+func (c *ReconciliationClient) AddStatusEvent(event model.InvestmentStatusChanged, since time.Time) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.Events = slices.DeleteFunc(c.Events, func(kept model.InvestmentStatusChanged) bool {
+		return kept.At.Before(since)
+	})
+	c.Events = append(c.Events, event)
+	return nil
+}
+
+func (c *ReconciliationClient) GetStatusEvents() (*[]model.InvestmentStatusChanged, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	foundEvents := append([]model.InvestmentStatusChanged{}, c.Events...)
+	return &foundEvents, nil
+}
+
+func (c *ReconciliationClient) AddRun(run model.ReconciliationRun) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.Runs = append(c.Runs, run)
+	return nil
+}
+
+func (c *ReconciliationClient) GetLatestRun() (*model.ReconciliationRun, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if len(c.Runs) == 0 {
+		return nil, internal.ErrNoReconciliationRun
+	}
+	run := c.Runs[len(c.Runs)-1]
+	return &run, nil
+}
@@ -0,0 +1,38 @@
+package repository_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/oliknight1/retail-isa-investment/investment-service/model"
+	"github.com/oliknight1/retail-isa-investment/investment-service/repository"
+)
+
+func TestAddStatusEventDropsOldEvents(t *testing.T) {
+	db := repository.NewReconciliationClient()
+	now := time.Now()
+	event := func(id string, at time.Time) model.InvestmentStatusChanged {
+		return model.InvestmentStatusChanged{
+			InvestmentId:           id,
+			InvestmentStatusChange: model.InvestmentStatusChange{Status: model.InvestmentSettled, At: at},
+		}
+	}
+
+	db.AddStatusEvent(event("inv-old", now.AddDate(0, 0, -20)), now.AddDate(0, 0, -30))
+	db.AddStatusEvent(event("inv-recent", now.AddDate(0, 0, -1)), now.AddDate(0, 0, -30))
+	if err := db.AddStatusEvent(event("inv-new", now), now.AddDate(0, 0, -14)); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	events, err := db.GetStatusEvents()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	var kept []string
+	for _, e := range *events {
+		kept = append(kept, e.InvestmentId)
+	}
+	if len(kept) != 2 || kept[0] != "inv-recent" || kept[1] != "inv-new" {
+		t.Errorf("expected only the events within the window, got %v", kept)
+	}
+}
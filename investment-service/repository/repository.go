@@ -1,16 +1,22 @@
 package repository
 
 import (
-	"fmt"
 	"sync"
 
+	"github.com/oliknight1/retail-isa-investment/investment-service/internal"
+
 	"github.com/oliknight1/retail-isa-investment/investment-service/model"
+	"github.com/oliknight1/retail-isa-investment/investment-service/taxyear"
 )
 
 type Repository interface {
 	CreateInvestment(investment model.Investment) error
+	UpdateInvestment(investment model.Investment) error
 	GetInvestmentById(id string) (*model.Investment, error)
 	GetInvestmentsByCustomerId(id string) (*[]model.Investment, error)
+	GetInvestmentsByAccountId(id string) (*[]model.Investment, error)
+	GetInvestmentsByTaxYear(taxYear taxyear.TaxYear) (*[]model.Investment, error)
+	GetInvestmentsByStatus(status model.InvestmentStatus) (*[]model.Investment, error)
 }
 
 type InvestmentClient struct {
@@ -31,15 +37,32 @@ func (c *InvestmentClient) CreateInvestment(investment model.Investment) error {
 	c.Investments[investment.Id] = investment
 	return nil
 }
+func (c *InvestmentClient) UpdateInvestment(investment model.Investment) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if _, ok := c.Investments[investment.Id]; !ok {
+		return internal.InvestmentNotFoundError(investment.Id)
+	}
+	c.Investments[investment.Id] = investment
+	return nil
+}
+
 func (c *InvestmentClient) GetInvestmentById(id string) (*model.Investment, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
 	investment, ok := c.Investments[id]
 	if !ok {
-		return nil, fmt.Errorf("investment with id %s not found", id)
+		return nil, internal.InvestmentNotFoundError(id)
 	}
 	return &investment, nil
 
 }
 func (c *InvestmentClient) GetInvestmentsByCustomerId(id string) (*[]model.Investment, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
 	var foundInvestments []model.Investment
 
 	for _, investment := range c.Investments {
@@ -50,3 +73,44 @@ func (c *InvestmentClient) GetInvestmentsByCustomerId(id string) (*[]model.Inves
 
 	return &foundInvestments, nil
 }
+func (c *InvestmentClient) GetInvestmentsByAccountId(id string) (*[]model.Investment, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	var foundInvestments []model.Investment
+	for _, investment := range c.Investments {
+		if investment.AccountId == id {
+			foundInvestments = append(foundInvestments, investment)
+		}
+	}
+
+	return &foundInvestments, nil
+}
+
+func (c *InvestmentClient) GetInvestmentsByTaxYear(taxYear taxyear.TaxYear) (*[]model.Investment, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	var foundInvestments []model.Investment
+	for _, investment := range c.Investments {
+		if investment.TaxYear == taxYear {
+			foundInvestments = append(foundInvestments, investment)
+		}
+	}
+
+	return &foundInvestments, nil
+}
+
+func (c *InvestmentClient) GetInvestmentsByStatus(status model.InvestmentStatus) (*[]model.Investment, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	var foundInvestments []model.Investment
+	for _, investment := range c.Investments {
+		if investment.Status == status {
+			foundInvestments = append(foundInvestments, investment)
+		}
+	}
+
+	return &foundInvestments, nil
+}
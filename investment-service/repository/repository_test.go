@@ -5,6 +5,7 @@ import (
 	"time"
 
 	"github.com/oliknight1/retail-isa-investment/investment-service/model"
+	"github.com/oliknight1/retail-isa-investment/investment-service/money"
 	"github.com/oliknight1/retail-isa-investment/investment-service/repository"
 )
 
@@ -18,7 +19,7 @@ func initDb(db *repository.InvestmentClient) {
 			Id:          "inv-1",
 			CustomerId:  "cust-1",
 			FundId:      "fund-1",
-			Amount:      1000.00,
+			Amount:      money.Pounds(1000),
 			Status:      "completed",
 			CreatedAt:   now.Add(-48 * time.Hour),
 			CompletedAt: &now,
@@ -27,7 +28,7 @@ func initDb(db *repository.InvestmentClient) {
 			Id:          "inv-2",
 			CustomerId:  "cust-1",
 			FundId:      "fund-2",
-			Amount:      500.00,
+			Amount:      money.Pounds(500),
 			Status:      "pending",
 			CreatedAt:   now.Add(-24 * time.Hour),
 			CompletedAt: nil,
@@ -36,7 +37,7 @@ func initDb(db *repository.InvestmentClient) {
 			Id:          "inv-3",
 			CustomerId:  "cust-2",
 			FundId:      "fund-1",
-			Amount:      2000.00,
+			Amount:      money.Pounds(2000),
 			Status:      "completed",
 			CreatedAt:   now.Add(-72 * time.Hour),
 			CompletedAt: &later,
@@ -45,7 +46,7 @@ func initDb(db *repository.InvestmentClient) {
 			Id:            "inv-4",
 			CustomerId:    "cust-3",
 			FundId:        "fund-3",
-			Amount:        750.00,
+			Amount:        money.Pounds(750),
 			Status:        "failed",
 			CreatedAt:     now.Add(-12 * time.Hour),
 			FailureReason: &failReason,
@@ -54,7 +55,7 @@ func initDb(db *repository.InvestmentClient) {
 			Id:          "inv-5",
 			CustomerId:  "cust-1",
 			FundId:      "fund-3",
-			Amount:      300.00,
+			Amount:      money.Pounds(300),
 			Status:      "completed",
 			CreatedAt:   now.Add(-6 * time.Hour),
 			CompletedAt: &now,
@@ -0,0 +1,69 @@
+package repository
+
+import (
+	"sync"
+
+	"github.com/oliknight1/retail-isa-investment/investment-service/internal"
+	"github.com/oliknight1/retail-isa-investment/investment-service/model"
+)
+
+type SwitchRepository interface {
+	CreateSwitch(fundSwitch model.Switch) error
+	UpdateSwitch(fundSwitch model.Switch) error
+	GetSwitchById(id string) (*model.Switch, error)
+	GetSwitchesByAccountId(id string) (*[]model.Switch, error)
+}
+
+type SwitchClient struct {
+	Switches map[string]model.Switch
+	mu       sync.Mutex
+}
+
+func NewSwitchClient() *SwitchClient {
+	return &SwitchClient{
+		Switches: make(map[string]model.Switch),
+	}
+}
+
+func (c *SwitchClient) CreateSwitch(fundSwitch model.Switch) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.Switches[fundSwitch.Id] = fundSwitch
+	return nil
+}
+
+func (c *SwitchClient) UpdateSwitch(fundSwitch model.Switch) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if _, ok := c.Switches[fundSwitch.Id]; !ok {
+		return internal.SwitchNotFoundError(fundSwitch.Id)
+	}
+	c.Switches[fundSwitch.Id] = fundSwitch
+	return nil
+}
+
+func (c *SwitchClient) GetSwitchById(id string) (*model.Switch, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	fundSwitch, ok := c.Switches[id]
+	if !ok {
+		return nil, internal.SwitchNotFoundError(id)
+	}
+	return &fundSwitch, nil
+}
+
+func (c *SwitchClient) GetSwitchesByAccountId(id string) (*[]model.Switch, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	foundSwitches := []model.Switch{}
+	for _, fundSwitch := range c.Switches {
+		if fundSwitch.AccountId == id {
+			foundSwitches = append(foundSwitches, fundSwitch)
+		}
+	}
+	return &foundSwitches, nil
+}
@@ -0,0 +1,69 @@
+package repository
+
+import (
+	"sync"
+
+	"github.com/oliknight1/retail-isa-investment/investment-service/internal"
+	"github.com/oliknight1/retail-isa-investment/investment-service/model"
+)
+
+type TransferRepository interface {
+	CreateTransfer(transfer model.Transfer) error
+	UpdateTransfer(transfer model.Transfer) error
+	GetTransferById(id string) (*model.Transfer, error)
+	GetTransfersByAccountId(id string) (*[]model.Transfer, error)
+}
+
+type TransferClient struct {
+	Transfers map[string]model.Transfer
+	mu        sync.Mutex
+}
+
+func NewTransferClient() *TransferClient {
+	return &TransferClient{
+		Transfers: make(map[string]model.Transfer),
+	}
+}
+
+func (c *TransferClient) CreateTransfer(transfer model.Transfer) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.Transfers[transfer.Id] = transfer
+	return nil
+}
+
+func (c *TransferClient) UpdateTransfer(transfer model.Transfer) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if _, ok := c.Transfers[transfer.Id]; !ok {
+		return internal.TransferNotFoundError(transfer.Id)
+	}
+	c.Transfers[transfer.Id] = transfer
+	return nil
+}
+
+func (c *TransferClient) GetTransferById(id string) (*model.Transfer, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	transfer, ok := c.Transfers[id]
+	if !ok {
+		return nil, internal.TransferNotFoundError(id)
+	}
+	return &transfer, nil
+}
+
+func (c *TransferClient) GetTransfersByAccountId(id string) (*[]model.Transfer, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	foundTransfers := []model.Transfer{}
+	for _, transfer := range c.Transfers {
+		if transfer.AccountId == id {
+			foundTransfers = append(foundTransfers, transfer)
+		}
+	}
+	return &foundTransfers, nil
+}
@@ -0,0 +1,211 @@
+package service
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/oliknight1/retail-isa-investment/investment-service/client"
+	"github.com/oliknight1/retail-isa-investment/investment-service/event"
+	"github.com/oliknight1/retail-isa-investment/investment-service/internal"
+	"github.com/oliknight1/retail-isa-investment/investment-service/logger"
+	"github.com/oliknight1/retail-isa-investment/investment-service/model"
+	"github.com/oliknight1/retail-isa-investment/investment-service/product"
+	"github.com/oliknight1/retail-isa-investment/investment-service/repository"
+	"go.uber.org/zap"
+)
+
+type AccountService interface {
+	OpenAccount(customerId string, productType model.ProductType) (*model.Account, error)
+	GetAccountById(string) (*model.Account, error)
+	GetAccountsByCustomerId(string) (*[]model.Account, error)
+	SetIncomePreference(id string, preference model.IncomePreference) (*model.Account, error)
+	GetCashBalance(id string) (*model.CashBalance, error)
+}
+
+type AccountServiceImpl struct {
+	repo      repository.AccountRepository
+	customers client.CustomerClient
+	ledger    LedgerService
+	locks     *Locks
+	publisher event.EventHandler
+	Logger    logger.Logger
+}
+
+func NewAccountService(repo repository.AccountRepository, customers client.CustomerClient, ledger LedgerService, locks *Locks, publisher event.EventHandler, logger logger.Logger) *AccountServiceImpl {
+	return &AccountServiceImpl{
+		repo,
+		customers,
+		ledger,
+		locks,
+		publisher,
+		logger,
+	}
+}
+
+func (s *AccountServiceImpl) OpenAccount(customerId string, productType model.ProductType) (*model.Account, error) {
+	if customerId == "" {
+		s.Logger.Error("missing customer_id in account opening request", zap.Error(internal.ErrMissingCustomerId))
+		return nil, internal.ErrMissingCustomerId
+	}
+	rules, err := product.For(productType)
+	if err != nil {
+		s.Logger.Error("invalid product type in account opening request", zap.Error(err))
+		return nil, err
+	}
+
+	now := time.Now()
+	customer, err := s.customers.GetCustomerById(customerId)
+	if err != nil {
+		s.Logger.Error("error fetching customer for account opening", zap.Error(err))
+		return nil, err
+	}
+	if err := rules.CheckOpeningAge(customer.DateOfBirth.AgeOn(now)); err != nil {
+		s.Logger.Error("customer not eligible to open account", zap.Error(err))
+		return nil, err
+	}
+
+	operatorId := customerId
+	if productType == model.JuniorISA {
+		if customer.RegisteredContactId == "" {
+			s.Logger.Error("junior ISA opened for customer without registered contact", zap.String("customer_id", customerId))
+			return nil, internal.ErrMissingContact
+		}
+		operatorId = customer.RegisteredContactId
+	}
+
+	account := model.Account{
+		Id:          uuid.New().String(),
+		CustomerId:  customerId,
+		OperatorId:  operatorId,
+		ProductType: productType,
+		Status:      model.AccountOpen,
+		OpenedAt:    now,
+	}
+	if err := s.repo.CreateAccount(account); err != nil {
+		return nil, err
+	}
+
+	if err := s.publisher.Publish("account.opened", account); err != nil {
+		s.Logger.Error("error publishing account.opened event", zap.Error(err))
+	}
+
+	return &account, nil
+}
+
+func (s *AccountServiceImpl) GetAccountById(id string) (*model.Account, error) {
+	if id == "" {
+		s.Logger.Error("missing account_id when requesting account", zap.Error(internal.ErrMissingAccountId))
+		return nil, internal.ErrMissingAccountId
+	}
+	return s.repo.GetAccountById(id)
+}
+
+func (s *AccountServiceImpl) GetAccountsByCustomerId(id string) (*[]model.Account, error) {
+	if id == "" {
+		s.Logger.Error("missing customer_id when requesting accounts", zap.Error(internal.ErrMissingCustomerId))
+		return nil, internal.ErrMissingCustomerId
+	}
+	return s.repo.GetAccountsByCustomerId(id)
+}
+
+// SetIncomePreference chooses whether income paid on the account's income units is paid into
+// its cash or reinvested in the fund that paid it
+func (s *AccountServiceImpl) SetIncomePreference(id string, preference model.IncomePreference) (*model.Account, error) {
+	if id == "" {
+		s.Logger.Error("missing account_id when setting income preference", zap.Error(internal.ErrMissingAccountId))
+		return nil, internal.ErrMissingAccountId
+	}
+	if preference != model.IncomePayOut && preference != model.IncomeReinvest {
+		s.Logger.Error("invalid income preference", zap.String("preference", string(preference)))
+		return nil, internal.ErrInvalidPreference
+	}
+	unlock := s.locks.accounts.Lock(id)
+	defer unlock()
+	account, err := s.repo.GetAccountById(id)
+	if err != nil {
+		s.Logger.Error("error fetching account to set income preference", zap.Error(err))
+		return nil, err
+	}
+
+	account.IncomePreference = preference
+	if err := s.repo.UpdateAccount(*account); err != nil {
+		s.Logger.Error("error saving income preference", zap.String("account_id", id), zap.Error(err))
+		return nil, err
+	}
+	return account, nil
+}
+
+// GetCashBalance returns the cash held in an account and how much of it is free to spend, as
+// recorded in the ledger
+func (s *AccountServiceImpl) GetCashBalance(id string) (*model.CashBalance, error) {
+	if id == "" {
+		s.Logger.Error("missing account_id when requesting cash balance", zap.Error(internal.ErrMissingAccountId))
+		return nil, internal.ErrMissingAccountId
+	}
+	account, err := s.repo.GetAccountById(id)
+	if err != nil {
+		s.Logger.Error("error fetching account for cash balance", zap.Error(err))
+		return nil, err
+	}
+	return s.ledger.GetCashBalance(account.Id)
+}
+
+// ConvertMaturedJunior turns each open Junior ISA held by the customer into an adult stocks and
+// shares ISA operated by the customer themselves
+func (s *AccountServiceImpl) ConvertMaturedJunior(customerId string) error {
+	accounts, err := s.repo.GetAccountsByCustomerId(customerId)
+	if err != nil {
+		s.Logger.Error("error fetching accounts to convert", zap.Error(err))
+		return err
+	}
+
+	for _, account := range *accounts {
+		if account.ProductType != model.JuniorISA || account.Status != model.AccountOpen {
+			continue
+		}
+		if err := s.convertJunior(account.Id, customerId); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// convertJunior converts one Junior ISA under the account's lock, reading it again so a change
+// made since it was listed is not lost
+func (s *AccountServiceImpl) convertJunior(id string, customerId string) error {
+	unlock := s.locks.accounts.Lock(id)
+	defer unlock()
+
+	account, err := s.repo.GetAccountById(id)
+	if err != nil {
+		s.Logger.Error("error fetching account to convert", zap.String("account_id", id), zap.Error(err))
+		return err
+	}
+	if account.ProductType != model.JuniorISA || account.Status != model.AccountOpen {
+		return nil
+	}
+	account.ProductType = model.StocksAndSharesISA
+	account.OperatorId = customerId
+	if err := s.repo.UpdateAccount(*account); err != nil {
+		s.Logger.Error("error converting junior ISA", zap.String("account_id", id), zap.Error(err))
+		return err
+	}
+	if err := s.publisher.Publish("account.converted", *account); err != nil {
+		s.Logger.Error("error publishing account.converted event", zap.Error(err))
+	}
+	s.Logger.Info("junior ISA converted to adult ISA", zap.String("account_id", id))
+	return nil
+}
+
+// OnJisaMatured handles customer.jisa.matured events from customer-service
+func (s *AccountServiceImpl) OnJisaMatured(data []byte) {
+	var matured model.JisaMatured
+	if err := json.Unmarshal(data, &matured); err != nil {
+		s.Logger.Error("failed to decode customer.jisa.matured event", zap.Error(err))
+		return
+	}
+	if err := s.ConvertMaturedJunior(matured.CustomerId); err != nil {
+		s.Logger.Error("failed to convert matured junior ISA", zap.String("customer_id", matured.CustomerId), zap.Error(err))
+	}
+}
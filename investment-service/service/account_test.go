@@ -0,0 +1,195 @@
+package service_test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/oliknight1/retail-isa-investment/investment-service/internal"
+	"github.com/oliknight1/retail-isa-investment/investment-service/logger"
+	"github.com/oliknight1/retail-isa-investment/investment-service/model"
+	"github.com/oliknight1/retail-isa-investment/investment-service/repository"
+	"github.com/oliknight1/retail-isa-investment/investment-service/service"
+)
+
+func TestOpenAccountSuccess(t *testing.T) {
+	var published []string
+	mockPub := &mockPublisher{
+		publishFn: func(subject string, payload any) error {
+			published = append(published, subject)
+			return nil
+		},
+	}
+	repo := repository.NewAccountClient()
+	logger := logger.NewMockLogger()
+	svc := service.NewAccountService(repo, customersAged(30), newLedger(logger), service.NewLocks(), mockPub, logger)
+
+	account, err := svc.OpenAccount("cust-1", model.LifetimeISA)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if account.Id == "" || account.Status != model.AccountOpen || account.ProductType != model.LifetimeISA {
+		t.Errorf("unexpected account: %+v", account)
+	}
+	if len(published) != 1 || published[0] != "account.opened" {
+		t.Errorf("expected account.opened event, got %v", published)
+	}
+
+	stored, err := svc.GetAccountById(account.Id)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if stored.CustomerId != "cust-1" {
+		t.Errorf("expected stored account for cust-1, got %+v", stored)
+	}
+}
+
+func TestOpenAccountFailures(t *testing.T) {
+	tests := []struct {
+		name        string
+		customerId  string
+		productType model.ProductType
+		customers   *mockCustomerClient
+		expectedErr error
+	}{
+		{
+			name:        "missing customerId",
+			customerId:  "",
+			productType: model.StocksAndSharesISA,
+			customers:   customersAged(30),
+			expectedErr: internal.ErrMissingCustomerId,
+		},
+		{
+			name:        "unknown product type",
+			customerId:  "cust-1",
+			productType: "pension",
+			customers:   customersAged(30),
+			expectedErr: internal.ErrInvalidProductType,
+		},
+		{
+			name:        "lifetime ISA opened at 40",
+			customerId:  "cust-1",
+			productType: model.LifetimeISA,
+			customers:   customersAged(40),
+			expectedErr: internal.ErrIneligibleAge,
+		},
+		{
+			name:        "lifetime ISA opened at 17",
+			customerId:  "cust-1",
+			productType: model.LifetimeISA,
+			customers:   customersAged(17),
+			expectedErr: internal.ErrIneligibleAge,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockPub := &mockPublisher{
+				publishFn: func(subject string, payload any) error {
+					t.Fatal("should not publish on validation failure")
+					return nil
+				},
+			}
+			logger := logger.NewMockLogger()
+			svc := service.NewAccountService(repository.NewAccountClient(), tt.customers, newLedger(logger), service.NewLocks(), mockPub, logger)
+
+			_, err := svc.OpenAccount(tt.customerId, tt.productType)
+			if !errors.Is(err, tt.expectedErr) {
+				t.Errorf("expected error %v, got %v", tt.expectedErr, err)
+			}
+		})
+	}
+}
+
+func TestOpenJuniorAccount(t *testing.T) {
+	mockPub := &mockPublisher{
+		publishFn: func(subject string, payload any) error {
+			return nil
+		},
+	}
+	logger := logger.NewMockLogger()
+
+	child := customersAged(10)
+	withContact := &mockCustomerClient{
+		getCustomerById: func(id string) (*model.Customer, error) {
+			customer, _ := child.GetCustomerById(id)
+			customer.RegisteredContactId = "parent-1"
+			return customer, nil
+		},
+	}
+
+	svc := service.NewAccountService(repository.NewAccountClient(), withContact, newLedger(logger), service.NewLocks(), mockPub, logger)
+	account, err := svc.OpenAccount("child-1", model.JuniorISA)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if account.CustomerId != "child-1" || account.OperatorId != "parent-1" {
+		t.Errorf("expected junior ISA held by the child and operated by the parent, got %+v", account)
+	}
+
+	svc = service.NewAccountService(repository.NewAccountClient(), child, newLedger(logger), service.NewLocks(), mockPub, logger)
+	if _, err := svc.OpenAccount("child-1", model.JuniorISA); !errors.Is(err, internal.ErrMissingContact) {
+		t.Errorf("expected missing contact error, got %v", err)
+	}
+}
+
+func TestConvertMaturedJunior(t *testing.T) {
+	var published []model.Account
+	mockPub := &mockPublisher{
+		publishFn: func(subject string, payload any) error {
+			if subject == "account.converted" {
+				published = append(published, payload.(model.Account))
+			}
+			return nil
+		},
+	}
+	repo := repository.NewAccountClient()
+	repo.CreateAccount(model.Account{Id: "acc-junior", CustomerId: "child-1", OperatorId: "parent-1", ProductType: model.JuniorISA, Status: model.AccountOpen})
+	repo.CreateAccount(model.Account{Id: "acc-parent", CustomerId: "parent-1", OperatorId: "parent-1", ProductType: model.StocksAndSharesISA, Status: model.AccountOpen})
+
+	logger := logger.NewMockLogger()
+	svc := service.NewAccountService(repo, customersAged(18), newLedger(logger), service.NewLocks(), mockPub, logger)
+
+	svc.OnJisaMatured([]byte(`{"customerId":"child-1","registeredContactId":"parent-1"}`))
+
+	converted, err := repo.GetAccountById("acc-junior")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if converted.ProductType != model.StocksAndSharesISA || converted.OperatorId != "child-1" {
+		t.Errorf("expected account converted to an adult ISA operated by the child, got %+v", converted)
+	}
+	if len(published) != 1 || published[0].Id != "acc-junior" {
+		t.Errorf("expected one account.converted event, got %+v", published)
+	}
+}
+
+func TestGetAccountByIdNotFound(t *testing.T) {
+	logger := logger.NewMockLogger()
+	svc := service.NewAccountService(repository.NewAccountClient(), customersAged(30), newLedger(logger), service.NewLocks(), nil, logger)
+
+	_, err := svc.GetAccountById("acc-missing")
+	if !errors.Is(err, internal.ErrAccountNotFound) {
+		t.Errorf("expected account not found error, got %v", err)
+	}
+}
+
+func TestSetIncomePreference(t *testing.T) {
+	repo := newAccountRepo()
+	svc := service.NewAccountService(repo, customersAged(30), newLedger(logger.NewMockLogger()), service.NewLocks(), nil, logger.NewMockLogger())
+
+	account, err := svc.SetIncomePreference("acc-1", model.IncomeReinvest)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	stored, _ := repo.GetAccountById("acc-1")
+	if account.IncomePreference != model.IncomeReinvest || stored.IncomePreference != model.IncomeReinvest {
+		t.Errorf("expected income to be reinvested, got %+v", stored)
+	}
+
+	if _, err := svc.SetIncomePreference("acc-1", "spend"); !errors.Is(err, internal.ErrInvalidPreference) {
+		t.Errorf("expected invalid preference error, got %v", err)
+	}
+	if _, err := svc.SetIncomePreference("acc-unknown", model.IncomePayOut); !errors.Is(err, internal.ErrAccountNotFound) {
+		t.Errorf("expected account not found error, got %v", err)
+	}
+}
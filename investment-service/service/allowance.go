@@ -0,0 +1,148 @@
+package service
+
+import (
+	"time"
+
+	"github.com/oliknight1/retail-isa-investment/investment-service/internal"
+	"github.com/oliknight1/retail-isa-investment/investment-service/logger"
+	"github.com/oliknight1/retail-isa-investment/investment-service/model"
+	"github.com/oliknight1/retail-isa-investment/investment-service/money"
+	"github.com/oliknight1/retail-isa-investment/investment-service/product"
+	"github.com/oliknight1/retail-isa-investment/investment-service/repository"
+	"github.com/oliknight1/retail-isa-investment/investment-service/taxyear"
+	"go.uber.org/zap"
+)
+
+type AllowanceService interface {
+	GetAllowance(customerId string) (*model.Allowance, error)
+	Subscribe(customerId string, taxYear taxyear.TaxYear, rules product.Rules, amount money.Money) (model.AllowanceUse, error)
+	Release(customerId string, taxYear taxyear.TaxYear, rules product.Rules, use model.AllowanceUse) error
+	Withdraw(customerId string, taxYear taxyear.TaxYear, rules product.Rules, amount money.Money) error
+	TransferIn(customerId string, transferId string, taxYear taxyear.TaxYear, rules product.Rules, amount money.Money) error
+}
+
+type AllowanceServiceImpl struct {
+	repo   repository.AllowanceRepository
+	limits taxyear.Limits
+	Logger logger.Logger
+}
+
+func NewAllowanceService(repo repository.AllowanceRepository, limits taxyear.Limits, logger logger.Logger) *AllowanceServiceImpl {
+	return &AllowanceServiceImpl{
+		repo,
+		limits,
+		logger,
+	}
+}
+
+func (s *AllowanceServiceImpl) GetAllowance(customerId string) (*model.Allowance, error) {
+	if customerId == "" {
+		s.Logger.Error("missing customer_id when requesting allowance", zap.Error(internal.ErrMissingCustomerId))
+		return nil, internal.ErrMissingCustomerId
+	}
+	current := taxyear.For(time.Now())
+	usage, err := s.repo.GetUsage(customerId, current)
+	if err != nil {
+		s.Logger.Error("error fetching allowance usage", zap.Error(err))
+		return nil, err
+	}
+
+	limit := s.limits.For(current)
+	allowance := &model.Allowance{
+		CustomerId: customerId,
+		TaxYear:    current,
+		Limit:      limit,
+		Used:       usage.Subscribed,
+		Remaining:  money.Max(limit.Sub(usage.Subscribed), money.Money{}),
+		Products:   usage.Products,
+	}
+	for productType, p := range usage.Products {
+		allowance.Withdrawn = allowance.Withdrawn.Add(p.Withdrawn)
+		allowance.Replaced = allowance.Replaced.Add(p.Replaced)
+		if rules, err := product.For(productType); err == nil && rules.Flexible {
+			allowance.Replaceable = allowance.Replaceable.Add(p.Withdrawn.Sub(p.Replaced))
+		}
+	}
+	return allowance, nil
+}
+
+// Subscribe records amount against the customer's allowance for the tax year, rejecting it
+// if the customer would go over that year's limit or the product's own limit. For a flexible
+// ISA any amount withdrawn earlier in the year is replaced first without using allowance
+func (s *AllowanceServiceImpl) Subscribe(customerId string, taxYear taxyear.TaxYear, rules product.Rules, amount money.Money) (model.AllowanceUse, error) {
+	limit := s.limits.For(taxYear)
+	var use model.AllowanceUse
+	err := s.repo.UpdateUsage(customerId, taxYear, func(usage *model.AllowanceUsage) error {
+		p := usage.Products[rules.Type]
+
+		use = model.AllowanceUse{Subscribed: amount}
+		if rules.Flexible {
+			use.Replaced = money.Min(amount, p.Withdrawn.Sub(p.Replaced))
+			use.Subscribed = amount.Sub(use.Replaced)
+		}
+
+		if rules.SubscriptionLimit.IsPositive() && p.Subscribed.Add(use.Subscribed).GreaterThan(rules.SubscriptionLimit) {
+			return internal.ProductLimitExceededError(string(rules.Type), rules.SubscriptionLimit.Sub(p.Subscribed))
+		}
+		if rules.CountsTowardsAllowance {
+			if usage.Subscribed.Add(use.Subscribed).GreaterThan(limit) {
+				return internal.AllowanceExceededError(limit.Sub(usage.Subscribed))
+			}
+			usage.Subscribed = usage.Subscribed.Add(use.Subscribed)
+		}
+		p.Subscribed = p.Subscribed.Add(use.Subscribed)
+		p.Replaced = p.Replaced.Add(use.Replaced)
+		usage.Products[rules.Type] = p
+		return nil
+	})
+	if err != nil {
+		return model.AllowanceUse{}, err
+	}
+	return use, nil
+}
+
+// Release gives back allowance previously taken by Subscribe
+func (s *AllowanceServiceImpl) Release(customerId string, taxYear taxyear.TaxYear, rules product.Rules, use model.AllowanceUse) error {
+	return s.repo.UpdateUsage(customerId, taxYear, func(usage *model.AllowanceUsage) error {
+		p := usage.Products[rules.Type]
+		if rules.CountsTowardsAllowance {
+			usage.Subscribed = money.Max(usage.Subscribed.Sub(use.Subscribed), money.Money{})
+		}
+		p.Subscribed = money.Max(p.Subscribed.Sub(use.Subscribed), money.Money{})
+		p.Replaced = money.Max(p.Replaced.Sub(use.Replaced), money.Money{})
+		usage.Products[rules.Type] = p
+		return nil
+	})
+}
+
+// Withdraw records money taken out of a product in the tax year. Withdrawals never reduce
+// the allowance used, but from a flexible ISA they can be replaced later in the same year
+func (s *AllowanceServiceImpl) Withdraw(customerId string, taxYear taxyear.TaxYear, rules product.Rules, amount money.Money) error {
+	return s.repo.UpdateUsage(customerId, taxYear, func(usage *model.AllowanceUsage) error {
+		p := usage.Products[rules.Type]
+		p.Withdrawn = p.Withdrawn.Add(amount)
+		usage.Products[rules.Type] = p
+		return nil
+	})
+}
+
+// TransferIn records subscriptions made with another provider this tax year once they have
+// been transferred to us. They have already used the customer's allowance so are counted
+// without checking the limits again. A transfer that has already been counted is skipped, so
+// completing it can be retried
+func (s *AllowanceServiceImpl) TransferIn(customerId string, transferId string, taxYear taxyear.TaxYear, rules product.Rules, amount money.Money) error {
+	return s.repo.UpdateUsage(customerId, taxYear, func(usage *model.AllowanceUsage) error {
+		if usage.TransfersIn[transferId] {
+			return nil
+		}
+		usage.TransfersIn[transferId] = true
+		p := usage.Products[rules.Type]
+		if rules.CountsTowardsAllowance {
+			usage.Subscribed = usage.Subscribed.Add(amount)
+		}
+		p.Subscribed = p.Subscribed.Add(amount)
+		p.TransferredIn = p.TransferredIn.Add(amount)
+		usage.Products[rules.Type] = p
+		return nil
+	})
+}
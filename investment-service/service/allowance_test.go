@@ -0,0 +1,222 @@
+package service_test
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/oliknight1/retail-isa-investment/investment-service/internal"
+	"github.com/oliknight1/retail-isa-investment/investment-service/logger"
+	"github.com/oliknight1/retail-isa-investment/investment-service/model"
+	"github.com/oliknight1/retail-isa-investment/investment-service/money"
+	"github.com/oliknight1/retail-isa-investment/investment-service/product"
+	"github.com/oliknight1/retail-isa-investment/investment-service/repository"
+	"github.com/oliknight1/retail-isa-investment/investment-service/service"
+	"github.com/oliknight1/retail-isa-investment/investment-service/taxyear"
+)
+
+func TestSubscribeWithinAllowance(t *testing.T) {
+	logger := logger.NewMockLogger()
+	svc := service.NewAllowanceService(repository.NewAllowanceClient(), taxyear.DefaultLimits(), logger)
+
+	current := taxyear.For(time.Now())
+	for _, amount := range []money.Money{money.Pounds(15000), money.Pounds(4000), money.Pounds(1000)} {
+		if _, err := svc.Subscribe("cust-1", current, stocksAndShares(t), amount); err != nil {
+			t.Fatalf("unexpected error subscribing %s: %v", amount, err)
+		}
+	}
+
+	actual, err := svc.GetAllowance("cust-1")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	expected := &model.Allowance{
+		CustomerId: "cust-1",
+		TaxYear:    actual.TaxYear,
+		Limit:      money.Pounds(20000),
+		Used:       money.Pounds(20000),
+		Remaining:  money.Pounds(0),
+		Products: map[model.ProductType]model.ProductUsage{
+			model.StocksAndSharesISA: {Subscribed: money.Pounds(20000)},
+		},
+	}
+	if diff := cmp.Diff(expected, actual); diff != "" {
+		t.Errorf("unexpected allowance (-want +got):\n%s", diff)
+	}
+}
+
+func TestSubscribeOverAllowance(t *testing.T) {
+	logger := logger.NewMockLogger()
+	svc := service.NewAllowanceService(repository.NewAllowanceClient(), taxyear.DefaultLimits(), logger)
+
+	current := taxyear.For(time.Now())
+	if _, err := svc.Subscribe("cust-1", current, stocksAndShares(t), money.Pounds(18000)); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	_, err := svc.Subscribe("cust-1", current, stocksAndShares(t), money.MustParse("2000.01"))
+	if !errors.Is(err, internal.ErrAllowanceExceeded) {
+		t.Fatalf("expected allowance exceeded error, got: %v", err)
+	}
+
+	actual, err := svc.GetAllowance("cust-1")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !actual.Used.Equal(money.Pounds(18000)) {
+		t.Errorf("expected rejected subscription to leave used at 18000, got %s", actual.Used)
+	}
+}
+
+func TestSubscribeTrackedPerCustomerAndTaxYear(t *testing.T) {
+	logger := logger.NewMockLogger()
+	svc := service.NewAllowanceService(repository.NewAllowanceClient(), taxyear.DefaultLimits(), logger)
+
+	current := taxyear.For(time.Now())
+	if _, err := svc.Subscribe("cust-1", current.Previous(), stocksAndShares(t), money.Pounds(20000)); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if _, err := svc.Subscribe("cust-2", current, stocksAndShares(t), money.Pounds(20000)); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if _, err := svc.Subscribe("cust-1", current, stocksAndShares(t), money.Pounds(20000)); err != nil {
+		t.Fatalf("expected full allowance in a new tax year, got: %v", err)
+	}
+}
+
+func TestGetAllowanceMissingCustomerId(t *testing.T) {
+	logger := logger.NewMockLogger()
+	svc := service.NewAllowanceService(repository.NewAllowanceClient(), taxyear.DefaultLimits(), logger)
+
+	_, err := svc.GetAllowance("")
+	if !errors.Is(err, internal.ErrMissingCustomerId) {
+		t.Fatalf("expected missing customer id error, got: %v", err)
+	}
+}
+
+func TestSubscribeUsesLimitForTaxYear(t *testing.T) {
+	logger := logger.NewMockLogger()
+	limits := taxyear.Limits{2017: money.Pounds(20000), 2026: money.Pounds(25000)}
+	svc := service.NewAllowanceService(repository.NewAllowanceClient(), limits, logger)
+
+	if _, err := svc.Subscribe("cust-1", 2025, stocksAndShares(t), money.MustParse("20000.01")); !errors.Is(err, internal.ErrAllowanceExceeded) {
+		t.Errorf("expected allowance exceeded error in 2025-26, got: %v", err)
+	}
+	if _, err := svc.Subscribe("cust-1", 2026, stocksAndShares(t), money.Pounds(25000)); err != nil {
+		t.Errorf("unexpected error in 2026-27: %v", err)
+	}
+}
+
+func TestSubscribeProductLimits(t *testing.T) {
+	lifetime, err := product.For(model.LifetimeISA)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	junior, err := product.For(model.JuniorISA)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	logger := logger.NewMockLogger()
+	svc := service.NewAllowanceService(repository.NewAllowanceClient(), taxyear.DefaultLimits(), logger)
+	current := taxyear.For(time.Now())
+
+	if _, err := svc.Subscribe("cust-1", current, lifetime, money.Pounds(4000)); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if _, err := svc.Subscribe("cust-1", current, lifetime, money.Pounds(1)); !errors.Is(err, internal.ErrAllowanceExceeded) {
+		t.Errorf("expected lifetime ISA limit to be enforced, got: %v", err)
+	}
+	if _, err := svc.Subscribe("cust-1", current, stocksAndShares(t), money.Pounds(16001)); !errors.Is(err, internal.ErrAllowanceExceeded) {
+		t.Errorf("expected lifetime ISA subscriptions to count towards the overall allowance, got: %v", err)
+	}
+	if _, err := svc.Subscribe("cust-1", current, junior, money.Pounds(9000)); err != nil {
+		t.Errorf("expected junior ISA not to count towards the overall allowance, got: %v", err)
+	}
+
+	actual, err := svc.GetAllowance("cust-1")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !actual.Used.Equal(money.Pounds(4000)) {
+		t.Errorf("expected used 4000, got %s", actual.Used)
+	}
+}
+
+func TestSubscribeReplacesFlexibleWithdrawals(t *testing.T) {
+	logger := logger.NewMockLogger()
+	svc := service.NewAllowanceService(repository.NewAllowanceClient(), taxyear.DefaultLimits(), logger)
+	current := taxyear.For(time.Now())
+
+	if _, err := svc.Subscribe("cust-1", current, stocksAndShares(t), money.Pounds(20000)); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := svc.Withdraw("cust-1", current, stocksAndShares(t), money.Pounds(5000)); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	use, err := svc.Subscribe("cust-1", current, stocksAndShares(t), money.Pounds(3000))
+	if err != nil {
+		t.Fatalf("expected withdrawn money to be replaceable, got: %v", err)
+	}
+	if diff := cmp.Diff(model.AllowanceUse{Replaced: money.Pounds(3000)}, use); diff != "" {
+		t.Errorf("unexpected allowance use (-want +got):\n%s", diff)
+	}
+	if _, err := svc.Subscribe("cust-1", current, stocksAndShares(t), money.MustParse("2000.01")); !errors.Is(err, internal.ErrAllowanceExceeded) {
+		t.Errorf("expected only the withdrawn amount to be replaceable, got: %v", err)
+	}
+
+	actual, err := svc.GetAllowance("cust-1")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	expected := &model.Allowance{
+		CustomerId:  "cust-1",
+		TaxYear:     actual.TaxYear,
+		Limit:       money.Pounds(20000),
+		Used:        money.Pounds(20000),
+		Remaining:   money.Pounds(0),
+		Withdrawn:   money.Pounds(5000),
+		Replaced:    money.Pounds(3000),
+		Replaceable: money.Pounds(2000),
+		Products: map[model.ProductType]model.ProductUsage{
+			model.StocksAndSharesISA: {Subscribed: money.Pounds(20000), Withdrawn: money.Pounds(5000), Replaced: money.Pounds(3000)},
+		},
+	}
+	if diff := cmp.Diff(expected, actual); diff != "" {
+		t.Errorf("unexpected allowance (-want +got):\n%s", diff)
+	}
+
+	if err := svc.Release("cust-1", current, stocksAndShares(t), use); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	actual, err = svc.GetAllowance("cust-1")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !actual.Replaceable.Equal(money.Pounds(5000)) {
+		t.Errorf("expected release to make the replaced amount replaceable again, got %s", actual.Replaceable)
+	}
+}
+
+func TestSubscribeDoesNotReplaceNonFlexibleWithdrawals(t *testing.T) {
+	lifetime, err := product.For(model.LifetimeISA)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	logger := logger.NewMockLogger()
+	svc := service.NewAllowanceService(repository.NewAllowanceClient(), taxyear.DefaultLimits(), logger)
+	current := taxyear.For(time.Now())
+
+	if _, err := svc.Subscribe("cust-1", current, lifetime, money.Pounds(4000)); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := svc.Withdraw("cust-1", current, lifetime, money.Pounds(1000)); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if _, err := svc.Subscribe("cust-1", current, lifetime, money.Pounds(1000)); !errors.Is(err, internal.ErrAllowanceExceeded) {
+		t.Errorf("expected lifetime ISA withdrawals not to be replaceable, got: %v", err)
+	}
+}
@@ -0,0 +1,121 @@
+package service
+
+import (
+	"bytes"
+	"encoding/csv"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/oliknight1/retail-isa-investment/investment-service/internal"
+	"github.com/oliknight1/retail-isa-investment/investment-service/logger"
+	"github.com/oliknight1/retail-isa-investment/investment-service/model"
+	"github.com/oliknight1/retail-isa-investment/investment-service/money"
+	"github.com/oliknight1/retail-isa-investment/investment-service/product"
+	"github.com/oliknight1/retail-isa-investment/investment-service/repository"
+	"github.com/oliknight1/retail-isa-investment/investment-service/taxyear"
+	"go.uber.org/zap"
+)
+
+// claimPeriodLayout formats the month a bonus claim belongs to
+const claimPeriodLayout = "2006-01"
+
+type BonusService interface {
+	RecordClaim(investment model.Investment, rules product.Rules) (*model.BonusClaim, error)
+	GenerateClaimFile(period string) ([]byte, error)
+	CancelClaim(investmentId string) error
+}
+
+type BonusServiceImpl struct {
+	repo   repository.BonusRepository
+	Logger logger.Logger
+}
+
+func NewBonusService(repo repository.BonusRepository, logger logger.Logger) *BonusServiceImpl {
+	return &BonusServiceImpl{
+		repo,
+		logger,
+	}
+}
+
+// RecordClaim creates the government bonus claim for a subscription into a product that pays one
+func (s *BonusServiceImpl) RecordClaim(investment model.Investment, rules product.Rules) (*model.BonusClaim, error) {
+	claim := model.BonusClaim{
+		Id:                 uuid.New().String(),
+		AccountId:          investment.AccountId,
+		CustomerId:         investment.CustomerId,
+		InvestmentId:       investment.Id,
+		SubscriptionAmount: investment.Amount,
+		BonusAmount:        rules.Bonus(investment.Amount),
+		ClaimPeriod:        taxyear.InLondon(investment.CreatedAt).Format(claimPeriodLayout),
+		Status:             model.BonusClaimPending,
+		CreatedAt:          time.Now(),
+	}
+	if err := s.repo.CreateClaim(claim); err != nil {
+		s.Logger.Error("error recording bonus claim", zap.String("investment_id", investment.Id), zap.Error(err))
+		return nil, err
+	}
+	return &claim, nil
+}
+
+// GenerateClaimFile builds the CSV of every bonus claim made in the period, ordered by subscription time
+func (s *BonusServiceImpl) GenerateClaimFile(period string) ([]byte, error) {
+	if _, err := time.Parse(claimPeriodLayout, period); err != nil {
+		s.Logger.Error("invalid bonus claim period", zap.String("period", period))
+		return nil, fmt.Errorf("%w: %s", internal.ErrInvalidClaimPeriod, period)
+	}
+
+	claims, err := s.repo.GetClaimsByPeriod(period)
+	if err != nil {
+		s.Logger.Error("error fetching bonus claims", zap.Error(err))
+		return nil, err
+	}
+	slices.SortFunc(*claims, func(a, b model.BonusClaim) int {
+		if c := a.CreatedAt.Compare(b.CreatedAt); c != 0 {
+			return c
+		}
+		return strings.Compare(a.Id, b.Id)
+	})
+
+	var buf bytes.Buffer
+	w := csv.NewWriter(&buf)
+	w.Write([]string{"claim_id", "account_id", "customer_id", "investment_id", "subscription_amount", "bonus_amount"})
+	var total money.Money
+	for _, claim := range *claims {
+		if claim.Status == model.BonusClaimCancelled {
+			continue
+		}
+		w.Write([]string{
+			claim.Id,
+			claim.AccountId,
+			claim.CustomerId,
+			claim.InvestmentId,
+			claim.SubscriptionAmount.String(),
+			claim.BonusAmount.String(),
+		})
+		total = total.Add(claim.BonusAmount)
+	}
+	w.Write([]string{"total", "", "", "", "", total.String()})
+	w.Flush()
+	if err := w.Error(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// CancelClaim stops the bonus being claimed for a subscription that has been cancelled.
+// A claim already submitted to HMRC is left as it is, since the bonus has to be repaid instead
+func (s *BonusServiceImpl) CancelClaim(investmentId string) error {
+	claim, err := s.repo.GetClaimByInvestmentId(investmentId)
+	if err != nil {
+		s.Logger.Error("error fetching bonus claim", zap.String("investment_id", investmentId), zap.Error(err))
+		return err
+	}
+	if claim == nil || claim.Status != model.BonusClaimPending {
+		return nil
+	}
+	claim.Status = model.BonusClaimCancelled
+	return s.repo.UpdateClaim(*claim)
+}
@@ -0,0 +1,65 @@
+package service_test
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/oliknight1/retail-isa-investment/investment-service/internal"
+	"github.com/oliknight1/retail-isa-investment/investment-service/logger"
+	"github.com/oliknight1/retail-isa-investment/investment-service/model"
+	"github.com/oliknight1/retail-isa-investment/investment-service/money"
+	"github.com/oliknight1/retail-isa-investment/investment-service/product"
+	"github.com/oliknight1/retail-isa-investment/investment-service/repository"
+	"github.com/oliknight1/retail-isa-investment/investment-service/service"
+)
+
+func TestGenerateClaimFile(t *testing.T) {
+	lifetime, err := product.For(model.LifetimeISA)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	logger := logger.NewMockLogger()
+	svc := service.NewBonusService(repository.NewBonusClient(), logger)
+
+	may := time.Date(2025, time.May, 10, 12, 0, 0, 0, london(t))
+	investments := []model.Investment{
+		{Id: "inv-1", AccountId: "acc-1", CustomerId: "cust-1", Amount: money.Pounds(1000), CreatedAt: may},
+		{Id: "inv-2", AccountId: "acc-2", CustomerId: "cust-2", Amount: money.MustParse("333.33"), CreatedAt: may.Add(time.Hour)},
+		{Id: "inv-3", AccountId: "acc-1", CustomerId: "cust-1", Amount: money.Pounds(500), CreatedAt: may.AddDate(0, 1, 0)},
+	}
+	for _, inv := range investments {
+		if _, err := svc.RecordClaim(inv, lifetime); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}
+
+	file, err := svc.GenerateClaimFile("2025-05")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	lines := strings.Split(strings.TrimSpace(string(file)), "\n")
+	if len(lines) != 4 {
+		t.Fatalf("expected header, 2 claims and a total, got:\n%s", file)
+	}
+	if !strings.Contains(lines[1], "inv-1") || !strings.HasSuffix(lines[1], ",1000.00,250.00") {
+		t.Errorf("unexpected first claim: %s", lines[1])
+	}
+	if !strings.HasSuffix(lines[2], ",333.33,83.33") {
+		t.Errorf("expected bonus rounded to the penny, got: %s", lines[2])
+	}
+	if lines[3] != "total,,,,,333.33" {
+		t.Errorf("unexpected total line: %s", lines[3])
+	}
+}
+
+func TestGenerateClaimFileInvalidPeriod(t *testing.T) {
+	logger := logger.NewMockLogger()
+	svc := service.NewBonusService(repository.NewBonusClient(), logger)
+
+	if _, err := svc.GenerateClaimFile("May 2025"); !errors.Is(err, internal.ErrInvalidClaimPeriod) {
+		t.Errorf("expected invalid claim period error, got %v", err)
+	}
+}
@@ -0,0 +1,282 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/oliknight1/retail-isa-investment/investment-service/client"
+	"github.com/oliknight1/retail-isa-investment/investment-service/dealing"
+	"github.com/oliknight1/retail-isa-investment/investment-service/event"
+	"github.com/oliknight1/retail-isa-investment/investment-service/logger"
+	"github.com/oliknight1/retail-isa-investment/investment-service/model"
+	"github.com/oliknight1/retail-isa-investment/investment-service/repository"
+	"go.uber.org/zap"
+)
+
+type DealingService interface {
+	Run(now time.Time) (*model.DealingRun, error)
+}
+
+// DealingServiceImpl forward prices validated buy and sell orders. Each run deals every order
+// whose valuation point has passed at its fund's price for that point
+type DealingServiceImpl struct {
+	repo      repository.Repository
+	ledger    LedgerService
+	switches  SwitchService
+	funds     client.FundClient
+	schedules dealing.Schedules
+	locks     *Locks
+	publisher event.EventHandler
+	Logger    logger.Logger
+	mu        sync.Mutex
+}
+
+func NewDealingService(
+	repo repository.Repository,
+	ledger LedgerService,
+	switches SwitchService,
+	funds client.FundClient,
+	schedules dealing.Schedules,
+	locks *Locks,
+	publisher event.EventHandler,
+	logger logger.Logger,
+) *DealingServiceImpl {
+	return &DealingServiceImpl{
+		repo:      repo,
+		ledger:    ledger,
+		switches:  switches,
+		funds:     funds,
+		schedules: schedules,
+		locks:     locks,
+		publisher: publisher,
+		Logger:    logger,
+	}
+}
+
+type valuation struct {
+	fundId string
+	at     time.Time
+}
+
+// Run deals validated orders that were received before the cut-off of a valuation point
+// that has now passed. Orders for a later valuation point, or whose fund has not published a
+// price for theirs yet, are left for the next run
+func (s *DealingServiceImpl) Run(now time.Time) (*model.DealingRun, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	validated, err := s.repo.GetInvestmentsByStatus(model.InvestmentValidated)
+	if err != nil {
+		s.Logger.Error("error fetching validated investments", zap.Error(err))
+		return nil, err
+	}
+
+	// deal orders in the order they were received so earlier sell orders are filled first
+	slices.SortFunc(*validated, func(a, b model.Investment) int { return a.CreatedAt.Compare(b.CreatedAt) })
+	run := &model.DealingRun{RunAt: now}
+	prices := make(map[valuation]*model.FundPrice)
+	for _, investment := range *validated {
+		if !investment.Type.Buys() && !investment.Type.Sells() {
+			continue
+		}
+		point := valuation{investment.FundId, s.schedules.ValuationPoint(investment.FundId, investment.CreatedAt)}
+		if point.at.After(now) {
+			run.NotYetDue++
+			continue
+		}
+
+		price, fetched := prices[point]
+		if !fetched {
+			price = s.priceAt(point)
+			prices[point] = price
+		}
+		if price == nil {
+			run.AwaitingPrice++
+			continue
+		}
+
+		dealt, err := s.dealOrder(investment.Id, *price, now)
+		switch {
+		case err != nil:
+			s.Logger.Error("error dealing investment", zap.String("investment_id", investment.Id), zap.Error(err))
+			run.Failed++
+		case dealt == nil:
+			// changed by someone else since it was fetched, so no longer needs dealing
+		case dealt.Status == model.InvestmentFailed:
+			run.Failed++
+		default:
+			run.Dealt++
+		}
+	}
+
+	s.Logger.Info("dealing run complete",
+		zap.Int("dealt", run.Dealt),
+		zap.Int("not_yet_due", run.NotYetDue),
+		zap.Int("awaiting_price", run.AwaitingPrice),
+		zap.Int("failed", run.Failed),
+	)
+	return run, nil
+}
+
+// dealOrder deals the investment with id at price. It holds the investment's lock and reads it
+// again first, so an order failed or cancelled since the run fetched it is left alone and nil
+// is returned. A sale also holds the account's lock, so no other order can set aside the units it
+// sells while it is dealt
+func (s *DealingServiceImpl) dealOrder(id string, price model.FundPrice, now time.Time) (*model.Investment, error) {
+	unlock := s.locks.investments.Lock(id)
+	defer unlock()
+	investment, err := s.repo.GetInvestmentById(id)
+	if err != nil {
+		return nil, err
+	}
+	if investment.Status != model.InvestmentValidated {
+		return nil, nil
+	}
+
+	since := len(investment.History)
+	if investment.Type.Sells() {
+		unlockAccount := s.locks.accounts.Lock(investment.AccountId)
+		defer unlockAccount()
+		err = s.sell(investment, price, now)
+	} else {
+		err = deal(investment, price, now)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := recordStatusChanges(s.ledger, *investment, since); err != nil {
+		return nil, fmt.Errorf("posting dealt investment: %w", err)
+	}
+	if err := s.repo.UpdateInvestment(*investment); err != nil {
+		return nil, fmt.Errorf("saving dealt investment: %w", err)
+	}
+	if subject := dealingSubject(*investment); subject != "" {
+		if err := s.publisher.Publish(subject, investment); err != nil {
+			s.Logger.Error("error publishing dealing event", zap.String("subject", subject), zap.Error(err))
+		}
+	}
+	publishStatusChanges(s.publisher, s.Logger, *investment, since)
+	if err := s.switches.Progress(*investment); err != nil {
+		s.Logger.Error("error moving switch on", zap.String("investment_id", investment.Id), zap.Error(err))
+	}
+	return investment, nil
+}
+
+// priceAt returns the fund's price valued exactly at the valuation point, or nil if fund-service
+// has not published it yet
+func (s *DealingServiceImpl) priceAt(point valuation) *model.FundPrice {
+	prices, err := s.funds.GetPrices(point.fundId, point.at, point.at)
+	if err != nil {
+		s.Logger.Error("error fetching fund price for dealing", zap.String("fund_id", point.fundId), zap.Error(err))
+		return nil
+	}
+	for _, price := range *prices {
+		if price.ValuedAt.Equal(point.at) {
+			return &price
+		}
+	}
+	return nil
+}
+
+// deal allocates the units a subscription buys at price and marks it dealt
+func deal(investment *model.Investment, price model.FundPrice, now time.Time) error {
+	dealingPrice := price.BuyPrice()
+	units, residual := model.AllocateUnits(investment.Amount, dealingPrice)
+	reason := fmt.Sprintf("%s units at %s valued %s", units, dealingPrice, price.ValuedAt.Format(time.RFC3339))
+	if err := transition(investment, model.InvestmentDealt, reason, model.ActorDealingRun, now); err != nil {
+		return err
+	}
+	investment.Dealing = &model.Dealing{
+		Price:    dealingPrice,
+		Units:    units,
+		Residual: residual,
+		ValuedAt: price.ValuedAt,
+		DealtAt:  now,
+	}
+	return nil
+}
+
+// sell works out how many units a redemption sells at price and marks it dealt. A sale by amount
+// sells enough units to raise it. No sale can sell more than the account still holds less the
+// units set aside for its other sell orders and transfers out, so an order for more than that
+// sells what is left instead. If nothing is left to sell the order fails
+func (s *DealingServiceImpl) sell(investment *model.Investment, price model.FundPrice, now time.Time) error {
+	held, err := heldUnits(s.repo, investment.AccountId, investment.FundId)
+	if err != nil {
+		return err
+	}
+	reserved, err := reservedUnits(s.repo, investment.AccountId, investment.FundId)
+	if err != nil {
+		return err
+	}
+	details := *investment.Redemption
+	available := held - (reserved - details.Reserved)
+	if available <= 0 {
+		reason := "no units held at the valuation point"
+		if err := transition(investment, model.InvestmentFailed, reason, model.ActorDealingRun, now); err != nil {
+			return err
+		}
+		investment.FailureReason = &reason
+		return nil
+	}
+
+	dealingPrice := price.SellPrice()
+	units := details.Reserved
+	switch {
+	case details.All:
+		units = available
+	case !details.Amount.IsZero():
+		units = model.UnitsFor(details.Amount, dealingPrice)
+	}
+	units = min(units, available)
+	details.Proceeds = units.Value(dealingPrice)
+	details.Full = units == held
+
+	reason := fmt.Sprintf("sold %s units at %s valued %s", units, dealingPrice, price.ValuedAt.Format(time.RFC3339))
+	if err := transition(investment, model.InvestmentDealt, reason, model.ActorDealingRun, now); err != nil {
+		return err
+	}
+	investment.Dealing = &model.Dealing{
+		Price:    dealingPrice,
+		Units:    units,
+		ValuedAt: price.ValuedAt,
+		DealtAt:  now,
+	}
+	investment.Redemption = &details
+	investment.Amount = details.Proceeds
+	return nil
+}
+
+// dealingSubject is the event published once an order has been through a dealing run. The legs
+// of a switch are reported by the switch instead
+func dealingSubject(investment model.Investment) string {
+	switch {
+	case investment.Type == model.Subscription:
+		return "investment.dealt"
+	case investment.Type == model.Purchase:
+		return "investment.purchase.dealt"
+	case investment.Type == model.Redemption && investment.Status == model.InvestmentFailed:
+		return "investment.redemption.failed"
+	case investment.Type == model.Redemption:
+		return "investment.redemption.dealt"
+	}
+	return ""
+}
+
+// Start runs dealing every interval until ctx is cancelled
+func (s *DealingServiceImpl) Start(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case now := <-ticker.C:
+			s.Run(now)
+		}
+	}
+}
@@ -0,0 +1,435 @@
+package service_test
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/oliknight1/retail-isa-investment/investment-service/dealing"
+	"github.com/oliknight1/retail-isa-investment/investment-service/internal"
+	"github.com/oliknight1/retail-isa-investment/investment-service/logger"
+	"github.com/oliknight1/retail-isa-investment/investment-service/model"
+	"github.com/oliknight1/retail-isa-investment/investment-service/money"
+	"github.com/oliknight1/retail-isa-investment/investment-service/repository"
+	"github.com/oliknight1/retail-isa-investment/investment-service/service"
+	"github.com/oliknight1/retail-isa-investment/investment-service/taxyear"
+)
+
+// newDealingService deals on the default schedule, moving switches on in the same repositories
+func newDealingService(repo *repository.InvestmentClient, accounts *repository.AccountClient, ledger service.LedgerService, funds *mockFundClient, publisher *mockPublisher, l logger.Logger) *service.DealingServiceImpl {
+	locks := service.NewLocks()
+	switches := service.NewSwitchService(repository.NewSwitchClient(), repo, accounts, funds, ledger, locks, publisher, l)
+	return service.NewDealingService(repo, ledger, switches, funds, dealing.DefaultSchedules(), locks, publisher, l)
+}
+
+func TestDealingRun(t *testing.T) {
+	// Wednesday 4 June 2025, midday valuation point in London
+	valuationPoint := taxyear.InLondon(time.Date(2025, time.June, 4, 11, 0, 0, 0, time.UTC))
+	repo := repository.NewInvestmentClient()
+	orders := []model.Investment{
+		{Id: "inv-before", FundId: "fund-1", CreatedAt: valuationPoint.Add(-time.Hour)},
+		{Id: "inv-after", FundId: "fund-1", CreatedAt: valuationPoint.Add(time.Minute)},
+		{Id: "inv-unpriced", FundId: "fund-unpriced", CreatedAt: valuationPoint.Add(-time.Hour)},
+	}
+	for _, order := range orders {
+		order.AccountId = "acc-1"
+		order.CustomerId = "cust-1"
+		order.Type = model.Subscription
+		order.Amount = money.Pounds(1000)
+		order.Status = model.InvestmentValidated
+		repo.CreateInvestment(order)
+	}
+
+	var requested []time.Time
+	funds := fundPriced("3.000000")
+	priced := funds.getPrices
+	funds.getPrices = func(fundId string, from, to time.Time) (*[]model.FundPrice, error) {
+		requested = append(requested, from)
+		if fundId == "fund-unpriced" {
+			return &[]model.FundPrice{}, nil
+		}
+		return priced(fundId, from, to)
+	}
+	var published []string
+	mockPub := &mockPublisher{
+		publishFn: func(subject string, payload any) error {
+			published = append(published, subject)
+			return nil
+		},
+	}
+	logger := logger.NewMockLogger()
+	svc := newDealingService(repo, newAccountRepo(), newLedger(logger), funds, mockPub, logger)
+
+	now := valuationPoint.Add(5 * time.Minute)
+	run, err := svc.Run(now)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	expected := &model.DealingRun{RunAt: now, Dealt: 1, NotYetDue: 1, AwaitingPrice: 1}
+	if diff := cmp.Diff(expected, run); diff != "" {
+		t.Errorf("unexpected run (-want +got):\n%s", diff)
+	}
+	for _, at := range requested {
+		if !at.Equal(valuationPoint) {
+			t.Errorf("expected prices to be asked for at the valuation point, got %v", at)
+		}
+	}
+	if diff := cmp.Diff([]string{"investment.dealt", "investment.status.dealt", "investment.units.moved"}, published); diff != "" {
+		t.Errorf("unexpected events (-want +got):\n%s", diff)
+	}
+
+	dealt, _ := repo.GetInvestmentById("inv-before")
+	if dealt.Status != model.InvestmentDealt || dealt.Dealing == nil || len(dealt.History) != 1 || dealt.History[0].Actor != model.ActorDealingRun {
+		t.Fatalf("expected order before the cut-off to be dealt, got %+v", dealt)
+	}
+	if dealt.Dealing.Units.String() != "333.3333" || !dealt.Dealing.ValuedAt.Equal(valuationPoint) {
+		t.Errorf("expected 333.3333 units at the valuation point, got %s at %v", dealt.Dealing.Units, dealt.Dealing.ValuedAt)
+	}
+	for _, id := range []string{"inv-after", "inv-unpriced"} {
+		if investment, _ := repo.GetInvestmentById(id); investment.Status != model.InvestmentValidated {
+			t.Errorf("expected %s to be left for a later run, got %s", id, investment.Status)
+		}
+	}
+
+	// the order after the cut-off is dealt at the next day's valuation point
+	published = nil
+	run, err = svc.Run(now.AddDate(0, 0, 1))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if run.Dealt != 1 || run.AwaitingPrice != 1 {
+		t.Errorf("expected the later order to be dealt next day, got %+v", run)
+	}
+	later, _ := repo.GetInvestmentById("inv-after")
+	if later.Dealing == nil || !later.Dealing.ValuedAt.Equal(valuationPoint.AddDate(0, 0, 1)) {
+		t.Errorf("expected inv-after to be valued on 5 June, got %+v", later.Dealing)
+	}
+}
+
+func TestCancelDealtInvestment(t *testing.T) {
+	logger := logger.NewMockLogger()
+	repo := repository.NewInvestmentClient()
+	nav := "3.000000"
+	funds := &mockFundClient{
+		getLatestPrice: func(fundId string) (*model.FundPrice, error) {
+			return fundPriced(nav).GetLatestPrice(fundId)
+		},
+		getPrices: fundPriced(nav).getPrices,
+	}
+	mockPub := &mockPublisher{publishFn: func(subject string, payload any) error { return nil }}
+	ledger := newLedger(logger)
+	svc := service.New(repo, newAccountRepo(), customersAged(30), funds, newAllowanceService(logger), newBonusService(logger), ledger, service.NewLocks(), mockPub, logger)
+	dealingSvc := newDealingService(repo, newAccountRepo(), ledger, funds, mockPub, logger)
+
+	investment, err := svc.CreateInvestment("acc-1", "fund-1", money.Pounds(1000))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	risen, err := svc.CreateInvestment("acc-1", "fund-2", money.Pounds(1000))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if _, err := dealingSvc.Run(time.Now().AddDate(0, 0, 7)); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	// the price falls by 10% before the customer cancels, so they bear that loss
+	nav = "2.700000"
+	cancelled, err := svc.CancelInvestment(investment.Id)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	expected := &model.Cancellation{RefundAmount: money.Pounds(900), MarketLoss: money.Pounds(100), CancelledAt: cancelled.Cancellation.CancelledAt}
+	if diff := cmp.Diff(expected, cancelled.Cancellation); diff != "" {
+		t.Errorf("unexpected cancellation (-want +got):\n%s", diff)
+	}
+	// a rise in price is not passed on, so the whole amount is refunded
+	nav = "3.300000"
+	if _, err := svc.CancelInvestment(risen.Id); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	balances, err := ledger.GetBalances("acc-1")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	actual := make(map[string]string)
+	for _, balance := range *balances {
+		actual[balance.Code] = balance.Balance.String()
+	}
+	// £2,000 paid in and £1,900 refunded, with the £100 loss booked on its own
+	expectedBalances := map[string]string{
+		"external:acc-1":        "-100.00",
+		"holdings:acc-1:fund-1": "0.00",
+		"holdings:acc-1:fund-2": "0.00",
+		"market_loss:acc-1":     "100.00",
+	}
+	if diff := cmp.Diff(expectedBalances, actual); diff != "" {
+		t.Errorf("unexpected balances (-want +got):\n%s", diff)
+	}
+}
+
+func TestCancelSoldInvestment(t *testing.T) {
+	logger := logger.NewMockLogger()
+	repo := repository.NewInvestmentClient()
+	accounts := newAccountRepo()
+	mockPub := &mockPublisher{publishFn: func(subject string, payload any) error { return nil }}
+	ledger := newLedger(logger)
+	svc := service.New(repo, accounts, customersAged(30), fundPriced("2.000000"), newAllowanceService(logger), newBonusService(logger), ledger, service.NewLocks(), mockPub, logger)
+	dealingSvc := newDealingService(repo, accounts, ledger, fundPriced("2.000000"), mockPub, logger)
+	week := time.Now().AddDate(0, 0, 7)
+
+	investment, err := svc.CreateInvestment("acc-1", "fund-1", money.Pounds(1000))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if _, err := dealingSvc.Run(week); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if _, err := svc.Redeem("acc-1", "fund-1", model.RedemptionOrder{All: true}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	// units set aside for a sell order cannot be given back either
+	if _, err := svc.CancelInvestment(investment.Id); !errors.Is(err, internal.ErrCannotCancel) {
+		t.Errorf("expected a subscription with units set aside to sell not to be cancelled, got: %v", err)
+	}
+	if _, err := dealingSvc.Run(week.AddDate(0, 0, 7)); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if _, err := svc.CancelInvestment(investment.Id); !errors.Is(err, internal.ErrCannotCancel) {
+		t.Errorf("expected a subscription whose units were sold not to be cancelled, got: %v", err)
+	}
+	if cash := cashIn(t, ledger, "acc-1"); !cash.Available.Equal(money.Pounds(1000)) {
+		t.Errorf("expected only the sale proceeds in cash, got %+v", cash)
+	}
+}
+
+func TestRedemption(t *testing.T) {
+	logger := logger.NewMockLogger()
+	repo := repository.NewInvestmentClient()
+	accounts := newAccountRepo()
+	repo.CreateInvestment(model.Investment{
+		Id:         "inv-bought",
+		AccountId:  "acc-1",
+		CustomerId: "cust-1",
+		FundId:     "fund-1",
+		Type:       model.Subscription,
+		Amount:     money.Pounds(1000),
+		Status:     model.InvestmentSettled,
+		Dealing:    &model.Dealing{Units: 5_000_000},
+		CreatedAt:  time.Now().AddDate(0, -1, 0),
+	})
+	var published []string
+	mockPub := &mockPublisher{
+		publishFn: func(subject string, payload any) error {
+			published = append(published, subject)
+			return nil
+		},
+	}
+	ledger := newLedger(logger)
+	svc := service.New(repo, accounts, customersAged(30), fundPriced("2.000000"), newAllowanceService(logger), newBonusService(logger), ledger, service.NewLocks(), mockPub, logger)
+
+	if _, err := svc.Redeem("acc-1", "fund-1", model.RedemptionOrder{Amount: money.Pounds(100), Units: 10_000}); !errors.Is(err, internal.ErrInvalidRedemption) {
+		t.Errorf("expected an order by both amount and units to be rejected, got: %v", err)
+	}
+	if _, err := svc.Redeem("acc-1", "fund-2", model.RedemptionOrder{All: true}); !errors.Is(err, internal.ErrInsufficientHolding) {
+		t.Errorf("expected a sale from a fund not held to be rejected, got: %v", err)
+	}
+	byUnits, err := svc.Redeem("acc-1", "fund-1", model.RedemptionOrder{Units: 1_000_000})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	byAmount, err := svc.Redeem("acc-1", "fund-1", model.RedemptionOrder{Amount: money.Pounds(250)})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if byAmount.Redemption.Reserved.String() != "125.0000" {
+		t.Errorf("expected 125 units held back at the latest price, got %s", byAmount.Redemption.Reserved)
+	}
+	// 500 held less 225 held back for the orders above leaves 275
+	if _, err := svc.Redeem("acc-1", "fund-1", model.RedemptionOrder{Units: 3_000_000}); !errors.Is(err, internal.ErrInsufficientHolding) {
+		t.Errorf("expected a sale of more than the remaining holding to be rejected, got: %v", err)
+	}
+	rest, err := svc.Redeem("acc-1", "fund-1", model.RedemptionOrder{All: true})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	published = nil
+	dealingSvc := newDealingService(repo, accounts, ledger, fundPriced("2.500000"), mockPub, logger)
+	run, err := dealingSvc.Run(time.Now().AddDate(0, 0, 7))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if run.Dealt != 3 {
+		t.Fatalf("expected all three sell orders to be dealt, got %+v", run)
+	}
+
+	tests := []struct {
+		id       string
+		units    string
+		proceeds money.Money
+		full     bool
+	}{
+		{byUnits.Id, "100.0000", money.Pounds(250), false},
+		{byAmount.Id, "100.0000", money.Pounds(250), false},
+		{rest.Id, "300.0000", money.Pounds(750), true},
+	}
+	for _, tt := range tests {
+		sold, _ := repo.GetInvestmentById(tt.id)
+		if sold.Status != model.InvestmentDealt || sold.Dealing.Units.String() != tt.units ||
+			!sold.Redemption.Proceeds.Equal(tt.proceeds) || sold.Redemption.Full != tt.full {
+			t.Errorf("expected %s units sold for %s (full %t), got %+v %+v", tt.units, tt.proceeds, tt.full, sold.Dealing, sold.Redemption)
+		}
+	}
+	if cash := cashIn(t, ledger, "acc-1"); !cash.Balance.Equal(money.Pounds(1250)) {
+		t.Errorf("expected proceeds to be credited to cash, got %s", cash.Balance)
+	}
+	dealtEvents := 0
+	for _, subject := range published {
+		if subject == "investment.redemption.dealt" {
+			dealtEvents++
+		}
+	}
+	if dealtEvents != 3 {
+		t.Errorf("expected an investment.redemption.dealt event per order, got %v", published)
+	}
+
+	if _, err := svc.Redeem("acc-1", "fund-1", model.RedemptionOrder{All: true}); !errors.Is(err, internal.ErrInsufficientHolding) {
+		t.Errorf("expected nothing left to sell, got: %v", err)
+	}
+}
+
+func TestRedemptionKeepsUnitsSetAside(t *testing.T) {
+	logger := logger.NewMockLogger()
+	repo := repository.NewInvestmentClient()
+	accounts := newAccountRepo()
+	repo.CreateInvestment(model.Investment{
+		Id:         "inv-bought",
+		AccountId:  "acc-1",
+		CustomerId: "cust-1",
+		FundId:     "fund-1",
+		Type:       model.Subscription,
+		Amount:     money.Pounds(1000),
+		Status:     model.InvestmentSettled,
+		Dealing:    &model.Dealing{Units: 1_000_000},
+		CreatedAt:  time.Now().AddDate(0, -1, 0),
+	})
+	// the order is placed at 10.00 and the price has fallen to 5.00 by the valuation point
+	funds := fundPriced("10.000000")
+	funds.getPrices = fundPriced("5.000000").getPrices
+	mockPub := &mockPublisher{publishFn: func(subject string, payload any) error { return nil }}
+	ledger := newLedger(logger)
+	allowance := newAllowanceService(logger)
+	locks := service.NewLocks()
+	svc := service.New(repo, accounts, customersAged(30), funds, allowance, newBonusService(logger), ledger, locks, mockPub, logger)
+	transfers := service.NewTransferService(repository.NewTransferClient(), accounts, repo, funds, allowance, ledger, locks, mockPub, logger)
+
+	sale, err := svc.Redeem("acc-1", "fund-1", model.RedemptionOrder{Amount: money.Pounds(500)})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if sale.Redemption.Reserved.String() != "50.0000" {
+		t.Fatalf("expected 50 units held back at the latest price, got %s", sale.Redemption.Reserved)
+	}
+	if _, err := transfers.RequestTransfer(model.Transfer{
+		AccountId:       "acc-1",
+		Direction:       model.TransferOut,
+		Method:          model.TransferStock,
+		Provider:        "Other Bank",
+		PriorYearAmount: money.Pounds(500),
+		Holdings:        []model.TransferHolding{{FundId: "fund-1", Units: 500_000}},
+	}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	dealingSvc := newDealingService(repo, accounts, ledger, funds, mockPub, logger)
+	if run, err := dealingSvc.Run(time.Now().AddDate(0, 0, 7)); err != nil || run.Dealt != 1 {
+		t.Fatalf("expected the sale to be dealt, got %+v, %v", run, err)
+	}
+	sold, _ := repo.GetInvestmentById(sale.Id)
+	if sold.Dealing.Units.String() != "50.0000" || !sold.Redemption.Proceeds.Equal(money.Pounds(250)) || sold.Redemption.Full {
+		t.Errorf("expected only the 50 units not set aside for the transfer to be sold, got %+v %+v", sold.Dealing, sold.Redemption)
+	}
+	if _, err := transfers.RequestTransfer(model.Transfer{
+		AccountId:       "acc-1",
+		Direction:       model.TransferOut,
+		Method:          model.TransferStock,
+		Provider:        "Other Bank",
+		PriorYearAmount: money.Pounds(10),
+		Holdings:        []model.TransferHolding{{FundId: "fund-1", Units: 1}},
+	}); !errors.Is(err, internal.ErrInsufficientHolding) {
+		t.Errorf("expected nothing left beyond the units set aside for the transfer, got: %v", err)
+	}
+}
+
+func TestPurchase(t *testing.T) {
+	logger := logger.NewMockLogger()
+	repo := repository.NewInvestmentClient()
+	accounts := newAccountRepo()
+	mockPub := &mockPublisher{publishFn: func(subject string, payload any) error { return nil }}
+	ledger := newLedger(logger)
+	locks := service.NewLocks()
+	svc := service.New(repo, accounts, customersAged(30), fundPriced("2.000000"), newAllowanceService(logger), newBonusService(logger), ledger, locks, mockPub, logger)
+	accountSvc := service.NewAccountService(accounts, customersAged(30), ledger, locks, mockPub, logger)
+
+	if _, err := svc.Deposit("acc-1", money.Pounds(500)); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	purchase, err := svc.Buy("acc-1", "fund-1", money.Pounds(400))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if purchase.Status != model.InvestmentValidated || !purchase.AllowanceUse.Subscribed.IsZero() {
+		t.Errorf("expected a validated purchase using no allowance, got %+v", purchase)
+	}
+	if _, err := svc.Buy("acc-1", "fund-1", money.Pounds(200)); !errors.Is(err, internal.ErrInsufficientCash) {
+		t.Errorf("expected a purchase of more than the available cash to be rejected, got: %v", err)
+	}
+	failing, err := svc.Buy("acc-1", "fund-2", money.Pounds(100))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	balance, err := accountSvc.GetCashBalance("acc-1")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	expected := &model.CashBalance{AccountId: "acc-1", Balance: money.Pounds(500), Reserved: money.Pounds(500), Available: money.Pounds(0)}
+	if diff := cmp.Diff(expected, balance); diff != "" {
+		t.Errorf("unexpected balance after reserving (-want +got):\n%s", diff)
+	}
+
+	// failing a purchase gives back its reservation
+	if _, err := svc.UpdateInvestmentStatus(failing.Id, model.InvestmentFailed, "fund suspended", "ops:jo"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	balance, _ = accountSvc.GetCashBalance("acc-1")
+	if !balance.Reserved.Equal(money.Pounds(400)) || !balance.Available.Equal(money.Pounds(100)) {
+		t.Errorf("expected £100 to be released, got %+v", balance)
+	}
+
+	// dealing spends the reservation
+	dealingSvc := newDealingService(repo, accounts, ledger, fundPriced("2.000000"), mockPub, logger)
+	if run, err := dealingSvc.Run(time.Now().AddDate(0, 0, 7)); err != nil || run.Dealt != 1 {
+		t.Fatalf("expected the purchase to be dealt, got %+v, %v", run, err)
+	}
+	dealt, _ := repo.GetInvestmentById(purchase.Id)
+	if dealt.Dealing == nil || dealt.Dealing.Units.String() != "200.0000" {
+		t.Fatalf("expected 200 units bought, got %+v", dealt.Dealing)
+	}
+	balance, _ = accountSvc.GetCashBalance("acc-1")
+	expected = &model.CashBalance{AccountId: "acc-1", Balance: money.Pounds(100), Reserved: money.Pounds(0), Available: money.Pounds(100)}
+	if diff := cmp.Diff(expected, balance); diff != "" {
+		t.Errorf("unexpected balance after dealing (-want +got):\n%s", diff)
+	}
+
+	// failing a dealt purchase credits back what it spent
+	if _, err := svc.UpdateInvestmentStatus(purchase.Id, model.InvestmentFailed, "trade rejected", "ops:jo"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	balance, _ = accountSvc.GetCashBalance("acc-1")
+	if !balance.Balance.Equal(money.Pounds(500)) || !balance.Available.Equal(money.Pounds(500)) {
+		t.Errorf("expected the purchase cost back in cash, got %+v", balance)
+	}
+}
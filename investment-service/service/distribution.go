@@ -0,0 +1,310 @@
+package service
+
+import (
+	"cmp"
+	"context"
+	"errors"
+	"fmt"
+	"maps"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/oliknight1/retail-isa-investment/investment-service/client"
+	"github.com/oliknight1/retail-isa-investment/investment-service/event"
+	"github.com/oliknight1/retail-isa-investment/investment-service/internal"
+	"github.com/oliknight1/retail-isa-investment/investment-service/logger"
+	"github.com/oliknight1/retail-isa-investment/investment-service/model"
+	"github.com/oliknight1/retail-isa-investment/investment-service/repository"
+	"github.com/oliknight1/retail-isa-investment/investment-service/taxyear"
+	"go.uber.org/zap"
+)
+
+type DistributionService interface {
+	GetDistributionsByCustomerId(id string) (*[]model.DistributionPayment, error)
+	Run(now time.Time) (*model.DistributionRun, error)
+}
+
+// DistributionServiceImpl pays out the income funds distribute once each distribution's pay
+// date arrives
+type DistributionServiceImpl struct {
+	repo        repository.DistributionRepository
+	investments repository.Repository
+	accounts    repository.AccountRepository
+	funds       client.FundClient
+	ledger      LedgerService
+	locks       *Locks
+	publisher   event.EventHandler
+	Logger      logger.Logger
+	mu          sync.Mutex
+}
+
+func NewDistributionService(
+	repo repository.DistributionRepository,
+	investments repository.Repository,
+	accounts repository.AccountRepository,
+	funds client.FundClient,
+	ledger LedgerService,
+	locks *Locks,
+	publisher event.EventHandler,
+	logger logger.Logger,
+) *DistributionServiceImpl {
+	return &DistributionServiceImpl{
+		repo:        repo,
+		investments: investments,
+		accounts:    accounts,
+		funds:       funds,
+		ledger:      ledger,
+		locks:       locks,
+		publisher:   publisher,
+		Logger:      logger,
+	}
+}
+
+func (s *DistributionServiceImpl) GetDistributionsByCustomerId(id string) (*[]model.DistributionPayment, error) {
+	if id == "" {
+		s.Logger.Error("missing customer_id when requesting distributions", zap.Error(internal.ErrMissingCustomerId))
+		return nil, internal.ErrMissingCustomerId
+	}
+	payments, err := s.repo.GetPaymentsByCustomerId(id)
+	if err != nil {
+		s.Logger.Error("error fetching distribution payments", zap.Error(err))
+		return nil, err
+	}
+	slices.SortFunc(*payments, func(a, b model.DistributionPayment) int { return a.PaidAt.Compare(b.PaidAt) })
+	return payments, nil
+}
+
+// Run pays every distribution whose pay date has arrived and has not been paid yet. A
+// distribution is left for the next run if its fund cannot be fetched, and is only marked paid
+// once every account due a share has been paid, so accounts that failed are retried next run
+func (s *DistributionServiceImpl) Run(now time.Time) (*model.DistributionRun, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	due, err := s.funds.GetDistributions(londonDate(now))
+	if err != nil {
+		s.Logger.Error("error fetching distributions", zap.Error(err))
+		return nil, err
+	}
+	var investments []model.Investment
+	for _, status := range []model.InvestmentStatus{model.InvestmentDealt, model.InvestmentSettled} {
+		found, err := s.investments.GetInvestmentsByStatus(status)
+		if err != nil {
+			s.Logger.Error("error fetching investments for distributions", zap.Error(err))
+			return nil, err
+		}
+		investments = append(investments, *found...)
+	}
+
+	slices.SortFunc(*due, func(a, b model.Distribution) int {
+		return cmp.Or(a.PayDate.Compare(b.PayDate.Time), cmp.Compare(a.Id, b.Id))
+	})
+	run := &model.DistributionRun{RunAt: now}
+	for _, distribution := range *due {
+		paid, err := s.repo.IsPaid(distribution.Id)
+		if err != nil {
+			s.Logger.Error("error checking distribution", zap.String("distribution_id", distribution.Id), zap.Error(err))
+			continue
+		}
+		if paid {
+			continue
+		}
+		fund, err := s.funds.GetFund(distribution.FundId)
+		if err != nil {
+			s.Logger.Error("error fetching fund for distribution", zap.String("distribution_id", distribution.Id), zap.Error(err))
+			continue
+		}
+
+		run.Distributions++
+		if !s.pay(run, distribution, *fund, investments, now) {
+			s.Logger.Info("distribution not paid to every account, retrying next run", zap.String("distribution_id", distribution.Id))
+			continue
+		}
+		if err := s.repo.AddPaidDistribution(distribution); err != nil {
+			s.Logger.Error("error saving paid distribution", zap.String("distribution_id", distribution.Id), zap.Error(err))
+		}
+	}
+
+	s.Logger.Info("distribution run complete",
+		zap.Int("distributions", run.Distributions),
+		zap.Int("paid", run.Paid),
+		zap.Int("reinvested", run.Reinvested),
+		zap.Int("failed", run.Failed),
+	)
+	return run, nil
+}
+
+// pay gives every account that held units of the fund before it went ex-dividend its share of
+// the distribution. Units bought at a valuation point on or after the ex-dividend date are
+// bought without the income, and units sold from then on still receive it. Accounts already
+// paid by an earlier run are skipped, and it reports whether every account has now been paid
+func (s *DistributionServiceImpl) pay(run *model.DistributionRun, distribution model.Distribution, fund model.Fund, investments []model.Investment, now time.Time) bool {
+	exDate := startOfDay(distribution.ExDate)
+	var qualifying []model.Investment
+	for _, investment := range investments {
+		if investment.FundId == distribution.FundId && investment.Dealing != nil && investment.Dealing.ValuedAt.Before(exDate) {
+			qualifying = append(qualifying, investment)
+		}
+	}
+
+	held := positions(qualifying)
+	complete := true
+	for _, accountId := range slices.Sorted(maps.Keys(held)) {
+		p := held[accountId][distribution.FundId]
+		amount := p.units.Value(distribution.Rate)
+		if !amount.IsPositive() {
+			continue
+		}
+		paid, err := s.repo.GetPayment(distribution.Id, accountId)
+		if err != nil {
+			s.Logger.Error("error checking distribution payment", zap.String("account_id", accountId), zap.String("distribution_id", distribution.Id), zap.Error(err))
+			run.Failed++
+			complete = false
+			continue
+		}
+		if paid != nil {
+			continue
+		}
+		account, err := s.accounts.GetAccountById(accountId)
+		if err != nil {
+			s.Logger.Error("error fetching account for distribution", zap.String("account_id", accountId), zap.Error(err))
+			run.Failed++
+			complete = false
+			continue
+		}
+
+		payment := model.DistributionPayment{
+			Id:             uuid.New().String(),
+			DistributionId: distribution.Id,
+			AccountId:      account.Id,
+			CustomerId:     account.CustomerId,
+			FundId:         distribution.FundId,
+			UnitClass:      fund.UnitClass,
+			Units:          p.units,
+			Rate:           distribution.Rate,
+			Amount:         amount,
+			PaidAt:         now,
+		}
+		if fund.UnitClass == model.UnitClassIncome && account.IncomePreference != model.IncomeReinvest {
+			err = s.payOut(&payment)
+		} else {
+			err = s.reinvest(&payment, now)
+		}
+		if err != nil {
+			s.Logger.Error("error paying distribution", zap.String("account_id", accountId), zap.String("distribution_id", distribution.Id), zap.Error(err))
+			run.Failed++
+			complete = false
+			continue
+		}
+		if err := s.repo.CreatePayment(payment); err != nil {
+			// the next run pays the account again, so take back a reinvestment. Income paid out is
+			// only posted to the ledger once however many times it is paid
+			s.Logger.Error("error saving distribution payment", zap.String("account_id", accountId), zap.Error(err))
+			s.undo(payment, now)
+			run.Failed++
+			complete = false
+			continue
+		}
+
+		subject := "investment.distribution." + string(payment.Outcome)
+		if err := s.publisher.Publish(subject, payment); err != nil {
+			s.Logger.Error("error publishing distribution event", zap.String("subject", subject), zap.Error(err))
+		}
+		if payment.Outcome == model.DistributionPaid {
+			run.Paid++
+		} else {
+			run.Reinvested++
+		}
+	}
+	return complete
+}
+
+// payOut credits the income to the account's cash
+func (s *DistributionServiceImpl) payOut(payment *model.DistributionPayment) error {
+	payment.Outcome = model.DistributionPaid
+	return s.ledger.RecordDistribution(*payment)
+}
+
+// reinvest places an order for more units of the fund with the income, dealt at its next
+// valuation point
+func (s *DistributionServiceImpl) reinvest(payment *model.DistributionPayment, now time.Time) error {
+	investment := model.Investment{
+		Id:         uuid.New().String(),
+		AccountId:  payment.AccountId,
+		CustomerId: payment.CustomerId,
+		FundId:     payment.FundId,
+		Type:       model.Reinvestment,
+		Amount:     payment.Amount,
+		TaxYear:    taxyear.For(now),
+		CreatedAt:  now,
+	}
+	reason := fmt.Sprintf("income of %s per unit on %s units to reinvest", payment.Rate, payment.Units)
+	if err := errors.Join(
+		transition(&investment, model.InvestmentPending, reason, model.ActorSystem, now),
+		transition(&investment, model.InvestmentValidated, "reinvested income uses no allowance", model.ActorSystem, now),
+	); err != nil {
+		return err
+	}
+	if err := recordStatusChanges(s.ledger, investment, 0); err != nil {
+		return err
+	}
+	if err := s.investments.CreateInvestment(investment); err != nil {
+		unrecord(s.ledger, s.Logger, investment)
+		return err
+	}
+	publishStatusChanges(s.publisher, s.Logger, investment, 0)
+
+	payment.Outcome = model.DistributionReinvested
+	payment.InvestmentId = &investment.Id
+	return nil
+}
+
+// undo takes back a reinvestment that could not be recorded by failing it before it is dealt
+func (s *DistributionServiceImpl) undo(payment model.DistributionPayment, now time.Time) {
+	if payment.Outcome != model.DistributionReinvested {
+		return
+	}
+	unlock := s.locks.investments.Lock(*payment.InvestmentId)
+	defer unlock()
+	investment, err := s.investments.GetInvestmentById(*payment.InvestmentId)
+	if err == nil {
+		since := len(investment.History)
+		reason := "distribution payment could not be recorded"
+		if err = transition(investment, model.InvestmentFailed, reason, model.ActorSystem, now); err == nil {
+			investment.FailureReason = &reason
+			err = recordStatusChanges(s.ledger, *investment, since)
+			if err == nil {
+				err = s.investments.UpdateInvestment(*investment)
+			}
+			if err == nil {
+				publishStatusChanges(s.publisher, s.Logger, *investment, since)
+			}
+		}
+	}
+	if err != nil {
+		s.Logger.Error("error failing unrecorded reinvestment", zap.String("investment_id", *payment.InvestmentId), zap.Error(err))
+	}
+}
+
+// startOfDay is midnight in London at the start of date
+func startOfDay(date model.Date) time.Time {
+	london := taxyear.InLondon(date.Time).Location()
+	return time.Date(date.Year(), date.Month(), date.Day(), 0, 0, 0, 0, london)
+}
+
+// Start pays distributions every interval until ctx is cancelled
+func (s *DistributionServiceImpl) Start(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case now := <-ticker.C:
+			s.Run(now)
+		}
+	}
+}
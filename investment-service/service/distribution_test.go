@@ -0,0 +1,204 @@
+package service_test
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/oliknight1/retail-isa-investment/investment-service/logger"
+	"github.com/oliknight1/retail-isa-investment/investment-service/model"
+	"github.com/oliknight1/retail-isa-investment/investment-service/money"
+	"github.com/oliknight1/retail-isa-investment/investment-service/repository"
+	"github.com/oliknight1/retail-isa-investment/investment-service/service"
+)
+
+func TestDistributionRun(t *testing.T) {
+	repo := repository.NewInvestmentClient()
+	for _, order := range []struct {
+		id        string
+		accountId string
+		fundId    string
+		kind      model.InvestmentType
+		units     model.Units
+		valuedAt  time.Time
+	}{
+		{"inv-1", "acc-1", "fund-inc", model.Subscription, 10_000_000, time.Date(2025, time.June, 2, 11, 0, 0, 0, time.UTC)},
+		{"inv-2", "acc-lisa", "fund-inc", model.Subscription, 5_000_000, time.Date(2025, time.June, 4, 11, 0, 0, 0, time.UTC)},
+		// bought at midday on the ex-dividend date, so without the income
+		{"inv-3", "acc-1", "fund-inc", model.Subscription, 2_000_000, time.Date(2025, time.June, 5, 11, 0, 0, 0, time.UTC)},
+		// sold after going ex-dividend, so the units still receive it
+		{"inv-4", "acc-1", "fund-inc", model.Redemption, 1_000_000, time.Date(2025, time.June, 6, 11, 0, 0, 0, time.UTC)},
+		{"inv-5", "acc-1", "fund-acc", model.Subscription, 3_000_000, time.Date(2025, time.June, 3, 11, 0, 0, 0, time.UTC)},
+	} {
+		repo.CreateInvestment(model.Investment{
+			Id:         order.id,
+			AccountId:  order.accountId,
+			CustomerId: "cust-1",
+			FundId:     order.fundId,
+			Type:       order.kind,
+			Amount:     order.units.Value(1_000_000),
+			Status:     model.InvestmentSettled,
+			Dealing:    &model.Dealing{Price: 1_000_000, Units: order.units, ValuedAt: order.valuedAt},
+		})
+	}
+	accounts := newAccountRepo()
+	lisa, _ := accounts.GetAccountById("acc-lisa")
+	lisa.IncomePreference = model.IncomeReinvest
+	accounts.UpdateAccount(*lisa)
+
+	declared := []model.Distribution{
+		{Id: "dist-inc", FundId: "fund-inc", Rate: 10_000, ExDate: model.NewDate(2025, time.June, 5), PayDate: model.NewDate(2025, time.June, 30)},
+		{Id: "dist-acc", FundId: "fund-acc", Rate: 20_000, ExDate: model.NewDate(2025, time.June, 5), PayDate: model.NewDate(2025, time.June, 30)},
+		{Id: "dist-later", FundId: "fund-inc", Rate: 10_000, ExDate: model.NewDate(2025, time.September, 5), PayDate: model.NewDate(2025, time.September, 30)},
+	}
+	funds := &mockFundClient{
+		getFund: func(fundId string) (*model.Fund, error) {
+			classes := map[string]model.UnitClass{"fund-inc": model.UnitClassIncome, "fund-acc": model.UnitClassAccumulation}
+			return &model.Fund{Id: fundId, UnitClass: classes[fundId]}, nil
+		},
+		getDistributions: func(paidBy model.Date) (*[]model.Distribution, error) {
+			due := []model.Distribution{}
+			for _, distribution := range declared {
+				if !distribution.PayDate.After(paidBy.Time) {
+					due = append(due, distribution)
+				}
+			}
+			return &due, nil
+		},
+	}
+	var published []string
+	mockPub := &mockPublisher{
+		publishFn: func(subject string, payload any) error {
+			published = append(published, subject)
+			return nil
+		},
+	}
+	ledger := newLedger(logger.NewMockLogger())
+	svc := service.NewDistributionService(repository.NewDistributionClient(), repo, accounts, funds, ledger, service.NewLocks(), mockPub, logger.NewMockLogger())
+
+	now := time.Date(2025, time.June, 30, 9, 0, 0, 0, time.UTC)
+	run, err := svc.Run(now)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	expected := model.DistributionRun{RunAt: now, Distributions: 2, Paid: 1, Reinvested: 2}
+	if *run != expected {
+		t.Errorf("expected %+v, got %+v", expected, *run)
+	}
+
+	// 1,000 income units at 1p each are paid into cash
+	if cash := cashIn(t, ledger, "acc-1"); !cash.Balance.Equal(money.Pounds(10)) {
+		t.Errorf("expected £10.00 of income paid to cash, got %s", cash.Balance)
+	}
+
+	// the Lifetime ISA asked for its income reinvested, and accumulation units always are
+	reinvested := map[string]money.Money{}
+	validated, _ := repo.GetInvestmentsByStatus(model.InvestmentValidated)
+	for _, investment := range *validated {
+		if investment.Type != model.Reinvestment || !investment.AllowanceUse.Subscribed.IsZero() {
+			t.Errorf("expected a reinvestment using no allowance, got %+v", investment)
+		}
+		reinvested[investment.AccountId+"/"+investment.FundId] = investment.Amount
+	}
+	if len(reinvested) != 2 || !reinvested["acc-lisa/fund-inc"].Equal(money.Pounds(5)) || !reinvested["acc-1/fund-acc"].Equal(money.Pounds(6)) {
+		t.Errorf("unexpected reinvestments %v", reinvested)
+	}
+
+	payments := 0
+	for _, subject := range published {
+		if subject == "investment.distribution.paid" || subject == "investment.distribution.reinvested" {
+			payments++
+		}
+	}
+	if payments != 3 {
+		t.Errorf("expected an event for each payment, got %v", published)
+	}
+
+	// a distribution is only paid once
+	run, err = svc.Run(now.Add(time.Hour))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if run.Distributions != 0 {
+		t.Errorf("expected nothing left to pay, got %+v", run)
+	}
+
+	history, err := svc.GetDistributionsByCustomerId("cust-1")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(*history) != 3 {
+		t.Errorf("expected 3 payments, got %+v", *history)
+	}
+}
+
+// unavailableAccounts cannot fetch the accounts in down
+type unavailableAccounts struct {
+	*repository.AccountClient
+	down map[string]bool
+}
+
+func (u unavailableAccounts) GetAccountById(id string) (*model.Account, error) {
+	if u.down[id] {
+		return nil, errors.New("database unavailable")
+	}
+	return u.AccountClient.GetAccountById(id)
+}
+
+func TestDistributionRunRetriesFailedAccounts(t *testing.T) {
+	repo := repository.NewInvestmentClient()
+	for _, accountId := range []string{"acc-1", "acc-junior"} {
+		repo.CreateInvestment(model.Investment{
+			Id:        "inv-" + accountId,
+			AccountId: accountId,
+			FundId:    "fund-inc",
+			Type:      model.Subscription,
+			Amount:    money.Pounds(100),
+			Status:    model.InvestmentSettled,
+			Dealing:   &model.Dealing{Price: 1_000_000, Units: 1_000_000, ValuedAt: time.Date(2025, time.June, 2, 11, 0, 0, 0, time.UTC)},
+		})
+	}
+	accounts := unavailableAccounts{newAccountRepo(), map[string]bool{"acc-junior": true}}
+	funds := &mockFundClient{
+		getFund: func(fundId string) (*model.Fund, error) {
+			return &model.Fund{Id: fundId, UnitClass: model.UnitClassIncome}, nil
+		},
+		getDistributions: func(model.Date) (*[]model.Distribution, error) {
+			return &[]model.Distribution{
+				{Id: "dist-inc", FundId: "fund-inc", Rate: 10_000, ExDate: model.NewDate(2025, time.June, 5), PayDate: model.NewDate(2025, time.June, 30)},
+			}, nil
+		},
+	}
+	nothing := &mockPublisher{publishFn: func(string, any) error { return nil }}
+	ledger := newLedger(logger.NewMockLogger())
+	svc := service.NewDistributionService(repository.NewDistributionClient(), repo, accounts, funds, ledger, service.NewLocks(), nothing, logger.NewMockLogger())
+
+	now := time.Date(2025, time.June, 30, 9, 0, 0, 0, time.UTC)
+	run, err := svc.Run(now)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if run.Paid != 1 || run.Failed != 1 {
+		t.Errorf("expected acc-1 paid and acc-junior failed, got %+v", run)
+	}
+
+	// the next run only pays the account that failed
+	delete(accounts.down, "acc-junior")
+	run, err = svc.Run(now.Add(time.Hour))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if run.Distributions != 1 || run.Paid != 1 || run.Failed != 0 {
+		t.Errorf("expected only acc-junior paid on retry, got %+v", run)
+	}
+	for _, accountId := range []string{"acc-1", "acc-junior"} {
+		if cash := cashIn(t, ledger, accountId); !cash.Balance.Equal(money.Pounds(1)) {
+			t.Errorf("expected %s to be paid 1.00 once, got %s", accountId, cash.Balance)
+		}
+	}
+
+	// once every account is paid the distribution is done
+	if run, err = svc.Run(now.Add(2 * time.Hour)); err != nil || run.Distributions != 0 {
+		t.Errorf("expected nothing left to pay, got %+v, %v", run, err)
+	}
+}
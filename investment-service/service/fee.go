@@ -0,0 +1,462 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"maps"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/oliknight1/retail-isa-investment/investment-service/client"
+	"github.com/oliknight1/retail-isa-investment/investment-service/event"
+	"github.com/oliknight1/retail-isa-investment/investment-service/fee"
+	"github.com/oliknight1/retail-isa-investment/investment-service/internal"
+	"github.com/oliknight1/retail-isa-investment/investment-service/logger"
+	"github.com/oliknight1/retail-isa-investment/investment-service/model"
+	"github.com/oliknight1/retail-isa-investment/investment-service/money"
+	"github.com/oliknight1/retail-isa-investment/investment-service/repository"
+	"go.uber.org/zap"
+)
+
+type FeeService interface {
+	GetFeesByCustomerId(id string) (*[]model.FeeCharge, error)
+	Run(now time.Time) (*model.FeeRun, error)
+}
+
+// FeeServiceImpl accrues the platform fee on every account's holdings each day and collects
+// each month's fees once the month is over
+type FeeServiceImpl struct {
+	repo        repository.FeeRepository
+	investments repository.Repository
+	accounts    repository.AccountRepository
+	funds       client.FundClient
+	ledger      LedgerService
+	schedule    fee.Schedule
+	locks       *Locks
+	publisher   event.EventHandler
+	Logger      logger.Logger
+	mu          sync.Mutex
+}
+
+func NewFeeService(
+	repo repository.FeeRepository,
+	investments repository.Repository,
+	accounts repository.AccountRepository,
+	funds client.FundClient,
+	ledger LedgerService,
+	schedule fee.Schedule,
+	locks *Locks,
+	publisher event.EventHandler,
+	logger logger.Logger,
+) *FeeServiceImpl {
+	return &FeeServiceImpl{
+		repo:        repo,
+		investments: investments,
+		accounts:    accounts,
+		funds:       funds,
+		ledger:      ledger,
+		schedule:    schedule,
+		locks:       locks,
+		publisher:   publisher,
+		Logger:      logger,
+	}
+}
+
+func (s *FeeServiceImpl) GetFeesByCustomerId(id string) (*[]model.FeeCharge, error) {
+	if id == "" {
+		s.Logger.Error("missing customer_id when requesting fees", zap.Error(internal.ErrMissingCustomerId))
+		return nil, internal.ErrMissingCustomerId
+	}
+	charges, err := s.repo.GetChargesByCustomerId(id)
+	if err != nil {
+		s.Logger.Error("error fetching fee charges", zap.Error(err))
+		return nil, err
+	}
+	slices.SortFunc(*charges, func(a, b model.FeeCharge) int { return a.CreatedAt.Compare(b.CreatedAt) })
+	return charges, nil
+}
+
+// Run accrues fees for every day up to today that has not been accrued yet, bills every account
+// for each month that has ended, and collects whatever has been billed
+func (s *FeeServiceImpl) Run(now time.Time) (*model.FeeRun, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	run := &model.FeeRun{RunAt: now}
+	if err := s.accrue(run, londonDate(now)); err != nil {
+		return nil, err
+	}
+	s.collect(run, now)
+
+	s.Logger.Info("fee run complete",
+		zap.Int("accrued", run.Accrued),
+		zap.Int("billed", run.Billed),
+		zap.Int("charged", run.Charged),
+		zap.Int("awaiting_sale", run.AwaitingSale),
+	)
+	return run, nil
+}
+
+// priceLookback is how far before the first day being accrued fund prices are fetched from, so
+// a day is still valued when its fund was last priced a few days before, such as over a weekend
+const priceLookback = 7 * 24 * time.Hour
+
+// accrue records a day's fees for each account for each day since it was last accrued, then
+// bills any month it has finished accruing. Each day is charged on the units the account held at
+// the end of that day valued at the last price published by then, so days the engine missed are
+// caught up as they were rather than at today's holding and prices. An account is accrued from
+// the first day it is found holding anything, and goes on accruing nothing once it has sold
+// everything so a gap in its accruals only ever means the engine was not running
+func (s *FeeServiceImpl) accrue(run *model.FeeRun, today model.Date) error {
+	var investments []model.Investment
+	for _, status := range []model.InvestmentStatus{model.InvestmentDealt, model.InvestmentSettled} {
+		found, err := s.investments.GetInvestmentsByStatus(status)
+		if err != nil {
+			s.Logger.Error("error fetching investments for fee accrual", zap.Error(err))
+			return err
+		}
+		investments = append(investments, *found...)
+	}
+	accrued, err := s.repo.GetAccruedAccountIds()
+	if err != nil {
+		s.Logger.Error("error fetching accounts accruing fees", zap.Error(err))
+		return err
+	}
+
+	byAccount := make(map[string][]model.Investment)
+	for _, investment := range investments {
+		byAccount[investment.AccountId] = append(byAccount[investment.AccountId], investment)
+	}
+	accountIds := slices.Collect(maps.Keys(positions(investments)))
+	for _, id := range *accrued {
+		if !slices.Contains(accountIds, id) {
+			accountIds = append(accountIds, id)
+		}
+	}
+	slices.Sort(accountIds)
+
+	values := &accrualValuation{
+		client: s.funds,
+		from:   startOfDay(today),
+		to:     startOfDay(model.Date{Time: today.AddDate(0, 0, 1)}),
+		prices: make(map[string][]model.FundPrice),
+		funds:  make(map[string]*model.Fund),
+	}
+	firstDays := make(map[string]model.Date)
+	for _, accountId := range accountIds {
+		accruals, err := s.repo.GetAccrualsByAccountId(accountId)
+		if err != nil {
+			s.Logger.Error("error fetching fee accruals", zap.String("account_id", accountId), zap.Error(err))
+			continue
+		}
+		day := today
+		if len(*accruals) > 0 {
+			day = model.Date{Time: (*accruals)[len(*accruals)-1].Date.AddDate(0, 0, 1)}
+		}
+		firstDays[accountId] = day
+		if from := startOfDay(day); from.Before(values.from) {
+			values.from = from
+		}
+	}
+
+	for _, accountId := range accountIds {
+		day, ok := firstDays[accountId]
+		if !ok {
+			continue
+		}
+		account, err := s.accounts.GetAccountById(accountId)
+		if err != nil {
+			s.Logger.Error("error fetching account for fee accrual", zap.String("account_id", accountId), zap.Error(err))
+			continue
+		}
+		for ; !day.After(today.Time); day = (model.Date{Time: day.AddDate(0, 0, 1)}) {
+			end := startOfDay(model.Date{Time: day.AddDate(0, 0, 1)})
+			value, fundCharges, err := values.value(heldAt(byAccount[accountId], end)[accountId], end)
+			if err != nil {
+				s.Logger.Error("error valuing account for fee accrual", zap.String("account_id", accountId), zap.Error(err))
+				break
+			}
+			accrual := model.FeeAccrual{
+				AccountId:   accountId,
+				CustomerId:  account.CustomerId,
+				Date:        day,
+				Value:       value,
+				Platform:    s.schedule.Daily(value, day.Time),
+				FundCharges: fee.Daily(fundCharges, day.Time),
+			}
+			if err := s.repo.AddAccrual(accrual); err != nil {
+				s.Logger.Error("error saving fee accrual", zap.String("account_id", accountId), zap.Error(err))
+				break
+			}
+			run.Accrued++
+		}
+		s.bill(run, *account, today)
+	}
+	return nil
+}
+
+// heldAt is each account's position in each fund from the orders dealt at a valuation point
+// before t
+func heldAt(investments []model.Investment, t time.Time) map[string]map[string]*position {
+	var dealt []model.Investment
+	for _, investment := range investments {
+		if investment.Dealing != nil && investment.Dealing.ValuedAt.Before(t) {
+			dealt = append(dealt, investment)
+		}
+	}
+	return positions(dealt)
+}
+
+// accrualValuation prices holdings as they stood on the days being accrued, fetching each fund's
+// prices and details once per run
+type accrualValuation struct {
+	client   client.FundClient
+	from, to time.Time
+	prices   map[string][]model.FundPrice
+	funds    map[string]*model.Fund
+}
+
+// value is what an account's holdings could be sold for at the last prices published before t,
+// along with what the funds' ongoing charges on them come to a year
+func (v *accrualValuation) value(held map[string]*position, t time.Time) (money.Money, model.Accrual, error) {
+	var value money.Money
+	var fundCharges model.Accrual
+	for fundId, p := range held {
+		if p.units <= 0 {
+			continue
+		}
+		price, err := v.priceAt(fundId, t)
+		if err != nil {
+			return money.Money{}, 0, err
+		}
+		fund, ok := v.funds[fundId]
+		if !ok {
+			if fund, err = v.client.GetFund(fundId); err != nil {
+				return money.Money{}, 0, err
+			}
+			v.funds[fundId] = fund
+		}
+		holding := p.units.Value(price.SellPrice())
+		value = value.Add(holding)
+		fundCharges += fee.OnValue(holding, fund.OngoingCharge)
+	}
+	return value, fundCharges, nil
+}
+
+// priceAt is the last price of a fund published before t, or its first price after t if it was
+// not priced in the week before. A fund with no prices over the whole run is valued at its
+// latest price
+func (v *accrualValuation) priceAt(fundId string, t time.Time) (*model.FundPrice, error) {
+	prices, ok := v.prices[fundId]
+	if !ok {
+		fetched, err := v.client.GetPrices(fundId, v.from.Add(-priceLookback), v.to)
+		if err != nil {
+			return nil, err
+		}
+		prices = slices.SortedFunc(slices.Values(*fetched), func(a, b model.FundPrice) int { return a.ValuedAt.Compare(b.ValuedAt) })
+		if len(prices) == 0 {
+			latest, err := v.client.GetLatestPrice(fundId)
+			if err != nil {
+				return nil, err
+			}
+			prices = []model.FundPrice{*latest}
+		}
+		v.prices[fundId] = prices
+	}
+	price := prices[0]
+	for _, p := range prices {
+		if !p.ValuedAt.Before(t) {
+			break
+		}
+		price = p
+	}
+	return &price, nil
+}
+
+// bill raises a charge for each month before today's that an account has accrued fees in and
+// not been charged for yet
+func (s *FeeServiceImpl) bill(run *model.FeeRun, account model.Account, today model.Date) {
+	accruals, err := s.repo.GetAccrualsByAccountId(account.Id)
+	if err != nil {
+		s.Logger.Error("error fetching fee accruals", zap.String("account_id", account.Id), zap.Error(err))
+		return
+	}
+	charges, err := s.repo.GetChargesByAccountId(account.Id)
+	if err != nil {
+		s.Logger.Error("error fetching fee charges", zap.String("account_id", account.Id), zap.Error(err))
+		return
+	}
+	billed := make(map[string]bool)
+	for _, charge := range *charges {
+		billed[charge.Month] = true
+	}
+
+	current := today.Format("2006-01")
+	months := make(map[string]*model.FeeCharge)
+	for _, accrual := range *accruals {
+		month := accrual.Date.Format("2006-01")
+		if month >= current || billed[month] {
+			continue
+		}
+		charge, ok := months[month]
+		if !ok {
+			charge = &model.FeeCharge{
+				Id:         uuid.New().String(),
+				AccountId:  account.Id,
+				CustomerId: account.CustomerId,
+				Month:      month,
+				Status:     model.FeeChargePending,
+				CreatedAt:  run.RunAt,
+			}
+			months[month] = charge
+		}
+		charge.Accrued += accrual.Platform
+		charge.FundCharges += accrual.FundCharges
+	}
+	for _, month := range slices.Sorted(maps.Keys(months)) {
+		charge := months[month]
+		charge.Amount = charge.Accrued.Money()
+		if err := s.repo.CreateCharge(*charge); err != nil {
+			s.Logger.Error("error saving fee charge", zap.String("account_id", account.Id), zap.Error(err))
+			continue
+		}
+		run.Billed++
+	}
+}
+
+// collect takes each pending charge out of its account's cash. Where there is not enough cash,
+// units of the account's largest holding are sold to raise the difference and the charge is
+// collected once the sale has been dealt and its proceeds credited
+func (s *FeeServiceImpl) collect(run *model.FeeRun, now time.Time) {
+	pending, err := s.repo.GetChargesByStatus(model.FeeChargePending)
+	if err != nil {
+		s.Logger.Error("error fetching pending fee charges", zap.Error(err))
+		return
+	}
+	slices.SortFunc(*pending, func(a, b model.FeeCharge) int { return a.CreatedAt.Compare(b.CreatedAt) })
+
+	for _, charge := range *pending {
+		account, err := s.accounts.GetAccountById(charge.AccountId)
+		if err != nil {
+			s.Logger.Error("error fetching account for fee collection", zap.String("charge_id", charge.Id), zap.Error(err))
+			continue
+		}
+		charged := charge
+		charged.Status = model.FeeChargeCharged
+		charged.Collection = model.CollectedFromCash
+		if charge.SaleId != nil {
+			charged.Collection = model.CollectedBySelling
+		}
+		charged.ChargedAt = &now
+		// the ledger checks and takes the cash in one step, so nothing else can spend it first
+		err = s.ledger.RecordFee(charged)
+		if errors.Is(err, internal.ErrInsufficientCash) {
+			if s.awaitingSale(charge) {
+				run.AwaitingSale++
+				continue
+			}
+			cash, err := s.ledger.GetCashBalance(account.Id)
+			if err != nil {
+				s.Logger.Error("error fetching cash for fee collection", zap.String("charge_id", charge.Id), zap.Error(err))
+				continue
+			}
+			if err := s.raiseSale(&charge, *account, charge.Amount.Sub(cash.Available), now); err != nil {
+				s.Logger.Error("error selling units to pay fees", zap.String("charge_id", charge.Id), zap.Error(err))
+				continue
+			}
+			run.AwaitingSale++
+			continue
+		}
+		if err != nil {
+			s.Logger.Error("error taking fees from cash", zap.String("charge_id", charge.Id), zap.Error(err))
+			continue
+		}
+
+		charge = charged
+		if err := s.repo.UpdateCharge(charge); err != nil {
+			s.Logger.Error("error saving fee charge", zap.String("charge_id", charge.Id), zap.Error(err))
+			continue
+		}
+		if err := s.publisher.Publish("fee.charged", charge); err != nil {
+			s.Logger.Error("error publishing fee.charged event", zap.Error(err))
+		}
+		run.Charged++
+	}
+}
+
+// awaitingSale reports whether units sold for a charge have still to be dealt
+func (s *FeeServiceImpl) awaitingSale(charge model.FeeCharge) bool {
+	if charge.SaleId == nil {
+		return false
+	}
+	sale, err := s.investments.GetInvestmentById(*charge.SaleId)
+	if err != nil {
+		s.Logger.Error("error fetching sale for fee charge", zap.String("charge_id", charge.Id), zap.Error(err))
+		return false
+	}
+	return sale.Dealing == nil && sale.Status.Live()
+}
+
+// raiseSale places a sell order for amount from the account's largest holding, or all of it if
+// the holding is worth less
+func (s *FeeServiceImpl) raiseSale(charge *model.FeeCharge, account model.Account, amount money.Money, now time.Time) error {
+	investments, err := s.investments.GetInvestmentsByAccountId(account.Id)
+	if err != nil {
+		return err
+	}
+	var fundId string
+	var largest money.Money
+	for id, p := range positions(*investments)[account.Id] {
+		price, err := s.funds.GetLatestPrice(id)
+		if err != nil {
+			return err
+		}
+		if value := p.units.Value(price.SellPrice()); fundId == "" || value.GreaterThan(largest) {
+			fundId, largest = id, value
+		}
+	}
+	if fundId == "" {
+		return internal.InsufficientHoldingError("nothing")
+	}
+
+	unlock := s.locks.accounts.Lock(account.Id)
+	defer unlock()
+	order := model.RedemptionOrder{Amount: amount}
+	if !largest.GreaterThan(amount) {
+		order = model.RedemptionOrder{All: true}
+	}
+	sale, err := newSale(s.investments, s.funds, account, fundId, order, model.Redemption, now)
+	if err != nil {
+		return err
+	}
+	sale.History[0].Reason = "sell order raised to pay fees for " + charge.Month
+	sale.History[0].Actor = model.ActorSystem
+	if err := recordStatusChanges(s.ledger, *sale, 0); err != nil {
+		return err
+	}
+	if err := s.investments.CreateInvestment(*sale); err != nil {
+		unrecord(s.ledger, s.Logger, *sale)
+		return err
+	}
+	publishStatusChanges(s.publisher, s.Logger, *sale, 0)
+
+	charge.SaleId = &sale.Id
+	return s.repo.UpdateCharge(*charge)
+}
+
+// Start runs the fee engine every interval until ctx is cancelled
+func (s *FeeServiceImpl) Start(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case now := <-ticker.C:
+			s.Run(now)
+		}
+	}
+}
@@ -0,0 +1,220 @@
+package service_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/oliknight1/retail-isa-investment/investment-service/fee"
+	"github.com/oliknight1/retail-isa-investment/investment-service/logger"
+	"github.com/oliknight1/retail-isa-investment/investment-service/model"
+	"github.com/oliknight1/retail-isa-investment/investment-service/money"
+	"github.com/oliknight1/retail-isa-investment/investment-service/repository"
+	"github.com/oliknight1/retail-isa-investment/investment-service/service"
+)
+
+func TestFeeRun(t *testing.T) {
+	repo := repository.NewInvestmentClient()
+	for _, holding := range []struct {
+		id        string
+		accountId string
+		units     model.Units
+	}{
+		{"inv-1", "acc-1", 100_000_000},
+		{"inv-2", "acc-lisa", 10_000_000},
+	} {
+		repo.CreateInvestment(model.Investment{
+			Id:         holding.id,
+			AccountId:  holding.accountId,
+			CustomerId: "cust-1",
+			FundId:     "fund-1",
+			Type:       model.Subscription,
+			Amount:     holding.units.Value(1_000_000),
+			Status:     model.InvestmentSettled,
+			Dealing:    &model.Dealing{Price: 1_000_000, Units: holding.units},
+		})
+	}
+	accounts := newAccountRepo()
+	ledger := newLedger(logger.NewMockLogger())
+	payIn(ledger, "acc-lisa", money.Pounds(5))
+
+	funds := fundPriced("1.000000")
+	funds.getFund = func(fundId string) (*model.Fund, error) {
+		return &model.Fund{Id: fundId, OngoingCharge: 0.2}, nil
+	}
+	var charged []model.FeeCharge
+	mockPub := &mockPublisher{
+		publishFn: func(subject string, payload any) error {
+			if subject == "fee.charged" {
+				charged = append(charged, payload.(model.FeeCharge))
+			}
+			return nil
+		},
+	}
+	fees := repository.NewFeeClient()
+	svc := service.NewFeeService(fees, repo, accounts, funds, ledger, fee.DefaultSchedule(), service.NewLocks(), mockPub, logger.NewMockLogger())
+
+	// the first run accrues the day it finds each account holding units
+	run, err := svc.Run(time.Date(2025, time.June, 29, 12, 0, 0, 0, time.UTC))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if run.Accrued != 2 || run.Billed != 0 {
+		t.Errorf("expected a day accrued on each account and nothing billed, got %+v", run)
+	}
+	accruals, _ := fees.GetAccrualsByAccountId("acc-1")
+	// 0.45% a year on £10,000 is £45, a 365th of it a day, and 0.2% of ongoing charges £20
+	expected := model.FeeAccrual{
+		AccountId:   "acc-1",
+		CustomerId:  "cust-1",
+		Date:        model.NewDate(2025, time.June, 29),
+		Value:       money.Pounds(10_000),
+		Platform:    123_288,
+		FundCharges: 54_795,
+	}
+	if len(*accruals) != 1 || (*accruals)[0] != expected {
+		t.Errorf("expected %+v, got %+v", expected, *accruals)
+	}
+
+	// a run in July catches up the days missed and bills June
+	now := time.Date(2025, time.July, 2, 12, 0, 0, 0, time.UTC)
+	run, err = svc.Run(now)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if run.Accrued != 6 || run.Billed != 2 || run.Charged != 1 || run.AwaitingSale != 1 {
+		t.Errorf("unexpected fee run %+v", run)
+	}
+
+	// the Lifetime ISA has cash to pay its 2p for two days on £1,000
+	if lisa := cashIn(t, ledger, "acc-lisa"); !lisa.Balance.Equal(money.MustParse("4.98")) {
+		t.Errorf("expected fees taken from cash, got %s", lisa.Balance)
+	}
+	if len(charged) != 1 || charged[0].AccountId != "acc-lisa" || charged[0].Collection != model.CollectedFromCash {
+		t.Errorf("expected the Lifetime ISA's fees charged from cash, got %+v", charged)
+	}
+
+	// the other account has no cash so units are sold to pay its 25p
+	pending, _ := fees.GetChargesByStatus(model.FeeChargePending)
+	if len(*pending) != 1 || (*pending)[0].SaleId == nil {
+		t.Fatalf("expected a pending charge waiting on a sale, got %+v", *pending)
+	}
+	charge := (*pending)[0]
+	if charge.Month != "2025-06" || charge.Accrued != 246_576 || !charge.Amount.Equal(money.Pence(25)) || charge.FundCharges != 109_590 {
+		t.Errorf("unexpected charge %+v", charge)
+	}
+	sale, err := repo.GetInvestmentById(*charge.SaleId)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if sale.Type != model.Redemption || sale.Status != model.InvestmentValidated || !sale.Redemption.Amount.Equal(money.Pence(25)) {
+		t.Errorf("expected a sell order for 25p, got %+v", sale)
+	}
+	if sale.History[0].Actor != model.ActorSystem {
+		t.Errorf("expected the sale to be raised by the system, got %s", sale.History[0].Actor)
+	}
+
+	// nothing more is sold while the sale waits to be dealt
+	run, _ = svc.Run(now.Add(time.Hour))
+	if run.AwaitingSale != 1 || run.Charged != 0 {
+		t.Errorf("expected the charge to wait on its sale, got %+v", run)
+	}
+	investments, _ := repo.GetInvestmentsByAccountId("acc-1")
+	if len(*investments) != 2 {
+		t.Errorf("expected a single sale, got %d investments", len(*investments))
+	}
+
+	// once the sale's proceeds are credited the charge is collected
+	payIn(ledger, "acc-1", money.Pence(25))
+	run, _ = svc.Run(now.Add(2 * time.Hour))
+	if run.Charged != 1 {
+		t.Errorf("expected the charge to be collected, got %+v", run)
+	}
+	if cash := cashIn(t, ledger, "acc-1"); !cash.Balance.IsZero() {
+		t.Errorf("expected the proceeds to pay the fees, got %s cash", cash.Balance)
+	}
+	if len(charged) != 2 || charged[1].Collection != model.CollectedBySelling || charged[1].ChargedAt == nil {
+		t.Errorf("expected the charge collected by selling units, got %+v", charged)
+	}
+
+	history, err := svc.GetFeesByCustomerId("cust-1")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	for _, charge := range *history {
+		if charge.Status != model.FeeChargeCharged {
+			t.Errorf("expected every charge collected, got %+v", charge)
+		}
+	}
+	if len(*history) != 2 {
+		t.Errorf("expected 2 charges, got %d", len(*history))
+	}
+}
+
+func TestFeeRunCatchesUpEachDayAsItWas(t *testing.T) {
+	repo := repository.NewInvestmentClient()
+	bought := func(id string, units model.Units, valuedAt time.Time) {
+		repo.CreateInvestment(model.Investment{
+			Id:         id,
+			AccountId:  "acc-1",
+			CustomerId: "cust-1",
+			FundId:     "fund-1",
+			Type:       model.Subscription,
+			Amount:     units.Value(1_000_000),
+			Status:     model.InvestmentDealt,
+			Dealing:    &model.Dealing{Price: 1_000_000, Units: units, ValuedAt: valuedAt},
+		})
+	}
+	bought("inv-1", 1_000_000, time.Date(2025, time.June, 2, 11, 0, 0, 0, time.UTC))
+	// bought on a day the engine missed, so only held from then
+	bought("inv-2", 1_000_000, time.Date(2025, time.July, 1, 11, 0, 0, 0, time.UTC))
+
+	prices := []model.FundPrice{
+		{FundId: "fund-1", Nav: 1_000_000, ValuedAt: time.Date(2025, time.June, 27, 11, 0, 0, 0, time.UTC)},
+		{FundId: "fund-1", Nav: 2_000_000, ValuedAt: time.Date(2025, time.July, 1, 11, 0, 0, 0, time.UTC)},
+		{FundId: "fund-1", Nav: 3_000_000, ValuedAt: time.Date(2025, time.July, 2, 11, 0, 0, 0, time.UTC)},
+	}
+	funds := &mockFundClient{
+		getFund: func(fundId string) (*model.Fund, error) {
+			return &model.Fund{Id: fundId}, nil
+		},
+		getLatestPrice: func(fundId string) (*model.FundPrice, error) {
+			return &prices[len(prices)-1], nil
+		},
+		getPrices: func(fundId string, from, to time.Time) (*[]model.FundPrice, error) {
+			var between []model.FundPrice
+			for _, price := range prices {
+				if !price.ValuedAt.Before(from) && !price.ValuedAt.After(to) {
+					between = append(between, price)
+				}
+			}
+			return &between, nil
+		},
+	}
+	nothing := &mockPublisher{publishFn: func(string, any) error { return nil }}
+	fees := repository.NewFeeClient()
+	svc := service.NewFeeService(fees, repo, newAccountRepo(), funds, newLedger(logger.NewMockLogger()), fee.DefaultSchedule(), service.NewLocks(), nothing, logger.NewMockLogger())
+
+	if _, err := svc.Run(time.Date(2025, time.June, 29, 12, 0, 0, 0, time.UTC)); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if _, err := svc.Run(time.Date(2025, time.July, 2, 12, 0, 0, 0, time.UTC)); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	accruals, _ := fees.GetAccrualsByAccountId("acc-1")
+	values := make(map[string]string)
+	for _, accrual := range *accruals {
+		values[accrual.Date.Format("2006-01-02")] = accrual.Value.String()
+	}
+	// each day is valued on the units held that day at the last price published by the end of it
+	expected := map[string]string{
+		"2025-06-29": "100.00",
+		"2025-06-30": "100.00",
+		"2025-07-01": "400.00",
+		"2025-07-02": "600.00",
+	}
+	if diff := cmp.Diff(expected, values); diff != "" {
+		t.Errorf("unexpected daily values (-want +got):\n%s", diff)
+	}
+}
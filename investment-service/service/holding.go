@@ -0,0 +1,113 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/oliknight1/retail-isa-investment/investment-service/client"
+	"github.com/oliknight1/retail-isa-investment/investment-service/internal"
+	"github.com/oliknight1/retail-isa-investment/investment-service/model"
+	"github.com/oliknight1/retail-isa-investment/investment-service/repository"
+	"github.com/oliknight1/retail-isa-investment/investment-service/taxyear"
+)
+
+// heldUnits is how many units of a fund an account holds, from the purchases, sales and stock
+// transfers that have been dealt and not since cancelled or failed
+func heldUnits(repo repository.Repository, accountId, fundId string) (model.Units, error) {
+	investments, err := repo.GetInvestmentsByAccountId(accountId)
+	if err != nil {
+		return 0, err
+	}
+	var held model.Units
+	for _, investment := range *investments {
+		if investment.FundId != fundId || investment.Dealing == nil || !investment.Status.Live() {
+			continue
+		}
+		switch {
+		case investment.AddsUnits():
+			held += investment.Dealing.Units
+		case investment.RemovesUnits():
+			held -= investment.Dealing.Units
+		}
+	}
+	return held, nil
+}
+
+// reservedUnits is how many units of a fund are held back for an account's sell orders and
+// stock transfers out that have not been dealt yet
+func reservedUnits(repo repository.Repository, accountId, fundId string) (model.Units, error) {
+	investments, err := repo.GetInvestmentsByAccountId(accountId)
+	if err != nil {
+		return 0, err
+	}
+	var reserved model.Units
+	for _, investment := range *investments {
+		if investment.RemovesUnits() && investment.FundId == fundId && investment.Dealing == nil && investment.Status.Live() {
+			reserved += investment.Redemption.Reserved
+		}
+	}
+	return reserved, nil
+}
+
+// newSale checks a sell order against the units of a fund an account has free to sell, which
+// is what it holds less any already set aside for other sell orders, and builds the validated
+// investment for it. An order by amount is valued at the fund's latest price
+func newSale(repo repository.Repository, funds client.FundClient, account model.Account, fundId string, order model.RedemptionOrder, investmentType model.InvestmentType, now time.Time) (*model.Investment, error) {
+	given := 0
+	for _, set := range []bool{!order.Amount.IsZero(), order.Units != 0, order.All} {
+		if set {
+			given++
+		}
+	}
+	if given != 1 || order.Amount.IsNegative() || order.Units < 0 {
+		return nil, internal.ErrInvalidRedemption
+	}
+
+	held, err := heldUnits(repo, account.Id, fundId)
+	if err != nil {
+		return nil, err
+	}
+	reserved, err := reservedUnits(repo, account.Id, fundId)
+	if err != nil {
+		return nil, err
+	}
+	available := max(held-reserved, 0)
+	price, err := funds.GetLatestPrice(fundId)
+	if err != nil {
+		return nil, err
+	}
+
+	details := &model.RedemptionDetails{RedemptionOrder: order}
+	switch {
+	case order.All:
+		details.Reserved = available
+	case order.Units > 0:
+		details.Reserved = order.Units
+	default:
+		details.Reserved = model.UnitsFor(order.Amount, price.SellPrice())
+	}
+	if available == 0 || details.Reserved > available {
+		return nil, internal.InsufficientHoldingError(fmt.Sprintf("%s units worth %s", available, available.Value(price.SellPrice())))
+	}
+
+	sale := model.Investment{
+		Id:         uuid.New().String(),
+		AccountId:  account.Id,
+		CustomerId: account.CustomerId,
+		FundId:     fundId,
+		Type:       investmentType,
+		Amount:     order.Amount,
+		TaxYear:    taxyear.For(now),
+		Redemption: details,
+		CreatedAt:  now,
+	}
+	if err := errors.Join(
+		transition(&sale, model.InvestmentPending, "sell order received", model.ActorCustomer, now),
+		transition(&sale, model.InvestmentValidated, "holding checks passed", model.ActorSystem, now),
+	); err != nil {
+		return nil, err
+	}
+	return &sale, nil
+}
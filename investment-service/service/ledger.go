@@ -0,0 +1,472 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/oliknight1/retail-isa-investment/investment-service/event"
+	"github.com/oliknight1/retail-isa-investment/investment-service/internal"
+	"github.com/oliknight1/retail-isa-investment/investment-service/logger"
+	"github.com/oliknight1/retail-isa-investment/investment-service/model"
+	"github.com/oliknight1/retail-isa-investment/investment-service/money"
+	"github.com/oliknight1/retail-isa-investment/investment-service/repository"
+	"go.uber.org/zap"
+)
+
+type LedgerService interface {
+	GetEntriesByCustomerId(id string, from, to time.Time) (*[]model.JournalEntry, error)
+	GetBalances(accountId string) (*[]model.LedgerBalance, error)
+	GetCashBalance(accountId string) (*model.CashBalance, error)
+	Reverse(id string, reason string) (*model.JournalEntry, error)
+	RecordInvestment(investment model.Investment, change model.InvestmentStatusChange) error
+	RecordSwitch(fundSwitch model.Switch) error
+	RecordFee(charge model.FeeCharge) error
+	RecordDistribution(payment model.DistributionPayment) error
+}
+
+// LedgerServiceImpl keeps a double-entry ledger of every movement of money in the ISAs. It is
+// the record of each account's cash: entries are posted by the code that moves the money, before
+// the change is saved, so anything that would spend cash an account does not have is refused.
+// Every ledger balance is worked out from the entries rather than stored
+type LedgerServiceImpl struct {
+	repo      repository.LedgerRepository
+	publisher event.EventHandler
+	Logger    logger.Logger
+	locks     keyedMutex
+}
+
+func NewLedgerService(repo repository.LedgerRepository, publisher event.EventHandler, logger logger.Logger) *LedgerServiceImpl {
+	return &LedgerServiceImpl{
+		repo:      repo,
+		publisher: publisher,
+		Logger:    logger,
+	}
+}
+
+// RecordInvestment posts the money an investment moved when it made change. Purchases,
+// withdrawals and cash transfers out reserve their cash once validated and are refused if the
+// account does not have it available. Orders move money once dealt, and deposits, withdrawals
+// and cash transfers once settled. An investment that fails or is cancelled has every entry
+// posted for it reversed. Posting the same change twice does nothing
+func (s *LedgerServiceImpl) RecordInvestment(investment model.Investment, change model.InvestmentStatusChange) error {
+	if change.Status == model.InvestmentFailed || change.Status == model.InvestmentCancelled {
+		return s.reverseInvestment(investment, change)
+	}
+	postings := investmentPostings(investment, change.Status)
+	if postings == nil {
+		return nil
+	}
+	entry := model.JournalEntry{
+		CustomerId:  investment.CustomerId,
+		AccountId:   investment.AccountId,
+		Reference:   investmentReference(investment.Id, change.Status),
+		Description: fmt.Sprintf("%s %s", investment.Type, change.Status),
+		Postings:    postings,
+		At:          change.At,
+	}
+	if change.Status == model.InvestmentValidated {
+		return s.spend(entry, investment.Amount)
+	}
+	return s.record(entry)
+}
+
+// spendsCash reports whether an investment pays for itself out of cash already in the account,
+// which is reserved from the account's available cash when it is validated
+func spendsCash(investment model.Investment) bool {
+	switch investment.Type {
+	case model.Purchase, model.Withdrawal:
+		return true
+	case model.TransferredOut:
+		return investment.FundId == ""
+	}
+	return false
+}
+
+func investmentReference(id string, status model.InvestmentStatus) string {
+	return "investment:" + id + ":" + string(status)
+}
+
+// investmentPostings is what an investment moving to status posts, or nil if it moves no money.
+// Subscriptions and reinvestments put the whole amount into the holding as any residual is not
+// paid into cash, while a purchase only spends what its units cost and gives the residual back
+// to cash. Stock transfers move their holding at the value recorded on the investment
+func investmentPostings(investment model.Investment, status model.InvestmentStatus) []model.Posting {
+	account := func(kind model.LedgerAccountKind, fundId string) model.LedgerAccount {
+		return model.LedgerAccount{Kind: kind, AccountId: investment.AccountId, FundId: fundId}
+	}
+	cash, reserved, external := account(model.LedgerCash, ""), account(model.LedgerReserved, ""), account(model.LedgerExternal, "")
+	holdings := account(model.LedgerHoldings, investment.FundId)
+
+	switch status {
+	case model.InvestmentValidated:
+		if spendsCash(investment) {
+			return transfer(reserved, cash, investment.Amount)
+		}
+		return nil
+	case model.InvestmentSettled:
+		switch {
+		case investment.Type == model.Deposit:
+			return transfer(cash, external, investment.Amount)
+		case investment.Type == model.TransferredIn && investment.FundId == "":
+			return transfer(cash, external, investment.Amount)
+		case investment.Type == model.TransferredOut && investment.FundId == "":
+			return transfer(external, reserved, investment.Amount)
+		case investment.Type == model.Withdrawal:
+			details := investment.Withdrawal
+			return postings(
+				model.Posting{Account: external, Side: model.Debit, Amount: details.NetAmount},
+				model.Posting{Account: account(model.LedgerCharges, ""), Side: model.Debit, Amount: details.Charge},
+				model.Posting{Account: reserved, Side: model.Credit, Amount: investment.Amount},
+			)
+		}
+		return nil
+	}
+	if status != model.InvestmentDealt || investment.Dealing == nil {
+		return nil
+	}
+	switch investment.Type {
+	case model.Subscription:
+		return transfer(holdings, external, investment.Amount)
+	case model.Purchase:
+		residual := investment.Dealing.Residual
+		return postings(
+			model.Posting{Account: holdings, Side: model.Debit, Amount: investment.Amount.Sub(residual)},
+			model.Posting{Account: cash, Side: model.Debit, Amount: residual},
+			model.Posting{Account: reserved, Side: model.Credit, Amount: investment.Amount},
+		)
+	case model.TransferredIn:
+		return transfer(holdings, external, investment.Amount)
+	case model.TransferredOut:
+		return transfer(external, holdings, investment.Amount)
+	case model.Reinvestment:
+		return transfer(holdings, account(model.LedgerIncome, investment.FundId), investment.Amount)
+	case model.SwitchBuy:
+		return transfer(holdings, account(model.LedgerSwitching, ""), investment.Amount)
+	case model.Redemption:
+		return transfer(cash, holdings, investment.Amount)
+	case model.SwitchSell:
+		return transfer(account(model.LedgerSwitching, ""), holdings, investment.Amount)
+	}
+	return nil
+}
+
+// transfer moves amount from one ledger account to another, or nothing if amount is not positive
+func transfer(to, from model.LedgerAccount, amount money.Money) []model.Posting {
+	if !amount.IsPositive() {
+		return nil
+	}
+	return []model.Posting{
+		{Account: to, Side: model.Debit, Amount: amount},
+		{Account: from, Side: model.Credit, Amount: amount},
+	}
+}
+
+// postings leaves out any posting that is not for a positive amount
+func postings(all ...model.Posting) []model.Posting {
+	return slices.DeleteFunc(all, func(posting model.Posting) bool { return !posting.Amount.IsPositive() })
+}
+
+// reverseInvestment reverses whatever a failed or cancelled investment posted. A reversal that
+// takes back cash the account has since spent or reserved is refused. A cancelled subscription
+// that was dealt is refunded instead, as the customer gets back less than it paid in when the
+// price has fallen
+func (s *LedgerServiceImpl) reverseInvestment(investment model.Investment, change model.InvestmentStatusChange) error {
+	for _, status := range []model.InvestmentStatus{model.InvestmentValidated, model.InvestmentDealt, model.InvestmentSettled} {
+		entry, err := s.repo.GetEntryByReference(investmentReference(investment.Id, status))
+		if errors.Is(err, internal.ErrEntryNotFound) {
+			continue
+		}
+		if err != nil {
+			s.Logger.Error("error fetching journal entry to reverse", zap.String("investment_id", investment.Id), zap.Error(err))
+			return err
+		}
+		reason := fmt.Sprintf("%s %s", investment.Type, change.Status)
+		if status == model.InvestmentDealt && investment.Cancellation != nil {
+			err = s.refund(*entry, investment, reason, change.At)
+		} else {
+			_, err = s.reverse(*entry, reason, change.At)
+		}
+		if err != nil && !errors.Is(err, internal.ErrDuplicateEntry) {
+			return err
+		}
+	}
+	return nil
+}
+
+// refund takes the units a cancelled subscription bought back out of the holding in place of
+// reversing entry, paying out the cancellation's refund and booking its market loss. It is
+// posted as the reversal of entry so it is only posted once
+func (s *LedgerServiceImpl) refund(entry model.JournalEntry, investment model.Investment, reason string, at time.Time) error {
+	account := func(kind model.LedgerAccountKind, fundId string) model.LedgerAccount {
+		return model.LedgerAccount{Kind: kind, AccountId: investment.AccountId, FundId: fundId}
+	}
+	cancellation := investment.Cancellation
+	refund := model.JournalEntry{
+		CustomerId:  entry.CustomerId,
+		AccountId:   entry.AccountId,
+		Reference:   reversalReference(entry.Reference),
+		Description: fmt.Sprintf("refund of %s: %s", entry.Description, reason),
+		ReversalOf:  &entry.Id,
+		Postings: postings(
+			model.Posting{Account: account(model.LedgerExternal, ""), Side: model.Debit, Amount: cancellation.RefundAmount},
+			model.Posting{Account: account(model.LedgerMarketLoss, ""), Side: model.Debit, Amount: cancellation.MarketLoss},
+			model.Posting{Account: account(model.LedgerHoldings, investment.FundId), Side: model.Credit, Amount: investment.Amount},
+		),
+		At: at,
+	}
+	return s.post(&refund, money.Money{})
+}
+
+// RecordSwitch posts the proceeds of a failed switch's sale into the account's cash, when they
+// were not used to buy the new fund
+func (s *LedgerServiceImpl) RecordSwitch(fundSwitch model.Switch) error {
+	if fundSwitch.Status != model.SwitchFailed {
+		return nil
+	}
+	cash := model.LedgerAccount{Kind: model.LedgerCash, AccountId: fundSwitch.AccountId}
+	switching := model.LedgerAccount{Kind: model.LedgerSwitching, AccountId: fundSwitch.AccountId}
+	return s.record(model.JournalEntry{
+		CustomerId:  fundSwitch.CustomerId,
+		AccountId:   fundSwitch.AccountId,
+		Reference:   "switch:" + fundSwitch.Id + ":" + string(fundSwitch.Status),
+		Description: "uninvested proceeds of switch from " + fundSwitch.FromFundId,
+		Postings:    transfer(cash, switching, fundSwitch.Uninvested),
+		At:          fundSwitch.History[len(fundSwitch.History)-1].At,
+	})
+}
+
+// RecordFee takes a charged fee out of an account's cash, refusing it if the account does not
+// have that much available
+func (s *LedgerServiceImpl) RecordFee(charge model.FeeCharge) error {
+	if charge.Status != model.FeeChargeCharged || charge.ChargedAt == nil {
+		return nil
+	}
+	cash := model.LedgerAccount{Kind: model.LedgerCash, AccountId: charge.AccountId}
+	fees := model.LedgerAccount{Kind: model.LedgerFees, AccountId: charge.AccountId}
+	return s.spend(model.JournalEntry{
+		CustomerId:  charge.CustomerId,
+		AccountId:   charge.AccountId,
+		Reference:   "fee:" + charge.Id,
+		Description: "platform fee for " + charge.Month,
+		Postings:    transfer(fees, cash, charge.Amount),
+		At:          *charge.ChargedAt,
+	}, charge.Amount)
+}
+
+// RecordDistribution posts income paid into an account's cash. Reinvested income is posted when
+// the units it buys are dealt. A distribution is only posted once for each account, however many
+// times its payment is recorded
+func (s *LedgerServiceImpl) RecordDistribution(payment model.DistributionPayment) error {
+	if payment.Outcome != model.DistributionPaid {
+		return nil
+	}
+	cash := model.LedgerAccount{Kind: model.LedgerCash, AccountId: payment.AccountId}
+	income := model.LedgerAccount{Kind: model.LedgerIncome, AccountId: payment.AccountId, FundId: payment.FundId}
+	return s.record(model.JournalEntry{
+		CustomerId:  payment.CustomerId,
+		AccountId:   payment.AccountId,
+		Reference:   "distribution:" + payment.DistributionId + ":" + payment.AccountId,
+		Description: "income from " + payment.FundId,
+		Postings:    transfer(cash, income, payment.Amount),
+		At:          payment.PaidAt,
+	})
+}
+
+// record posts entry unless it has been posted already, so a change that is made again after it
+// failed to save is not posted twice
+func (s *LedgerServiceImpl) record(entry model.JournalEntry) error {
+	return s.spend(entry, money.Money{})
+}
+
+// spend posts entry like record, first checking the account has amount of cash available for it
+func (s *LedgerServiceImpl) spend(entry model.JournalEntry, amount money.Money) error {
+	if len(entry.Postings) == 0 {
+		return nil
+	}
+	err := s.post(&entry, amount)
+	if errors.Is(err, internal.ErrDuplicateEntry) {
+		s.Logger.Info("journal entry already posted", zap.String("reference", entry.Reference))
+		return nil
+	}
+	return err
+}
+
+// post checks entry balances and appends it to the ledger. When it spends cash the account's
+// available cash is checked under the account's lock, so two entries cannot both spend the same
+// cash. An entry already posted is refused before its cash is checked
+func (s *LedgerServiceImpl) post(entry *model.JournalEntry, spends money.Money) error {
+	unlock := s.locks.Lock(entry.AccountId)
+	defer unlock()
+
+	if spends.IsPositive() {
+		_, err := s.repo.GetEntryByReference(entry.Reference)
+		if err == nil {
+			return internal.DuplicateEntryError(entry.Reference)
+		}
+		if !errors.Is(err, internal.ErrEntryNotFound) {
+			return err
+		}
+		balance, err := s.cashBalance(entry.AccountId)
+		if err != nil {
+			return err
+		}
+		if balance.Available.LessThan(spends) {
+			return internal.InsufficientCashError(balance.Available)
+		}
+	}
+	if !entry.Balanced() {
+		s.Logger.Error("unbalanced journal entry", zap.String("reference", entry.Reference))
+		return fmt.Errorf("%w: %s", internal.ErrUnbalancedEntry, entry.Reference)
+	}
+	entry.Id = uuid.New().String()
+	entry.PostedAt = time.Now()
+	if err := s.repo.Post(*entry); err != nil {
+		if !errors.Is(err, internal.ErrDuplicateEntry) {
+			s.Logger.Error("error posting journal entry", zap.String("reference", entry.Reference), zap.Error(err))
+		}
+		return err
+	}
+	if err := s.publisher.Publish("ledger.entry.posted", entry); err != nil {
+		s.Logger.Error("error publishing ledger.entry.posted event", zap.Error(err))
+	}
+	return nil
+}
+
+// Reverse corrects a journal entry by posting the same amounts on the opposite sides. An entry
+// can only be reversed once, and not if it would take out more cash than the account has available
+func (s *LedgerServiceImpl) Reverse(id string, reason string) (*model.JournalEntry, error) {
+	entry, err := s.repo.GetEntryById(id)
+	if err != nil {
+		s.Logger.Error("error fetching journal entry to reverse", zap.Error(err))
+		return nil, err
+	}
+	if reason == "" {
+		reason = "correction"
+	}
+	return s.reverse(*entry, reason, time.Now())
+}
+
+func reversalReference(reference string) string {
+	return "reversal:" + reference
+}
+
+func (s *LedgerServiceImpl) reverse(entry model.JournalEntry, reason string, at time.Time) (*model.JournalEntry, error) {
+	reversal := model.JournalEntry{
+		CustomerId:  entry.CustomerId,
+		AccountId:   entry.AccountId,
+		Reference:   reversalReference(entry.Reference),
+		Description: fmt.Sprintf("reversal of %s: %s", entry.Description, reason),
+		ReversalOf:  &entry.Id,
+		At:          at,
+	}
+	var spends money.Money
+	for _, posting := range entry.Postings {
+		posting.Side = posting.Side.Opposite()
+		reversal.Postings = append(reversal.Postings, posting)
+		if posting.Account.Kind == model.LedgerCash {
+			spends = spends.Sub(posting.Signed())
+		}
+	}
+	if err := s.post(&reversal, spends); err != nil {
+		return nil, err
+	}
+	return &reversal, nil
+}
+
+// GetEntriesByCustomerId returns the entries posted for a customer's accounts in the order they
+// were posted. A zero from or to leaves that end of the range open
+func (s *LedgerServiceImpl) GetEntriesByCustomerId(id string, from, to time.Time) (*[]model.JournalEntry, error) {
+	if id == "" {
+		s.Logger.Error("missing customer_id when requesting ledger", zap.Error(internal.ErrMissingCustomerId))
+		return nil, internal.ErrMissingCustomerId
+	}
+	entries, err := s.repo.GetEntriesByCustomerId(id)
+	if err != nil {
+		s.Logger.Error("error fetching journal entries", zap.Error(err))
+		return nil, err
+	}
+	inRange := slices.DeleteFunc(*entries, func(entry model.JournalEntry) bool {
+		return (!from.IsZero() && entry.At.Before(from)) || (!to.IsZero() && entry.At.After(to))
+	})
+	return &inRange, nil
+}
+
+// GetBalances adds up every entry posted for an account into the balance of each ledger account
+// it has touched
+func (s *LedgerServiceImpl) GetBalances(accountId string) (*[]model.LedgerBalance, error) {
+	if accountId == "" {
+		s.Logger.Error("missing account_id when requesting ledger balances", zap.Error(internal.ErrMissingAccountId))
+		return nil, internal.ErrMissingAccountId
+	}
+	entries, err := s.repo.GetEntriesByAccountId(accountId)
+	if err != nil {
+		s.Logger.Error("error fetching journal entries", zap.Error(err))
+		return nil, err
+	}
+	return ledgerBalances(*entries), nil
+}
+
+// GetCashBalance is an account's cash from its ledger balances. Balance is all of the cash in the
+// account, Reserved the part set aside for orders, withdrawals and transfers out not yet dealt or
+// settled, and Available what is left to spend
+func (s *LedgerServiceImpl) GetCashBalance(accountId string) (*model.CashBalance, error) {
+	if accountId == "" {
+		s.Logger.Error("missing account_id when requesting cash balance", zap.Error(internal.ErrMissingAccountId))
+		return nil, internal.ErrMissingAccountId
+	}
+	balance, err := s.cashBalance(accountId)
+	if err != nil {
+		s.Logger.Error("error fetching journal entries", zap.Error(err))
+		return nil, err
+	}
+	return balance, nil
+}
+
+func (s *LedgerServiceImpl) cashBalance(accountId string) (*model.CashBalance, error) {
+	entries, err := s.repo.GetEntriesByAccountId(accountId)
+	if err != nil {
+		return nil, err
+	}
+	return cashBalance(accountId, *ledgerBalances(*entries)), nil
+}
+
+// cashBalance picks an account's cash out of its ledger balances
+func cashBalance(accountId string, balances []model.LedgerBalance) *model.CashBalance {
+	cash := &model.CashBalance{AccountId: accountId}
+	for _, balance := range balances {
+		switch balance.Account.Kind {
+		case model.LedgerCash:
+			cash.Available = balance.Balance
+		case model.LedgerReserved:
+			cash.Reserved = balance.Balance
+		}
+	}
+	cash.Balance = cash.Available.Add(cash.Reserved)
+	return cash
+}
+
+// ledgerBalances sums entries into a balance per ledger account, ordered by account code
+func ledgerBalances(entries []model.JournalEntry) *[]model.LedgerBalance {
+	byCode := make(map[string]*model.LedgerBalance)
+	balances := []model.LedgerBalance{}
+	for _, entry := range entries {
+		for _, posting := range entry.Postings {
+			code := posting.Account.String()
+			balance, ok := byCode[code]
+			if !ok {
+				balance = &model.LedgerBalance{Account: posting.Account, Code: code}
+				byCode[code] = balance
+			}
+			balance.Balance = balance.Balance.Add(posting.Signed())
+		}
+	}
+	for _, balance := range byCode {
+		balances = append(balances, *balance)
+	}
+	slices.SortFunc(balances, func(a, b model.LedgerBalance) int { return strings.Compare(a.Code, b.Code) })
+	return &balances
+}
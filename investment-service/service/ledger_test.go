@@ -0,0 +1,206 @@
+package service_test
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/oliknight1/retail-isa-investment/investment-service/internal"
+	"github.com/oliknight1/retail-isa-investment/investment-service/logger"
+	"github.com/oliknight1/retail-isa-investment/investment-service/model"
+	"github.com/oliknight1/retail-isa-investment/investment-service/money"
+	"github.com/oliknight1/retail-isa-investment/investment-service/repository"
+	"github.com/oliknight1/retail-isa-investment/investment-service/service"
+)
+
+func TestLedger(t *testing.T) {
+	logger := logger.NewMockLogger()
+	repo := repository.NewInvestmentClient()
+	accounts := newAccountRepo()
+	var posted int
+	ledgerPub := &mockPublisher{
+		publishFn: func(subject string, payload any) error {
+			if subject == "ledger.entry.posted" {
+				posted++
+			}
+			return nil
+		},
+	}
+	ledger := service.NewLedgerService(repository.NewLedgerClient(), ledgerPub, logger)
+	nothing := &mockPublisher{publishFn: func(string, any) error { return nil }}
+	svc := service.New(repo, accounts, customersAged(30), fundPriced("2.000000"), newAllowanceService(logger), newBonusService(logger), ledger, service.NewLocks(), nothing, logger)
+	dealingSvc := newDealingService(repo, accounts, ledger, fundPriced("2.000000"), nothing, logger)
+	week := time.Now().AddDate(0, 0, 7)
+
+	if _, err := svc.Deposit("acc-1", money.Pounds(500)); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	purchase, err := svc.Buy("acc-1", "fund-1", money.Pounds(400))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	// failing an order before it is dealt reverses its reservation
+	undealt, err := svc.Buy("acc-1", "fund-2", money.Pounds(50))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if _, err := svc.UpdateInvestmentStatus(undealt.Id, model.InvestmentFailed, "fund suspended", "ops:jo"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if _, err := dealingSvc.Run(week); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if _, err := svc.Redeem("acc-1", "fund-1", model.RedemptionOrder{Units: 500_000}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if _, err := dealingSvc.Run(week.AddDate(0, 0, 7)); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	withdrawal, err := svc.Withdraw("acc-1", money.Pounds(50), model.WithdrawalOther)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if _, err := svc.UpdateInvestmentStatus(withdrawal.Id, model.InvestmentSettled, "paid", "ops:jo"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	now := time.Now()
+	fee := model.FeeCharge{Id: "fee-1", AccountId: "acc-1", CustomerId: "cust-1", Month: "2025-06", Amount: money.Pounds(5), Status: model.FeeChargeCharged, ChargedAt: &now}
+	if err := ledger.RecordFee(fee); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	payment := model.DistributionPayment{Id: "pay-1", DistributionId: "dist-1", AccountId: "acc-1", CustomerId: "cust-1", FundId: "fund-1", Amount: money.Pounds(10), Outcome: model.DistributionPaid, PaidAt: now}
+	if err := ledger.RecordDistribution(payment); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	// a fee recorded again is not posted twice
+	if err := ledger.RecordFee(fee); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	balances, err := ledger.GetBalances("acc-1")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	actual := make(map[string]string)
+	for _, balance := range *balances {
+		actual[balance.Code] = balance.Balance.String()
+	}
+	expected := map[string]string{
+		"cash:acc-1":            "155.00",
+		"external:acc-1":        "-450.00",
+		"fees:acc-1":            "5.00",
+		"holdings:acc-1:fund-1": "300.00",
+		"income:acc-1:fund-1":   "-10.00",
+		"reserved:acc-1":        "0.00",
+	}
+	if diff := cmp.Diff(expected, actual); diff != "" {
+		t.Errorf("unexpected balances (-want +got):\n%s", diff)
+	}
+	// spending more than the account's cash is refused
+	overdrawn := model.FeeCharge{Id: "fee-2", AccountId: "acc-1", CustomerId: "cust-1", Month: "2025-07", Amount: money.Pounds(156), Status: model.FeeChargeCharged, ChargedAt: &now}
+	if err := ledger.RecordFee(overdrawn); !errors.Is(err, internal.ErrInsufficientCash) {
+		t.Errorf("expected insufficient cash error, got: %v", err)
+	}
+
+	entries, err := ledger.GetEntriesByCustomerId("cust-1", time.Time{}, time.Time{})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(*entries) != 10 || posted != 10 {
+		t.Fatalf("expected 10 entries posted, got %d (%d events)", len(*entries), posted)
+	}
+	for _, entry := range *entries {
+		if !entry.Balanced() {
+			t.Errorf("expected every entry to balance, got %+v", entry)
+		}
+	}
+	// entries are dated when the money moved, which for dealt orders is the dealing run
+	if dealt, _ := ledger.GetEntriesByCustomerId("cust-1", week, time.Time{}); len(*dealt) != 2 {
+		t.Errorf("expected the two dealt orders from the first run on, got %d", len(*dealt))
+	}
+
+	// failing a dealt purchase reverses what it posted, giving back the cash it spent
+	if _, err := svc.UpdateInvestmentStatus(purchase.Id, model.InvestmentFailed, "trade rejected", "ops:jo"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if cash := cashIn(t, ledger, "acc-1"); !cash.Available.Equal(money.Pounds(555)) || !cash.Reserved.IsZero() {
+		t.Errorf("expected £555 available and nothing reserved, got %+v", cash)
+	}
+
+	// corrections are posted as reversals and the original entry is kept
+	charged, err := ledger.GetEntriesByCustomerId("cust-1", now, now)
+	if err != nil || len(*charged) != 2 {
+		t.Fatalf("expected the fee and income entries at now, got %v, %v", charged, err)
+	}
+	feeEntry := (*charged)[0]
+	reversal, err := ledger.Reverse(feeEntry.Id, "charged in error")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if reversal.ReversalOf == nil || *reversal.ReversalOf != feeEntry.Id || reversal.Postings[0].Side != model.Credit {
+		t.Errorf("unexpected reversal: %+v", reversal)
+	}
+	if _, err := ledger.Reverse(feeEntry.Id, "again"); !errors.Is(err, internal.ErrDuplicateEntry) {
+		t.Errorf("expected a second reversal to be refused, got: %v", err)
+	}
+	if _, err := ledger.Reverse("entry-unknown", ""); !errors.Is(err, internal.ErrEntryNotFound) {
+		t.Errorf("expected entry not found error, got: %v", err)
+	}
+}
+
+func TestLedgerRejectsUnbalancedEntries(t *testing.T) {
+	logger := logger.NewMockLogger()
+	ledgerRepo := repository.NewLedgerClient()
+	ledger := service.NewLedgerService(ledgerRepo, &mockPublisher{publishFn: func(string, any) error { return nil }}, logger)
+
+	// a withdrawal whose net amount and charge do not add up to what left the account
+	withdrawal := model.Investment{
+		Id:         "inv-1",
+		AccountId:  "acc-1",
+		CustomerId: "cust-1",
+		Type:       model.Withdrawal,
+		Amount:     money.Pounds(100),
+		Withdrawal: &model.WithdrawalDetails{NetAmount: money.Pounds(90)},
+	}
+	err := ledger.RecordInvestment(withdrawal, model.InvestmentStatusChange{Status: model.InvestmentSettled, At: time.Now()})
+	if !errors.Is(err, internal.ErrUnbalancedEntry) {
+		t.Errorf("expected unbalanced entry error, got: %v", err)
+	}
+	if len(ledgerRepo.Entries) != 0 {
+		t.Errorf("expected nothing posted, got %+v", ledgerRepo.Entries)
+	}
+}
+
+func TestLedgerRefusesReversalOfSpentCash(t *testing.T) {
+	logger := logger.NewMockLogger()
+	ledger := newLedger(logger)
+	now := time.Now()
+
+	redemption := model.Investment{
+		Id:         "inv-1",
+		AccountId:  "acc-1",
+		CustomerId: "cust-1",
+		FundId:     "fund-1",
+		Type:       model.Redemption,
+		Amount:     money.Pounds(100),
+		Dealing:    &model.Dealing{Units: 500_000},
+	}
+	if err := ledger.RecordInvestment(redemption, model.InvestmentStatusChange{Status: model.InvestmentDealt, At: now}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	fee := model.FeeCharge{Id: "fee-1", AccountId: "acc-1", CustomerId: "cust-1", Month: "2025-06", Amount: money.Pounds(30), Status: model.FeeChargeCharged, ChargedAt: &now}
+	if err := ledger.RecordFee(fee); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	// the proceeds have been partly spent, so the redemption cannot be failed without overdrawing
+	err := ledger.RecordInvestment(redemption, model.InvestmentStatusChange{Status: model.InvestmentFailed, At: now})
+	if !errors.Is(err, internal.ErrInsufficientCash) {
+		t.Errorf("expected insufficient cash error, got: %v", err)
+	}
+	if cash := cashIn(t, ledger, "acc-1"); !cash.Available.Equal(money.Pounds(70)) {
+		t.Errorf("expected £70 still available, got %+v", cash)
+	}
+}
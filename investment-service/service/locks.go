@@ -0,0 +1,62 @@
+package service
+
+import "sync"
+
+// keyedMutex serialises work on the same key, such as an account or investment id, while letting
+// work on different keys go ahead at the same time. A key's lock is only kept while it is held
+// or waited for, so the map does not grow with every key ever locked
+type keyedMutex struct {
+	mu    sync.Mutex
+	locks map[string]*keyedLock
+}
+
+// keyedLock is the lock on one key, with the number of callers holding or waiting for it
+type keyedLock struct {
+	sync.Mutex
+	refs int
+}
+
+// Lock waits for the lock on key and returns the function that releases it
+func (m *keyedMutex) Lock(key string) func() {
+	m.mu.Lock()
+	if m.locks == nil {
+		m.locks = make(map[string]*keyedLock)
+	}
+	lock, ok := m.locks[key]
+	if !ok {
+		lock = &keyedLock{}
+		m.locks[key] = lock
+	}
+	lock.refs++
+	m.mu.Unlock()
+
+	lock.Lock()
+	return func() {
+		lock.Unlock()
+		m.mu.Lock()
+		lock.refs--
+		if lock.refs == 0 {
+			delete(m.locks, key)
+		}
+		m.mu.Unlock()
+	}
+}
+
+// Locks are the locks shared by every service that changes accounts or investments. One is made
+// at start-up and passed to each of them, so they all wait on the same keys
+type Locks struct {
+	// accounts is held while units of a fund are checked against an account's holding and set
+	// aside for a sell order or stock transfer out, so two cannot both pass the check against the
+	// same units, and while an account is read, changed and saved, so one change cannot overwrite
+	// another with a stale copy. Cash is reserved by the ledger instead, which checks and posts
+	// each reservation under its own lock
+	accounts keyedMutex
+	// investments is held while an investment is read, moved to a new status and saved, so the
+	// dealing run and operations cannot both change the same investment from the status they
+	// read. It is always taken before the account's lock when both are needed
+	investments keyedMutex
+}
+
+func NewLocks() *Locks {
+	return &Locks{}
+}
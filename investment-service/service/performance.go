@@ -0,0 +1,393 @@
+package service
+
+import (
+	"cmp"
+	"math"
+	"slices"
+	"time"
+
+	"github.com/oliknight1/retail-isa-investment/investment-service/client"
+	"github.com/oliknight1/retail-isa-investment/investment-service/internal"
+	"github.com/oliknight1/retail-isa-investment/investment-service/logger"
+	"github.com/oliknight1/retail-isa-investment/investment-service/model"
+	"github.com/oliknight1/retail-isa-investment/investment-service/money"
+	"github.com/oliknight1/retail-isa-investment/investment-service/repository"
+	"github.com/oliknight1/retail-isa-investment/investment-service/taxyear"
+	"go.uber.org/zap"
+)
+
+type PerformanceService interface {
+	GetCustomerPerformance(customerId string, period model.Period, from, to time.Time) (*model.Performance, error)
+	GetAccountPerformance(accountId string, period model.Period, from, to time.Time) (*model.Performance, error)
+}
+
+type PerformanceServiceImpl struct {
+	investments repository.Repository
+	accounts    repository.AccountRepository
+	funds       client.FundClient
+	Logger      logger.Logger
+}
+
+func NewPerformanceService(
+	investments repository.Repository,
+	accounts repository.AccountRepository,
+	funds client.FundClient,
+	logger logger.Logger,
+) *PerformanceServiceImpl {
+	return &PerformanceServiceImpl{
+		investments,
+		accounts,
+		funds,
+		logger,
+	}
+}
+
+// cashFlow is money paid into a fund when units are bought, or taken out of it when they are
+// sold, at the valuation point the order was dealt at
+type cashFlow struct {
+	at     time.Time
+	amount money.Money
+	units  model.Units
+	price  model.UnitPrice
+}
+
+// fundHistory is every dealt order for one fund in one account, along with the fund's prices in
+// valuation order
+type fundHistory struct {
+	accountId string
+	fundId    string
+	flows     []cashFlow
+	prices    []model.FundPrice
+}
+
+// GetCustomerPerformance measures everything a customer has invested in funds over a period. The
+// from and to times are only used for a custom period
+func (s *PerformanceServiceImpl) GetCustomerPerformance(customerId string, period model.Period, from, to time.Time) (*model.Performance, error) {
+	if customerId == "" {
+		s.Logger.Error("missing customer_id when requesting performance", zap.Error(internal.ErrMissingCustomerId))
+		return nil, internal.ErrMissingCustomerId
+	}
+	investments, err := s.investments.GetInvestmentsByCustomerId(customerId)
+	if err != nil {
+		s.Logger.Error("error fetching investments for performance", zap.Error(err))
+		return nil, err
+	}
+	return s.measure(customerId, *investments, period, from, to)
+}
+
+// GetAccountPerformance measures the funds held in one account over a period
+func (s *PerformanceServiceImpl) GetAccountPerformance(accountId string, period model.Period, from, to time.Time) (*model.Performance, error) {
+	if accountId == "" {
+		s.Logger.Error("missing account_id when requesting performance", zap.Error(internal.ErrMissingAccountId))
+		return nil, internal.ErrMissingAccountId
+	}
+	account, err := s.accounts.GetAccountById(accountId)
+	if err != nil {
+		s.Logger.Error("error fetching account for performance", zap.Error(err))
+		return nil, err
+	}
+	investments, err := s.investments.GetInvestmentsByAccountId(accountId)
+	if err != nil {
+		s.Logger.Error("error fetching investments for performance", zap.Error(err))
+		return nil, err
+	}
+	return s.measure(account.CustomerId, *investments, period, from, to)
+}
+
+func (s *PerformanceServiceImpl) measure(customerId string, investments []model.Investment, period model.Period, from, to time.Time) (*model.Performance, error) {
+	histories := fundHistories(investments)
+	from, to, err := periodRange(period, from, to, time.Now(), inception(histories))
+	if err != nil {
+		s.Logger.Error("invalid performance period", zap.String("period", string(period)), zap.Error(err))
+		return nil, err
+	}
+	if err := s.fetchPrices(histories, to); err != nil {
+		return nil, err
+	}
+
+	performance := &model.Performance{
+		CustomerId: customerId,
+		Period:     period,
+		From:       from,
+		To:         to,
+		Return:     returnOver(histories, from, to),
+	}
+	for start := 0; start < len(histories); {
+		end := start
+		for end < len(histories) && histories[end].accountId == histories[start].accountId {
+			end++
+		}
+		account := model.AccountPerformance{AccountId: histories[start].accountId}
+		for _, history := range histories[start:end] {
+			fund := returnOver([]*fundHistory{history}, from, to)
+			if active(fund) {
+				account.Funds = append(account.Funds, model.FundPerformance{FundId: history.fundId, Return: fund})
+			}
+		}
+		if len(account.Funds) > 0 {
+			account.Return = returnOver(histories[start:end], from, to)
+			performance.Accounts = append(performance.Accounts, account)
+		}
+		start = end
+	}
+	return performance, nil
+}
+
+// fundHistories groups dealt purchases, sales and stock transfers by account and fund. A purchase
+// pays in what was spent on units and a sale takes out its proceeds, while reinvested income adds
+// units without paying anything in
+func fundHistories(investments []model.Investment) []*fundHistory {
+	type key struct{ accountId, fundId string }
+	byFund := make(map[key]*fundHistory)
+	var histories []*fundHistory
+	for _, investment := range investments {
+		if investment.Dealing == nil || !investment.Status.Live() || !(investment.AddsUnits() || investment.RemovesUnits()) {
+			continue
+		}
+		k := key{investment.AccountId, investment.FundId}
+		history, ok := byFund[k]
+		if !ok {
+			history = &fundHistory{accountId: investment.AccountId, fundId: investment.FundId}
+			byFund[k] = history
+			histories = append(histories, history)
+		}
+		flow := cashFlow{
+			at:     investment.Dealing.ValuedAt,
+			amount: investment.Amount.Sub(investment.Dealing.Residual),
+			units:  investment.Dealing.Units,
+			price:  investment.Dealing.Price,
+		}
+		switch {
+		case investment.RemovesUnits():
+			flow.amount, flow.units = investment.Amount.Neg(), -flow.units
+		case investment.Type == model.TransferredIn:
+			// stock transferred in is paid in at what it was worth when it arrived
+			flow.amount = flow.units.Value(flow.price)
+		case investment.Type == model.Reinvestment:
+			// reinvested income was earned by the holding rather than paid in
+			flow.amount = money.Money{}
+		}
+		history.flows = append(history.flows, flow)
+	}
+	for _, history := range histories {
+		slices.SortFunc(history.flows, func(a, b cashFlow) int { return a.at.Compare(b.at) })
+	}
+	slices.SortFunc(histories, func(a, b *fundHistory) int {
+		return cmp.Or(cmp.Compare(a.accountId, b.accountId), cmp.Compare(a.fundId, b.fundId))
+	})
+	return histories
+}
+
+// fetchPrices loads each fund's prices from its first dealing up to the end of the period, once
+// per fund however many accounts hold it
+func (s *PerformanceServiceImpl) fetchPrices(histories []*fundHistory, to time.Time) error {
+	first := make(map[string]time.Time)
+	for _, history := range histories {
+		at := history.flows[0].at
+		if earliest, ok := first[history.fundId]; !ok || at.Before(earliest) {
+			first[history.fundId] = at
+		}
+	}
+	prices := make(map[string][]model.FundPrice)
+	for fundId, from := range first {
+		if from.After(to) {
+			continue
+		}
+		fetched, err := s.funds.GetPrices(fundId, from, to)
+		if err != nil {
+			s.Logger.Error("error fetching fund prices for performance", zap.String("fund_id", fundId), zap.Error(err))
+			return err
+		}
+		slices.SortFunc(*fetched, func(a, b model.FundPrice) int { return a.ValuedAt.Compare(b.ValuedAt) })
+		prices[fundId] = *fetched
+	}
+	for _, history := range histories {
+		history.prices = prices[history.fundId]
+	}
+	return nil
+}
+
+func inception(histories []*fundHistory) time.Time {
+	var first time.Time
+	for _, history := range histories {
+		if at := history.flows[0].at; first.IsZero() || at.Before(first) {
+			first = at
+		}
+	}
+	return first
+}
+
+// periodRange works out when a period starts and ends. Every period ends now apart from a custom
+// one, which cannot end in the future. The year to date starts on 1 January in London
+func periodRange(period model.Period, from, to, now, inception time.Time) (time.Time, time.Time, error) {
+	switch period {
+	case model.PeriodOneMonth:
+		return now.AddDate(0, -1, 0), now, nil
+	case model.PeriodThreeMonths:
+		return now.AddDate(0, -3, 0), now, nil
+	case model.PeriodYearToDate:
+		local := taxyear.InLondon(now)
+		return time.Date(local.Year(), time.January, 1, 0, 0, 0, 0, local.Location()), now, nil
+	case model.PeriodOneYear:
+		return now.AddDate(-1, 0, 0), now, nil
+	case model.PeriodInception:
+		if inception.IsZero() || inception.After(now) {
+			return now, now, nil
+		}
+		return inception, now, nil
+	case model.PeriodCustom:
+		if to.After(now) {
+			to = now
+		}
+		if from.IsZero() || to.IsZero() || !from.Before(to) {
+			return from, to, internal.ErrInvalidPeriod
+		}
+		return from, to, nil
+	}
+	return from, to, internal.ErrInvalidPeriod
+}
+
+// active reports whether anything was held or dealt during the period
+func active(r model.Return) bool {
+	return !r.StartValue.IsZero() || !r.EndValue.IsZero() || r.TimeWeighted != nil
+}
+
+// unitsAt is how many units are held at t, counting anything dealt at t if inclusive
+func (h *fundHistory) unitsAt(t time.Time, inclusive bool) model.Units {
+	var units model.Units
+	for _, flow := range h.flows {
+		if flow.at.After(t) || (!inclusive && flow.at.Equal(t)) {
+			break
+		}
+		units += flow.units
+	}
+	return units
+}
+
+// priceAt is the last price the units could have been sold for at t. A dealing price is used if
+// it is more recent than any valuation fetched
+func (h *fundHistory) priceAt(t time.Time) model.UnitPrice {
+	var price model.UnitPrice
+	var pricedAt time.Time
+	for _, p := range h.prices {
+		if p.ValuedAt.After(t) {
+			break
+		}
+		price, pricedAt = p.SellPrice(), p.ValuedAt
+	}
+	for _, flow := range h.flows {
+		if flow.at.After(t) {
+			break
+		}
+		if flow.at.After(pricedAt) {
+			price, pricedAt = flow.price, flow.at
+		}
+	}
+	return price
+}
+
+func valueAt(histories []*fundHistory, t time.Time, inclusive bool) money.Money {
+	var value money.Money
+	for _, history := range histories {
+		if units := history.unitsAt(t, inclusive); units > 0 {
+			value = value.Add(units.Value(history.priceAt(t)))
+		}
+	}
+	return value
+}
+
+// returnOver measures holdings between from and to. The time-weighted return chains the growth
+// between each day money went in or out, valuing the holdings just before each cash flow. The
+// money-weighted return is the rate that grows the starting value and every cash flow to the
+// end value
+func returnOver(histories []*fundHistory, from, to time.Time) model.Return {
+	var flows []cashFlow
+	for _, history := range histories {
+		for _, flow := range history.flows {
+			if flow.at.After(from) && !flow.at.After(to) {
+				flows = append(flows, flow)
+			}
+		}
+	}
+	slices.SortFunc(flows, func(a, b cashFlow) int { return a.at.Compare(b.at) })
+
+	result := model.Return{
+		StartValue: valueAt(histories, from, true),
+		EndValue:   valueAt(histories, to, true),
+	}
+	growth, measured := 1.0, false
+	base := result.StartValue
+	for i := 0; i < len(flows); {
+		at := flows[i].at
+		before := valueAt(histories, at, false)
+		if base.IsPositive() {
+			growth *= ratio(before, base)
+			measured = true
+		}
+		base = before
+		for ; i < len(flows) && flows[i].at.Equal(at); i++ {
+			base = base.Add(flows[i].amount)
+			result.NetInvested = result.NetInvested.Add(flows[i].amount)
+		}
+	}
+	if base.IsPositive() {
+		growth *= ratio(result.EndValue, base)
+		measured = true
+	}
+	result.Gain = result.EndValue.Sub(result.StartValue).Sub(result.NetInvested)
+	if measured {
+		timeWeighted := asPercent(growth - 1)
+		result.TimeWeighted = &timeWeighted
+	}
+	result.MoneyWeighted = moneyWeighted(result.StartValue, flows, result.EndValue, from, to)
+	return result
+}
+
+// moneyWeighted solves for the return r where the starting value and each cash flow, compounded
+// at r for the share of the period left after it, add up to the end value. It is nil if nothing
+// was invested or no such rate exists
+func moneyWeighted(start money.Money, flows []cashFlow, end money.Money, from, to time.Time) *float64 {
+	span := to.Sub(from)
+	if span <= 0 {
+		return nil
+	}
+	type term struct{ amount, share float64 }
+	terms := []term{{float64(start.Minor), 1}}
+	for _, flow := range flows {
+		terms = append(terms, term{float64(flow.amount.Minor), float64(to.Sub(flow.at)) / float64(span)})
+	}
+	surplus := func(r float64) float64 {
+		total := -float64(end.Minor)
+		for _, t := range terms {
+			total += t.amount * math.Pow(1+r, t.share)
+		}
+		return total
+	}
+
+	low, high := -0.999999, 1.0
+	for high < 1e6 && math.Signbit(surplus(low)) == math.Signbit(surplus(high)) {
+		high *= 2
+	}
+	if surplus(low) == 0 || math.Signbit(surplus(low)) == math.Signbit(surplus(high)) {
+		return nil
+	}
+	for range 200 {
+		mid := (low + high) / 2
+		if math.Signbit(surplus(mid)) == math.Signbit(surplus(low)) {
+			low = mid
+		} else {
+			high = mid
+		}
+	}
+	moneyWeighted := asPercent((low + high) / 2)
+	return &moneyWeighted
+}
+
+func ratio(a, b money.Money) float64 {
+	return float64(a.Minor) / float64(b.Minor)
+}
+
+// asPercent writes a fraction as a percentage to 2 decimal places
+func asPercent(fraction float64) float64 {
+	return math.Round(fraction*10000) / 100
+}
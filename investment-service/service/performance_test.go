@@ -0,0 +1,176 @@
+package service_test
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/oliknight1/retail-isa-investment/investment-service/internal"
+	"github.com/oliknight1/retail-isa-investment/investment-service/logger"
+	"github.com/oliknight1/retail-isa-investment/investment-service/model"
+	"github.com/oliknight1/retail-isa-investment/investment-service/money"
+	"github.com/oliknight1/retail-isa-investment/investment-service/repository"
+	"github.com/oliknight1/retail-isa-investment/investment-service/service"
+)
+
+// fundHistory returns a fund client with single priced valuations, keyed by days after start
+func fundHistory(start time.Time, navs map[string]map[int]model.UnitPrice) *mockFundClient {
+	return &mockFundClient{
+		getPrices: func(fundId string, from, to time.Time) (*[]model.FundPrice, error) {
+			var prices []model.FundPrice
+			for day, nav := range navs[fundId] {
+				valuedAt := start.AddDate(0, 0, day)
+				if !valuedAt.Before(from) && !valuedAt.After(to) {
+					prices = append(prices, model.FundPrice{FundId: fundId, Nav: nav, ValuedAt: valuedAt})
+				}
+			}
+			return &prices, nil
+		},
+	}
+}
+
+func percentage(p float64) *float64 {
+	return &p
+}
+
+func TestGetPerformance(t *testing.T) {
+	start := time.Date(2025, time.June, 2, 11, 0, 0, 0, time.UTC)
+	repo := repository.NewInvestmentClient()
+	orders := []struct {
+		id        string
+		accountId string
+		fundId    string
+		status    model.InvestmentStatus
+		amount    money.Money
+		units     model.Units
+		price     model.UnitPrice
+		day       int
+	}{
+		{"inv-1", "acc-1", "fund-1", model.InvestmentSettled, money.Pounds(1000), 10_000_000, 1_000_000, 0},
+		// the price falls 10% before more is bought, then rises 20%
+		{"inv-2", "acc-1", "fund-1", model.InvestmentDealt, money.Pounds(900), 10_000_000, 900_000, 10},
+		{"inv-3", "acc-lisa", "fund-2", model.InvestmentDealt, money.Pounds(500), 2_500_000, 2_000_000, 0},
+		{"inv-cancelled", "acc-lisa", "fund-2", model.InvestmentCancelled, money.Pounds(200), 1_000_000, 2_000_000, 10},
+	}
+	for _, order := range orders {
+		repo.CreateInvestment(model.Investment{
+			Id:         order.id,
+			AccountId:  order.accountId,
+			CustomerId: "cust-1",
+			FundId:     order.fundId,
+			Type:       model.Subscription,
+			Amount:     order.amount,
+			Status:     order.status,
+			Dealing: &model.Dealing{
+				Price:    order.price,
+				Units:    order.units,
+				ValuedAt: start.AddDate(0, 0, order.day),
+				DealtAt:  start.AddDate(0, 0, order.day),
+			},
+		})
+	}
+	funds := fundHistory(start, map[string]map[int]model.UnitPrice{
+		"fund-1": {0: 1_000_000, 10: 900_000, 20: 1_080_000},
+		"fund-2": {0: 2_000_000, 10: 2_100_000, 20: 2_200_000},
+	})
+	svc := service.NewPerformanceService(repo, newAccountRepo(), funds, logger.NewMockLogger())
+
+	end := start.AddDate(0, 0, 20)
+	performance, err := svc.GetCustomerPerformance("cust-1", model.PeriodCustom, start, end)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	// money put in before the rise earns more than the funds' own growth
+	fund1 := model.Return{
+		StartValue:    money.Pounds(1000),
+		EndValue:      money.Pounds(2160),
+		NetInvested:   money.Pounds(900),
+		Gain:          money.Pounds(260),
+		TimeWeighted:  percentage(8),
+		MoneyWeighted: percentage(18.17),
+	}
+	fund2 := model.Return{
+		StartValue:    money.Pounds(500),
+		EndValue:      money.Pounds(550),
+		Gain:          money.Pounds(50),
+		TimeWeighted:  percentage(10),
+		MoneyWeighted: percentage(10),
+	}
+	expected := &model.Performance{
+		CustomerId: "cust-1",
+		Period:     model.PeriodCustom,
+		From:       start,
+		To:         end,
+		Return: model.Return{
+			StartValue:    money.Pounds(1500),
+			EndValue:      money.Pounds(2710),
+			NetInvested:   money.Pounds(900),
+			Gain:          money.Pounds(310),
+			TimeWeighted:  percentage(10.73),
+			MoneyWeighted: percentage(16.03),
+		},
+		Accounts: []model.AccountPerformance{
+			{AccountId: "acc-1", Return: fund1, Funds: []model.FundPerformance{{FundId: "fund-1", Return: fund1}}},
+			{AccountId: "acc-lisa", Return: fund2, Funds: []model.FundPerformance{{FundId: "fund-2", Return: fund2}}},
+		},
+	}
+	if diff := cmp.Diff(expected, performance); diff != "" {
+		t.Errorf("unexpected performance (-want +got):\n%s", diff)
+	}
+
+	// since inception runs from the first dealing to now, valued at the last known prices
+	performance, err = svc.GetAccountPerformance("acc-1", model.PeriodInception, time.Time{}, time.Time{})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !performance.From.Equal(start) || len(performance.Accounts) != 1 || !performance.EndValue.Equal(money.Pounds(2160)) {
+		t.Errorf("unexpected performance since inception: %+v", performance)
+	}
+	if performance.TimeWeighted == nil || *performance.TimeWeighted != 8 {
+		t.Errorf("expected a time-weighted return of 8%%, got %v", performance.TimeWeighted)
+	}
+
+	// a period after everything was dealt has no cash flows, so both returns are the price change
+	performance, err = svc.GetCustomerPerformance("cust-1", model.PeriodCustom, start.AddDate(0, 0, 10), end)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if *performance.TimeWeighted != 16.56 || *performance.MoneyWeighted != 16.56 {
+		t.Errorf("expected both returns to be 16.56%%, got %v and %v", *performance.TimeWeighted, *performance.MoneyWeighted)
+	}
+}
+
+func TestGetPerformanceFailures(t *testing.T) {
+	start := time.Date(2025, time.June, 2, 11, 0, 0, 0, time.UTC)
+	svc := service.NewPerformanceService(repository.NewInvestmentClient(), newAccountRepo(), fundHistory(start, nil), logger.NewMockLogger())
+
+	tests := []struct {
+		name     string
+		get      func() (*model.Performance, error)
+		expected error
+	}{
+		{"missing customer", func() (*model.Performance, error) {
+			return svc.GetCustomerPerformance("", model.PeriodOneYear, time.Time{}, time.Time{})
+		}, internal.ErrMissingCustomerId},
+		{"unknown period", func() (*model.Performance, error) {
+			return svc.GetCustomerPerformance("cust-1", "5y", time.Time{}, time.Time{})
+		}, internal.ErrInvalidPeriod},
+		{"custom without dates", func() (*model.Performance, error) {
+			return svc.GetCustomerPerformance("cust-1", model.PeriodCustom, start, time.Time{})
+		}, internal.ErrInvalidPeriod},
+		{"custom ending before it starts", func() (*model.Performance, error) {
+			return svc.GetCustomerPerformance("cust-1", model.PeriodCustom, start, start.AddDate(0, 0, -1))
+		}, internal.ErrInvalidPeriod},
+		{"unknown account", func() (*model.Performance, error) {
+			return svc.GetAccountPerformance("acc-missing", model.PeriodOneYear, time.Time{}, time.Time{})
+		}, internal.ErrAccountNotFound},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := tt.get(); !errors.Is(err, tt.expected) {
+				t.Errorf("expected %v, got %v", tt.expected, err)
+			}
+		})
+	}
+}
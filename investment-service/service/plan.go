@@ -0,0 +1,372 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/oliknight1/retail-isa-investment/investment-service/event"
+	"github.com/oliknight1/retail-isa-investment/investment-service/internal"
+	"github.com/oliknight1/retail-isa-investment/investment-service/logger"
+	"github.com/oliknight1/retail-isa-investment/investment-service/model"
+	"github.com/oliknight1/retail-isa-investment/investment-service/money"
+	"github.com/oliknight1/retail-isa-investment/investment-service/product"
+	"github.com/oliknight1/retail-isa-investment/investment-service/repository"
+	"github.com/oliknight1/retail-isa-investment/investment-service/taxyear"
+	"go.uber.org/zap"
+)
+
+type PlanService interface {
+	CreatePlan(plan model.Plan) (*model.Plan, error)
+	GetPlanById(id string) (*model.Plan, error)
+	GetPlansByCustomerId(id string) (*[]model.Plan, error)
+	PausePlan(id string) (*model.Plan, error)
+	ResumePlan(id string) (*model.Plan, error)
+	CancelPlan(id string) (*model.Plan, error)
+	Run(now time.Time) (*model.PlanSchedulerRun, error)
+}
+
+type PlanServiceImpl struct {
+	repo        repository.PlanRepository
+	accounts    repository.AccountRepository
+	investments InvestmentService
+	allowance   AllowanceService
+	publisher   event.EventHandler
+	Logger      logger.Logger
+	mu          sync.Mutex
+}
+
+func NewPlanService(
+	repo repository.PlanRepository,
+	accounts repository.AccountRepository,
+	investments InvestmentService,
+	allowance AllowanceService,
+	publisher event.EventHandler,
+	logger logger.Logger,
+) *PlanServiceImpl {
+	return &PlanServiceImpl{
+		repo:        repo,
+		accounts:    accounts,
+		investments: investments,
+		allowance:   allowance,
+		publisher:   publisher,
+		Logger:      logger,
+	}
+}
+
+// CreatePlan sets up a regular investment into an open account. Allocations must be to different
+// funds eligible for the account and add up to 100%, and the plan starts on the first DayOfMonth
+// on or after StartDate, which defaults to today and cannot be in the past
+func (s *PlanServiceImpl) CreatePlan(plan model.Plan) (*model.Plan, error) {
+	if plan.AccountId == "" {
+		s.Logger.Error("missing account_id in plan request", zap.Error(internal.ErrMissingAccountId))
+		return nil, internal.ErrMissingAccountId
+	}
+	now := time.Now()
+	today := londonDate(now)
+	if plan.StartDate.IsZero() {
+		plan.StartDate = today
+	}
+	if err := validatePlan(plan, today); err != nil {
+		s.Logger.Error("invalid plan request", zap.Error(err))
+		return nil, err
+	}
+
+	account, err := s.accounts.GetAccountById(plan.AccountId)
+	if err != nil {
+		s.Logger.Error("error fetching account for plan", zap.Error(err))
+		return nil, err
+	}
+	if account.Status != model.AccountOpen {
+		s.Logger.Error("plan for closed account", zap.String("account_id", plan.AccountId))
+		return nil, internal.ErrAccountClosed
+	}
+	rules, err := product.For(account.ProductType)
+	if err != nil {
+		s.Logger.Error("account has unknown product type", zap.Error(err))
+		return nil, err
+	}
+	for _, allocation := range plan.Allocations {
+		if !rules.FundEligible(allocation.FundId) {
+			s.Logger.Error("fund not eligible for account", zap.String("fund_id", allocation.FundId), zap.String("product_type", string(account.ProductType)))
+			return nil, internal.ErrFundNotEligible
+		}
+	}
+
+	plan.Id = uuid.New().String()
+	plan.CustomerId = account.CustomerId
+	plan.Status = model.PlanActive
+	plan.NextDueDate = nextDueDate(plan.StartDate, plan.DayOfMonth)
+	plan.Runs = nil
+	plan.CreatedAt = now
+	if err := s.repo.CreatePlan(plan); err != nil {
+		s.Logger.Error("error saving plan", zap.Error(err))
+		return nil, err
+	}
+	s.publish("plan.created", plan)
+	return &plan, nil
+}
+
+func validatePlan(plan model.Plan, today model.Date) error {
+	if !plan.Amount.IsPositive() {
+		return internal.ErrZeroTransactionAmount
+	}
+	if plan.DayOfMonth < 1 || plan.DayOfMonth > model.MaxPlanDay {
+		return fmt.Errorf("%w: day of month must be between 1 and %d", internal.ErrInvalidPlan, model.MaxPlanDay)
+	}
+	if plan.StartDate.Before(today.Time) {
+		return fmt.Errorf("%w: start date is in the past", internal.ErrInvalidPlan)
+	}
+	if plan.EndDate != nil && plan.EndDate.Before(plan.StartDate.Time) {
+		return fmt.Errorf("%w: end date is before the start date", internal.ErrInvalidPlan)
+	}
+	if len(plan.Allocations) == 0 {
+		return fmt.Errorf("%w: at least one fund allocation is required", internal.ErrInvalidPlan)
+	}
+	total := 0
+	funds := make(map[string]bool)
+	for _, allocation := range plan.Allocations {
+		if allocation.FundId == "" {
+			return internal.ErrMissingFundId
+		}
+		if funds[allocation.FundId] {
+			return fmt.Errorf("%w: %s is allocated more than once", internal.ErrInvalidPlan, allocation.FundId)
+		}
+		if allocation.Percent <= 0 {
+			return fmt.Errorf("%w: allocation to %s must be a positive percentage", internal.ErrInvalidPlan, allocation.FundId)
+		}
+		funds[allocation.FundId] = true
+		total += allocation.Percent
+	}
+	if total != 100 {
+		return fmt.Errorf("%w: allocations add up to %d%%, not 100%%", internal.ErrInvalidPlan, total)
+	}
+	for _, amount := range split(plan.Amount, plan.Allocations) {
+		if !amount.IsPositive() {
+			return fmt.Errorf("%w: %s is too small to split between %d funds", internal.ErrInvalidPlan, plan.Amount, len(plan.Allocations))
+		}
+	}
+	return nil
+}
+
+func (s *PlanServiceImpl) GetPlanById(id string) (*model.Plan, error) {
+	plan, err := s.repo.GetPlanById(id)
+	if err != nil {
+		s.Logger.Error("error fetching plan", zap.String("plan_id", id), zap.Error(err))
+		return nil, err
+	}
+	return plan, nil
+}
+
+func (s *PlanServiceImpl) GetPlansByCustomerId(id string) (*[]model.Plan, error) {
+	if id == "" {
+		s.Logger.Error("missing customer_id when requesting plans", zap.Error(internal.ErrMissingCustomerId))
+		return nil, internal.ErrMissingCustomerId
+	}
+	plans, err := s.repo.GetPlansByCustomerId(id)
+	if err != nil {
+		s.Logger.Error("error fetching plans", zap.Error(err))
+		return nil, err
+	}
+	return plans, nil
+}
+
+// PausePlan stops a plan investing until it is resumed
+func (s *PlanServiceImpl) PausePlan(id string) (*model.Plan, error) {
+	return s.changeStatus(id, model.PlanPaused, "plan.paused")
+}
+
+// ResumePlan restarts a paused plan from its next due date after today. Months missed while it
+// was paused are not made up
+func (s *PlanServiceImpl) ResumePlan(id string) (*model.Plan, error) {
+	return s.changeStatus(id, model.PlanActive, "plan.resumed")
+}
+
+// CancelPlan stops a plan for good
+func (s *PlanServiceImpl) CancelPlan(id string) (*model.Plan, error) {
+	return s.changeStatus(id, model.PlanCancelled, "plan.cancelled")
+}
+
+func (s *PlanServiceImpl) changeStatus(id string, status model.PlanStatus, subject string) (*model.Plan, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	plan, err := s.repo.GetPlanById(id)
+	if err != nil {
+		s.Logger.Error("error fetching plan", zap.String("plan_id", id), zap.Error(err))
+		return nil, err
+	}
+	if !plan.Status.CanTransition(status) {
+		s.Logger.Error("invalid plan status change", zap.String("plan_id", id), zap.String("from", string(plan.Status)), zap.String("to", string(status)))
+		return nil, fmt.Errorf("%w: plan is %s", internal.ErrInvalidTransition, plan.Status)
+	}
+	plan.Status = status
+	if status == model.PlanActive {
+		plan.NextDueDate = nextDueDate(londonDate(time.Now()), plan.DayOfMonth)
+	}
+	if err := s.repo.UpdatePlan(*plan); err != nil {
+		s.Logger.Error("error saving plan", zap.String("plan_id", id), zap.Error(err))
+		return nil, err
+	}
+	s.publish(subject, *plan)
+	return plan, nil
+}
+
+// Run invests for every active plan that has fallen due by now, catching up on any due dates
+// missed since the last run. A run is skipped if the customer's remaining allowance will not
+// cover it, and plans past their end date are ended
+func (s *PlanServiceImpl) Run(now time.Time) (*model.PlanSchedulerRun, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	plans, err := s.repo.GetPlansByStatus(model.PlanActive)
+	if err != nil {
+		s.Logger.Error("error fetching active plans", zap.Error(err))
+		return nil, err
+	}
+	slices.SortFunc(*plans, func(a, b model.Plan) int { return a.CreatedAt.Compare(b.CreatedAt) })
+
+	summary := &model.PlanSchedulerRun{RunAt: now}
+	today := londonDate(now)
+	for _, plan := range *plans {
+		ran := len(plan.Runs)
+		for !plan.NextDueDate.After(today.Time) && !planEnded(plan) {
+			run := s.invest(plan, now)
+			plan.Runs = append(plan.Runs, run)
+			plan.NextDueDate = model.Date{Time: plan.NextDueDate.AddDate(0, 1, 0)}
+			switch run.Outcome {
+			case model.PlanRunInvested:
+				summary.Invested++
+			case model.PlanRunSkipped:
+				summary.Skipped++
+			case model.PlanRunFailed:
+				summary.Failed++
+			}
+		}
+		ended := planEnded(plan)
+		if ended {
+			plan.Status = model.PlanEnded
+			summary.Ended++
+		}
+		if len(plan.Runs) == ran && !ended {
+			continue
+		}
+		if err := s.repo.UpdatePlan(plan); err != nil {
+			s.Logger.Error("error saving plan after run", zap.String("plan_id", plan.Id), zap.Error(err))
+			continue
+		}
+		for _, run := range plan.Runs[ran:] {
+			s.publish("plan.run."+string(run.Outcome), model.PlanRunEvent{
+				PlanId:     plan.Id,
+				AccountId:  plan.AccountId,
+				CustomerId: plan.CustomerId,
+				Amount:     plan.Amount,
+				PlanRun:    run,
+			})
+		}
+		if ended {
+			s.publish("plan.ended", plan)
+		}
+	}
+
+	s.Logger.Info("plan run complete",
+		zap.Int("invested", summary.Invested),
+		zap.Int("skipped", summary.Skipped),
+		zap.Int("failed", summary.Failed),
+		zap.Int("ended", summary.Ended),
+	)
+	return summary, nil
+}
+
+// invest makes one payment of a plan, creating an investment for each fund it is split between
+func (s *PlanServiceImpl) invest(plan model.Plan, now time.Time) model.PlanRun {
+	run := model.PlanRun{DueDate: plan.NextDueDate, RanAt: now}
+
+	allowance, err := s.allowance.GetAllowance(plan.CustomerId)
+	if err != nil {
+		s.Logger.Error("error fetching allowance for plan", zap.String("plan_id", plan.Id), zap.Error(err))
+		run.Outcome, run.Reason = model.PlanRunFailed, err.Error()
+		return run
+	}
+	if available := allowance.Remaining.Add(allowance.Replaceable); available.LessThan(plan.Amount) {
+		run.Outcome = model.PlanRunSkipped
+		run.Reason = fmt.Sprintf("%s of allowance left this tax year", available)
+		return run
+	}
+
+	amounts := split(plan.Amount, plan.Allocations)
+	for i, allocation := range plan.Allocations {
+		investment, err := s.investments.CreateInvestment(plan.AccountId, allocation.FundId, amounts[i])
+		if err != nil {
+			s.Logger.Error("error investing for plan", zap.String("plan_id", plan.Id), zap.String("fund_id", allocation.FundId), zap.Error(err))
+			run.Outcome, run.Reason = model.PlanRunFailed, err.Error()
+			// a product's own limit can still stop the first payment, which is a skip as nothing was invested
+			if errors.Is(err, internal.ErrAllowanceExceeded) && len(run.InvestmentIds) == 0 {
+				run.Outcome = model.PlanRunSkipped
+			}
+			return run
+		}
+		run.InvestmentIds = append(run.InvestmentIds, investment.Id)
+	}
+	run.Outcome = model.PlanRunInvested
+	return run
+}
+
+func (s *PlanServiceImpl) publish(subject string, payload any) {
+	if err := s.publisher.Publish(subject, payload); err != nil {
+		s.Logger.Error("error publishing plan event", zap.String("subject", subject), zap.Error(err))
+	}
+}
+
+// split divides amount between allocations by percentage, rounding each share down and giving
+// the pennies left over to the last fund
+func split(amount money.Money, allocations []model.FundAllocation) []money.Money {
+	amounts := make([]money.Money, len(allocations))
+	remaining := amount
+	for i, allocation := range allocations {
+		if i == len(allocations)-1 {
+			amounts[i] = remaining
+			break
+		}
+		amounts[i] = money.Pence(amount.Minor * int64(allocation.Percent) / 100)
+		remaining = remaining.Sub(amounts[i])
+	}
+	return amounts
+}
+
+func planEnded(plan model.Plan) bool {
+	return plan.EndDate != nil && plan.NextDueDate.After(plan.EndDate.Time)
+}
+
+// nextDueDate is the first date falling on day of the month on or after from
+func nextDueDate(from model.Date, day int) model.Date {
+	due := model.NewDate(from.Year(), from.Month(), day)
+	if due.Before(from.Time) {
+		due = model.Date{Time: due.AddDate(0, 1, 0)}
+	}
+	return due
+}
+
+func londonDate(t time.Time) model.Date {
+	local := taxyear.InLondon(t)
+	return model.NewDate(local.Year(), local.Month(), local.Day())
+}
+
+// Start runs the scheduler every interval until ctx is cancelled
+func (s *PlanServiceImpl) Start(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case now := <-ticker.C:
+			s.Run(now)
+		}
+	}
+}
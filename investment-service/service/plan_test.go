@@ -0,0 +1,203 @@
+package service_test
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/oliknight1/retail-isa-investment/investment-service/internal"
+	"github.com/oliknight1/retail-isa-investment/investment-service/logger"
+	"github.com/oliknight1/retail-isa-investment/investment-service/model"
+	"github.com/oliknight1/retail-isa-investment/investment-service/money"
+	"github.com/oliknight1/retail-isa-investment/investment-service/repository"
+	"github.com/oliknight1/retail-isa-investment/investment-service/service"
+	"github.com/oliknight1/retail-isa-investment/investment-service/taxyear"
+)
+
+func TestCreatePlanFailures(t *testing.T) {
+	logger := logger.NewMockLogger()
+	mockPub := &mockPublisher{publishFn: func(subject string, payload any) error { return nil }}
+	svc := service.NewPlanService(repository.NewPlanClient(), newAccountRepo(), nil, newAllowanceService(logger), mockPub, logger)
+
+	valid := func() model.Plan {
+		return model.Plan{
+			AccountId:   "acc-1",
+			Allocations: []model.FundAllocation{{FundId: "fund-1", Percent: 50}, {FundId: "fund-2", Percent: 50}},
+			Amount:      money.Pounds(100),
+			DayOfMonth:  1,
+		}
+	}
+	tests := []struct {
+		name     string
+		change   func(plan *model.Plan)
+		expected error
+	}{
+		{"missing account", func(plan *model.Plan) { plan.AccountId = "" }, internal.ErrMissingAccountId},
+		{"zero amount", func(plan *model.Plan) { plan.Amount = money.Money{} }, internal.ErrZeroTransactionAmount},
+		{"day after the 28th", func(plan *model.Plan) { plan.DayOfMonth = 31 }, internal.ErrInvalidPlan},
+		{"no funds", func(plan *model.Plan) { plan.Allocations = nil }, internal.ErrInvalidPlan},
+		{"allocations short of 100%", func(plan *model.Plan) { plan.Allocations[1].Percent = 40 }, internal.ErrInvalidPlan},
+		{"same fund twice", func(plan *model.Plan) { plan.Allocations[1].FundId = "fund-1" }, internal.ErrInvalidPlan},
+		{"too small to split", func(plan *model.Plan) { plan.Amount = money.Pence(1) }, internal.ErrInvalidPlan},
+		{"starts in the past", func(plan *model.Plan) {
+			plan.StartDate = model.Date{Time: time.Now().AddDate(0, 0, -2)}
+		}, internal.ErrInvalidPlan},
+		{"ends before it starts", func(plan *model.Plan) {
+			plan.StartDate = model.Date{Time: time.Now().AddDate(0, 1, 0)}
+			plan.EndDate = &model.Date{Time: plan.StartDate.AddDate(0, 0, -1)}
+		}, internal.ErrInvalidPlan},
+		{"closed account", func(plan *model.Plan) { plan.AccountId = "acc-closed" }, internal.ErrAccountClosed},
+		{"ineligible fund", func(plan *model.Plan) { plan.AccountId = "acc-cash" }, internal.ErrFundNotEligible},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			plan := valid()
+			tt.change(&plan)
+			if _, err := svc.CreatePlan(plan); !errors.Is(err, tt.expected) {
+				t.Errorf("expected %v, got %v", tt.expected, err)
+			}
+		})
+	}
+}
+
+func TestPlanRun(t *testing.T) {
+	logger := logger.NewMockLogger()
+	repo := repository.NewInvestmentClient()
+	var published []string
+	mockPub := &mockPublisher{
+		publishFn: func(subject string, payload any) error {
+			if strings.HasPrefix(subject, "plan.") {
+				published = append(published, subject)
+			}
+			return nil
+		},
+	}
+	investments := service.New(repo, newAccountRepo(), customersAged(30), fundPriced("1.000000"), newAllowanceService(logger), newBonusService(logger), newLedger(logger), service.NewLocks(), mockPub, logger)
+	svc := service.NewPlanService(repository.NewPlanClient(), newAccountRepo(), investments, newAllowanceService(logger), mockPub, logger)
+
+	// starts next month and runs two months later, so three payments have fallen due by then
+	today := taxyear.InLondon(time.Now())
+	start := model.NewDate(today.Year(), today.Month()+1, 1)
+	now := start.AddDate(0, 2, 0).Add(12 * time.Hour)
+	plan, err := svc.CreatePlan(model.Plan{
+		AccountId:   "acc-1",
+		Allocations: []model.FundAllocation{{FundId: "fund-1", Percent: 60}, {FundId: "fund-2", Percent: 40}},
+		Amount:      money.MustParse("8000.01"),
+		DayOfMonth:  1,
+		StartDate:   start,
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if plan.Status != model.PlanActive || !plan.NextDueDate.Equal(start.Time) || plan.CustomerId != "cust-1" {
+		t.Fatalf("unexpected plan: %+v", plan)
+	}
+
+	summary, err := svc.Run(now)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	// the third payment would take the customer over their £20,000 allowance
+	if diff := cmp.Diff(&model.PlanSchedulerRun{RunAt: now, Invested: 2, Skipped: 1}, summary); diff != "" {
+		t.Errorf("unexpected run (-want +got):\n%s", diff)
+	}
+	plan, _ = svc.GetPlanById(plan.Id)
+	if len(plan.Runs) != 3 || plan.Runs[2].Outcome != model.PlanRunSkipped || plan.Runs[2].Reason == "" {
+		t.Fatalf("expected two payments and a skipped one, got %+v", plan.Runs)
+	}
+	if expected := start.AddDate(0, 3, 0); !plan.NextDueDate.Equal(expected) {
+		t.Errorf("expected next payment on %s, got %s", expected, plan.NextDueDate)
+	}
+	bought, _ := repo.GetInvestmentsByAccountId("acc-1")
+	if len(*bought) != 4 {
+		t.Fatalf("expected an investment per fund per payment, got %d", len(*bought))
+	}
+	first, _ := repo.GetInvestmentById(plan.Runs[0].InvestmentIds[0])
+	second, _ := repo.GetInvestmentById(plan.Runs[0].InvestmentIds[1])
+	// pennies left over from the split go to the last fund
+	if first.FundId != "fund-1" || !first.Amount.Equal(money.Pounds(4800)) || !second.Amount.Equal(money.MustParse("3200.01")) {
+		t.Errorf("expected a 60/40 split, got %s in %s and %s in %s", first.Amount, first.FundId, second.Amount, second.FundId)
+	}
+
+	// running again the same day does nothing
+	if summary, _ := svc.Run(now); summary.Invested+summary.Skipped+summary.Failed != 0 {
+		t.Errorf("expected nothing to be due, got %+v", summary)
+	}
+
+	if _, err := svc.ResumePlan(plan.Id); !errors.Is(err, internal.ErrInvalidTransition) {
+		t.Errorf("expected an active plan not to be resumed, got %v", err)
+	}
+	if plan, err = svc.PausePlan(plan.Id); err != nil || plan.Status != model.PlanPaused {
+		t.Fatalf("expected plan to be paused, got %+v, %v", plan, err)
+	}
+	if summary, _ := svc.Run(now.AddDate(0, 2, 0)); summary.Invested+summary.Skipped+summary.Failed != 0 {
+		t.Errorf("expected a paused plan not to invest, got %+v", summary)
+	}
+	if plan, err = svc.ResumePlan(plan.Id); err != nil || plan.Status != model.PlanActive {
+		t.Fatalf("expected plan to be resumed, got %+v, %v", plan, err)
+	}
+	if plan, err = svc.CancelPlan(plan.Id); err != nil || plan.Status != model.PlanCancelled {
+		t.Fatalf("expected plan to be cancelled, got %+v, %v", plan, err)
+	}
+	if _, err := svc.PausePlan(plan.Id); !errors.Is(err, internal.ErrInvalidTransition) {
+		t.Errorf("expected a cancelled plan not to be paused, got %v", err)
+	}
+
+	expected := []string{"plan.created", "plan.run.invested", "plan.run.invested", "plan.run.skipped", "plan.paused", "plan.resumed", "plan.cancelled"}
+	if diff := cmp.Diff(expected, published); diff != "" {
+		t.Errorf("unexpected events (-want +got):\n%s", diff)
+	}
+}
+
+func TestPlanRunFails(t *testing.T) {
+	logger := logger.NewMockLogger()
+	failing := &mockRepo{
+		createInvestment: func(investment model.Investment) error { return errors.New("db failure") },
+	}
+	var published []string
+	mockPub := &mockPublisher{
+		publishFn: func(subject string, payload any) error {
+			if strings.HasPrefix(subject, "plan.") {
+				published = append(published, subject)
+			}
+			return nil
+		},
+	}
+	investments := service.New(failing, newAccountRepo(), customersAged(30), fundPriced("1.000000"), newAllowanceService(logger), newBonusService(logger), newLedger(logger), service.NewLocks(), mockPub, logger)
+	plans := repository.NewPlanClient()
+	svc := service.NewPlanService(plans, newAccountRepo(), investments, newAllowanceService(logger), mockPub, logger)
+
+	// the plan ends before its second payment
+	today := taxyear.InLondon(time.Now())
+	start := model.NewDate(today.Year(), today.Month()+1, 1)
+	end := model.Date{Time: start.AddDate(0, 0, 10)}
+	now := start.AddDate(0, 1, 0).Add(12 * time.Hour)
+	plan, err := svc.CreatePlan(model.Plan{
+		AccountId:   "acc-1",
+		Allocations: []model.FundAllocation{{FundId: "fund-1", Percent: 100}},
+		Amount:      money.Pounds(100),
+		DayOfMonth:  1,
+		StartDate:   start,
+		EndDate:     &end,
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	summary, err := svc.Run(now)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if diff := cmp.Diff(&model.PlanSchedulerRun{RunAt: now, Failed: 1, Ended: 1}, summary); diff != "" {
+		t.Errorf("unexpected run (-want +got):\n%s", diff)
+	}
+	plan, _ = svc.GetPlanById(plan.Id)
+	if plan.Status != model.PlanEnded || len(plan.Runs) != 1 || plan.Runs[0].Reason != "db failure" {
+		t.Errorf("expected one failed payment and the plan to end, got %+v", plan)
+	}
+	if diff := cmp.Diff([]string{"plan.created", "plan.run.failed", "plan.ended"}, published); diff != "" {
+		t.Errorf("unexpected events (-want +got):\n%s", diff)
+	}
+}
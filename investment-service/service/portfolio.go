@@ -0,0 +1,192 @@
+package service
+
+import (
+	"maps"
+	"math"
+	"math/big"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/oliknight1/retail-isa-investment/investment-service/client"
+	"github.com/oliknight1/retail-isa-investment/investment-service/internal"
+	"github.com/oliknight1/retail-isa-investment/investment-service/logger"
+	"github.com/oliknight1/retail-isa-investment/investment-service/model"
+	"github.com/oliknight1/retail-isa-investment/investment-service/money"
+	"github.com/oliknight1/retail-isa-investment/investment-service/repository"
+	"go.uber.org/zap"
+)
+
+type PortfolioService interface {
+	GetPortfolio(customerId string) (*model.Portfolio, error)
+}
+
+type PortfolioServiceImpl struct {
+	investments repository.Repository
+	accounts    repository.AccountRepository
+	funds       client.FundClient
+	ledger      LedgerService
+	Logger      logger.Logger
+}
+
+func NewPortfolioService(
+	investments repository.Repository,
+	accounts repository.AccountRepository,
+	funds client.FundClient,
+	ledger LedgerService,
+	logger logger.Logger,
+) *PortfolioServiceImpl {
+	return &PortfolioServiceImpl{
+		investments,
+		accounts,
+		funds,
+		ledger,
+		logger,
+	}
+}
+
+// position is the units of a fund held in an account and what they cost
+type position struct {
+	units    model.Units
+	bookCost money.Money
+}
+
+// GetPortfolio builds a customer's holdings from their dealt purchases and sales and values
+// them at each fund's latest price. Closed accounts that hold nothing are left out
+func (s *PortfolioServiceImpl) GetPortfolio(customerId string) (*model.Portfolio, error) {
+	if customerId == "" {
+		s.Logger.Error("missing customer_id when requesting portfolio", zap.Error(internal.ErrMissingCustomerId))
+		return nil, internal.ErrMissingCustomerId
+	}
+	accounts, err := s.accounts.GetAccountsByCustomerId(customerId)
+	if err != nil {
+		s.Logger.Error("error fetching accounts for portfolio", zap.Error(err))
+		return nil, err
+	}
+	investments, err := s.investments.GetInvestmentsByCustomerId(customerId)
+	if err != nil {
+		s.Logger.Error("error fetching investments for portfolio", zap.Error(err))
+		return nil, err
+	}
+	positions := positions(*investments)
+
+	portfolio := &model.Portfolio{CustomerId: customerId, ValuedAt: time.Now()}
+	prices := make(map[string]*model.FundPrice)
+	for _, account := range *accounts {
+		held := positions[account.Id]
+		cash, err := s.ledger.GetCashBalance(account.Id)
+		if err != nil {
+			s.Logger.Error("error fetching cash for portfolio", zap.String("account_id", account.Id), zap.Error(err))
+			return nil, err
+		}
+		if account.Status == model.AccountClosed && len(held) == 0 && cash.Balance.IsZero() {
+			continue
+		}
+		valuation := model.AccountValuation{
+			AccountId:   account.Id,
+			ProductType: account.ProductType,
+			Cash:        cash.Balance,
+			Value:       cash.Balance,
+		}
+		for _, fundId := range slices.Sorted(maps.Keys(held)) {
+			price, ok := prices[fundId]
+			if !ok {
+				price, err = s.funds.GetLatestPrice(fundId)
+				if err != nil {
+					s.Logger.Error("error fetching fund price for portfolio", zap.String("fund_id", fundId), zap.Error(err))
+					return nil, err
+				}
+				prices[fundId] = price
+			}
+			holding := value(fundId, held[fundId], *price)
+			valuation.Holdings = append(valuation.Holdings, holding)
+			valuation.BookCost = valuation.BookCost.Add(holding.BookCost)
+			valuation.Value = valuation.Value.Add(holding.Value)
+		}
+		valuation.Gain = valuation.Value.Sub(valuation.Cash).Sub(valuation.BookCost)
+		for i := range valuation.Holdings {
+			valuation.Holdings[i].Allocation = percent(valuation.Holdings[i].Value, valuation.Value)
+		}
+
+		portfolio.Accounts = append(portfolio.Accounts, valuation)
+		portfolio.BookCost = portfolio.BookCost.Add(valuation.BookCost)
+		portfolio.Value = portfolio.Value.Add(valuation.Value)
+		portfolio.Gain = portfolio.Gain.Add(valuation.Gain)
+	}
+	slices.SortFunc(portfolio.Accounts, func(a, b model.AccountValuation) int { return strings.Compare(a.AccountId, b.AccountId) })
+
+	return portfolio, nil
+}
+
+// positions replays dealt purchases and sales in the order they were dealt, giving the units and
+// book cost of every fund held in each account. A purchase adds what was spent on units, and a
+// sale takes out book cost in proportion to the units sold so the average cost is unchanged
+func positions(investments []model.Investment) map[string]map[string]*position {
+	var dealt []model.Investment
+	for _, investment := range investments {
+		if investment.Dealing != nil && investment.Status.Live() && (investment.AddsUnits() || investment.RemovesUnits()) {
+			dealt = append(dealt, investment)
+		}
+	}
+	slices.SortFunc(dealt, func(a, b model.Investment) int { return a.Dealing.DealtAt.Compare(b.Dealing.DealtAt) })
+
+	held := make(map[string]map[string]*position)
+	for _, investment := range dealt {
+		if held[investment.AccountId] == nil {
+			held[investment.AccountId] = make(map[string]*position)
+		}
+		p := held[investment.AccountId][investment.FundId]
+		if p == nil {
+			p = &position{}
+			held[investment.AccountId][investment.FundId] = p
+		}
+		units := investment.Dealing.Units
+		if investment.AddsUnits() {
+			p.units += units
+			p.bookCost = p.bookCost.Add(investment.Amount.Sub(investment.Dealing.Residual))
+			continue
+		}
+		p.bookCost = p.bookCost.Sub(proportion(p.bookCost, units, p.units))
+		p.units -= units
+		if p.units <= 0 {
+			delete(held[investment.AccountId], investment.FundId)
+		}
+	}
+	return held
+}
+
+// value prices a position at what its units could be sold for
+func value(fundId string, p *position, price model.FundPrice) model.Holding {
+	sellPrice := price.SellPrice()
+	holding := model.Holding{
+		FundId:      fundId,
+		Units:       p.units,
+		BookCost:    p.bookCost,
+		AverageCost: model.PricePerUnit(p.bookCost, p.units),
+		Price:       sellPrice,
+		PricedAt:    price.ValuedAt,
+		Value:       p.units.Value(sellPrice),
+	}
+	holding.Gain = holding.Value.Sub(holding.BookCost)
+	holding.GainPercent = percent(holding.Gain, holding.BookCost)
+	return holding
+}
+
+// proportion is part/whole of amount, rounded half up to the nearest penny
+func proportion(amount money.Money, part, whole model.Units) money.Money {
+	if whole <= 0 || part >= whole {
+		return amount
+	}
+	n := new(big.Int).Mul(big.NewInt(amount.Minor), big.NewInt(int64(part)))
+	n.Add(n, big.NewInt(int64(whole)/2))
+	n.Div(n, big.NewInt(int64(whole)))
+	return money.Pence(n.Int64())
+}
+
+// percent is part as a percentage of whole to 2 decimal places, or 0 if whole is zero
+func percent(part, whole money.Money) float64 {
+	if whole.IsZero() {
+		return 0
+	}
+	return math.Round(float64(part.Minor)*10000/float64(whole.Minor)) / 100
+}
@@ -0,0 +1,127 @@
+package service_test
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-cmp/cmp/cmpopts"
+	"github.com/oliknight1/retail-isa-investment/investment-service/internal"
+	"github.com/oliknight1/retail-isa-investment/investment-service/logger"
+	"github.com/oliknight1/retail-isa-investment/investment-service/model"
+	"github.com/oliknight1/retail-isa-investment/investment-service/money"
+	"github.com/oliknight1/retail-isa-investment/investment-service/repository"
+	"github.com/oliknight1/retail-isa-investment/investment-service/service"
+)
+
+func TestGetPortfolio(t *testing.T) {
+	dealtAt := time.Date(2025, time.June, 2, 12, 0, 0, 0, time.UTC)
+	repo := repository.NewInvestmentClient()
+	orders := []struct {
+		id       string
+		fundId   string
+		kind     model.InvestmentType
+		status   model.InvestmentStatus
+		amount   money.Money
+		units    string
+		residual money.Money
+		day      int
+	}{
+		{"inv-1", "fund-1", model.Subscription, model.InvestmentDealt, money.Pounds(1000), "1000.0000", money.Money{}, 0},
+		// 400 units at 1.49995 leaves 2p over, which is not part of the book cost
+		{"inv-2", "fund-1", model.Subscription, model.InvestmentSettled, money.MustParse("600.02"), "400.0000", money.Pence(2), 1},
+		// half the holding is sold so half the book cost goes with it
+		{"inv-3", "fund-1", model.Redemption, model.InvestmentDealt, money.Pounds(700), "700.0000", money.Money{}, 2},
+		{"inv-4", "fund-2", model.SwitchBuy, model.InvestmentDealt, money.Pounds(500), "250.0000", money.Money{}, 3},
+		{"inv-failed", "fund-2", model.Subscription, model.InvestmentFailed, money.Pounds(100), "50.0000", money.Money{}, 3},
+		{"inv-undealt", "fund-1", model.Subscription, model.InvestmentValidated, money.Pounds(100), "", money.Money{}, 4},
+	}
+	for _, order := range orders {
+		investment := model.Investment{
+			Id:         order.id,
+			AccountId:  "acc-1",
+			CustomerId: "cust-1",
+			FundId:     order.fundId,
+			Type:       order.kind,
+			Amount:     order.amount,
+			Status:     order.status,
+		}
+		if order.units != "" {
+			units, _ := model.ParseUnits(order.units)
+			investment.Dealing = &model.Dealing{Units: units, Residual: order.residual, DealtAt: dealtAt.AddDate(0, 0, order.day)}
+		}
+		repo.CreateInvestment(investment)
+	}
+	accounts := newAccountRepo()
+	ledger := newLedger(logger.NewMockLogger())
+	payIn(ledger, "acc-1", money.Pounds(160))
+
+	pricedAt := dealtAt.AddDate(0, 0, 7)
+	var requested []string
+	funds := &mockFundClient{
+		getLatestPrice: func(fundId string) (*model.FundPrice, error) {
+			requested = append(requested, fundId)
+			nav := map[string]model.UnitPrice{"fund-1": 1_200_000, "fund-2": 2_000_000}[fundId]
+			bid := nav - 100_000
+			return &model.FundPrice{FundId: fundId, Nav: nav, Bid: &bid, ValuedAt: pricedAt}, nil
+		},
+	}
+	svc := service.NewPortfolioService(repo, accounts, funds, ledger, logger.NewMockLogger())
+
+	portfolio, err := svc.GetPortfolio("cust-1")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	// holdings are valued at the bid price, what the units could be sold for
+	expected := &model.Portfolio{
+		CustomerId: "cust-1",
+		Accounts: []model.AccountValuation{
+			{
+				AccountId:   "acc-1",
+				ProductType: model.StocksAndSharesISA,
+				Holdings: []model.Holding{
+					{FundId: "fund-1", Units: 7_000_000, BookCost: money.Pounds(800), AverageCost: 1_142_857, Price: 1_100_000, PricedAt: pricedAt, Value: money.Pounds(770), Gain: money.Pounds(-30), GainPercent: -3.75, Allocation: 54.8},
+					{FundId: "fund-2", Units: 2_500_000, BookCost: money.Pounds(500), AverageCost: 2_000_000, Price: 1_900_000, PricedAt: pricedAt, Value: money.Pounds(475), Gain: money.Pounds(-25), GainPercent: -5, Allocation: 33.81},
+				},
+				Cash:     money.Pounds(160),
+				BookCost: money.Pounds(1300),
+				Value:    money.Pounds(1405),
+				Gain:     money.Pounds(-55),
+			},
+			{AccountId: "acc-cash", ProductType: model.CashISA},
+			{AccountId: "acc-lisa", ProductType: model.LifetimeISA},
+		},
+		BookCost: money.Pounds(1300),
+		Value:    money.Pounds(1405),
+		Gain:     money.Pounds(-55),
+	}
+	if diff := cmp.Diff(expected, portfolio, cmpopts.IgnoreFields(model.Portfolio{}, "ValuedAt")); diff != "" {
+		t.Errorf("unexpected portfolio (-want +got):\n%s", diff)
+	}
+	if diff := cmp.Diff([]string{"fund-1", "fund-2"}, requested); diff != "" {
+		t.Errorf("expected each fund to be priced once (-want +got):\n%s", diff)
+	}
+}
+
+func TestGetPortfolioFailures(t *testing.T) {
+	repo := repository.NewInvestmentClient()
+	units, _ := model.ParseUnits("10.0000")
+	repo.CreateInvestment(model.Investment{
+		Id: "inv-1", AccountId: "acc-1", CustomerId: "cust-1", FundId: "fund-1", Type: model.Subscription,
+		Amount: money.Pounds(10), Status: model.InvestmentDealt, Dealing: &model.Dealing{Units: units},
+	})
+	funds := &mockFundClient{
+		getLatestPrice: func(fundId string) (*model.FundPrice, error) {
+			return nil, errors.New("fund-service unavailable")
+		},
+	}
+	svc := service.NewPortfolioService(repo, newAccountRepo(), funds, newLedger(logger.NewMockLogger()), logger.NewMockLogger())
+
+	if _, err := svc.GetPortfolio(""); !errors.Is(err, internal.ErrMissingCustomerId) {
+		t.Errorf("expected ErrMissingCustomerId, got %v", err)
+	}
+	if _, err := svc.GetPortfolio("cust-1"); err == nil {
+		t.Error("expected an error when a holding cannot be priced")
+	}
+}
@@ -0,0 +1,413 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"maps"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/oliknight1/retail-isa-investment/investment-service/client"
+	"github.com/oliknight1/retail-isa-investment/investment-service/event"
+	"github.com/oliknight1/retail-isa-investment/investment-service/internal"
+	"github.com/oliknight1/retail-isa-investment/investment-service/logger"
+	"github.com/oliknight1/retail-isa-investment/investment-service/model"
+	"github.com/oliknight1/retail-isa-investment/investment-service/money"
+	"github.com/oliknight1/retail-isa-investment/investment-service/repository"
+	"go.uber.org/zap"
+)
+
+// reconciliationGrace is how long fund-service and the event log are given to catch up with a
+// change, as each hears of it through NATS, and how long a change posted to the ledger has to be
+// saved. Anything changed more recently is left for the next run
+const reconciliationGrace = 5 * time.Minute
+
+// eventWindow is how far back status changes and events are checked against each other. A
+// missing or unexpected event is reported by every run for a fortnight and then dropped, rather
+// than being reported forever once it has been looked into
+const eventWindow = 14 * 24 * time.Hour
+
+var investmentStatuses = []model.InvestmentStatus{
+	model.InvestmentPending,
+	model.InvestmentValidated,
+	model.InvestmentDealt,
+	model.InvestmentSettled,
+	model.InvestmentFailed,
+	model.InvestmentCancelled,
+}
+
+type ReconciliationService interface {
+	Run(now time.Time) (*model.ReconciliationRun, error)
+	GetLatestRun() (*model.ReconciliationRun, error)
+}
+
+// ReconciliationServiceImpl checks investment-service's records against the ledger, each
+// account's reserved cash against the orders waiting on it, each fund's units against
+// fund-service's register and each investment's status history against the status events
+// received from NATS, and reports every break it finds
+type ReconciliationServiceImpl struct {
+	repo        repository.ReconciliationRepository
+	investments repository.Repository
+	ledger      repository.LedgerRepository
+	funds       client.FundClient
+	publisher   event.EventHandler
+	Logger      logger.Logger
+	mu          sync.Mutex
+}
+
+func NewReconciliationService(
+	repo repository.ReconciliationRepository,
+	investments repository.Repository,
+	ledger repository.LedgerRepository,
+	funds client.FundClient,
+	publisher event.EventHandler,
+	logger logger.Logger,
+) *ReconciliationServiceImpl {
+	return &ReconciliationServiceImpl{
+		repo:        repo,
+		investments: investments,
+		ledger:      ledger,
+		funds:       funds,
+		publisher:   publisher,
+		Logger:      logger,
+	}
+}
+
+// Run reconciles everything and saves the run. If anything does not match, or a check could not
+// be made, the run is published as reconciliation.break
+func (s *ReconciliationServiceImpl) Run(now time.Time) (*model.ReconciliationRun, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var investments []model.Investment
+	for _, status := range investmentStatuses {
+		found, err := s.investments.GetInvestmentsByStatus(status)
+		if err != nil {
+			s.Logger.Error("error fetching investments for reconciliation", zap.Error(err))
+			return nil, err
+		}
+		investments = append(investments, *found...)
+	}
+	slices.SortFunc(investments, func(a, b model.Investment) int { return a.CreatedAt.Compare(b.CreatedAt) })
+	events, err := s.repo.GetStatusEvents()
+	if err != nil {
+		s.Logger.Error("error fetching status events for reconciliation", zap.Error(err))
+		return nil, err
+	}
+
+	settledBy := now.Add(-reconciliationGrace)
+	run := &model.ReconciliationRun{Id: uuid.New().String(), RunAt: now, Investments: len(investments), Events: len(*events)}
+	ledgerBreaks, err := s.reconcileLedger(investments, settledBy)
+	if err != nil {
+		return nil, err
+	}
+	cashBreaks, accounts, err := s.reconcileCash(investments, settledBy)
+	if err != nil {
+		return nil, err
+	}
+	run.Accounts = accounts
+	// fund-service being unavailable fails the units check but the rest is still reported
+	unitBreaks := []model.ReconciliationBreak{}
+	if inIssue, err := s.funds.GetUnitsInIssue(); err != nil {
+		s.Logger.Error("error fetching units in issue for reconciliation", zap.Error(err))
+		run.Failed = append(run.Failed, model.CheckUnits)
+	} else {
+		unitBreaks, run.Funds = reconcileUnits(investments, *inIssue, settledBy)
+	}
+	run.Breaks = slices.Concat(ledgerBreaks, cashBreaks, unitBreaks, reconcileEvents(investments, *events, now.Add(-eventWindow), settledBy))
+
+	if err := s.repo.AddRun(*run); err != nil {
+		s.Logger.Error("error saving reconciliation run", zap.Error(err))
+		return nil, err
+	}
+	if len(run.Breaks) > 0 || len(run.Failed) > 0 {
+		if err := s.publisher.Publish("reconciliation.break", run); err != nil {
+			s.Logger.Error("error publishing reconciliation.break event", zap.Error(err))
+		}
+	}
+
+	s.Logger.Info("reconciliation run complete",
+		zap.Int("investments", run.Investments),
+		zap.Int("accounts", run.Accounts),
+		zap.Int("funds", run.Funds),
+		zap.Int("events", run.Events),
+		zap.Int("breaks", len(run.Breaks)),
+		zap.Int("failed", len(run.Failed)),
+	)
+	return run, nil
+}
+
+// reconcileLedger checks every investment that moved money has the journal entry it should, and
+// that any which failed or was cancelled has had its entries reversed
+func (s *ReconciliationServiceImpl) reconcileLedger(investments []model.Investment, settledBy time.Time) ([]model.ReconciliationBreak, error) {
+	breaks := []model.ReconciliationBreak{}
+	for _, investment := range investments {
+		for _, status := range []model.InvestmentStatus{model.InvestmentValidated, model.InvestmentDealt, model.InvestmentSettled} {
+			change := changeTo(investment, status)
+			if change == nil || change.At.After(settledBy) {
+				continue
+			}
+			reference := investmentReference(investment.Id, status)
+			entry, err := s.ledger.GetEntryByReference(reference)
+			if err != nil && !errors.Is(err, internal.ErrEntryNotFound) {
+				s.Logger.Error("error fetching journal entry for reconciliation", zap.String("reference", reference), zap.Error(err))
+				return nil, err
+			}
+
+			if !investment.Status.Live() {
+				if entry == nil || lastChanged(investment).After(settledBy) {
+					continue
+				}
+				if _, err := s.ledger.GetEntryByReference(reversalReference(reference)); errors.Is(err, internal.ErrEntryNotFound) {
+					breaks = append(breaks, model.ReconciliationBreak{
+						Type:    model.BreakLedgerNotReversed,
+						Amount:  debits(entry.Postings),
+						Records: []string{investment.Id, entry.Id},
+						Detail:  fmt.Sprintf("%s is %s but %s has not been reversed", investment.Type, investment.Status, reference),
+					})
+				}
+				continue
+			}
+
+			expected := investmentPostings(investment, status)
+			switch {
+			case expected == nil:
+			case entry == nil:
+				breaks = append(breaks, model.ReconciliationBreak{
+					Type:    model.BreakLedgerMissing,
+					Amount:  debits(expected),
+					Records: []string{investment.Id},
+					Detail:  fmt.Sprintf("%s was %s but %s has not been posted", investment.Type, status, reference),
+				})
+			case !samePostings(expected, entry.Postings):
+				breaks = append(breaks, model.ReconciliationBreak{
+					Type:    model.BreakLedgerMismatch,
+					Amount:  debits(expected).Sub(debits(entry.Postings)),
+					Records: []string{investment.Id, entry.Id},
+					Detail:  fmt.Sprintf("%s posted %s where the %s moved %s", reference, debits(entry.Postings), investment.Type, debits(expected)),
+				})
+			}
+		}
+	}
+	return breaks, nil
+}
+
+// reconcileCash checks the cash each account has reserved in the ledger against the purchases,
+// withdrawals and cash transfers out still waiting on it, and that no account has spent more
+// cash than it holds. An account with a change too recent to have been saved is left out
+func (s *ReconciliationServiceImpl) reconcileCash(investments []model.Investment, settledBy time.Time) ([]model.ReconciliationBreak, int, error) {
+	settling := make(map[string]bool)
+	waiting := make(map[string]money.Money)
+	for _, investment := range investments {
+		settling[investment.AccountId] = settling[investment.AccountId] || lastChanged(investment).After(settledBy)
+		if investment.Status == model.InvestmentValidated && spendsCash(investment) {
+			waiting[investment.AccountId] = waiting[investment.AccountId].Add(investment.Amount)
+		}
+	}
+
+	breaks := []model.ReconciliationBreak{}
+	accountIds := slices.Sorted(maps.Keys(settling))
+	for _, accountId := range accountIds {
+		if settling[accountId] {
+			continue
+		}
+		entries, err := s.ledger.GetEntriesByAccountId(accountId)
+		if err != nil {
+			s.Logger.Error("error fetching journal entries for reconciliation", zap.String("account_id", accountId), zap.Error(err))
+			return nil, 0, err
+		}
+		cash := cashBalance(accountId, *ledgerBalances(*entries))
+		if !cash.Reserved.Equal(waiting[accountId]) {
+			breaks = append(breaks, model.ReconciliationBreak{
+				Type:    model.BreakCashMismatch,
+				Amount:  cash.Reserved.Sub(waiting[accountId]),
+				Records: []string{accountId},
+				Detail:  fmt.Sprintf("ledger has %s cash reserved but orders waiting on cash total %s", cash.Reserved, waiting[accountId]),
+			})
+		}
+		if cash.Available.IsNegative() {
+			breaks = append(breaks, model.ReconciliationBreak{
+				Type:    model.BreakCashMismatch,
+				Amount:  cash.Available,
+				Records: []string{accountId},
+				Detail:  fmt.Sprintf("ledger has %s cash available, so more has been spent than the account holds", cash.Available),
+			})
+		}
+	}
+	return breaks, len(accountIds), nil
+}
+
+// reconcileUnits checks the units held of each fund across every account against the units
+// fund-service has in issue. A fund dealt too recently for fund-service to have caught up is
+// left out. fund-service builds its register from the investment.units.moved events published
+// here, so this finds movements lost between the two services rather than checking against an
+// independent record, and a register lost by fund-service shows every fund as a break
+func reconcileUnits(investments []model.Investment, inIssue []model.FundUnits, settledBy time.Time) ([]model.ReconciliationBreak, int) {
+	held := make(map[string]model.Units)
+	dealing := make(map[string][]string)
+	settling := make(map[string]bool)
+	for _, investment := range investments {
+		if investment.Dealing == nil {
+			continue
+		}
+		settling[investment.FundId] = settling[investment.FundId] || lastChanged(investment).After(settledBy)
+		if !investment.Status.Live() {
+			continue
+		}
+		switch {
+		case investment.AddsUnits():
+			held[investment.FundId] += investment.Dealing.Units
+		case investment.RemovesUnits():
+			held[investment.FundId] -= investment.Dealing.Units
+		}
+		dealing[investment.FundId] = append(dealing[investment.FundId], investment.Id)
+	}
+	issued := make(map[string]model.Units)
+	for _, fund := range inIssue {
+		issued[fund.FundId] = fund.Units
+	}
+
+	breaks := []model.ReconciliationBreak{}
+	fundIds := slices.Sorted(maps.Keys(settling))
+	for _, fundId := range slices.Sorted(maps.Keys(issued)) {
+		if _, ok := settling[fundId]; !ok {
+			fundIds = append(fundIds, fundId)
+		}
+	}
+	for _, fundId := range fundIds {
+		if settling[fundId] || held[fundId] == issued[fundId] {
+			continue
+		}
+		breaks = append(breaks, model.ReconciliationBreak{
+			Type:    model.BreakUnitsMismatch,
+			Units:   held[fundId] - issued[fundId],
+			Records: append([]string{fundId}, dealing[fundId]...),
+			Detail:  fmt.Sprintf("accounts hold %s units of %s but fund-service has %s in issue", held[fundId], fundId, issued[fundId]),
+		})
+	}
+	return breaks, len(fundIds)
+}
+
+// reconcileEvents checks every status change an investment made was received as an event, and
+// every event received was for a change an investment made. Only changes and events since from
+// are checked
+func reconcileEvents(investments []model.Investment, events []model.InvestmentStatusChanged, from, settledBy time.Time) []model.ReconciliationBreak {
+	type key struct {
+		investmentId string
+		status       model.InvestmentStatus
+		at           int64
+	}
+	received := make(map[key]bool)
+	for _, event := range events {
+		received[key{event.InvestmentId, event.Status, event.At.UnixNano()}] = true
+	}
+
+	breaks := []model.ReconciliationBreak{}
+	made := make(map[key]bool)
+	for _, investment := range investments {
+		for _, change := range investment.History {
+			k := key{investment.Id, change.Status, change.At.UnixNano()}
+			made[k] = true
+			if change.At.Before(from) || change.At.After(settledBy) || received[k] {
+				continue
+			}
+			breaks = append(breaks, model.ReconciliationBreak{
+				Type:    model.BreakEventMissing,
+				Amount:  investment.Amount,
+				Records: []string{investment.Id},
+				Detail:  fmt.Sprintf("no investment.status.%s event was received for the change at %s", change.Status, change.At.Format(time.RFC3339)),
+			})
+		}
+	}
+	for _, event := range events {
+		if event.At.Before(from) || event.At.After(settledBy) || made[key{event.InvestmentId, event.Status, event.At.UnixNano()}] {
+			continue
+		}
+		breaks = append(breaks, model.ReconciliationBreak{
+			Type:    model.BreakEventUnexpected,
+			Records: []string{event.InvestmentId},
+			Detail:  fmt.Sprintf("investment.status.%s event at %s matches no change the investment made", event.Status, event.At.Format(time.RFC3339)),
+		})
+	}
+	return breaks
+}
+
+// changeTo is the change that moved an investment to status, or nil if it never has been
+func changeTo(investment model.Investment, status model.InvestmentStatus) *model.InvestmentStatusChange {
+	for _, change := range investment.History {
+		if change.Status == status {
+			return &change
+		}
+	}
+	return nil
+}
+
+// lastChanged is when an investment last changed status
+func lastChanged(investment model.Investment) time.Time {
+	if len(investment.History) == 0 {
+		return investment.CreatedAt
+	}
+	return investment.History[len(investment.History)-1].At
+}
+
+// debits is the total debited by postings, which for a balanced entry is the amount it moved
+func debits(postings []model.Posting) money.Money {
+	var total money.Money
+	for _, posting := range postings {
+		if posting.Side == model.Debit {
+			total = total.Add(posting.Amount)
+		}
+	}
+	return total
+}
+
+// samePostings reports whether two sets of postings move the same amounts between the same
+// ledger accounts
+func samePostings(a, b []model.Posting) bool {
+	return slices.EqualFunc(
+		*ledgerBalances([]model.JournalEntry{{Postings: a}}),
+		*ledgerBalances([]model.JournalEntry{{Postings: b}}),
+		func(x, y model.LedgerBalance) bool { return x.Code == y.Code && x.Balance.Equal(y.Balance) },
+	)
+}
+
+func (s *ReconciliationServiceImpl) GetLatestRun() (*model.ReconciliationRun, error) {
+	run, err := s.repo.GetLatestRun()
+	if err != nil {
+		s.Logger.Error("error fetching latest reconciliation run", zap.Error(err))
+		return nil, err
+	}
+	return run, nil
+}
+
+// OnStatusChanged handles investment.status.* events, keeping them to reconcile against. Events
+// older than the window checked are dropped as new ones arrive
+func (s *ReconciliationServiceImpl) OnStatusChanged(data []byte) {
+	var changed model.InvestmentStatusChanged
+	if err := json.Unmarshal(data, &changed); err != nil {
+		s.Logger.Error("failed to decode investment status event", zap.Error(err))
+		return
+	}
+	if err := s.repo.AddStatusEvent(changed, time.Now().Add(-eventWindow)); err != nil {
+		s.Logger.Error("error saving investment status event", zap.String("investment_id", changed.InvestmentId), zap.Error(err))
+	}
+}
+
+// Start reconciles every interval until ctx is cancelled
+func (s *ReconciliationServiceImpl) Start(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case now := <-ticker.C:
+			s.Run(now)
+		}
+	}
+}
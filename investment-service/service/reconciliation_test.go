@@ -0,0 +1,200 @@
+package service_test
+
+import (
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/oliknight1/retail-isa-investment/investment-service/internal"
+	"github.com/oliknight1/retail-isa-investment/investment-service/logger"
+	"github.com/oliknight1/retail-isa-investment/investment-service/model"
+	"github.com/oliknight1/retail-isa-investment/investment-service/money"
+	"github.com/oliknight1/retail-isa-investment/investment-service/repository"
+	"github.com/oliknight1/retail-isa-investment/investment-service/service"
+)
+
+// lossyLedger loses the entries posted while dropping is set
+type lossyLedger struct {
+	*repository.LedgerClient
+	dropping *bool
+}
+
+func (l lossyLedger) Post(entry model.JournalEntry) error {
+	if *l.dropping {
+		return nil
+	}
+	return l.LedgerClient.Post(entry)
+}
+
+func TestReconciliation(t *testing.T) {
+	logger := logger.NewMockLogger()
+	repo := repository.NewInvestmentClient()
+	accounts := newAccountRepo()
+	reconciliationRepo := repository.NewReconciliationClient()
+	nothing := &mockPublisher{publishFn: func(string, any) error { return nil }}
+	// a ledger and a bus that lose everything while dropping is set
+	var dropping bool
+	ledgerRepo := lossyLedger{repository.NewLedgerClient(), &dropping}
+	ledger := service.NewLedgerService(ledgerRepo, nothing, logger)
+
+	// fund-service's unit register
+	inIssue := make(map[string]model.Units)
+	funds := fundPriced("2.000000")
+	funds.getUnitsInIssue = func() (*[]model.FundUnits, error) {
+		units := []model.FundUnits{}
+		for fundId, issued := range inIssue {
+			units = append(units, model.FundUnits{FundId: fundId, Units: issued})
+		}
+		return &units, nil
+	}
+	var breaksPublished []model.ReconciliationRun
+	reconciliationPub := &mockPublisher{
+		publishFn: func(subject string, payload any) error {
+			if subject == "reconciliation.break" {
+				breaksPublished = append(breaksPublished, *payload.(*model.ReconciliationRun))
+			}
+			return nil
+		},
+	}
+	reconciliation := service.NewReconciliationService(reconciliationRepo, repo, ledgerRepo, funds, reconciliationPub, logger)
+	bus := &mockPublisher{
+		publishFn: func(subject string, payload any) error {
+			if dropping {
+				return nil
+			}
+			data, err := json.Marshal(payload)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			switch {
+			case strings.HasPrefix(subject, "investment.status."):
+				reconciliation.OnStatusChanged(data)
+			case subject == "investment.units.moved":
+				var movement model.UnitMovement
+				json.Unmarshal(data, &movement)
+				inIssue[movement.FundId] += movement.Units
+			}
+			return nil
+		},
+	}
+	svc := service.New(repo, accounts, customersAged(30), funds, newAllowanceService(logger), newBonusService(logger), ledger, service.NewLocks(), bus, logger)
+	dealingSvc := newDealingService(repo, accounts, ledger, funds, bus, logger)
+	week := time.Now().AddDate(0, 0, 7)
+
+	if _, err := reconciliation.GetLatestRun(); !errors.Is(err, internal.ErrNoReconciliationRun) {
+		t.Errorf("expected no reconciliation run yet, got: %v", err)
+	}
+
+	if _, err := svc.Deposit("acc-1", money.Pounds(500)); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if _, err := svc.Buy("acc-1", "fund-1", money.Pounds(400)); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if _, err := dealingSvc.Run(week); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	run, err := reconciliation.Run(week.Add(time.Hour))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(run.Breaks) != 0 || len(breaksPublished) != 0 {
+		t.Fatalf("expected everything to reconcile, got %+v", run.Breaks)
+	}
+	if run.Investments != 2 || run.Accounts != 1 || run.Funds != 1 || run.Events == 0 {
+		t.Errorf("unexpected counts: %+v", run)
+	}
+
+	// a purchase whose journal entry and events are lost leaves its cash unreserved in the ledger
+	dropping = true
+	lost, err := svc.Buy("acc-1", "fund-1", money.Pounds(50))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	dropping = false
+	// fund-service loses track of a unit, and an event arrives for an investment that does not exist
+	inIssue["fund-1"] -= 1
+	reconciliationRepo.AddStatusEvent(model.InvestmentStatusChanged{
+		InvestmentId:           "inv-unknown",
+		InvestmentStatusChange: model.InvestmentStatusChange{Status: model.InvestmentSettled, At: time.Now().Add(-time.Hour)},
+	}, time.Now().AddDate(0, 0, -14))
+	// anything changed within the last few minutes is still settling and left for the next run
+	if run, err := reconciliation.Run(time.Now()); err != nil || len(run.Breaks) != 1 || run.Breaks[0].Type != model.BreakEventUnexpected {
+		t.Fatalf("expected only the unexpected event while the purchase settles, got %+v, %v", run, err)
+	}
+
+	run, err = reconciliation.Run(week.Add(time.Hour))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	found := make(map[model.BreakType]int)
+	for _, b := range run.Breaks {
+		found[b.Type]++
+		switch b.Type {
+		case model.BreakLedgerMissing, model.BreakEventMissing:
+			if b.Records[0] != lost.Id || !b.Amount.Equal(money.Pounds(50)) {
+				t.Errorf("expected the lost purchase of 50.00, got %+v", b)
+			}
+		case model.BreakCashMismatch:
+			if b.Records[0] != "acc-1" || !b.Amount.Equal(money.Pounds(-50)) {
+				t.Errorf("expected acc-1 to have 50.00 less reserved than its purchase waits on, got %+v", b)
+			}
+		case model.BreakUnitsMismatch:
+			if b.Records[0] != "fund-1" || b.Units != 1 {
+				t.Errorf("expected fund-1 to be a unit short in fund-service, got %+v", b)
+			}
+		}
+	}
+	expected := map[model.BreakType]int{
+		model.BreakLedgerMissing:   1,
+		model.BreakCashMismatch:    1,
+		model.BreakUnitsMismatch:   1,
+		model.BreakEventMissing:    len(lost.History),
+		model.BreakEventUnexpected: 1,
+	}
+	if diff := cmp.Diff(expected, found); diff != "" {
+		t.Errorf("unexpected breaks (-want +got):\n%s", diff)
+	}
+	if len(breaksPublished) != 2 || breaksPublished[1].Id != run.Id {
+		t.Errorf("expected reconciliation.break published for each run with breaks, got %d", len(breaksPublished))
+	}
+	latest, err := reconciliation.GetLatestRun()
+	if err != nil || latest.Id != run.Id {
+		t.Errorf("expected the latest run to be %s, got %+v, %v", run.Id, latest, err)
+	}
+
+	// fund-service being down fails the units check without stopping the rest
+	funds.getUnitsInIssue = func() (*[]model.FundUnits, error) {
+		return nil, errors.New("fund-service unavailable")
+	}
+	run, err = reconciliation.Run(week.Add(2 * time.Hour))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if diff := cmp.Diff([]model.ReconciliationCheck{model.CheckUnits}, run.Failed); diff != "" {
+		t.Errorf("expected the units check to fail (-want +got):\n%s", diff)
+	}
+	found = make(map[model.BreakType]int)
+	for _, b := range run.Breaks {
+		found[b.Type]++
+	}
+	delete(expected, model.BreakUnitsMismatch)
+	if diff := cmp.Diff(expected, found); diff != "" {
+		t.Errorf("expected every other break still reported (-want +got):\n%s", diff)
+	}
+
+	// event breaks are only reported for a fortnight
+	run, err = reconciliation.Run(time.Now().AddDate(0, 0, 15))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	for _, b := range run.Breaks {
+		if b.Type == model.BreakEventMissing || b.Type == model.BreakEventUnexpected {
+			t.Errorf("expected old event breaks to be dropped, got %+v", b)
+		}
+	}
+}
@@ -0,0 +1,80 @@
+package service
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/oliknight1/retail-isa-investment/investment-service/event"
+	"github.com/oliknight1/retail-isa-investment/investment-service/logger"
+	"github.com/oliknight1/retail-isa-investment/investment-service/model"
+	"github.com/oliknight1/retail-isa-investment/investment-service/repository"
+	"github.com/oliknight1/retail-isa-investment/investment-service/taxyear"
+	"go.uber.org/zap"
+)
+
+// RolloverService closes each tax year once it has ended, resetting the allowance counters
+// and announcing the close on isa.taxyear.closed
+type RolloverService struct {
+	allowances repository.AllowanceRepository
+	publisher  event.EventHandler
+	Logger     logger.Logger
+	current    taxyear.TaxYear
+	mu         sync.Mutex
+}
+
+func NewRolloverService(allowances repository.AllowanceRepository, publisher event.EventHandler, logger logger.Logger, now time.Time) *RolloverService {
+	return &RolloverService{
+		allowances: allowances,
+		publisher:  publisher,
+		Logger:     logger,
+		current:    taxyear.For(now),
+	}
+}
+
+// Run closes every open tax year that ended before now. Running it again within the same
+// tax year does nothing
+func (s *RolloverService) Run(now time.Time) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for s.current < taxyear.For(now) {
+		closing := s.current
+		usage, err := s.allowances.CloseTaxYear(closing)
+		if err != nil {
+			s.Logger.Error("error closing tax year", zap.Stringer("tax_year", closing), zap.Error(err))
+			return err
+		}
+
+		closed := model.TaxYearClosed{
+			TaxYear:     closing,
+			ClosedAt:    now,
+			Subscribers: len(usage),
+		}
+		for _, u := range usage {
+			closed.TotalSubscribed = closed.TotalSubscribed.Add(u.Subscribed)
+		}
+		if err := s.publisher.Publish("isa.taxyear.closed", closed); err != nil {
+			s.Logger.Error("error publishing isa.taxyear.closed event", zap.Error(err))
+		}
+
+		s.Logger.Info("tax year closed", zap.Stringer("tax_year", closing))
+		s.current = closing.Next()
+	}
+	return nil
+}
+
+// Start runs the rollover every interval until ctx is cancelled
+func (s *RolloverService) Start(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case now := <-ticker.C:
+			s.Run(now)
+		}
+	}
+}
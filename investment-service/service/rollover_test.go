@@ -0,0 +1,73 @@
+package service_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/oliknight1/retail-isa-investment/investment-service/logger"
+	"github.com/oliknight1/retail-isa-investment/investment-service/model"
+	"github.com/oliknight1/retail-isa-investment/investment-service/money"
+	"github.com/oliknight1/retail-isa-investment/investment-service/repository"
+	"github.com/oliknight1/retail-isa-investment/investment-service/service"
+	"github.com/oliknight1/retail-isa-investment/investment-service/taxyear"
+)
+
+func TestRolloverClosesEndedTaxYear(t *testing.T) {
+	repo := repository.NewAllowanceClient()
+	logger := logger.NewMockLogger()
+	allowance := service.NewAllowanceService(repo, taxyear.DefaultLimits(), logger)
+	if _, err := allowance.Subscribe("cust-1", 2024, stocksAndShares(t), money.Pounds(15000)); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if _, err := allowance.Subscribe("cust-2", 2024, stocksAndShares(t), money.Pounds(5000)); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	var published []model.TaxYearClosed
+	mockPub := &mockPublisher{
+		publishFn: func(subject string, payload any) error {
+			if subject != "isa.taxyear.closed" {
+				t.Errorf("unexpected subject: %s", subject)
+			}
+			published = append(published, payload.(model.TaxYearClosed))
+			return nil
+		},
+	}
+
+	lastDay := taxyear.TaxYear(2024).End().Add(-time.Second)
+	svc := service.NewRolloverService(repo, mockPub, logger, lastDay)
+
+	if err := svc.Run(lastDay); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(published) != 0 {
+		t.Fatalf("expected no close before the year ends, got %d events", len(published))
+	}
+
+	firstDay := taxyear.TaxYear(2025).Start()
+	if err := svc.Run(firstDay); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := svc.Run(firstDay.Add(time.Hour)); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if len(published) != 1 {
+		t.Fatalf("expected 1 close event, got %d", len(published))
+	}
+	closed := published[0]
+	if closed.TaxYear != 2024 || closed.Subscribers != 2 || !closed.TotalSubscribed.Equal(money.Pounds(20000)) {
+		t.Errorf("unexpected close event: %+v", closed)
+	}
+
+	usage, err := repo.GetUsage("cust-1", 2024)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !usage.Subscribed.Equal(money.Pounds(0)) {
+		t.Errorf("expected counter to be reset, got %s", usage.Subscribed)
+	}
+	if _, err := allowance.Subscribe("cust-1", 2024, stocksAndShares(t), money.Pounds(100)); err == nil {
+		t.Error("expected subscribing to a closed tax year to fail")
+	}
+}
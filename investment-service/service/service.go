@@ -1,71 +1,163 @@
 package service
 
 import (
-	"log"
+	"errors"
+	"fmt"
 	"time"
 
 	"github.com/google/uuid"
+	"github.com/oliknight1/retail-isa-investment/investment-service/client"
 	"github.com/oliknight1/retail-isa-investment/investment-service/event"
 	"github.com/oliknight1/retail-isa-investment/investment-service/internal"
 	"github.com/oliknight1/retail-isa-investment/investment-service/logger"
 	"github.com/oliknight1/retail-isa-investment/investment-service/model"
+	"github.com/oliknight1/retail-isa-investment/investment-service/money"
+	"github.com/oliknight1/retail-isa-investment/investment-service/product"
 	"github.com/oliknight1/retail-isa-investment/investment-service/repository"
+	"github.com/oliknight1/retail-isa-investment/investment-service/taxyear"
 	"go.uber.org/zap"
 )
 
 type InvestmentService interface {
-	CreateInvestment(customerId string, fundId string, amount float64) (*model.Investment, error)
+	CreateInvestment(accountId string, fundId string, amount money.Money) (*model.Investment, error)
 	GetInvestmentById(string) (*model.Investment, error)
 	GetInvestmentsByCustomerId(string) (*[]model.Investment, error)
+	Deposit(accountId string, amount money.Money) (*model.Investment, error)
+	Buy(accountId string, fundId string, amount money.Money) (*model.Investment, error)
+	Withdraw(accountId string, amount money.Money, reason model.WithdrawalReason) (*model.Investment, error)
+	Redeem(accountId string, fundId string, order model.RedemptionOrder) (*model.Investment, error)
+	CancelInvestment(id string) (*model.Investment, error)
+	UpdateInvestmentStatus(id string, status model.InvestmentStatus, reason, actor string) (*model.Investment, error)
 }
 
 type InvestmentServiceImpl struct {
 	repo      repository.Repository
+	accounts  repository.AccountRepository
+	customers client.CustomerClient
+	funds     client.FundClient
+	allowance AllowanceService
+	bonuses   BonusService
+	ledger    LedgerService
+	locks     *Locks
 	publisher event.EventHandler
 	Logger    logger.Logger
 }
 
-func New(repo repository.Repository, publisher event.EventHandler, logger logger.Logger) *InvestmentServiceImpl {
+func New(
+	repo repository.Repository,
+	accounts repository.AccountRepository,
+	customers client.CustomerClient,
+	funds client.FundClient,
+	allowance AllowanceService,
+	bonuses BonusService,
+	ledger LedgerService,
+	locks *Locks,
+	publisher event.EventHandler,
+	logger logger.Logger,
+) *InvestmentServiceImpl {
 	return &InvestmentServiceImpl{
 		repo,
+		accounts,
+		customers,
+		funds,
+		allowance,
+		bonuses,
+		ledger,
+		locks,
 		publisher,
 		logger,
 	}
 }
 
-func (s *InvestmentServiceImpl) CreateInvestment(customerId string, fundId string, amount float64) (*model.Investment, error) {
-	if customerId == "" {
-		s.Logger.Error("missing customer_id in creation request", internal.ErrMissingCustomerId)
-		return nil, internal.ErrMissingCustomerId
+func (s *InvestmentServiceImpl) CreateInvestment(accountId string, fundId string, amount money.Money) (*model.Investment, error) {
+	if accountId == "" {
+		s.Logger.Error("missing account_id in creation request", zap.Error(internal.ErrMissingAccountId))
+		return nil, internal.ErrMissingAccountId
 	}
 	if fundId == "" {
 		s.Logger.Error("missing fund_id in creation request", internal.ErrMissingFundId)
 		return nil, internal.ErrMissingFundId
 	}
-	if amount <= 0 {
+	if !amount.IsPositive() {
 		s.Logger.Error("invalid transaction amount in creation request", internal.ErrZeroTransactionAmount)
 		return nil, internal.ErrZeroTransactionAmount
 	}
+
+	account, err := s.accounts.GetAccountById(accountId)
+	if err != nil {
+		s.Logger.Error("error fetching account for investment", zap.Error(err))
+		return nil, err
+	}
+	if account.Status != model.AccountOpen {
+		s.Logger.Error("investment into closed account", zap.String("account_id", accountId))
+		return nil, internal.ErrAccountClosed
+	}
+	rules, err := product.For(account.ProductType)
+	if err != nil {
+		s.Logger.Error("account has unknown product type", zap.Error(err))
+		return nil, err
+	}
+	if !rules.FundEligible(fundId) {
+		s.Logger.Error("fund not eligible for account", zap.String("fund_id", fundId), zap.String("product_type", string(account.ProductType)))
+		return nil, internal.ErrFundNotEligible
+	}
+
+	now := time.Now()
+	taxYear := taxyear.For(now)
+	use, err := s.subscribe(*account, rules, amount, now)
+	if err != nil {
+		return nil, err
+	}
+
 	investment := model.Investment{
-		Id:         uuid.New().String(),
-		CustomerId: customerId,
-		FundId:     fundId,
-		Amount:     amount,
-		Status:     "pending",
-		CreatedAt:  time.Now(),
+		Id:           uuid.New().String(),
+		AccountId:    accountId,
+		CustomerId:   account.CustomerId,
+		FundId:       fundId,
+		Type:         model.Subscription,
+		Amount:       amount,
+		TaxYear:      taxYear,
+		AllowanceUse: use,
+		CreatedAt:    now,
+	}
+	if err := errors.Join(
+		transition(&investment, model.InvestmentPending, "order received", model.ActorCustomer, now),
+		transition(&investment, model.InvestmentValidated, "eligibility and allowance checks passed", model.ActorSystem, now),
+	); err != nil {
+		if releaseErr := s.allowance.Release(account.CustomerId, taxYear, rules, use); releaseErr != nil {
+			s.Logger.Error("error releasing allowance after failed investment", zap.Error(releaseErr))
+		}
+		return nil, err
+	}
+	if err := recordStatusChanges(s.ledger, investment, 0); err != nil {
+		s.Logger.Error("error posting investment to ledger", zap.Error(err))
+		if releaseErr := s.allowance.Release(account.CustomerId, taxYear, rules, use); releaseErr != nil {
+			s.Logger.Error("error releasing allowance after failed investment", zap.Error(releaseErr))
+		}
+		return nil, err
 	}
 	if err := s.repo.CreateInvestment(investment); err != nil {
+		unrecord(s.ledger, s.Logger, investment)
+		if releaseErr := s.allowance.Release(account.CustomerId, taxYear, rules, use); releaseErr != nil {
+			s.Logger.Error("error releasing allowance after failed investment", zap.Error(releaseErr))
+		}
 		return nil, err
 	}
 
+	if rules.BonusRate > 0 {
+		if _, err := s.bonuses.RecordClaim(investment, rules); err != nil {
+			s.Logger.Error("error recording bonus claim for investment", zap.String("investment_id", investment.Id), zap.Error(err))
+		}
+	}
+
 	s.publisher.Publish("investment.created", investment)
+	publishStatusChanges(s.publisher, s.Logger, investment, 0)
 
 	if err := s.publisher.Publish("investment.processed", investment); err != nil {
 		s.Logger.Error("error publishing investment.processed event", zap.Error(err))
 	}
 
 	if err := s.publisher.Publish("investment.validation.pending", investment); err != nil {
-		log.Println("error publishing investment.validation.pending event: %v", err)
 		s.Logger.Error("error publishing investment.pending event", zap.Error(err))
 	}
 	internal.InvestmentValidationEvents.Inc()
@@ -73,6 +165,540 @@ func (s *InvestmentServiceImpl) CreateInvestment(customerId string, fundId strin
 	return &investment, nil
 }
 
+// Deposit pays amount into an account as cash. It is checked and uses allowance like a
+// subscription, but is settled straight away as there is nothing to deal
+func (s *InvestmentServiceImpl) Deposit(accountId string, amount money.Money) (*model.Investment, error) {
+	if accountId == "" {
+		s.Logger.Error("missing account_id in deposit request", zap.Error(internal.ErrMissingAccountId))
+		return nil, internal.ErrMissingAccountId
+	}
+	if !amount.IsPositive() {
+		s.Logger.Error("invalid transaction amount in deposit request", zap.Error(internal.ErrZeroTransactionAmount))
+		return nil, internal.ErrZeroTransactionAmount
+	}
+
+	account, err := s.accounts.GetAccountById(accountId)
+	if err != nil {
+		s.Logger.Error("error fetching account for deposit", zap.Error(err))
+		return nil, err
+	}
+	if account.Status != model.AccountOpen {
+		s.Logger.Error("deposit into closed account", zap.String("account_id", accountId))
+		return nil, internal.ErrAccountClosed
+	}
+	rules, err := product.For(account.ProductType)
+	if err != nil {
+		s.Logger.Error("account has unknown product type", zap.Error(err))
+		return nil, err
+	}
+
+	now := time.Now()
+	taxYear := taxyear.For(now)
+	use, err := s.subscribe(*account, rules, amount, now)
+	if err != nil {
+		return nil, err
+	}
+
+	deposit := model.Investment{
+		Id:           uuid.New().String(),
+		AccountId:    accountId,
+		CustomerId:   account.CustomerId,
+		Type:         model.Deposit,
+		Amount:       amount,
+		TaxYear:      taxYear,
+		AllowanceUse: use,
+		CreatedAt:    now,
+	}
+	if err := errors.Join(
+		transition(&deposit, model.InvestmentPending, "deposit received", model.ActorCustomer, now),
+		transition(&deposit, model.InvestmentValidated, "eligibility and allowance checks passed", model.ActorSystem, now),
+		transition(&deposit, model.InvestmentSettled, "cash credited to the account", model.ActorSystem, now),
+	); err != nil {
+		if releaseErr := s.allowance.Release(account.CustomerId, taxYear, rules, use); releaseErr != nil {
+			s.Logger.Error("error releasing allowance after failed deposit", zap.Error(releaseErr))
+		}
+		return nil, err
+	}
+	// credit the cash before saving the settled deposit, and undo both if either fails
+	if err := recordStatusChanges(s.ledger, deposit, 0); err != nil {
+		s.Logger.Error("error crediting deposit to account", zap.String("investment_id", deposit.Id), zap.Error(err))
+		if releaseErr := s.allowance.Release(account.CustomerId, taxYear, rules, use); releaseErr != nil {
+			s.Logger.Error("error releasing allowance after failed deposit", zap.Error(releaseErr))
+		}
+		return nil, err
+	}
+	if err := s.repo.CreateInvestment(deposit); err != nil {
+		s.Logger.Error("error saving deposit", zap.Error(err))
+		unrecord(s.ledger, s.Logger, deposit)
+		if releaseErr := s.allowance.Release(account.CustomerId, taxYear, rules, use); releaseErr != nil {
+			s.Logger.Error("error releasing allowance after failed deposit", zap.Error(releaseErr))
+		}
+		return nil, err
+	}
+
+	if rules.BonusRate > 0 {
+		if _, err := s.bonuses.RecordClaim(deposit, rules); err != nil {
+			s.Logger.Error("error recording bonus claim for deposit", zap.String("investment_id", deposit.Id), zap.Error(err))
+		}
+	}
+
+	if err := s.publisher.Publish("investment.deposit.created", deposit); err != nil {
+		s.Logger.Error("error publishing investment.deposit.created event", zap.Error(err))
+	}
+	publishStatusChanges(s.publisher, s.Logger, deposit, 0)
+
+	return &deposit, nil
+}
+
+// Buy places an order to buy units of a fund with cash already in an account. The amount is
+// reserved from the account's available cash until the order is dealt at the fund's next
+// valuation point, so the same cash cannot be spent twice
+func (s *InvestmentServiceImpl) Buy(accountId string, fundId string, amount money.Money) (*model.Investment, error) {
+	if accountId == "" {
+		s.Logger.Error("missing account_id in purchase request", zap.Error(internal.ErrMissingAccountId))
+		return nil, internal.ErrMissingAccountId
+	}
+	if fundId == "" {
+		s.Logger.Error("missing fund_id in purchase request", zap.Error(internal.ErrMissingFundId))
+		return nil, internal.ErrMissingFundId
+	}
+	if !amount.IsPositive() {
+		s.Logger.Error("invalid transaction amount in purchase request", zap.Error(internal.ErrZeroTransactionAmount))
+		return nil, internal.ErrZeroTransactionAmount
+	}
+
+	account, err := s.accounts.GetAccountById(accountId)
+	if err != nil {
+		s.Logger.Error("error fetching account for purchase", zap.Error(err))
+		return nil, err
+	}
+	if account.Status != model.AccountOpen {
+		s.Logger.Error("purchase in closed account", zap.String("account_id", accountId))
+		return nil, internal.ErrAccountClosed
+	}
+	rules, err := product.For(account.ProductType)
+	if err != nil {
+		s.Logger.Error("account has unknown product type", zap.Error(err))
+		return nil, err
+	}
+	if !rules.FundEligible(fundId) {
+		s.Logger.Error("fund not eligible for account", zap.String("fund_id", fundId), zap.String("product_type", string(account.ProductType)))
+		return nil, internal.ErrFundNotEligible
+	}
+
+	now := time.Now()
+	purchase := model.Investment{
+		Id:         uuid.New().String(),
+		AccountId:  accountId,
+		CustomerId: account.CustomerId,
+		FundId:     fundId,
+		Type:       model.Purchase,
+		Amount:     amount,
+		TaxYear:    taxyear.For(now),
+		CreatedAt:  now,
+	}
+	if err := errors.Join(
+		transition(&purchase, model.InvestmentPending, "order received", model.ActorCustomer, now),
+		transition(&purchase, model.InvestmentValidated, "cash reserved", model.ActorSystem, now),
+	); err != nil {
+		return nil, err
+	}
+	if err := recordStatusChanges(s.ledger, purchase, 0); err != nil {
+		s.Logger.Error("purchase rejected by cash check", zap.String("account_id", accountId), zap.Error(err))
+		return nil, err
+	}
+	if err := s.repo.CreateInvestment(purchase); err != nil {
+		s.Logger.Error("error saving purchase", zap.Error(err))
+		unrecord(s.ledger, s.Logger, purchase)
+		return nil, err
+	}
+
+	if err := s.publisher.Publish("investment.purchase.requested", purchase); err != nil {
+		s.Logger.Error("error publishing investment.purchase.requested event", zap.Error(err))
+	}
+	publishStatusChanges(s.publisher, s.Logger, purchase, 0)
+
+	return &purchase, nil
+}
+
+// subscribe checks the customer can pay amount into the account and uses the allowance for it
+func (s *InvestmentServiceImpl) subscribe(account model.Account, rules product.Rules, amount money.Money, now time.Time) (model.AllowanceUse, error) {
+	customerId := account.CustomerId
+	eligibility, err := s.customers.GetEligibility(customerId, account.ProductType)
+	if err != nil {
+		s.Logger.Error("error checking customer eligibility for investment", zap.Error(err))
+		return model.AllowanceUse{}, err
+	}
+	if !eligibility.Eligible {
+		s.Logger.Error("customer not eligible for product", zap.String("customer_id", customerId), zap.Strings("reasons", eligibility.Reasons))
+		return model.AllowanceUse{}, internal.CustomerIneligibleError(eligibility.Reasons)
+	}
+
+	if rules.MaxSubscriptionAge > 0 {
+		customer, err := s.customers.GetCustomerById(customerId)
+		if err != nil {
+			s.Logger.Error("error fetching customer for investment", zap.Error(err))
+			return model.AllowanceUse{}, err
+		}
+		if err := rules.CheckSubscriptionAge(customer.DateOfBirth.AgeOn(now)); err != nil {
+			s.Logger.Error("customer not eligible to subscribe", zap.Error(err))
+			return model.AllowanceUse{}, err
+		}
+	}
+
+	use, err := s.allowance.Subscribe(customerId, taxyear.For(now), rules, amount)
+	if err != nil {
+		s.Logger.Error("investment rejected by allowance check", zap.Error(err))
+		return model.AllowanceUse{}, err
+	}
+	return use, nil
+}
+
+// Withdraw takes amount out of an account's cash. The amount is reserved from the available
+// cash until the withdrawal is settled, so it cannot also be spent or withdrawn again. Once it
+// settles the gross amount is recorded against the tax year so it can be replaced without using
+// allowance if the ISA is flexible. Any withdrawal charge for the product is deducted from what is
+// paid out
+func (s *InvestmentServiceImpl) Withdraw(accountId string, amount money.Money, reason model.WithdrawalReason) (*model.Investment, error) {
+	if accountId == "" {
+		s.Logger.Error("missing account_id in withdrawal request", zap.Error(internal.ErrMissingAccountId))
+		return nil, internal.ErrMissingAccountId
+	}
+	if !amount.IsPositive() {
+		s.Logger.Error("invalid transaction amount in withdrawal request", zap.Error(internal.ErrZeroTransactionAmount))
+		return nil, internal.ErrZeroTransactionAmount
+	}
+	if reason == "" {
+		reason = model.WithdrawalOther
+	}
+	if !reason.Valid() {
+		s.Logger.Error("invalid reason in withdrawal request", zap.String("reason", string(reason)))
+		return nil, internal.ErrInvalidReason
+	}
+
+	account, err := s.accounts.GetAccountById(accountId)
+	if err != nil {
+		s.Logger.Error("error fetching account for withdrawal", zap.Error(err))
+		return nil, err
+	}
+	if account.Status != model.AccountOpen {
+		s.Logger.Error("withdrawal from closed account", zap.String("account_id", accountId))
+		return nil, internal.ErrAccountClosed
+	}
+	rules, err := product.For(account.ProductType)
+	if err != nil {
+		s.Logger.Error("account has unknown product type", zap.Error(err))
+		return nil, err
+	}
+	if !rules.WithdrawalsAllowed {
+		s.Logger.Error("withdrawal from product that does not allow them", zap.String("product_type", string(account.ProductType)))
+		return nil, internal.ErrWithdrawalNotAllowed
+	}
+
+	customerId := account.CustomerId
+	now := time.Now()
+	if reason == model.WithdrawalAgeSixty {
+		customer, err := s.customers.GetCustomerById(customerId)
+		if err != nil {
+			s.Logger.Error("error fetching customer for withdrawal", zap.Error(err))
+			return nil, err
+		}
+		if customer.DateOfBirth.AgeOn(now) < 60 {
+			s.Logger.Error("age_60 withdrawal by customer under 60", zap.String("customer_id", customerId))
+			return nil, fmt.Errorf("%w: must be 60 or over to withdraw for reason %s", internal.ErrIneligibleAge, reason)
+		}
+	}
+
+	taxYear := taxyear.For(now)
+	charge := rules.WithdrawalCharge(amount, reason)
+	withdrawal := model.Investment{
+		Id:         uuid.New().String(),
+		AccountId:  accountId,
+		CustomerId: customerId,
+		Type:       model.Withdrawal,
+		Amount:     amount,
+		TaxYear:    taxYear,
+		Withdrawal: &model.WithdrawalDetails{
+			Reason:    reason,
+			Charge:    charge,
+			NetAmount: amount.Sub(charge),
+		},
+		CreatedAt: now,
+	}
+	if err := errors.Join(
+		transition(&withdrawal, model.InvestmentPending, "withdrawal requested", model.ActorCustomer, now),
+		transition(&withdrawal, model.InvestmentValidated, "cash reserved", model.ActorSystem, now),
+	); err != nil {
+		return nil, err
+	}
+	if err := recordStatusChanges(s.ledger, withdrawal, 0); err != nil {
+		s.Logger.Error("withdrawal rejected by cash check", zap.String("account_id", accountId), zap.Error(err))
+		return nil, err
+	}
+	if err := s.repo.CreateInvestment(withdrawal); err != nil {
+		s.Logger.Error("error saving withdrawal", zap.Error(err))
+		unrecord(s.ledger, s.Logger, withdrawal)
+		return nil, err
+	}
+	if err := s.publisher.Publish("investment.withdrawal.created", withdrawal); err != nil {
+		s.Logger.Error("error publishing investment.withdrawal.created event", zap.Error(err))
+	}
+	publishStatusChanges(s.publisher, s.Logger, withdrawal, 0)
+
+	return &withdrawal, nil
+}
+
+// Redeem places an order to sell units of a fund held in an account. The order is checked
+// against the units held less any already set aside for other sell orders, and is priced at
+// the fund's next valuation point by the dealing run
+func (s *InvestmentServiceImpl) Redeem(accountId string, fundId string, order model.RedemptionOrder) (*model.Investment, error) {
+	if accountId == "" {
+		s.Logger.Error("missing account_id in redemption request", zap.Error(internal.ErrMissingAccountId))
+		return nil, internal.ErrMissingAccountId
+	}
+	if fundId == "" {
+		s.Logger.Error("missing fund_id in redemption request", zap.Error(internal.ErrMissingFundId))
+		return nil, internal.ErrMissingFundId
+	}
+
+	account, err := s.accounts.GetAccountById(accountId)
+	if err != nil {
+		s.Logger.Error("error fetching account for redemption", zap.Error(err))
+		return nil, err
+	}
+	if account.Status != model.AccountOpen {
+		s.Logger.Error("redemption from closed account", zap.String("account_id", accountId))
+		return nil, internal.ErrAccountClosed
+	}
+
+	unlock := s.locks.accounts.Lock(account.Id)
+	defer unlock()
+	redemption, err := newSale(s.repo, s.funds, *account, fundId, order, model.Redemption, time.Now())
+	if err != nil {
+		s.Logger.Error("redemption rejected", zap.String("account_id", accountId), zap.String("fund_id", fundId), zap.Error(err))
+		return nil, err
+	}
+	if err := recordStatusChanges(s.ledger, *redemption, 0); err != nil {
+		s.Logger.Error("error posting redemption to ledger", zap.Error(err))
+		return nil, err
+	}
+	if err := s.repo.CreateInvestment(*redemption); err != nil {
+		s.Logger.Error("error saving redemption", zap.Error(err))
+		unrecord(s.ledger, s.Logger, *redemption)
+		return nil, err
+	}
+
+	if err := s.publisher.Publish("investment.redemption.requested", redemption); err != nil {
+		s.Logger.Error("error publishing investment.redemption.requested event", zap.Error(err))
+	}
+	publishStatusChanges(s.publisher, s.Logger, *redemption, 0)
+
+	return redemption, nil
+}
+
+// CoolingOffPeriod is how long after subscribing a customer has the right to cancel
+const CoolingOffPeriod = 30 * 24 * time.Hour
+
+// CancelInvestment reverses a subscription made within the cooling-off period, giving back
+// the allowance it used and recording the refund due to the customer. Once units have been
+// bought any fall in their value is kept back from the refund, and the subscription can only be
+// cancelled while the account still holds all of its units free of other sell orders. A
+// subscription cannot be cancelled once the tax year it was made in has ended
+func (s *InvestmentServiceImpl) CancelInvestment(id string) (*model.Investment, error) {
+	unlock := s.locks.investments.Lock(id)
+	defer unlock()
+	investment, err := s.repo.GetInvestmentById(id)
+	if err != nil {
+		s.Logger.Error("error fetching investment to cancel", zap.Error(err))
+		return nil, err
+	}
+	if investment.Type != model.Subscription || !investment.Status.CanTransition(model.InvestmentCancelled) {
+		s.Logger.Error("investment cannot be cancelled", zap.String("investment_id", id), zap.String("status", string(investment.Status)))
+		return nil, fmt.Errorf("%w: %s %s", internal.ErrCannotCancel, investment.Status, investment.Type)
+	}
+	now := time.Now()
+	if now.Sub(investment.CreatedAt) > CoolingOffPeriod {
+		s.Logger.Error("cancellation after cooling-off period", zap.String("investment_id", id))
+		return nil, internal.ErrCoolingOffExpired
+	}
+	if yearEnded(*investment, now) {
+		s.Logger.Error("cancellation after tax year end", zap.String("investment_id", id), zap.Stringer("tax_year", investment.TaxYear))
+		return nil, fmt.Errorf("%w: subscribed in tax year %s, which has ended", internal.ErrCannotCancel, investment.TaxYear)
+	}
+
+	account, err := s.accounts.GetAccountById(investment.AccountId)
+	if err != nil {
+		s.Logger.Error("error fetching account for cancellation", zap.Error(err))
+		return nil, err
+	}
+	rules, err := product.For(account.ProductType)
+	if err != nil {
+		s.Logger.Error("account has unknown product type", zap.Error(err))
+		return nil, err
+	}
+
+	cancellation := &model.Cancellation{
+		RefundAmount: investment.Amount,
+		CancelledAt:  now,
+	}
+	if investment.Dealing != nil {
+		unlockAccount := s.locks.accounts.Lock(account.Id)
+		defer unlockAccount()
+		held, err := heldUnits(s.repo, account.Id, investment.FundId)
+		if err != nil {
+			s.Logger.Error("error fetching holding for cancellation", zap.Error(err))
+			return nil, err
+		}
+		reserved, err := reservedUnits(s.repo, account.Id, investment.FundId)
+		if err != nil {
+			s.Logger.Error("error fetching holding for cancellation", zap.Error(err))
+			return nil, err
+		}
+		if held-reserved < investment.Dealing.Units {
+			s.Logger.Error("cancellation of units already sold", zap.String("investment_id", id))
+			return nil, fmt.Errorf("%w: only %s of its %s units are still held", internal.ErrCannotCancel, max(held-reserved, 0), investment.Dealing.Units)
+		}
+		price, err := s.funds.GetLatestPrice(investment.FundId)
+		if err != nil {
+			s.Logger.Error("error fetching fund price for cancellation", zap.Error(err))
+			return nil, err
+		}
+		value := investment.Dealing.Units.Value(price.SellPrice()).Add(investment.Dealing.Residual)
+		cancellation.MarketLoss = money.Max(investment.Amount.Sub(value), money.Money{})
+		cancellation.RefundAmount = investment.Amount.Sub(cancellation.MarketLoss)
+	}
+	since := len(investment.History)
+	if err := transition(investment, model.InvestmentCancelled, "cancelled in cooling-off period", model.ActorCustomer, now); err != nil {
+		s.Logger.Error("invalid investment status change", zap.String("from", string(investment.Status)), zap.String("to", string(model.InvestmentCancelled)))
+		return nil, err
+	}
+	investment.Cancellation = cancellation
+	if err := recordStatusChanges(s.ledger, *investment, since); err != nil {
+		s.Logger.Error("error posting cancellation to ledger", zap.Error(err))
+		return nil, err
+	}
+	if err := s.repo.UpdateInvestment(*investment); err != nil {
+		s.Logger.Error("error saving cancelled investment", zap.Error(err))
+		return nil, err
+	}
+	s.reverseSubscription(*investment, rules)
+
+	if err := s.publisher.Publish("investment.cancelled", investment); err != nil {
+		s.Logger.Error("error publishing investment.cancelled event", zap.Error(err))
+	}
+	publishStatusChanges(s.publisher, s.Logger, *investment, since)
+
+	return investment, nil
+}
+
+// UpdateInvestmentStatus lets operations settle an investment once the money has changed hands,
+// or fail it with a reason. Purchases and sales can only be settled once they have been dealt.
+// The change is posted to the ledger before it is saved and refused if that fails, so settling a
+// withdrawal pays it out of the cash it reserved, and failing an investment reverses everything
+// it posted, freeing a reservation or taking back a dealt redemption's proceeds. Failing a
+// subscription also gives back the allowance it used, so it is refused once the tax year it was
+// made in has ended, and settling a withdrawal records it against the tax year so a flexible ISA
+// can replace it. Cancellations go through CancelInvestment so the customer's refund is worked out
+func (s *InvestmentServiceImpl) UpdateInvestmentStatus(id string, status model.InvestmentStatus, reason, actor string) (*model.Investment, error) {
+	if actor == "" {
+		s.Logger.Error("missing actor in investment status change", zap.Error(internal.ErrMissingActor))
+		return nil, internal.ErrMissingActor
+	}
+	unlock := s.locks.investments.Lock(id)
+	defer unlock()
+	investment, err := s.repo.GetInvestmentById(id)
+	if err != nil {
+		s.Logger.Error("error fetching investment to update status", zap.Error(err))
+		return nil, err
+	}
+	if status != model.InvestmentSettled && status != model.InvestmentFailed {
+		s.Logger.Error("investment status cannot be set directly", zap.String("status", string(status)))
+		return nil, fmt.Errorf("%w: %s cannot be set directly", internal.ErrInvalidTransition, status)
+	}
+	if investment.TransferId != nil {
+		s.Logger.Error("changing a transfer investment", zap.String("investment_id", id))
+		return nil, fmt.Errorf("%w: investment is part of transfer %s", internal.ErrInvalidTransition, *investment.TransferId)
+	}
+	if status == model.InvestmentFailed && investment.SwitchId != nil {
+		s.Logger.Error("failing a switch leg", zap.String("investment_id", id))
+		return nil, fmt.Errorf("%w: investment is part of switch %s", internal.ErrInvalidTransition, *investment.SwitchId)
+	}
+	dealable := investment.Type.Buys() || investment.Type.Sells()
+	if status == model.InvestmentSettled && dealable && investment.Dealing == nil {
+		s.Logger.Error("settling investment before it is dealt", zap.String("investment_id", id))
+		return nil, fmt.Errorf("%w: %s has not been dealt", internal.ErrInvalidTransition, investment.Type)
+	}
+	now := time.Now()
+	if status == model.InvestmentFailed && yearEnded(*investment, now) {
+		s.Logger.Error("failing subscription after tax year end", zap.String("investment_id", id), zap.Stringer("tax_year", investment.TaxYear))
+		return nil, fmt.Errorf("%w: subscribed in tax year %s, which has ended", internal.ErrInvalidTransition, investment.TaxYear)
+	}
+
+	since := len(investment.History)
+	if err := transition(investment, status, reason, actor, now); err != nil {
+		s.Logger.Error("invalid investment status change", zap.String("from", string(investment.Status)), zap.String("to", string(status)))
+		return nil, err
+	}
+	if status == model.InvestmentFailed {
+		investment.FailureReason = &reason
+	}
+	// the change is only saved once the money it moves has been posted
+	if err := recordStatusChanges(s.ledger, *investment, since); err != nil {
+		s.Logger.Error("error posting investment status to ledger", zap.String("investment_id", id), zap.Error(err))
+		return nil, err
+	}
+	if err := s.repo.UpdateInvestment(*investment); err != nil {
+		s.Logger.Error("error saving investment status", zap.Error(err))
+		return nil, err
+	}
+	failedSubscription := status == model.InvestmentFailed && investment.Type == model.Subscription
+	settledWithdrawal := status == model.InvestmentSettled && investment.Type == model.Withdrawal
+	if failedSubscription || settledWithdrawal {
+		account, err := s.accounts.GetAccountById(investment.AccountId)
+		if err != nil {
+			s.Logger.Error("error fetching account for investment status change", zap.Error(err))
+			return nil, err
+		}
+		rules, err := product.For(account.ProductType)
+		if err != nil {
+			s.Logger.Error("account has unknown product type", zap.Error(err))
+			return nil, err
+		}
+		if failedSubscription {
+			s.reverseSubscription(*investment, rules)
+		}
+		if settledWithdrawal {
+			settledAt := investment.History[len(investment.History)-1].At
+			if err := s.allowance.Withdraw(investment.CustomerId, taxyear.For(settledAt), rules, investment.Amount); err != nil {
+				s.Logger.Error("error recording withdrawal against allowance", zap.String("investment_id", id), zap.Error(err))
+			}
+		}
+	}
+	publishStatusChanges(s.publisher, s.Logger, *investment, since)
+
+	return investment, nil
+}
+
+// yearEnded reports whether investment is a subscription made in a tax year that has ended. Its
+// allowance is part of that year's closed totals and cannot be given back, so it can no longer be
+// cancelled or failed
+func yearEnded(investment model.Investment, now time.Time) bool {
+	return investment.Type == model.Subscription && investment.TaxYear < taxyear.For(now)
+}
+
+// reverseSubscription gives back the allowance a subscription used and cancels any bonus claim
+// on it that has not been submitted
+func (s *InvestmentServiceImpl) reverseSubscription(investment model.Investment, rules product.Rules) {
+	if err := s.allowance.Release(investment.CustomerId, investment.TaxYear, rules, investment.AllowanceUse); err != nil {
+		s.Logger.Error("error releasing allowance for investment", zap.String("investment_id", investment.Id), zap.Error(err))
+	}
+	if rules.BonusRate > 0 {
+		if err := s.bonuses.CancelClaim(investment.Id); err != nil {
+			s.Logger.Error("error cancelling bonus claim", zap.String("investment_id", investment.Id), zap.Error(err))
+		}
+	}
+}
+
 func (s *InvestmentServiceImpl) GetInvestmentById(id string) (*model.Investment, error) {
 	if id == "" {
 		s.Logger.Error("missing fund_id when requesting investment", zap.Error(internal.ErrMissingFundId))
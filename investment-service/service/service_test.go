@@ -2,25 +2,171 @@ package service_test
 
 import (
 	"errors"
+	"strings"
+	"sync"
 	"testing"
 	"time"
 
 	"github.com/google/go-cmp/cmp"
+	"github.com/google/uuid"
 	"github.com/oliknight1/retail-isa-investment/investment-service/internal"
 	"github.com/oliknight1/retail-isa-investment/investment-service/logger"
 	"github.com/oliknight1/retail-isa-investment/investment-service/model"
+	"github.com/oliknight1/retail-isa-investment/investment-service/money"
+	"github.com/oliknight1/retail-isa-investment/investment-service/product"
+	"github.com/oliknight1/retail-isa-investment/investment-service/repository"
 	"github.com/oliknight1/retail-isa-investment/investment-service/service"
+	"github.com/oliknight1/retail-isa-investment/investment-service/taxyear"
 )
 
+func newAllowanceService(l logger.Logger) service.AllowanceService {
+	return service.NewAllowanceService(repository.NewAllowanceClient(), taxyear.DefaultLimits(), l)
+}
+
+type mockCustomerClient struct {
+	getCustomerById func(id string) (*model.Customer, error)
+	getEligibility  func(id string, productType model.ProductType) (*model.Eligibility, error)
+}
+
+func (m *mockCustomerClient) GetCustomerById(id string) (*model.Customer, error) {
+	return m.getCustomerById(id)
+}
+
+// GetEligibility treats every customer as eligible unless getEligibility is set
+func (m *mockCustomerClient) GetEligibility(id string, productType model.ProductType) (*model.Eligibility, error) {
+	if m.getEligibility == nil {
+		return &model.Eligibility{CustomerId: id, Product: productType, Eligible: true}, nil
+	}
+	return m.getEligibility(id, productType)
+}
+
+// customersAged returns a customer client where every customer is the given age today
+func customersAged(age int) *mockCustomerClient {
+	now := time.Now()
+	return &mockCustomerClient{
+		getCustomerById: func(id string) (*model.Customer, error) {
+			return &model.Customer{
+				Id:          id,
+				Name:        "Oli",
+				DateOfBirth: model.NewDate(now.Year()-age, now.Month(), now.Day()),
+			}, nil
+		},
+	}
+}
+
+type mockFundClient struct {
+	getFund          func(fundId string) (*model.Fund, error)
+	getLatestPrice   func(fundId string) (*model.FundPrice, error)
+	getPrices        func(fundId string, from, to time.Time) (*[]model.FundPrice, error)
+	getDistributions func(paidBy model.Date) (*[]model.Distribution, error)
+	getUnitsInIssue  func() (*[]model.FundUnits, error)
+}
+
+func (m *mockFundClient) GetFund(fundId string) (*model.Fund, error) {
+	return m.getFund(fundId)
+}
+
+func (m *mockFundClient) GetLatestPrice(fundId string) (*model.FundPrice, error) {
+	return m.getLatestPrice(fundId)
+}
+
+func (m *mockFundClient) GetPrices(fundId string, from, to time.Time) (*[]model.FundPrice, error) {
+	return m.getPrices(fundId, from, to)
+}
+
+func (m *mockFundClient) GetDistributions(paidBy model.Date) (*[]model.Distribution, error) {
+	return m.getDistributions(paidBy)
+}
+
+func (m *mockFundClient) GetUnitsInIssue() (*[]model.FundUnits, error) {
+	return m.getUnitsInIssue()
+}
+
+// fundPriced returns a fund client where every fund is single priced at nav, with a price at
+// every valuation point asked for
+func fundPriced(nav string) *mockFundClient {
+	return &mockFundClient{
+		getLatestPrice: func(fundId string) (*model.FundPrice, error) {
+			price, err := model.ParseUnitPrice(nav)
+			if err != nil {
+				return nil, err
+			}
+			return &model.FundPrice{FundId: fundId, Nav: price, ValuedAt: time.Now().Truncate(time.Hour)}, nil
+		},
+		getPrices: func(fundId string, from, to time.Time) (*[]model.FundPrice, error) {
+			price, err := model.ParseUnitPrice(nav)
+			if err != nil {
+				return nil, err
+			}
+			return &[]model.FundPrice{{FundId: fundId, Nav: price, ValuedAt: from}}, nil
+		},
+	}
+}
+
+func newBonusService(l logger.Logger) service.BonusService {
+	return service.NewBonusService(repository.NewBonusClient(), l)
+}
+
+func stocksAndShares(t *testing.T) product.Rules {
+	rules, err := product.For(model.StocksAndSharesISA)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	return rules
+}
+
+func newAccountRepo() *repository.AccountClient {
+	db := repository.NewAccountClient()
+	closedAt := time.Now()
+	accounts := []model.Account{
+		{Id: "acc-1", CustomerId: "cust-1", ProductType: model.StocksAndSharesISA, Status: model.AccountOpen},
+		{Id: "acc-cash", CustomerId: "cust-1", ProductType: model.CashISA, Status: model.AccountOpen},
+		{Id: "acc-lisa", CustomerId: "cust-1", ProductType: model.LifetimeISA, Status: model.AccountOpen},
+		{Id: "acc-junior", CustomerId: "child-1", OperatorId: "cust-1", ProductType: model.JuniorISA, Status: model.AccountOpen},
+		{Id: "acc-closed", CustomerId: "cust-1", ProductType: model.StocksAndSharesISA, Status: model.AccountClosed, ClosedAt: &closedAt},
+	}
+	for _, account := range accounts {
+		db.CreateAccount(account)
+	}
+	return db
+}
+
+func newLedger(l logger.Logger) *service.LedgerServiceImpl {
+	return service.NewLedgerService(repository.NewLedgerClient(), &mockPublisher{publishFn: func(string, any) error { return nil }}, l)
+}
+
+// payIn posts amount into an account's cash in the ledger, as a settled deposit would
+func payIn(ledger service.LedgerService, accountId string, amount money.Money) {
+	deposit := model.Investment{Id: uuid.New().String(), AccountId: accountId, Type: model.Deposit, Amount: amount}
+	ledger.RecordInvestment(deposit, model.InvestmentStatusChange{Status: model.InvestmentSettled, At: time.Now()})
+}
+
+// cashIn returns an account's cash balance in the ledger
+func cashIn(t *testing.T, ledger service.LedgerService, accountId string) *model.CashBalance {
+	t.Helper()
+	balance, err := ledger.GetCashBalance(accountId)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	return balance
+}
+
 type mockRepo struct {
 	createInvestment           func(investment model.Investment) error
+	updateInvestment           func(investment model.Investment) error
 	getInvestmentById          func(id string) (*model.Investment, error)
 	getInvestmentsByCustomerId func(id string) (*[]model.Investment, error)
+	getInvestmentsByAccountId  func(id string) (*[]model.Investment, error)
+	getInvestmentsByTaxYear    func(taxYear taxyear.TaxYear) (*[]model.Investment, error)
+	getInvestmentsByStatus     func(status model.InvestmentStatus) (*[]model.Investment, error)
 }
 
 func (m *mockRepo) CreateInvestment(investment model.Investment) error {
 	return m.createInvestment(investment)
 }
+func (m *mockRepo) UpdateInvestment(investment model.Investment) error {
+	return m.updateInvestment(investment)
+}
 func (m *mockRepo) GetInvestmentById(id string) (*model.Investment, error) {
 	return m.getInvestmentById(id)
 }
@@ -29,6 +175,18 @@ func (m *mockRepo) GetInvestmentsByCustomerId(id string) (*[]model.Investment, e
 	return m.getInvestmentsByCustomerId(id)
 }
 
+func (m *mockRepo) GetInvestmentsByAccountId(id string) (*[]model.Investment, error) {
+	return m.getInvestmentsByAccountId(id)
+}
+
+func (m *mockRepo) GetInvestmentsByTaxYear(taxYear taxyear.TaxYear) (*[]model.Investment, error) {
+	return m.getInvestmentsByTaxYear(taxYear)
+}
+
+func (m *mockRepo) GetInvestmentsByStatus(status model.InvestmentStatus) (*[]model.Investment, error) {
+	return m.getInvestmentsByStatus(status)
+}
+
 type mockPublisher struct {
 	publishFn func(subject string, payload any) error
 	close     func()
@@ -37,6 +195,9 @@ type mockPublisher struct {
 func (m *mockPublisher) Publish(subject string, payload any) error {
 	return m.publishFn(subject, payload)
 }
+func (m *mockPublisher) Subscribe(subject string, handle func(data []byte)) error {
+	return nil
+}
 func (m *mockPublisher) Close() {
 	m.close()
 }
@@ -55,13 +216,13 @@ func TestCreateInvestmentSuccess(t *testing.T) {
 	}
 
 	logger := logger.NewMockLogger()
-	svc := service.New(mockRepo, mockPub, logger)
+	svc := service.New(mockRepo, newAccountRepo(), customersAged(30), fundPriced("2.000000"), newAllowanceService(logger), newBonusService(logger), newLedger(logger), service.NewLocks(), mockPub, logger)
 
-	customerId := "cust-1"
+	accountId := "acc-1"
 	fundId := "fund-1"
-	amount := 100.0
+	amount := money.Pounds(100)
 
-	investment, err := svc.CreateInvestment(customerId, fundId, amount)
+	investment, err := svc.CreateInvestment(accountId, fundId, amount)
 	if err != nil {
 		t.Fatalf("expected no error, got %v", err)
 	}
@@ -71,11 +232,19 @@ func TestCreateInvestmentSuccess(t *testing.T) {
 
 	expected := &model.Investment{
 		Id:         investment.Id,
-		CustomerId: customerId,
+		AccountId:  accountId,
+		CustomerId: "cust-1",
 		FundId:     fundId,
+		Type:       model.Subscription,
 		Amount:     amount,
-		Status:     "pending",
-		CreatedAt:  investment.CreatedAt,
+		Status:     model.InvestmentValidated,
+		History: []model.InvestmentStatusChange{
+			{Status: model.InvestmentPending, Reason: "order received", Actor: model.ActorCustomer, At: investment.CreatedAt},
+			{From: model.InvestmentPending, Status: model.InvestmentValidated, Reason: "eligibility and allowance checks passed", Actor: model.ActorSystem, At: investment.CreatedAt},
+		},
+		TaxYear:      taxyear.For(investment.CreatedAt),
+		AllowanceUse: model.AllowanceUse{Subscribed: amount},
+		CreatedAt:    investment.CreatedAt,
 	}
 
 	if diff := cmp.Diff(expected, investment,
@@ -91,37 +260,58 @@ func TestCreateInvestmentSuccess(t *testing.T) {
 func TestCreateInvestmentFailures(t *testing.T) {
 	tests := []struct {
 		name        string
-		customerId  string
+		accountId   string
 		fundId      string
-		amount      float64
+		amount      money.Money
 		expectedErr string
 	}{
 		{
-			name:        "missing customerId",
-			customerId:  "",
+			name:        "missing accountId",
+			accountId:   "",
+			fundId:      "fund-1",
+			amount:      money.Pounds(100),
+			expectedErr: internal.ErrMissingAccountId.Error(),
+		},
+		{
+			name:        "account not found",
+			accountId:   "acc-missing",
+			fundId:      "fund-1",
+			amount:      money.Pounds(100),
+			expectedErr: internal.AccountNotFoundError("acc-missing").Error(),
+		},
+		{
+			name:        "account closed",
+			accountId:   "acc-closed",
 			fundId:      "fund-1",
-			amount:      100,
-			expectedErr: internal.ErrMissingCustomerId.Error(),
+			amount:      money.Pounds(100),
+			expectedErr: internal.ErrAccountClosed.Error(),
+		},
+		{
+			name:        "fund not eligible for cash ISA",
+			accountId:   "acc-cash",
+			fundId:      "fund-1",
+			amount:      money.Pounds(100),
+			expectedErr: internal.ErrFundNotEligible.Error(),
 		},
 		{
 			name:        "missing fundId",
-			customerId:  "cust-1",
+			accountId:   "acc-1",
 			fundId:      "",
-			amount:      100,
+			amount:      money.Pounds(100),
 			expectedErr: internal.ErrMissingFundId.Error(),
 		},
 		{
 			name:        "amount is zero",
-			customerId:  "cust-1",
+			accountId:   "acc-1",
 			fundId:      "fund-1",
-			amount:      0,
+			amount:      money.Pounds(0),
 			expectedErr: internal.ErrZeroTransactionAmount.Error(),
 		},
 		{
 			name:        "amount is negative",
-			customerId:  "cust-1",
+			accountId:   "acc-1",
 			fundId:      "fund-1",
-			amount:      -50,
+			amount:      money.Pounds(-50),
 			expectedErr: internal.ErrZeroTransactionAmount.Error(),
 		},
 	}
@@ -143,9 +333,9 @@ func TestCreateInvestmentFailures(t *testing.T) {
 			}
 
 			logger := logger.NewMockLogger()
-			svc := service.New(mockRepo, mockPub, logger)
+			svc := service.New(mockRepo, newAccountRepo(), customersAged(30), fundPriced("2.000000"), newAllowanceService(logger), newBonusService(logger), newLedger(logger), service.NewLocks(), mockPub, logger)
 
-			investment, err := svc.CreateInvestment(tt.customerId, tt.fundId, tt.amount)
+			investment, err := svc.CreateInvestment(tt.accountId, tt.fundId, tt.amount)
 
 			if err == nil {
 				t.Fatalf("expected error '%s', got nil", tt.expectedErr)
@@ -160,12 +350,191 @@ func TestCreateInvestmentFailures(t *testing.T) {
 	}
 }
 
+func TestCreateInvestmentExceedsAllowance(t *testing.T) {
+	mockRepo := &mockRepo{
+		createInvestment: func(inv model.Investment) error {
+			t.Fatal("should not call CreateInvestment when allowance is exceeded")
+			return nil
+		},
+	}
+	mockPub := &mockPublisher{
+		publishFn: func(subject string, payload any) error {
+			t.Fatal("should not publish when allowance is exceeded")
+			return nil
+		},
+		close: func() {},
+	}
+
+	logger := logger.NewMockLogger()
+	allowance := newAllowanceService(logger)
+	if _, err := allowance.Subscribe("cust-1", taxyear.For(time.Now()), stocksAndShares(t), money.Pounds(19950)); err != nil {
+		t.Fatalf("unexpected error seeding allowance: %v", err)
+	}
+	svc := service.New(mockRepo, newAccountRepo(), customersAged(30), fundPriced("2.000000"), allowance, newBonusService(logger), newLedger(logger), service.NewLocks(), mockPub, logger)
+
+	investment, err := svc.CreateInvestment("acc-1", "fund-1", money.Pounds(100))
+	if !errors.Is(err, internal.ErrAllowanceExceeded) {
+		t.Fatalf("expected allowance exceeded error, got: %v", err)
+	}
+	if investment != nil {
+		t.Errorf("expected nil investment, got: %+v", investment)
+	}
+}
+
+func TestCreateInvestmentRepoFailsReleasesAllowance(t *testing.T) {
+	mockRepo := &mockRepo{
+		createInvestment: func(inv model.Investment) error {
+			return errors.New("db failure")
+		},
+	}
+
+	logger := logger.NewMockLogger()
+	allowance := newAllowanceService(logger)
+	svc := service.New(mockRepo, newAccountRepo(), customersAged(30), fundPriced("2.000000"), allowance, newBonusService(logger), newLedger(logger), service.NewLocks(), nil, logger)
+
+	if _, err := svc.CreateInvestment("acc-1", "fund-1", money.Pounds(500)); err == nil {
+		t.Fatal("expected error, got nil")
+	}
+
+	actual, err := allowance.GetAllowance("cust-1")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !actual.Used.Equal(money.Pounds(0)) {
+		t.Errorf("expected allowance to be released, used: %s", actual.Used)
+	}
+}
+
+func TestDepositRepoFailsTakesBackCash(t *testing.T) {
+	mockRepo := &mockRepo{
+		createInvestment: func(inv model.Investment) error {
+			return errors.New("db failure")
+		},
+	}
+
+	logger := logger.NewMockLogger()
+	allowance := newAllowanceService(logger)
+	ledger := newLedger(logger)
+	svc := service.New(mockRepo, newAccountRepo(), customersAged(30), fundPriced("2.000000"), allowance, newBonusService(logger), ledger, service.NewLocks(), nil, logger)
+
+	if _, err := svc.Deposit("acc-1", money.Pounds(500)); err == nil {
+		t.Fatal("expected error, got nil")
+	}
+
+	if cash := cashIn(t, ledger, "acc-1"); !cash.Balance.IsZero() {
+		t.Errorf("expected the cash credited to be taken back, got %s", cash.Balance)
+	}
+	actual, err := allowance.GetAllowance("cust-1")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !actual.Used.IsZero() {
+		t.Errorf("expected allowance to be released, used: %s", actual.Used)
+	}
+}
+
+func TestCreateInvestmentLifetimeISA(t *testing.T) {
+	mockRepo := &mockRepo{
+		createInvestment: func(inv model.Investment) error {
+			return nil
+		},
+	}
+	mockPub := &mockPublisher{
+		publishFn: func(subject string, payload any) error {
+			return nil
+		},
+	}
+	logger := logger.NewMockLogger()
+	bonusRepo := repository.NewBonusClient()
+	bonuses := service.NewBonusService(bonusRepo, logger)
+
+	t.Run("records a bonus claim for each subscription", func(t *testing.T) {
+		svc := service.New(mockRepo, newAccountRepo(), customersAged(30), fundPriced("2.000000"), newAllowanceService(logger), bonuses, newLedger(logger), service.NewLocks(), mockPub, logger)
+
+		investment, err := svc.CreateInvestment("acc-lisa", "fund-1", money.Pounds(1000))
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		claims, err := bonusRepo.GetClaimsByPeriod(investment.CreatedAt.In(london(t)).Format("2006-01"))
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if len(*claims) != 1 {
+			t.Fatalf("expected 1 bonus claim, got %d", len(*claims))
+		}
+		claim := (*claims)[0]
+		if claim.InvestmentId != investment.Id || !claim.BonusAmount.Equal(money.Pounds(250)) {
+			t.Errorf("unexpected bonus claim: %+v", claim)
+		}
+	})
+
+	t.Run("rejects subscriptions over the lifetime ISA limit", func(t *testing.T) {
+		svc := service.New(mockRepo, newAccountRepo(), customersAged(30), fundPriced("2.000000"), newAllowanceService(logger), bonuses, newLedger(logger), service.NewLocks(), mockPub, logger)
+
+		_, err := svc.CreateInvestment("acc-lisa", "fund-1", money.MustParse("4000.01"))
+		if !errors.Is(err, internal.ErrAllowanceExceeded) {
+			t.Errorf("expected allowance exceeded error, got %v", err)
+		}
+	})
+
+	t.Run("rejects subscriptions from customers aged 50 or over", func(t *testing.T) {
+		svc := service.New(mockRepo, newAccountRepo(), customersAged(50), fundPriced("2.000000"), newAllowanceService(logger), bonuses, newLedger(logger), service.NewLocks(), mockPub, logger)
+
+		_, err := svc.CreateInvestment("acc-lisa", "fund-1", money.Pounds(100))
+		if !errors.Is(err, internal.ErrIneligibleAge) {
+			t.Errorf("expected ineligible age error, got %v", err)
+		}
+	})
+}
+
+func TestCreateInvestmentJuniorISALimit(t *testing.T) {
+	mockRepo := &mockRepo{
+		createInvestment: func(inv model.Investment) error {
+			return nil
+		},
+	}
+	mockPub := &mockPublisher{
+		publishFn: func(subject string, payload any) error {
+			return nil
+		},
+	}
+	logger := logger.NewMockLogger()
+	svc := service.New(mockRepo, newAccountRepo(), customersAged(10), fundPriced("2.000000"), newAllowanceService(logger), newBonusService(logger), newLedger(logger), service.NewLocks(), mockPub, logger)
+
+	investment, err := svc.CreateInvestment("acc-junior", "fund-1", money.Pounds(9000))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if investment.CustomerId != "child-1" {
+		t.Errorf("expected investment to belong to the child, got %s", investment.CustomerId)
+	}
+
+	if _, err := svc.CreateInvestment("acc-junior", "fund-1", money.MustParse("0.01")); !errors.Is(err, internal.ErrAllowanceExceeded) {
+		t.Errorf("expected junior ISA limit to be enforced, got %v", err)
+	}
+
+	// the account has not been converted yet but the child has turned 18
+	svc = service.New(mockRepo, newAccountRepo(), customersAged(18), fundPriced("2.000000"), newAllowanceService(logger), newBonusService(logger), newLedger(logger), service.NewLocks(), mockPub, logger)
+	if _, err := svc.CreateInvestment("acc-junior", "fund-1", money.Pounds(100)); !errors.Is(err, internal.ErrIneligibleAge) {
+		t.Errorf("expected a subscription from an adult into a junior ISA to be rejected, got %v", err)
+	}
+}
+
+func london(t *testing.T) *time.Location {
+	loc, err := time.LoadLocation("Europe/London")
+	if err != nil {
+		t.Fatalf("failed to load location: %v", err)
+	}
+	return loc
+}
+
 func TestGetInvestmentByIdSuccess(t *testing.T) {
 	expected := &model.Investment{
 		Id:         "inv-1",
 		CustomerId: "cust-1",
 		FundId:     "fund-1",
-		Amount:     200.0,
+		Amount:     money.Pounds(200),
 		Status:     "completed",
 		CreatedAt:  time.Now(),
 	}
@@ -176,7 +545,7 @@ func TestGetInvestmentByIdSuccess(t *testing.T) {
 		},
 	}
 	logger := logger.NewMockLogger()
-	svc := service.New(mockRepo, nil, logger)
+	svc := service.New(mockRepo, newAccountRepo(), customersAged(30), fundPriced("2.000000"), newAllowanceService(logger), newBonusService(logger), newLedger(logger), service.NewLocks(), nil, logger)
 
 	actual, err := svc.GetInvestmentById("inv-1")
 	if err != nil {
@@ -194,7 +563,7 @@ func TestGetInvestmentByIdRepoFails(t *testing.T) {
 		},
 	}
 	logger := logger.NewMockLogger()
-	svc := service.New(mockRepo, nil, logger)
+	svc := service.New(mockRepo, newAccountRepo(), customersAged(30), fundPriced("2.000000"), newAllowanceService(logger), newBonusService(logger), newLedger(logger), service.NewLocks(), nil, logger)
 
 	_, err := svc.GetInvestmentById("missing-id")
 	if err == nil {
@@ -212,7 +581,7 @@ func TestGetInvestmentByCustomerIdSuccess(t *testing.T) {
 			Id:         "inv-1",
 			CustomerId: "cust-1",
 			FundId:     "fund-1",
-			Amount:     200.0,
+			Amount:     money.Pounds(200),
 			Status:     "completed",
 			CreatedAt:  time.Now(),
 		},
@@ -220,7 +589,7 @@ func TestGetInvestmentByCustomerIdSuccess(t *testing.T) {
 			Id:         "inv-2",
 			CustomerId: "cust-1",
 			FundId:     "fund-2",
-			Amount:     100.0,
+			Amount:     money.Pounds(100),
 			Status:     "completed",
 			CreatedAt:  time.Now(),
 		},
@@ -228,7 +597,7 @@ func TestGetInvestmentByCustomerIdSuccess(t *testing.T) {
 			Id:         "inv-3",
 			CustomerId: "cust-2",
 			FundId:     "fund-3",
-			Amount:     300.0,
+			Amount:     money.Pounds(300),
 			Status:     "completed",
 			CreatedAt:  time.Now(),
 		},
@@ -240,7 +609,7 @@ func TestGetInvestmentByCustomerIdSuccess(t *testing.T) {
 		},
 	}
 	logger := logger.NewMockLogger()
-	svc := service.New(mockRepo, nil, logger)
+	svc := service.New(mockRepo, newAccountRepo(), customersAged(30), fundPriced("2.000000"), newAllowanceService(logger), newBonusService(logger), newLedger(logger), service.NewLocks(), nil, logger)
 
 	actual, err := svc.GetInvestmentsByCustomerId("cust-1")
 	if err != nil {
@@ -253,3 +622,481 @@ func TestGetInvestmentByCustomerIdSuccess(t *testing.T) {
 		t.Errorf("unexpected result (-want +got):\n%s", diff)
 	}
 }
+
+func TestWithdraw(t *testing.T) {
+	mockPub := &mockPublisher{
+		publishFn: func(subject string, payload any) error {
+			return nil
+		},
+	}
+	logger := logger.NewMockLogger()
+	// ledgerWithCash is a ledger with cash paid into one of the test accounts
+	ledgerWithCash := func(accountId string, cash money.Money) *service.LedgerServiceImpl {
+		ledger := newLedger(logger)
+		payIn(ledger, accountId, cash)
+		return ledger
+	}
+
+	t.Run("restores allowance for a flexible ISA", func(t *testing.T) {
+		ledger := ledgerWithCash("acc-1", money.Pounds(20000))
+		allowance := newAllowanceService(logger)
+		svc := service.New(repository.NewInvestmentClient(), newAccountRepo(), customersAged(30), fundPriced("2.000000"), allowance, newBonusService(logger), ledger, service.NewLocks(), mockPub, logger)
+
+		withdrawal, err := svc.Withdraw("acc-1", money.Pounds(5000), "")
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		expected := &model.WithdrawalDetails{Reason: model.WithdrawalOther, NetAmount: money.Pounds(5000)}
+		if withdrawal.Type != model.Withdrawal || !cmp.Equal(expected, withdrawal.Withdrawal) {
+			t.Errorf("unexpected withdrawal: %+v", withdrawal)
+		}
+		if cash := cashIn(t, ledger, "acc-1"); !cash.Reserved.Equal(money.Pounds(5000)) || !cash.Available.Equal(money.Pounds(15000)) {
+			t.Errorf("expected 5000 reserved until the withdrawal settles, got %+v", cash)
+		}
+
+		actual, err := allowance.GetAllowance("cust-1")
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if !actual.Replaceable.IsZero() {
+			t.Errorf("expected nothing replaceable before the withdrawal settles, got: %+v", actual)
+		}
+		if _, err := svc.UpdateInvestmentStatus(withdrawal.Id, model.InvestmentSettled, "paid", "ops:jo"); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if actual, err = allowance.GetAllowance("cust-1"); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if !actual.Withdrawn.Equal(money.Pounds(5000)) || !actual.Replaceable.Equal(money.Pounds(5000)) {
+			t.Errorf("expected 5000 withdrawn and replaceable, got: %+v", actual)
+		}
+	})
+
+	t.Run("failed withdrawal restores no allowance", func(t *testing.T) {
+		ledger := ledgerWithCash("acc-1", money.Pounds(25000))
+		allowance := newAllowanceService(logger)
+		svc := service.New(repository.NewInvestmentClient(), newAccountRepo(), customersAged(30), fundPriced("2.000000"), allowance, newBonusService(logger), ledger, service.NewLocks(), mockPub, logger)
+
+		if _, err := svc.CreateInvestment("acc-1", "fund-1", money.Pounds(20000)); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		withdrawal, err := svc.Withdraw("acc-1", money.Pounds(5000), "")
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if _, err := svc.UpdateInvestmentStatus(withdrawal.Id, model.InvestmentFailed, "bank details wrong", "ops:jo"); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if _, err := svc.CreateInvestment("acc-1", "fund-1", money.Pounds(5000)); !errors.Is(err, internal.ErrAllowanceExceeded) {
+			t.Errorf("expected the allowance to be exceeded, got: %v", err)
+		}
+	})
+
+	t.Run("charges an unauthorised lifetime ISA withdrawal", func(t *testing.T) {
+		svc := service.New(repository.NewInvestmentClient(), newAccountRepo(), customersAged(30), fundPriced("2.000000"), newAllowanceService(logger), newBonusService(logger), ledgerWithCash("acc-lisa", money.Pounds(1000)), service.NewLocks(), mockPub, logger)
+
+		withdrawal, err := svc.Withdraw("acc-lisa", money.Pounds(1000), model.WithdrawalOther)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if !withdrawal.Withdrawal.Charge.Equal(money.Pounds(250)) || !withdrawal.Withdrawal.NetAmount.Equal(money.Pounds(750)) {
+			t.Errorf("expected 250 charge, got: %+v", withdrawal.Withdrawal)
+		}
+	})
+
+	tests := []struct {
+		name        string
+		accountId   string
+		amount      money.Money
+		reason      model.WithdrawalReason
+		customers   *mockCustomerClient
+		expectedErr error
+	}{
+		{"more than the cash available", "acc-1", money.MustParse("1500.01"), model.WithdrawalOther, customersAged(30), internal.ErrInsufficientCash},
+		{"junior ISA", "acc-junior", money.Pounds(100), model.WithdrawalOther, customersAged(10), internal.ErrWithdrawalNotAllowed},
+		{"closed account", "acc-closed", money.Pounds(100), model.WithdrawalOther, customersAged(30), internal.ErrAccountClosed},
+		{"unknown reason", "acc-1", money.Pounds(100), "holiday", customersAged(30), internal.ErrInvalidReason},
+		{"age 60 reason under 60", "acc-lisa", money.Pounds(100), model.WithdrawalAgeSixty, customersAged(59), internal.ErrIneligibleAge},
+		{"zero amount", "acc-1", money.Money{}, model.WithdrawalOther, customersAged(30), internal.ErrZeroTransactionAmount},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := &mockRepo{
+				createInvestment: func(inv model.Investment) error {
+					t.Fatal("should not save a rejected withdrawal")
+					return nil
+				},
+			}
+			svc := service.New(repo, newAccountRepo(), tt.customers, fundPriced("2.000000"), newAllowanceService(logger), newBonusService(logger), ledgerWithCash(tt.accountId, money.Pounds(1500)), service.NewLocks(), mockPub, logger)
+
+			withdrawal, err := svc.Withdraw(tt.accountId, tt.amount, tt.reason)
+			if !errors.Is(err, tt.expectedErr) {
+				t.Fatalf("expected %v, got: %v", tt.expectedErr, err)
+			}
+			if withdrawal != nil {
+				t.Errorf("expected nil withdrawal, got: %+v", withdrawal)
+			}
+		})
+	}
+}
+
+func TestWithdrawConcurrently(t *testing.T) {
+	logger := logger.NewMockLogger()
+	ledger := newLedger(logger)
+	payIn(ledger, "acc-1", money.Pounds(1000))
+	nothing := &mockPublisher{publishFn: func(string, any) error { return nil }}
+	svc := service.New(repository.NewInvestmentClient(), newAccountRepo(), customersAged(30), fundPriced("2.000000"), newAllowanceService(logger), newBonusService(logger), ledger, service.NewLocks(), nothing, logger)
+
+	var wg sync.WaitGroup
+	var mu sync.Mutex
+	withdrawn := 0
+	for range 10 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if _, err := svc.Withdraw("acc-1", money.Pounds(300), model.WithdrawalOther); err == nil {
+				mu.Lock()
+				withdrawn++
+				mu.Unlock()
+			}
+		}()
+	}
+	wg.Wait()
+	if withdrawn != 3 {
+		t.Errorf("expected only 3 withdrawals of 300.00 from 1000.00, got %d", withdrawn)
+	}
+}
+
+func TestWithdrawalSettlement(t *testing.T) {
+	logger := logger.NewMockLogger()
+	ledger := newLedger(logger)
+	payIn(ledger, "acc-1", money.Pounds(1000))
+	nothing := &mockPublisher{publishFn: func(string, any) error { return nil }}
+	svc := service.New(repository.NewInvestmentClient(), newAccountRepo(), customersAged(30), fundPriced("2.000000"), newAllowanceService(logger), newBonusService(logger), ledger, service.NewLocks(), nothing, logger)
+
+	withdrawal, err := svc.Withdraw("acc-1", money.Pounds(600), model.WithdrawalOther)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	// the reserved cash cannot be spent while the withdrawal is paid out
+	if _, err := svc.Buy("acc-1", "fund-1", money.Pounds(500)); !errors.Is(err, internal.ErrInsufficientCash) {
+		t.Errorf("expected the purchase to be refused, got: %v", err)
+	}
+	if _, err := svc.UpdateInvestmentStatus(withdrawal.Id, model.InvestmentSettled, "paid", "ops:jo"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if cash := cashIn(t, ledger, "acc-1"); !cash.Balance.Equal(money.Pounds(400)) || !cash.Reserved.IsZero() {
+		t.Errorf("expected 600 paid out of cash, got %+v", cash)
+	}
+
+	failed, err := svc.Withdraw("acc-1", money.Pounds(300), model.WithdrawalOther)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if _, err := svc.UpdateInvestmentStatus(failed.Id, model.InvestmentFailed, "bank details wrong", "ops:jo"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if cash := cashIn(t, ledger, "acc-1"); !cash.Available.Equal(money.Pounds(400)) {
+		t.Errorf("expected a failed withdrawal to free its cash, got %+v", cash)
+	}
+}
+
+func TestCreateInvestmentIneligibleCustomer(t *testing.T) {
+	mockRepo := &mockRepo{
+		createInvestment: func(inv model.Investment) error {
+			t.Fatal("should not call CreateInvestment for an ineligible customer")
+			return nil
+		},
+	}
+	customers := customersAged(30)
+	customers.getEligibility = func(id string, productType model.ProductType) (*model.Eligibility, error) {
+		if productType != model.StocksAndSharesISA {
+			t.Errorf("expected eligibility for stocks and shares ISA, got %s", productType)
+		}
+		return &model.Eligibility{
+			CustomerId: id,
+			Product:    productType,
+			Reasons:    []string{"not_uk_resident", "missing_national_insurance_number"},
+		}, nil
+	}
+
+	logger := logger.NewMockLogger()
+	allowance := newAllowanceService(logger)
+	svc := service.New(mockRepo, newAccountRepo(), customers, fundPriced("2.000000"), allowance, newBonusService(logger), newLedger(logger), service.NewLocks(), nil, logger)
+
+	_, err := svc.CreateInvestment("acc-1", "fund-1", money.Pounds(100))
+	if !errors.Is(err, internal.ErrCustomerIneligible) {
+		t.Fatalf("expected customer ineligible error, got: %v", err)
+	}
+	if !strings.Contains(err.Error(), "not_uk_resident") {
+		t.Errorf("expected error to give the reason, got: %v", err)
+	}
+
+	actual, err := allowance.GetAllowance("cust-1")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !actual.Used.Equal(money.Pounds(0)) {
+		t.Errorf("expected no allowance used, got %s", actual.Used)
+	}
+}
+
+func TestCancelInvestment(t *testing.T) {
+	var published []string
+	mockPub := &mockPublisher{
+		publishFn: func(subject string, payload any) error {
+			published = append(published, subject)
+			return nil
+		},
+	}
+	logger := logger.NewMockLogger()
+	repo := repository.NewInvestmentClient()
+	allowance := newAllowanceService(logger)
+	bonusRepo := repository.NewBonusClient()
+	bonuses := service.NewBonusService(bonusRepo, logger)
+	svc := service.New(repo, newAccountRepo(), customersAged(30), fundPriced("2.000000"), allowance, bonuses, newLedger(logger), service.NewLocks(), mockPub, logger)
+
+	investment, err := svc.CreateInvestment("acc-lisa", "fund-1", money.Pounds(1000))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	published = nil
+
+	cancelled, err := svc.CancelInvestment(investment.Id)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if cancelled.Status != "cancelled" || cancelled.Cancellation == nil || !cancelled.Cancellation.RefundAmount.Equal(money.Pounds(1000)) {
+		t.Errorf("unexpected cancelled investment: %+v", cancelled)
+	}
+	if diff := cmp.Diff([]string{"investment.cancelled", "investment.status.cancelled"}, published); diff != "" {
+		t.Errorf("unexpected events (-want +got):\n%s", diff)
+	}
+	if last := cancelled.History[len(cancelled.History)-1]; last.From != model.InvestmentValidated || last.Actor != model.ActorCustomer {
+		t.Errorf("expected cancellation by the customer in history, got %+v", last)
+	}
+
+	actual, err := allowance.GetAllowance("cust-1")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !actual.Used.Equal(money.Pounds(0)) {
+		t.Errorf("expected allowance to be restored, used: %s", actual.Used)
+	}
+	claim, err := bonusRepo.GetClaimByInvestmentId(investment.Id)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if claim.Status != model.BonusClaimCancelled {
+		t.Errorf("expected bonus claim to be cancelled, got %s", claim.Status)
+	}
+
+	if _, err := svc.CancelInvestment(investment.Id); !errors.Is(err, internal.ErrCannotCancel) {
+		t.Errorf("expected a cancelled investment not to be cancelled again, got: %v", err)
+	}
+}
+
+func TestCancelInvestmentAfterCoolingOff(t *testing.T) {
+	logger := logger.NewMockLogger()
+	repo := repository.NewInvestmentClient()
+	repo.CreateInvestment(model.Investment{
+		Id:         "inv-1",
+		AccountId:  "acc-1",
+		CustomerId: "cust-1",
+		Type:       model.Subscription,
+		Amount:     money.Pounds(100),
+		Status:     model.InvestmentSettled,
+		CreatedAt:  time.Now().Add(-service.CoolingOffPeriod - time.Minute),
+	})
+	svc := service.New(repo, newAccountRepo(), customersAged(30), fundPriced("2.000000"), newAllowanceService(logger), newBonusService(logger), newLedger(logger), service.NewLocks(), nil, logger)
+
+	if _, err := svc.CancelInvestment("inv-1"); !errors.Is(err, internal.ErrCoolingOffExpired) {
+		t.Errorf("expected cooling-off expired error, got: %v", err)
+	}
+	if _, err := svc.CancelInvestment("inv-unknown"); !errors.Is(err, internal.ErrInvestmentNotFound) {
+		t.Errorf("expected investment not found error, got: %v", err)
+	}
+}
+
+func TestCancelInvestmentAfterTaxYearEnd(t *testing.T) {
+	logger := logger.NewMockLogger()
+	repo := repository.NewInvestmentClient()
+	allowances := repository.NewAllowanceClient()
+	allowance := service.NewAllowanceService(allowances, taxyear.DefaultLimits(), logger)
+	lastYear := taxyear.For(time.Now()).Previous()
+	rollover := service.NewRolloverService(allowances, &mockPublisher{publishFn: func(subject string, payload any) error { return nil }}, logger, lastYear.Start())
+	svc := service.New(repo, newAccountRepo(), customersAged(30), fundPriced("2.000000"), allowance, newBonusService(logger), newLedger(logger), service.NewLocks(), nil, logger)
+
+	// made before the year end and still in its cooling-off period once the year has closed
+	repo.CreateInvestment(model.Investment{
+		Id:           "inv-1",
+		AccountId:    "acc-1",
+		CustomerId:   "cust-1",
+		FundId:       "fund-1",
+		Type:         model.Subscription,
+		Amount:       money.Pounds(100),
+		Status:       model.InvestmentValidated,
+		TaxYear:      lastYear,
+		AllowanceUse: model.AllowanceUse{Subscribed: money.Pounds(100)},
+		CreatedAt:    time.Now().Add(-time.Hour),
+	})
+	if err := rollover.Run(time.Now()); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if _, err := svc.CancelInvestment("inv-1"); !errors.Is(err, internal.ErrCannotCancel) {
+		t.Errorf("expected a subscription from a closed tax year not to be cancelled, got: %v", err)
+	}
+	if _, err := svc.UpdateInvestmentStatus("inv-1", model.InvestmentFailed, "payment bounced", "ops:jo"); !errors.Is(err, internal.ErrInvalidTransition) {
+		t.Errorf("expected a subscription from a closed tax year not to be failed, got: %v", err)
+	}
+	if investment, _ := repo.GetInvestmentById("inv-1"); investment.Status != model.InvestmentValidated {
+		t.Errorf("expected the subscription to be left as it was, got %s", investment.Status)
+	}
+}
+
+func TestUpdateInvestmentStatus(t *testing.T) {
+	var published []string
+	mockPub := &mockPublisher{
+		publishFn: func(subject string, payload any) error {
+			published = append(published, subject)
+			return nil
+		},
+	}
+	logger := logger.NewMockLogger()
+	repo := repository.NewInvestmentClient()
+	allowance := newAllowanceService(logger)
+	svc := service.New(repo, newAccountRepo(), customersAged(30), fundPriced("2.000000"), allowance, newBonusService(logger), newLedger(logger), service.NewLocks(), mockPub, logger)
+
+	investment, err := svc.CreateInvestment("acc-1", "fund-1", money.Pounds(1000))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	published = nil
+
+	if _, err := svc.UpdateInvestmentStatus(investment.Id, model.InvestmentFailed, "payment bounced", ""); !errors.Is(err, internal.ErrMissingActor) {
+		t.Errorf("expected missing actor error, got: %v", err)
+	}
+	if _, err := svc.UpdateInvestmentStatus(investment.Id, model.InvestmentSettled, "", "ops:jo"); !errors.Is(err, internal.ErrInvalidTransition) {
+		t.Errorf("expected a subscription not to settle before it is dealt, got: %v", err)
+	}
+	if _, err := svc.UpdateInvestmentStatus(investment.Id, model.InvestmentCancelled, "", "ops:jo"); !errors.Is(err, internal.ErrInvalidTransition) {
+		t.Errorf("expected cancellation to be refused, got: %v", err)
+	}
+
+	failed, err := svc.UpdateInvestmentStatus(investment.Id, model.InvestmentFailed, "payment bounced", "ops:jo")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	last := failed.History[len(failed.History)-1]
+	expected := model.InvestmentStatusChange{From: model.InvestmentValidated, Status: model.InvestmentFailed, Reason: "payment bounced", Actor: "ops:jo", At: last.At}
+	if diff := cmp.Diff(expected, last); diff != "" {
+		t.Errorf("unexpected status change (-want +got):\n%s", diff)
+	}
+	if failed.FailureReason == nil || *failed.FailureReason != "payment bounced" {
+		t.Errorf("expected failure reason to be kept, got %v", failed.FailureReason)
+	}
+	if diff := cmp.Diff([]string{"investment.status.failed"}, published); diff != "" {
+		t.Errorf("unexpected events (-want +got):\n%s", diff)
+	}
+	actual, err := allowance.GetAllowance("cust-1")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !actual.Used.IsZero() {
+		t.Errorf("expected allowance to be given back, used: %s", actual.Used)
+	}
+
+	if _, err := svc.UpdateInvestmentStatus(investment.Id, model.InvestmentSettled, "", "ops:jo"); !errors.Is(err, internal.ErrInvalidTransition) {
+		t.Errorf("expected a failed investment not to settle, got: %v", err)
+	}
+	if _, err := svc.UpdateInvestmentStatus("inv-unknown", model.InvestmentFailed, "", "ops:jo"); !errors.Is(err, internal.ErrInvestmentNotFound) {
+		t.Errorf("expected investment not found error, got: %v", err)
+	}
+}
+
+// slowInvestments gives concurrent status changes time to read the same investment
+type slowInvestments struct {
+	*repository.InvestmentClient
+}
+
+func (s slowInvestments) GetInvestmentById(id string) (*model.Investment, error) {
+	investment, err := s.InvestmentClient.GetInvestmentById(id)
+	time.Sleep(time.Millisecond)
+	return investment, err
+}
+
+func TestUpdateInvestmentStatusConcurrently(t *testing.T) {
+	logger := logger.NewMockLogger()
+	nothing := &mockPublisher{publishFn: func(string, any) error { return nil }}
+	repo := slowInvestments{repository.NewInvestmentClient()}
+	svc := service.New(repo, newAccountRepo(), customersAged(30), fundPriced("2.000000"), newAllowanceService(logger), newBonusService(logger), newLedger(logger), service.NewLocks(), nothing, logger)
+
+	investment, err := svc.CreateInvestment("acc-1", "fund-1", money.Pounds(1000))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	var wg sync.WaitGroup
+	var mu sync.Mutex
+	changed := 0
+	for range 10 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if _, err := svc.UpdateInvestmentStatus(investment.Id, model.InvestmentFailed, "payment bounced", "ops:jo"); err == nil {
+				mu.Lock()
+				changed++
+				mu.Unlock()
+			}
+		}()
+	}
+	wg.Wait()
+	failed, _ := repo.GetInvestmentById(investment.Id)
+	if changed != 1 || len(failed.History) != 3 {
+		t.Errorf("expected the investment to be failed once, got %d changes and history %+v", changed, failed.History)
+	}
+}
+
+func TestDeposit(t *testing.T) {
+	var published []string
+	mockPub := &mockPublisher{
+		publishFn: func(subject string, payload any) error {
+			published = append(published, subject)
+			return nil
+		},
+	}
+	logger := logger.NewMockLogger()
+	ledger := newLedger(logger)
+	allowance := newAllowanceService(logger)
+	svc := service.New(repository.NewInvestmentClient(), newAccountRepo(), customersAged(30), fundPriced("2.000000"), allowance, newBonusService(logger), ledger, service.NewLocks(), mockPub, logger)
+
+	if _, err := svc.Deposit("acc-closed", money.Pounds(100)); !errors.Is(err, internal.ErrAccountClosed) {
+		t.Errorf("expected a deposit into a closed account to be rejected, got: %v", err)
+	}
+	if _, err := svc.Deposit("acc-1", money.Pounds(20_001)); !errors.Is(err, internal.ErrAllowanceExceeded) {
+		t.Errorf("expected a deposit over the allowance to be rejected, got: %v", err)
+	}
+
+	deposit, err := svc.Deposit("acc-1", money.Pounds(500))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if deposit.Type != model.Deposit || deposit.Status != model.InvestmentSettled || len(deposit.History) != 3 {
+		t.Errorf("expected a settled deposit, got %+v", deposit)
+	}
+	if cash := cashIn(t, ledger, "acc-1"); !cash.Balance.Equal(money.Pounds(500)) {
+		t.Errorf("expected £500 cash, got %s", cash.Balance)
+	}
+	used, err := allowance.GetAllowance("cust-1")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !used.Used.Equal(money.Pounds(500)) {
+		t.Errorf("expected the deposit to use allowance, used: %s", used.Used)
+	}
+	expected := []string{"investment.deposit.created", "investment.status.pending", "investment.status.validated", "investment.status.settled"}
+	if diff := cmp.Diff(expected, published); diff != "" {
+		t.Errorf("unexpected events (-want +got):\n%s", diff)
+	}
+}
@@ -0,0 +1,104 @@
+package service
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/oliknight1/retail-isa-investment/investment-service/event"
+	"github.com/oliknight1/retail-isa-investment/investment-service/internal"
+	"github.com/oliknight1/retail-isa-investment/investment-service/logger"
+	"github.com/oliknight1/retail-isa-investment/investment-service/model"
+	"go.uber.org/zap"
+)
+
+// transition moves an investment to status and records why and by whom in its history. A new
+// investment with no status may start in any status, otherwise a move the state machine does
+// not allow is rejected and the investment is left as it was
+func transition(investment *model.Investment, status model.InvestmentStatus, reason, actor string, at time.Time) error {
+	if investment.Status != "" && !investment.Status.CanTransition(status) {
+		return fmt.Errorf("%w: %s to %s", internal.ErrInvalidTransition, investment.Status, status)
+	}
+	investment.History = append(investment.History, model.InvestmentStatusChange{
+		From:   investment.Status,
+		Status: status,
+		Reason: reason,
+		Actor:  actor,
+		At:     at,
+	})
+	investment.Status = status
+	if status == model.InvestmentSettled {
+		investment.CompletedAt = &at
+	}
+	return nil
+}
+
+// recordStatusChanges posts what each change in the investment's history from index since
+// onwards moved to the ledger. Call it before the changes are saved, so a change the ledger
+// refuses, such as spending cash the account does not have, is never saved
+func recordStatusChanges(ledger LedgerService, investment model.Investment, since int) error {
+	for _, change := range investment.History[since:] {
+		if err := ledger.RecordInvestment(investment, change); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// unrecord reverses whatever was posted to the ledger for a new investment that could not then
+// be saved
+func unrecord(ledger LedgerService, l logger.Logger, investment model.Investment) {
+	change := model.InvestmentStatusChange{Status: model.InvestmentFailed, Reason: "investment could not be saved", At: time.Now()}
+	if err := ledger.RecordInvestment(investment, change); err != nil {
+		l.Error("error reversing ledger entries of unsaved investment", zap.String("investment_id", investment.Id), zap.Error(err))
+	}
+}
+
+// publishStatusChanges publishes an investment.status.<status> event for each change in the
+// investment's history from index since onwards, and investment.units.moved for any change that
+// creates or cancels units of its fund. Call it once the changes have been saved
+func publishStatusChanges(publisher event.EventHandler, l logger.Logger, investment model.Investment, since int) {
+	for _, change := range investment.History[since:] {
+		subject := "investment.status." + string(change.Status)
+		changed := model.InvestmentStatusChanged{
+			InvestmentId:           investment.Id,
+			AccountId:              investment.AccountId,
+			CustomerId:             investment.CustomerId,
+			Type:                   investment.Type,
+			InvestmentStatusChange: change,
+		}
+		if err := publisher.Publish(subject, changed); err != nil {
+			l.Error("error publishing investment status event", zap.String("subject", subject), zap.Error(err))
+		}
+		if movement := unitMovement(investment, change); movement != nil {
+			if err := publisher.Publish("investment.units.moved", movement); err != nil {
+				l.Error("error publishing unit movement", zap.String("investment_id", investment.Id), zap.Error(err))
+			}
+		}
+	}
+}
+
+// unitMovement is the units a change creates or cancels, or nil if it moves none. Dealing an
+// order moves its units, and failing or cancelling one that was dealt moves them back
+func unitMovement(investment model.Investment, change model.InvestmentStatusChange) *model.UnitMovement {
+	if investment.Dealing == nil || investment.Dealing.Units == 0 {
+		return nil
+	}
+	units := investment.Dealing.Units
+	if investment.RemovesUnits() {
+		units = -units
+	}
+	switch change.Status {
+	case model.InvestmentDealt:
+	case model.InvestmentFailed, model.InvestmentCancelled:
+		units = -units
+	default:
+		return nil
+	}
+	return &model.UnitMovement{
+		Id:           investment.Id + ":" + string(change.Status),
+		FundId:       investment.FundId,
+		InvestmentId: investment.Id,
+		Units:        units,
+		At:           change.At,
+	}
+}
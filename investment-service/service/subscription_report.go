@@ -0,0 +1,146 @@
+package service
+
+import (
+	"fmt"
+	"maps"
+	"slices"
+	"time"
+
+	"github.com/oliknight1/retail-isa-investment/investment-service/client"
+	"github.com/oliknight1/retail-isa-investment/investment-service/internal"
+	"github.com/oliknight1/retail-isa-investment/investment-service/logger"
+	"github.com/oliknight1/retail-isa-investment/investment-service/model"
+	"github.com/oliknight1/retail-isa-investment/investment-service/money"
+	"github.com/oliknight1/retail-isa-investment/investment-service/repository"
+	"github.com/oliknight1/retail-isa-investment/investment-service/taxyear"
+	"go.uber.org/zap"
+)
+
+type SubscriptionReportService interface {
+	GenerateReport(taxYear string) (*model.SubscriptionReport, error)
+}
+
+type SubscriptionReportServiceImpl struct {
+	investments repository.Repository
+	accounts    repository.AccountRepository
+	customers   client.CustomerClient
+	Logger      logger.Logger
+}
+
+func NewSubscriptionReportService(investments repository.Repository, accounts repository.AccountRepository, customers client.CustomerClient, logger logger.Logger) *SubscriptionReportServiceImpl {
+	return &SubscriptionReportServiceImpl{
+		investments,
+		accounts,
+		customers,
+		logger,
+	}
+}
+
+// GenerateReport builds the subscription report for a tax year formatted like 2025-26, which can only be
+// done once the year has ended. Each subscriber's total per product is the money that used
+// their allowance that year, so replaced withdrawals and prior year money transferred in are
+// left out
+func (s *SubscriptionReportServiceImpl) GenerateReport(taxYear string) (*model.SubscriptionReport, error) {
+	year, err := taxyear.Parse(taxYear)
+	if err != nil {
+		s.Logger.Error("invalid tax year for subscription report", zap.String("tax_year", taxYear))
+		return nil, fmt.Errorf("%w: %s", internal.ErrInvalidTaxYear, taxYear)
+	}
+	if time.Now().Before(year.End()) {
+		s.Logger.Error("subscription report for a tax year still open", zap.String("tax_year", taxYear))
+		return nil, fmt.Errorf("%w: %s ends %s", internal.ErrTaxYearNotEnded, year, year.End().Format(time.DateOnly))
+	}
+
+	investments, err := s.investments.GetInvestmentsByTaxYear(year)
+	if err != nil {
+		s.Logger.Error("error fetching investments for subscription report", zap.Error(err))
+		return nil, err
+	}
+
+	totals := make(map[string]map[model.ProductType]money.Money)
+	for _, investment := range *investments {
+		if !investment.Status.Live() || investment.AllowanceUse.Subscribed.IsZero() {
+			continue
+		}
+		account, err := s.accounts.GetAccountById(investment.AccountId)
+		if err != nil {
+			s.Logger.Error("error fetching account for subscription report", zap.Error(err))
+			return nil, err
+		}
+		if totals[investment.CustomerId] == nil {
+			totals[investment.CustomerId] = make(map[model.ProductType]money.Money)
+		}
+		products := totals[investment.CustomerId]
+		products[account.ProductType] = products[account.ProductType].Add(investment.AllowanceUse.Subscribed)
+	}
+
+	report := &model.SubscriptionReport{
+		TaxYear:     year,
+		GeneratedAt: time.Now(),
+		Subscribers: []model.ReportSubscriber{},
+		Errors:      []model.ReportError{},
+	}
+	for _, customerId := range slices.Sorted(maps.Keys(totals)) {
+		products := totals[customerId]
+		customer, err := s.customers.GetCustomerById(customerId)
+		if err != nil {
+			s.Logger.Error("error fetching customer for subscription report", zap.String("customer_id", customerId), zap.Error(err))
+			report.Errors = append(report.Errors, model.ReportError{
+				CustomerId: customerId,
+				Field:      "customer",
+				Message:    "customer could not be fetched",
+			})
+			continue
+		}
+		if errs := validateSubscriber(customer, products); len(errs) > 0 {
+			report.Errors = append(report.Errors, errs...)
+			continue
+		}
+
+		subscriber := model.ReportSubscriber{
+			CustomerId:              customer.Id,
+			Name:                    customer.Name,
+			NationalInsuranceNumber: customer.NationalInsuranceNumber,
+			DateOfBirth:             customer.DateOfBirth.String(),
+		}
+		for _, productType := range slices.Sorted(maps.Keys(products)) {
+			subscriber.Subscriptions = append(subscriber.Subscriptions, model.ReportSubscription{
+				ProductType: productType,
+				Amount:      products[productType],
+			})
+			subscriber.TotalSubscribed = subscriber.TotalSubscribed.Add(products[productType])
+		}
+		report.Subscribers = append(report.Subscribers, subscriber)
+	}
+
+	s.Logger.Info("subscription report generated",
+		zap.String("tax_year", year.String()),
+		zap.Int("subscribers", len(report.Subscribers)),
+		zap.Int("errors", len(report.Errors)),
+	)
+	return report, nil
+}
+
+// validateSubscriber lists the data HMRC requires that is missing for a subscriber. Only
+// Junior ISA subscribers can be reported without a National Insurance number
+func validateSubscriber(customer *model.Customer, products map[model.ProductType]money.Money) []model.ReportError {
+	var errs []model.ReportError
+	missing := func(field string) {
+		errs = append(errs, model.ReportError{
+			CustomerId: customer.Id,
+			Field:      field,
+			Message:    field + " is required",
+		})
+	}
+	if customer.Name == "" {
+		missing("name")
+	}
+	if customer.DateOfBirth.IsZero() {
+		missing("dateOfBirth")
+	}
+	onlyJunior := len(products) == 1 && products[model.JuniorISA].IsPositive()
+	if customer.NationalInsuranceNumber == "" && !onlyJunior {
+		missing("nationalInsuranceNumber")
+	}
+	return errs
+}
@@ -0,0 +1,99 @@
+package service_test
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-cmp/cmp/cmpopts"
+	"github.com/oliknight1/retail-isa-investment/investment-service/internal"
+	"github.com/oliknight1/retail-isa-investment/investment-service/logger"
+	"github.com/oliknight1/retail-isa-investment/investment-service/model"
+	"github.com/oliknight1/retail-isa-investment/investment-service/money"
+	"github.com/oliknight1/retail-isa-investment/investment-service/repository"
+	"github.com/oliknight1/retail-isa-investment/investment-service/service"
+	"github.com/oliknight1/retail-isa-investment/investment-service/taxyear"
+)
+
+func TestGenerateReport(t *testing.T) {
+	investments := repository.NewInvestmentClient()
+	for _, investment := range []model.Investment{
+		{Id: "1", AccountId: "acc-1", CustomerId: "cust-1", Amount: money.Pounds(5000), Status: "completed", TaxYear: 2024, AllowanceUse: model.AllowanceUse{Subscribed: money.Pounds(5000)}},
+		{Id: "2", AccountId: "acc-1", CustomerId: "cust-1", Amount: money.Pounds(1000), Status: "completed", TaxYear: 2024, AllowanceUse: model.AllowanceUse{Subscribed: money.Pounds(400), Replaced: money.Pounds(600)}},
+		{Id: "3", AccountId: "acc-lisa", CustomerId: "cust-1", Amount: money.Pounds(4000), Status: "pending", TaxYear: 2024, AllowanceUse: model.AllowanceUse{Subscribed: money.Pounds(4000)}},
+		{Id: "4", AccountId: "acc-1", CustomerId: "cust-1", Amount: money.Pounds(9999), Status: "failed", TaxYear: 2024, AllowanceUse: model.AllowanceUse{Subscribed: money.Pounds(9999)}},
+		{Id: "5", AccountId: "acc-1", CustomerId: "cust-1", Amount: money.Pounds(700), Status: "completed", TaxYear: 2024, Type: model.Withdrawal},
+		{Id: "6", AccountId: "acc-1", CustomerId: "cust-1", Amount: money.Pounds(3000), Status: "completed", TaxYear: 2023, AllowanceUse: model.AllowanceUse{Subscribed: money.Pounds(3000)}},
+		{Id: "7", AccountId: "acc-junior", CustomerId: "child-1", Amount: money.Pounds(250), Status: "completed", TaxYear: 2024, AllowanceUse: model.AllowanceUse{Subscribed: money.Pounds(250)}},
+		{Id: "8", AccountId: "acc-cash", CustomerId: "cust-2", Amount: money.Pounds(100), Status: "completed", TaxYear: 2024, AllowanceUse: model.AllowanceUse{Subscribed: money.Pounds(100)}},
+	} {
+		investments.CreateInvestment(investment)
+	}
+	accounts := newAccountRepo()
+	accounts.CreateAccount(model.Account{Id: "acc-cash", CustomerId: "cust-2", ProductType: model.CashISA, Status: model.AccountOpen})
+
+	customers := &mockCustomerClient{
+		getCustomerById: func(id string) (*model.Customer, error) {
+			switch id {
+			case "cust-1":
+				return &model.Customer{Id: id, Name: "Oli", DateOfBirth: model.NewDate(1990, 5, 21), NationalInsuranceNumber: "AB123456C"}, nil
+			case "child-1":
+				return &model.Customer{Id: id, Name: "Jane", DateOfBirth: model.NewDate(2015, 9, 1)}, nil
+			case "cust-2":
+				return &model.Customer{Id: id, DateOfBirth: model.NewDate(1985, 1, 1)}, nil
+			}
+			return nil, errors.New("not found")
+		},
+	}
+	svc := service.NewSubscriptionReportService(investments, accounts, customers, logger.NewMockLogger())
+
+	actual, err := svc.GenerateReport("2024-25")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	expectedSubscribers := []model.ReportSubscriber{
+		{
+			CustomerId:      "child-1",
+			Name:            "Jane",
+			DateOfBirth:     "2015-09-01",
+			Subscriptions:   []model.ReportSubscription{{ProductType: model.JuniorISA, Amount: money.Pounds(250)}},
+			TotalSubscribed: money.Pounds(250),
+		},
+		{
+			CustomerId:              "cust-1",
+			Name:                    "Oli",
+			NationalInsuranceNumber: "AB123456C",
+			DateOfBirth:             "1990-05-21",
+			Subscriptions: []model.ReportSubscription{
+				{ProductType: model.LifetimeISA, Amount: money.Pounds(4000)},
+				{ProductType: model.StocksAndSharesISA, Amount: money.Pounds(5400)},
+			},
+			TotalSubscribed: money.Pounds(9400),
+		},
+	}
+	if diff := cmp.Diff(expectedSubscribers, actual.Subscribers); diff != "" {
+		t.Errorf("unexpected subscribers (-want +got):\n%s", diff)
+	}
+
+	expectedErrors := []model.ReportError{
+		{CustomerId: "cust-2", Field: "name", Message: "name is required"},
+		{CustomerId: "cust-2", Field: "nationalInsuranceNumber", Message: "nationalInsuranceNumber is required"},
+	}
+	if diff := cmp.Diff(expectedErrors, actual.Errors, cmpopts.EquateEmpty()); diff != "" {
+		t.Errorf("unexpected validation errors (-want +got):\n%s", diff)
+	}
+}
+
+func TestGenerateReportInvalidTaxYear(t *testing.T) {
+	svc := service.NewSubscriptionReportService(repository.NewInvestmentClient(), newAccountRepo(), customersAged(30), logger.NewMockLogger())
+
+	if _, err := svc.GenerateReport("2024"); !errors.Is(err, internal.ErrInvalidTaxYear) {
+		t.Errorf("expected invalid tax year error, got: %v", err)
+	}
+	current := taxyear.For(time.Now()).String()
+	if _, err := svc.GenerateReport(current); !errors.Is(err, internal.ErrTaxYearNotEnded) {
+		t.Errorf("expected %s to be rejected as it has not ended, got: %v", current, err)
+	}
+}
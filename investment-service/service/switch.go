@@ -0,0 +1,259 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/oliknight1/retail-isa-investment/investment-service/client"
+	"github.com/oliknight1/retail-isa-investment/investment-service/event"
+	"github.com/oliknight1/retail-isa-investment/investment-service/internal"
+	"github.com/oliknight1/retail-isa-investment/investment-service/logger"
+	"github.com/oliknight1/retail-isa-investment/investment-service/model"
+	"github.com/oliknight1/retail-isa-investment/investment-service/product"
+	"github.com/oliknight1/retail-isa-investment/investment-service/repository"
+	"github.com/oliknight1/retail-isa-investment/investment-service/taxyear"
+	"go.uber.org/zap"
+)
+
+type SwitchService interface {
+	RequestSwitch(accountId string, fromFundId string, toFundId string, order model.RedemptionOrder) (*model.Switch, error)
+	GetSwitchById(id string) (*model.Switch, error)
+	GetSwitchesByAccountId(id string) (*[]model.Switch, error)
+	Progress(leg model.Investment) error
+}
+
+type SwitchServiceImpl struct {
+	repo        repository.SwitchRepository
+	investments repository.Repository
+	accounts    repository.AccountRepository
+	funds       client.FundClient
+	ledger      LedgerService
+	locks       *Locks
+	publisher   event.EventHandler
+	Logger      logger.Logger
+}
+
+func NewSwitchService(
+	repo repository.SwitchRepository,
+	investments repository.Repository,
+	accounts repository.AccountRepository,
+	funds client.FundClient,
+	ledger LedgerService,
+	locks *Locks,
+	publisher event.EventHandler,
+	logger logger.Logger,
+) *SwitchServiceImpl {
+	return &SwitchServiceImpl{
+		repo,
+		investments,
+		accounts,
+		funds,
+		ledger,
+		locks,
+		publisher,
+		logger,
+	}
+}
+
+// RequestSwitch places the sell leg of a switch from one fund to another. The order is checked
+// against the holding in the same way as a redemption, and the fund being bought must be
+// eligible for the account's product
+func (s *SwitchServiceImpl) RequestSwitch(accountId string, fromFundId string, toFundId string, order model.RedemptionOrder) (*model.Switch, error) {
+	if accountId == "" {
+		s.Logger.Error("missing account_id in switch request", zap.Error(internal.ErrMissingAccountId))
+		return nil, internal.ErrMissingAccountId
+	}
+	if fromFundId == "" || toFundId == "" {
+		s.Logger.Error("missing fund_id in switch request", zap.Error(internal.ErrMissingFundId))
+		return nil, internal.ErrMissingFundId
+	}
+	if fromFundId == toFundId {
+		s.Logger.Error("switch into the same fund", zap.String("fund_id", fromFundId))
+		return nil, fmt.Errorf("%w: cannot switch %s into itself", internal.ErrInvalidSwitch, fromFundId)
+	}
+
+	account, err := s.accounts.GetAccountById(accountId)
+	if err != nil {
+		s.Logger.Error("error fetching account for switch", zap.Error(err))
+		return nil, err
+	}
+	if account.Status != model.AccountOpen {
+		s.Logger.Error("switch in closed account", zap.String("account_id", accountId))
+		return nil, internal.ErrAccountClosed
+	}
+	rules, err := product.For(account.ProductType)
+	if err != nil {
+		s.Logger.Error("account has unknown product type", zap.Error(err))
+		return nil, err
+	}
+	if !rules.FundEligible(toFundId) {
+		s.Logger.Error("fund not eligible for account", zap.String("fund_id", toFundId), zap.String("product_type", string(account.ProductType)))
+		return nil, internal.ErrFundNotEligible
+	}
+
+	unlock := s.locks.accounts.Lock(account.Id)
+	defer unlock()
+	now := time.Now()
+	sale, err := newSale(s.investments, s.funds, *account, fromFundId, order, model.SwitchSell, now)
+	if err != nil {
+		s.Logger.Error("switch rejected", zap.String("account_id", accountId), zap.String("fund_id", fromFundId), zap.Error(err))
+		return nil, err
+	}
+	fundSwitch := model.Switch{
+		Id:         uuid.New().String(),
+		AccountId:  accountId,
+		CustomerId: account.CustomerId,
+		FromFundId: fromFundId,
+		ToFundId:   toFundId,
+		Order:      order,
+		SellId:     sale.Id,
+		Status:     model.SwitchSelling,
+		History:    []model.SwitchStatusChange{{Status: model.SwitchSelling, At: now}},
+		CreatedAt:  now,
+	}
+	sale.SwitchId = &fundSwitch.Id
+	if err := s.repo.CreateSwitch(fundSwitch); err != nil {
+		s.Logger.Error("error saving switch", zap.Error(err))
+		return nil, err
+	}
+	if err := recordStatusChanges(s.ledger, *sale, 0); err != nil {
+		s.Logger.Error("error posting switch sale to ledger", zap.Error(err))
+		return nil, errors.Join(err, s.fail(&fundSwitch, "sale could not be placed", now))
+	}
+	if err := s.investments.CreateInvestment(*sale); err != nil {
+		s.Logger.Error("error saving switch sale", zap.Error(err))
+		unrecord(s.ledger, s.Logger, *sale)
+		return nil, errors.Join(err, s.fail(&fundSwitch, "sale could not be placed", now))
+	}
+	s.publish(fundSwitch)
+	publishStatusChanges(s.publisher, s.Logger, *sale, 0)
+
+	return &fundSwitch, nil
+}
+
+// fail marks a switch whose sale could not be placed as failed, so it is not left selling
+func (s *SwitchServiceImpl) fail(fundSwitch *model.Switch, reason string, at time.Time) error {
+	fundSwitch.Status = model.SwitchFailed
+	fundSwitch.FailureReason = &reason
+	fundSwitch.History = append(fundSwitch.History, model.SwitchStatusChange{Status: model.SwitchFailed, At: at})
+	if err := s.repo.UpdateSwitch(*fundSwitch); err != nil {
+		s.Logger.Error("error saving failed switch", zap.String("switch_id", fundSwitch.Id), zap.Error(err))
+		return err
+	}
+	return nil
+}
+
+// Progress moves a switch on once one of its legs has been dealt or has failed. When the sale
+// is priced its proceeds are put into a purchase of the new fund, which the dealing run prices
+// at that fund's next valuation point. If that purchase cannot be placed the proceeds are
+// posted to the account's cash in the ledger and the switch fails, rather than leaving it
+// selling with the money in neither fund
+func (s *SwitchServiceImpl) Progress(leg model.Investment) error {
+	if leg.SwitchId == nil {
+		return nil
+	}
+	fundSwitch, err := s.repo.GetSwitchById(*leg.SwitchId)
+	if err != nil {
+		s.Logger.Error("error fetching switch", zap.Error(err))
+		return err
+	}
+	at := leg.History[len(leg.History)-1].At
+
+	var next model.SwitchStatus
+	switch {
+	case leg.Status == model.InvestmentFailed:
+		next = model.SwitchFailed
+		fundSwitch.FailureReason = leg.FailureReason
+	case leg.Status == model.InvestmentDealt && leg.Type == model.SwitchSell:
+		next = model.SwitchBuying
+		purchase, err := s.buy(*fundSwitch, leg, at)
+		if err != nil {
+			s.Logger.Error("error placing switch purchase, keeping proceeds as cash", zap.String("switch_id", fundSwitch.Id), zap.Error(err))
+			next = model.SwitchFailed
+			reason := fmt.Sprintf("purchase of %s could not be placed, proceeds kept as cash: %v", fundSwitch.ToFundId, err)
+			fundSwitch.FailureReason = &reason
+			fundSwitch.Uninvested = leg.Redemption.Proceeds
+			break
+		}
+		fundSwitch.BuyId = &purchase.Id
+	case leg.Status == model.InvestmentDealt && leg.Type == model.SwitchBuy:
+		next = model.SwitchCompleted
+		fundSwitch.CompletedAt = &at
+	default:
+		return nil
+	}
+	if !fundSwitch.Status.CanTransition(next) {
+		s.Logger.Error("invalid switch status change", zap.String("from", string(fundSwitch.Status)), zap.String("to", string(next)))
+		return fmt.Errorf("%w: %s to %s", internal.ErrInvalidTransition, fundSwitch.Status, next)
+	}
+
+	fundSwitch.Status = next
+	fundSwitch.History = append(fundSwitch.History, model.SwitchStatusChange{Status: next, At: at})
+	if err := s.ledger.RecordSwitch(*fundSwitch); err != nil {
+		s.Logger.Error("error crediting switch proceeds to cash", zap.String("switch_id", fundSwitch.Id), zap.Error(err))
+		return err
+	}
+	if err := s.repo.UpdateSwitch(*fundSwitch); err != nil {
+		s.Logger.Error("error saving switch", zap.Error(err))
+		return err
+	}
+	s.publish(*fundSwitch)
+	return nil
+}
+
+// buy places the purchase leg of a switch with the proceeds of its sale
+func (s *SwitchServiceImpl) buy(fundSwitch model.Switch, sale model.Investment, at time.Time) (*model.Investment, error) {
+	purchase := model.Investment{
+		Id:         uuid.New().String(),
+		AccountId:  fundSwitch.AccountId,
+		CustomerId: fundSwitch.CustomerId,
+		FundId:     fundSwitch.ToFundId,
+		Type:       model.SwitchBuy,
+		Amount:     sale.Redemption.Proceeds,
+		TaxYear:    taxyear.For(at),
+		SwitchId:   &fundSwitch.Id,
+		CreatedAt:  at,
+	}
+	if err := errors.Join(
+		transition(&purchase, model.InvestmentPending, "switch sale priced", model.ActorDealingRun, at),
+		transition(&purchase, model.InvestmentValidated, "buying with switch sale proceeds", model.ActorSystem, at),
+	); err != nil {
+		return nil, err
+	}
+	if err := recordStatusChanges(s.ledger, purchase, 0); err != nil {
+		s.Logger.Error("error posting switch purchase to ledger", zap.Error(err))
+		return nil, err
+	}
+	if err := s.investments.CreateInvestment(purchase); err != nil {
+		s.Logger.Error("error saving switch purchase", zap.Error(err))
+		unrecord(s.ledger, s.Logger, purchase)
+		return nil, err
+	}
+	publishStatusChanges(s.publisher, s.Logger, purchase, 0)
+	return &purchase, nil
+}
+
+func (s *SwitchServiceImpl) publish(fundSwitch model.Switch) {
+	subject := "investment.switch." + string(fundSwitch.Status)
+	if err := s.publisher.Publish(subject, fundSwitch); err != nil {
+		s.Logger.Error("error publishing switch event", zap.String("subject", subject), zap.Error(err))
+	}
+}
+
+func (s *SwitchServiceImpl) GetSwitchById(id string) (*model.Switch, error) {
+	if id == "" {
+		s.Logger.Error("missing switch_id when requesting switch", zap.Error(internal.ErrSwitchNotFound))
+		return nil, internal.ErrSwitchNotFound
+	}
+	return s.repo.GetSwitchById(id)
+}
+
+func (s *SwitchServiceImpl) GetSwitchesByAccountId(id string) (*[]model.Switch, error) {
+	if id == "" {
+		s.Logger.Error("missing account_id when requesting switches", zap.Error(internal.ErrMissingAccountId))
+		return nil, internal.ErrMissingAccountId
+	}
+	return s.repo.GetSwitchesByAccountId(id)
+}
@@ -0,0 +1,216 @@
+package service_test
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/oliknight1/retail-isa-investment/investment-service/dealing"
+	"github.com/oliknight1/retail-isa-investment/investment-service/internal"
+	"github.com/oliknight1/retail-isa-investment/investment-service/logger"
+	"github.com/oliknight1/retail-isa-investment/investment-service/model"
+	"github.com/oliknight1/retail-isa-investment/investment-service/money"
+	"github.com/oliknight1/retail-isa-investment/investment-service/repository"
+	"github.com/oliknight1/retail-isa-investment/investment-service/service"
+)
+
+func TestSwitch(t *testing.T) {
+	logger := logger.NewMockLogger()
+	repo := repository.NewInvestmentClient()
+	accounts := newAccountRepo()
+	repo.CreateInvestment(model.Investment{
+		Id:         "inv-bought",
+		AccountId:  "acc-1",
+		CustomerId: "cust-1",
+		FundId:     "fund-1",
+		Type:       model.Subscription,
+		Amount:     money.Pounds(1000),
+		Status:     model.InvestmentSettled,
+		Dealing:    &model.Dealing{Units: 5_000_000},
+		CreatedAt:  time.Now().AddDate(0, -1, 0),
+	})
+	var published []string
+	mockPub := &mockPublisher{
+		publishFn: func(subject string, payload any) error {
+			published = append(published, subject)
+			return nil
+		},
+	}
+	funds := fundPriced("2.000000")
+	ledger := newLedger(logger)
+	locks := service.NewLocks()
+	switches := service.NewSwitchService(repository.NewSwitchClient(), repo, accounts, funds, ledger, locks, mockPub, logger)
+	dealingSvc := service.NewDealingService(repo, ledger, switches, funds, dealing.DefaultSchedules(), locks, mockPub, logger)
+
+	if _, err := switches.RequestSwitch("acc-1", "fund-1", "fund-1", model.RedemptionOrder{All: true}); !errors.Is(err, internal.ErrInvalidSwitch) {
+		t.Errorf("expected a switch into the same fund to be rejected, got: %v", err)
+	}
+	if _, err := switches.RequestSwitch("acc-cash", "fund-1", "fund-2", model.RedemptionOrder{All: true}); !errors.Is(err, internal.ErrFundNotEligible) {
+		t.Errorf("expected a switch into an ineligible fund to be rejected, got: %v", err)
+	}
+	if _, err := switches.RequestSwitch("acc-1", "fund-1", "fund-2", model.RedemptionOrder{Units: 6_000_000}); !errors.Is(err, internal.ErrInsufficientHolding) {
+		t.Errorf("expected a switch of more than the holding to be rejected, got: %v", err)
+	}
+
+	fundSwitch, err := switches.RequestSwitch("acc-1", "fund-1", "fund-2", model.RedemptionOrder{Amount: money.Pounds(400)})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if fundSwitch.Status != model.SwitchSelling {
+		t.Errorf("expected switch to be selling, got %s", fundSwitch.Status)
+	}
+
+	// the sale is priced first, then its proceeds buy the new fund at the following valuation point
+	now := time.Now().AddDate(0, 0, 7)
+	if _, err := dealingSvc.Run(now); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	fundSwitch, _ = switches.GetSwitchById(fundSwitch.Id)
+	if fundSwitch.Status != model.SwitchBuying || fundSwitch.BuyId == nil {
+		t.Fatalf("expected switch to be buying once the sale is priced, got %+v", fundSwitch)
+	}
+	purchase, _ := repo.GetInvestmentById(*fundSwitch.BuyId)
+	if purchase.Type != model.SwitchBuy || purchase.FundId != "fund-2" || !purchase.Amount.Equal(money.Pounds(400)) {
+		t.Errorf("expected a purchase of fund-2 with the sale proceeds, got %+v", purchase)
+	}
+
+	if _, err := dealingSvc.Run(now.AddDate(0, 0, 7)); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	fundSwitch, _ = switches.GetSwitchById(fundSwitch.Id)
+	if fundSwitch.Status != model.SwitchCompleted || fundSwitch.CompletedAt == nil || len(fundSwitch.History) != 3 {
+		t.Errorf("expected switch to be completed with full history, got %+v", fundSwitch)
+	}
+	purchase, _ = repo.GetInvestmentById(*fundSwitch.BuyId)
+	if purchase.Status != model.InvestmentDealt || purchase.Dealing.Units.String() != "200.0000" {
+		t.Errorf("expected 200 units of fund-2 to be bought, got %+v", purchase.Dealing)
+	}
+
+	var switchEvents []string
+	for _, subject := range published {
+		if subject == "investment.switch.selling" || subject == "investment.switch.buying" || subject == "investment.switch.completed" ||
+			subject == "investment.dealt" || subject == "investment.redemption.dealt" {
+			switchEvents = append(switchEvents, subject)
+		}
+	}
+	expected := []string{"investment.switch.selling", "investment.switch.buying", "investment.switch.completed"}
+	if diff := cmp.Diff(expected, switchEvents); diff != "" {
+		t.Errorf("unexpected events (-want +got):\n%s", diff)
+	}
+
+	if !purchase.AllowanceUse.Subscribed.IsZero() || !purchase.AllowanceUse.Replaced.IsZero() {
+		t.Errorf("expected a switch not to use allowance, got %+v", purchase.AllowanceUse)
+	}
+	if cash := cashIn(t, ledger, "acc-1"); !cash.Balance.IsZero() {
+		t.Errorf("expected switch proceeds not to be credited to cash, got %s", cash.Balance)
+	}
+}
+
+// failingPurchases cannot save the purchase leg of a switch
+type failingPurchases struct {
+	*repository.InvestmentClient
+}
+
+func (f failingPurchases) CreateInvestment(investment model.Investment) error {
+	if investment.Type == model.SwitchBuy {
+		return errors.New("database unavailable")
+	}
+	return f.InvestmentClient.CreateInvestment(investment)
+}
+
+func TestSwitchPurchaseFails(t *testing.T) {
+	logger := logger.NewMockLogger()
+	repo := failingPurchases{repository.NewInvestmentClient()}
+	accounts := newAccountRepo()
+	repo.CreateInvestment(model.Investment{
+		Id:         "inv-bought",
+		AccountId:  "acc-1",
+		CustomerId: "cust-1",
+		FundId:     "fund-1",
+		Type:       model.Subscription,
+		Amount:     money.Pounds(1000),
+		Status:     model.InvestmentSettled,
+		Dealing:    &model.Dealing{Units: 5_000_000},
+		CreatedAt:  time.Now().AddDate(0, -1, 0),
+	})
+	ledgerRepo := repository.NewLedgerClient()
+	nothing := &mockPublisher{publishFn: func(string, any) error { return nil }}
+	ledger := service.NewLedgerService(ledgerRepo, nothing, logger)
+	funds := fundPriced("2.000000")
+	locks := service.NewLocks()
+	switches := service.NewSwitchService(repository.NewSwitchClient(), repo, accounts, funds, ledger, locks, nothing, logger)
+	dealingSvc := service.NewDealingService(repo, ledger, switches, funds, dealing.DefaultSchedules(), locks, nothing, logger)
+
+	fundSwitch, err := switches.RequestSwitch("acc-1", "fund-1", "fund-2", model.RedemptionOrder{Amount: money.Pounds(400)})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if _, err := dealingSvc.Run(time.Now().AddDate(0, 0, 7)); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	fundSwitch, _ = switches.GetSwitchById(fundSwitch.Id)
+	if fundSwitch.Status != model.SwitchFailed || fundSwitch.FailureReason == nil || fundSwitch.BuyId != nil {
+		t.Fatalf("expected the switch to fail when its purchase cannot be placed, got %+v", fundSwitch)
+	}
+	if !fundSwitch.Uninvested.Equal(money.Pounds(400)) {
+		t.Errorf("expected 400.00 uninvested, got %s", fundSwitch.Uninvested)
+	}
+	if cash := cashIn(t, ledger, "acc-1"); !cash.Available.Equal(money.Pounds(400)) {
+		t.Errorf("expected the sale proceeds to be credited to cash, got %s", cash.Available)
+	}
+	entries, _ := ledgerRepo.GetEntriesByAccountId("acc-1")
+	balances := make(map[model.LedgerAccountKind]money.Money)
+	for _, entry := range *entries {
+		for _, posting := range entry.Postings {
+			balances[posting.Account.Kind] = balances[posting.Account.Kind].Add(posting.Signed())
+		}
+	}
+	if !balances[model.LedgerSwitching].IsZero() {
+		t.Errorf("expected the ledger to move the proceeds out of switching, got %+v", balances)
+	}
+}
+
+// failingSales cannot save the sell leg of a switch
+type failingSales struct {
+	*repository.InvestmentClient
+}
+
+func (f failingSales) CreateInvestment(investment model.Investment) error {
+	if investment.Type == model.SwitchSell {
+		return errors.New("database unavailable")
+	}
+	return f.InvestmentClient.CreateInvestment(investment)
+}
+
+func TestSwitchSaleFails(t *testing.T) {
+	logger := logger.NewMockLogger()
+	repo := failingSales{repository.NewInvestmentClient()}
+	repo.CreateInvestment(model.Investment{
+		Id:         "inv-bought",
+		AccountId:  "acc-1",
+		CustomerId: "cust-1",
+		FundId:     "fund-1",
+		Type:       model.Subscription,
+		Amount:     money.Pounds(1000),
+		Status:     model.InvestmentSettled,
+		Dealing:    &model.Dealing{Units: 5_000_000},
+		CreatedAt:  time.Now().AddDate(0, -1, 0),
+	})
+	nothing := &mockPublisher{publishFn: func(string, any) error { return nil }}
+	switches := service.NewSwitchService(repository.NewSwitchClient(), repo, newAccountRepo(), fundPriced("2.000000"), newLedger(logger), service.NewLocks(), nothing, logger)
+
+	if _, err := switches.RequestSwitch("acc-1", "fund-1", "fund-2", model.RedemptionOrder{Amount: money.Pounds(400)}); err == nil {
+		t.Fatal("expected an error when the sale cannot be saved")
+	}
+	saved, err := switches.GetSwitchesByAccountId("acc-1")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	for _, fundSwitch := range *saved {
+		if fundSwitch.Status != model.SwitchFailed || fundSwitch.FailureReason == nil {
+			t.Errorf("expected the switch not to be left selling, got %+v", fundSwitch)
+		}
+	}
+}
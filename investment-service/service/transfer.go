@@ -0,0 +1,529 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/oliknight1/retail-isa-investment/investment-service/client"
+	"github.com/oliknight1/retail-isa-investment/investment-service/event"
+	"github.com/oliknight1/retail-isa-investment/investment-service/internal"
+	"github.com/oliknight1/retail-isa-investment/investment-service/logger"
+	"github.com/oliknight1/retail-isa-investment/investment-service/model"
+	"github.com/oliknight1/retail-isa-investment/investment-service/product"
+	"github.com/oliknight1/retail-isa-investment/investment-service/repository"
+	"github.com/oliknight1/retail-isa-investment/investment-service/taxyear"
+	"go.uber.org/zap"
+)
+
+type TransferService interface {
+	RequestTransfer(details model.Transfer) (*model.Transfer, error)
+	UpdateTransferStatus(id string, status model.TransferStatus, reason string) (*model.Transfer, error)
+	GetTransferById(string) (*model.Transfer, error)
+	GetTransfersByAccountId(string) (*[]model.Transfer, error)
+}
+
+type TransferServiceImpl struct {
+	repo        repository.TransferRepository
+	accounts    repository.AccountRepository
+	investments repository.Repository
+	funds       client.FundClient
+	allowance   AllowanceService
+	ledger      LedgerService
+	locks       *Locks
+	publisher   event.EventHandler
+	Logger      logger.Logger
+	transfers   keyedMutex
+}
+
+func NewTransferService(
+	repo repository.TransferRepository,
+	accounts repository.AccountRepository,
+	investments repository.Repository,
+	funds client.FundClient,
+	allowance AllowanceService,
+	ledger LedgerService,
+	locks *Locks,
+	publisher event.EventHandler,
+	logger logger.Logger,
+) *TransferServiceImpl {
+	return &TransferServiceImpl{
+		repo:        repo,
+		accounts:    accounts,
+		investments: investments,
+		funds:       funds,
+		allowance:   allowance,
+		ledger:      ledger,
+		locks:       locks,
+		publisher:   publisher,
+		Logger:      logger,
+	}
+}
+
+// RequestTransfer starts moving an ISA to or from another provider. The account, direction,
+// method, provider, amounts and holdings are taken from details and everything else is assigned
+// here
+func (s *TransferServiceImpl) RequestTransfer(details model.Transfer) (*model.Transfer, error) {
+	if details.AccountId == "" {
+		s.Logger.Error("missing account_id in transfer request", zap.Error(internal.ErrMissingAccountId))
+		return nil, internal.ErrMissingAccountId
+	}
+	if err := validateTransfer(details); err != nil {
+		s.Logger.Error("invalid transfer request", zap.Error(err))
+		return nil, err
+	}
+
+	account, err := s.accounts.GetAccountById(details.AccountId)
+	if err != nil {
+		s.Logger.Error("error fetching account for transfer", zap.Error(err))
+		return nil, err
+	}
+	if account.Status != model.AccountOpen {
+		s.Logger.Error("transfer for closed account", zap.String("account_id", account.Id))
+		return nil, internal.ErrAccountClosed
+	}
+
+	now := time.Now()
+	transfer := model.Transfer{
+		Id:                uuid.New().String(),
+		AccountId:         account.Id,
+		CustomerId:        account.CustomerId,
+		Direction:         details.Direction,
+		Method:            details.Method,
+		Provider:          details.Provider,
+		TaxYear:           taxyear.For(now),
+		CurrentYearAmount: details.CurrentYearAmount,
+		PriorYearAmount:   details.PriorYearAmount,
+		Holdings:          details.Holdings,
+		Status:            model.TransferRequested,
+		History:           []model.TransferStatusChange{{Status: model.TransferRequested, At: now}},
+		CreatedAt:         now,
+	}
+	if transfer.Direction == model.TransferOut {
+		unlock := s.locks.accounts.Lock(account.Id)
+		defer unlock()
+		if err := s.setAside(transfer, now); err != nil {
+			s.Logger.Error("transfer out rejected", zap.String("account_id", account.Id), zap.Error(err))
+			return nil, err
+		}
+	}
+	if err := s.repo.CreateTransfer(transfer); err != nil {
+		s.Logger.Error("error saving transfer", zap.Error(err))
+		if transfer.Direction == model.TransferOut {
+			if err := s.release(transfer, "transfer could not be saved", now); err != nil {
+				s.Logger.Error("error releasing what was set aside for failed transfer", zap.Error(err))
+			}
+		}
+		return nil, err
+	}
+	s.publish(transfer)
+
+	return &transfer, nil
+}
+
+// setAside sets aside what a transfer out moves until it completes, with a validated
+// transfer_out investment for its cash, which the ledger reserves from the account's available
+// cash, or for each fund it moves once every holding has been checked against the units the
+// account has free to sell
+func (s *TransferServiceImpl) setAside(transfer model.Transfer, now time.Time) error {
+	reason := "cash reserved"
+	var legs []model.Investment
+	if transfer.Method == model.TransferCash {
+		leg := transferLeg(transfer, "", now)
+		leg.Amount = transfer.Amount()
+		legs = append(legs, leg)
+	} else {
+		reason = "holding checks passed"
+		for _, holding := range transfer.Holdings {
+			held, err := heldUnits(s.investments, transfer.AccountId, holding.FundId)
+			if err != nil {
+				return err
+			}
+			reserved, err := reservedUnits(s.investments, transfer.AccountId, holding.FundId)
+			if err != nil {
+				return err
+			}
+			if available := max(held-reserved, 0); holding.Units > available {
+				return internal.InsufficientHoldingError(fmt.Sprintf("%s units of %s", available, holding.FundId))
+			}
+			leg := transferLeg(transfer, holding.FundId, now)
+			leg.Redemption = &model.RedemptionDetails{
+				RedemptionOrder: model.RedemptionOrder{Units: holding.Units},
+				Reserved:        holding.Units,
+			}
+			legs = append(legs, leg)
+		}
+	}
+
+	for _, leg := range legs {
+		if err := errors.Join(
+			transition(&leg, model.InvestmentPending, string(transfer.Method)+" transfer out to "+transfer.Provider+" requested", model.ActorCustomer, now),
+			transition(&leg, model.InvestmentValidated, reason, model.ActorSystem, now),
+		); err != nil {
+			return err
+		}
+		if err := recordStatusChanges(s.ledger, leg, 0); err != nil {
+			return errors.Join(err, s.release(transfer, "transfer could not be requested", now))
+		}
+		if err := s.investments.CreateInvestment(leg); err != nil {
+			unrecord(s.ledger, s.Logger, leg)
+			return errors.Join(err, s.release(transfer, "transfer could not be requested", now))
+		}
+		publishStatusChanges(s.publisher, s.Logger, leg, 0)
+	}
+	return nil
+}
+
+// transferLeg is a transfer_out investment for the part of a transfer in fundId, or its cash
+// when fundId is empty
+func transferLeg(transfer model.Transfer, fundId string, now time.Time) model.Investment {
+	return model.Investment{
+		Id:         uuid.New().String(),
+		AccountId:  transfer.AccountId,
+		CustomerId: transfer.CustomerId,
+		FundId:     fundId,
+		Type:       model.TransferredOut,
+		TaxYear:    taxyear.For(now),
+		TransferId: &transfer.Id,
+		CreatedAt:  now,
+	}
+}
+
+// release fails the investments of a transfer out that is rejected or could not be saved, which
+// frees the cash or units they set aside
+func (s *TransferServiceImpl) release(transfer model.Transfer, reason string, now time.Time) error {
+	legs, err := s.legs(transfer)
+	if err != nil {
+		return err
+	}
+	var errs []error
+	for _, leg := range legs {
+		if leg.Status != model.InvestmentValidated {
+			continue
+		}
+		since := len(leg.History)
+		if err := transition(&leg, model.InvestmentFailed, reason, model.ActorSystem, now); err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		leg.FailureReason = &reason
+		if err := recordStatusChanges(s.ledger, leg, since); err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		if err := s.investments.UpdateInvestment(leg); err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		publishStatusChanges(s.publisher, s.Logger, leg, since)
+	}
+	return errors.Join(errs...)
+}
+
+// legs returns the investments recorded for a transfer
+func (s *TransferServiceImpl) legs(transfer model.Transfer) ([]model.Investment, error) {
+	investments, err := s.investments.GetInvestmentsByAccountId(transfer.AccountId)
+	if err != nil {
+		return nil, err
+	}
+	var legs []model.Investment
+	for _, investment := range *investments {
+		if investment.TransferId != nil && *investment.TransferId == transfer.Id {
+			legs = append(legs, investment)
+		}
+	}
+	return legs, nil
+}
+
+func validateTransfer(details model.Transfer) error {
+	if details.Direction != model.TransferIn && details.Direction != model.TransferOut {
+		return fmt.Errorf("%w: direction must be %q or %q", internal.ErrInvalidTransfer, model.TransferIn, model.TransferOut)
+	}
+	if details.Method != model.TransferCash && details.Method != model.TransferStock {
+		return fmt.Errorf("%w: method must be %q or %q", internal.ErrInvalidTransfer, model.TransferCash, model.TransferStock)
+	}
+	if details.Provider == "" {
+		return fmt.Errorf("%w: provider is required", internal.ErrInvalidTransfer)
+	}
+	if details.CurrentYearAmount.IsNegative() || details.PriorYearAmount.IsNegative() {
+		return fmt.Errorf("%w: amounts cannot be negative", internal.ErrInvalidTransfer)
+	}
+	if details.Amount().IsZero() {
+		return internal.ErrZeroTransactionAmount
+	}
+	if details.Method == model.TransferCash && len(details.Holdings) > 0 {
+		return fmt.Errorf("%w: a cash transfer moves no holdings", internal.ErrInvalidTransfer)
+	}
+	if details.Method == model.TransferStock && len(details.Holdings) == 0 {
+		return fmt.Errorf("%w: a stock transfer must list the holdings it moves", internal.ErrInvalidTransfer)
+	}
+	funds := make(map[string]bool)
+	for _, holding := range details.Holdings {
+		if holding.FundId == "" || funds[holding.FundId] {
+			return fmt.Errorf("%w: each holding must be a different fund", internal.ErrInvalidTransfer)
+		}
+		if holding.Units <= 0 || holding.BookCost.IsNegative() {
+			return fmt.Errorf("%w: holdings need positive units and a book cost that is not negative", internal.ErrInvalidTransfer)
+		}
+		funds[holding.FundId] = true
+	}
+	return nil
+}
+
+// UpdateTransferStatus moves a transfer on to its next status. A reason is only kept when
+// the transfer is rejected. Completing a transfer records the money moving in or out of
+// the account against the customer's investments. Changes to the same transfer are made one
+// at a time, so it can only be completed once
+func (s *TransferServiceImpl) UpdateTransferStatus(id string, status model.TransferStatus, reason string) (*model.Transfer, error) {
+	unlock := s.transfers.Lock(id)
+	defer unlock()
+
+	transfer, err := s.repo.GetTransferById(id)
+	if err != nil {
+		s.Logger.Error("error fetching transfer", zap.Error(err))
+		return nil, err
+	}
+	if !transfer.Status.CanTransition(status) {
+		s.Logger.Error("invalid transfer status change", zap.String("from", string(transfer.Status)), zap.String("to", string(status)))
+		return nil, fmt.Errorf("%w: %s to %s", internal.ErrInvalidTransition, transfer.Status, status)
+	}
+
+	now := time.Now()
+	if status == model.TransferCompleted {
+		if err := s.complete(*transfer, now); err != nil {
+			return nil, err
+		}
+		transfer.CompletedAt = &now
+	}
+	if status == model.TransferRejected && transfer.Direction == model.TransferOut {
+		if err := s.release(*transfer, "transfer rejected", now); err != nil {
+			s.Logger.Error("error releasing what was set aside for transfer", zap.String("transfer_id", id), zap.Error(err))
+			return nil, err
+		}
+	}
+	if status == model.TransferRejected && reason != "" {
+		transfer.RejectionReason = &reason
+	}
+	transfer.Status = status
+	transfer.History = append(transfer.History, model.TransferStatusChange{Status: status, At: now})
+	if err := s.repo.UpdateTransfer(*transfer); err != nil {
+		s.Logger.Error("error saving transfer", zap.Error(err))
+		return nil, err
+	}
+	s.publish(*transfer)
+
+	return transfer, nil
+}
+
+// complete records the transferred money on the account. Current year subscriptions moving in
+// are added to the allowance used, unless the tax year has ended since the transfer was
+// requested, in which case they have become prior year money. Cash moves in and out of the
+// account's cash, while stock moves units of each fund it lists. The allowance is only counted
+// once every investment has been recorded, and both skip what an earlier attempt recorded, so a
+// completion that fails part way through can be retried
+func (s *TransferServiceImpl) complete(transfer model.Transfer, now time.Time) error {
+	account, err := s.accounts.GetAccountById(transfer.AccountId)
+	if err != nil {
+		s.Logger.Error("error fetching account for transfer", zap.Error(err))
+		return err
+	}
+	rules, err := product.For(account.ProductType)
+	if err != nil {
+		s.Logger.Error("account has unknown product type", zap.Error(err))
+		return err
+	}
+	prices := make(map[string]model.FundPrice)
+	for _, holding := range transfer.Holdings {
+		price, err := s.funds.GetLatestPrice(holding.FundId)
+		if err != nil {
+			s.Logger.Error("error pricing transferred holding", zap.String("fund_id", holding.FundId), zap.Error(err))
+			return err
+		}
+		prices[holding.FundId] = *price
+	}
+
+	if transfer.Direction == model.TransferOut {
+		return s.completeOut(transfer, prices, now)
+	}
+
+	var use model.AllowanceUse
+	taxYear := taxyear.For(now)
+	if transfer.CurrentYearAmount.IsPositive() && taxYear == transfer.TaxYear {
+		use.Subscribed = transfer.CurrentYearAmount
+	}
+	recorded, err := s.legs(transfer)
+	if err != nil {
+		s.Logger.Error("error fetching investments for transfer", zap.Error(err))
+		return err
+	}
+	if transfer.Method == model.TransferCash {
+		err = s.completeCashIn(transfer, use, recorded, now)
+	} else {
+		err = s.completeStockIn(transfer, use, recorded, prices, now)
+	}
+	if err != nil {
+		return err
+	}
+	if use.Subscribed.IsPositive() {
+		if err := s.allowance.TransferIn(transfer.CustomerId, transfer.Id, taxYear, rules, use.Subscribed); err != nil {
+			s.Logger.Error("error recording transfer against allowance", zap.Error(err))
+			return err
+		}
+	}
+	return nil
+}
+
+// completeCashIn records a cash transfer in as one investment for its amount, credited to the
+// account's cash, unless it was recorded by an earlier attempt
+func (s *TransferServiceImpl) completeCashIn(transfer model.Transfer, use model.AllowanceUse, recorded []model.Investment, now time.Time) error {
+	if len(recorded) > 0 {
+		return nil
+	}
+	investment := model.Investment{
+		Id:           uuid.New().String(),
+		AccountId:    transfer.AccountId,
+		CustomerId:   transfer.CustomerId,
+		Type:         model.TransferredIn,
+		Amount:       transfer.Amount(),
+		TaxYear:      taxyear.For(now),
+		AllowanceUse: use,
+		TransferId:   &transfer.Id,
+		CreatedAt:    now,
+	}
+	if err := transition(&investment, model.InvestmentSettled, "transfer completed", model.ActorSystem, now); err != nil {
+		return err
+	}
+	if err := recordStatusChanges(s.ledger, investment, 0); err != nil {
+		s.Logger.Error("error crediting transferred cash", zap.String("transfer_id", transfer.Id), zap.Error(err))
+		return err
+	}
+	if err := s.investments.CreateInvestment(investment); err != nil {
+		s.Logger.Error("error saving transferred investment", zap.Error(err))
+		unrecord(s.ledger, s.Logger, investment)
+		return err
+	}
+	publishStatusChanges(s.publisher, s.Logger, investment, 0)
+	return nil
+}
+
+// completeStockIn adds the units of each fund re-registered to us, carrying over their book cost
+// from the ceding provider. Any current year subscription is recorded against the first. Funds
+// already recorded by an earlier attempt are skipped
+func (s *TransferServiceImpl) completeStockIn(transfer model.Transfer, use model.AllowanceUse, recorded []model.Investment, prices map[string]model.FundPrice, now time.Time) error {
+	done := make(map[string]bool)
+	for _, leg := range recorded {
+		done[leg.FundId] = true
+	}
+	for _, holding := range transfer.Holdings {
+		legUse := use
+		use = model.AllowanceUse{}
+		if done[holding.FundId] {
+			continue
+		}
+		price := prices[holding.FundId]
+		leg := model.Investment{
+			Id:           uuid.New().String(),
+			AccountId:    transfer.AccountId,
+			CustomerId:   transfer.CustomerId,
+			FundId:       holding.FundId,
+			Type:         model.TransferredIn,
+			Amount:       holding.BookCost,
+			TaxYear:      taxyear.For(now),
+			AllowanceUse: legUse,
+			TransferId:   &transfer.Id,
+			Dealing: &model.Dealing{
+				Price:    price.SellPrice(),
+				Units:    holding.Units,
+				ValuedAt: price.ValuedAt,
+				DealtAt:  now,
+			},
+			CreatedAt: now,
+		}
+		if err := errors.Join(
+			transition(&leg, model.InvestmentDealt, "units re-registered from "+transfer.Provider, model.ActorSystem, now),
+			transition(&leg, model.InvestmentSettled, "transfer completed", model.ActorSystem, now),
+		); err != nil {
+			return err
+		}
+		if err := recordStatusChanges(s.ledger, leg, 0); err != nil {
+			s.Logger.Error("error posting transferred holding", zap.String("fund_id", holding.FundId), zap.Error(err))
+			return err
+		}
+		if err := s.investments.CreateInvestment(leg); err != nil {
+			s.Logger.Error("error saving transferred holding", zap.String("fund_id", holding.FundId), zap.Error(err))
+			unrecord(s.ledger, s.Logger, leg)
+			return err
+		}
+		publishStatusChanges(s.publisher, s.Logger, leg, 0)
+	}
+	return nil
+}
+
+// completeOut pays out the cash reserved for a transfer out, or takes out the units set aside
+// for each fund valued at the fund's latest price
+func (s *TransferServiceImpl) completeOut(transfer model.Transfer, prices map[string]model.FundPrice, now time.Time) error {
+	legs, err := s.legs(transfer)
+	if err != nil {
+		s.Logger.Error("error fetching investments for transfer", zap.Error(err))
+		return err
+	}
+	for _, leg := range legs {
+		if leg.Status != model.InvestmentValidated {
+			continue
+		}
+		since := len(leg.History)
+		if leg.FundId == "" {
+			err = transition(&leg, model.InvestmentSettled, "transfer completed", model.ActorSystem, now)
+		} else {
+			price := prices[leg.FundId]
+			leg.Dealing = &model.Dealing{
+				Price:    price.SellPrice(),
+				Units:    leg.Redemption.Reserved,
+				ValuedAt: price.ValuedAt,
+				DealtAt:  now,
+			}
+			leg.Amount = leg.Dealing.Units.Value(leg.Dealing.Price)
+			err = errors.Join(
+				transition(&leg, model.InvestmentDealt, "units re-registered to "+transfer.Provider, model.ActorSystem, now),
+				transition(&leg, model.InvestmentSettled, "transfer completed", model.ActorSystem, now),
+			)
+		}
+		if err != nil {
+			return err
+		}
+		if err := recordStatusChanges(s.ledger, leg, since); err != nil {
+			s.Logger.Error("error posting transferred holding", zap.String("investment_id", leg.Id), zap.Error(err))
+			return err
+		}
+		if err := s.investments.UpdateInvestment(leg); err != nil {
+			s.Logger.Error("error saving transferred holding", zap.String("investment_id", leg.Id), zap.Error(err))
+			return err
+		}
+		publishStatusChanges(s.publisher, s.Logger, leg, since)
+	}
+	return nil
+}
+
+func (s *TransferServiceImpl) publish(transfer model.Transfer) {
+	subject := "isa.transfer." + string(transfer.Status)
+	if err := s.publisher.Publish(subject, transfer); err != nil {
+		s.Logger.Error("error publishing transfer event", zap.String("subject", subject), zap.Error(err))
+	}
+}
+
+func (s *TransferServiceImpl) GetTransferById(id string) (*model.Transfer, error) {
+	if id == "" {
+		s.Logger.Error("missing transfer_id when requesting transfer", zap.Error(internal.ErrTransferNotFound))
+		return nil, internal.ErrTransferNotFound
+	}
+	return s.repo.GetTransferById(id)
+}
+
+func (s *TransferServiceImpl) GetTransfersByAccountId(id string) (*[]model.Transfer, error) {
+	if id == "" {
+		s.Logger.Error("missing account_id when requesting transfers", zap.Error(internal.ErrMissingAccountId))
+		return nil, internal.ErrMissingAccountId
+	}
+	return s.repo.GetTransfersByAccountId(id)
+}
@@ -0,0 +1,447 @@
+package service_test
+
+import (
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/oliknight1/retail-isa-investment/investment-service/internal"
+	"github.com/oliknight1/retail-isa-investment/investment-service/logger"
+	"github.com/oliknight1/retail-isa-investment/investment-service/model"
+	"github.com/oliknight1/retail-isa-investment/investment-service/money"
+	"github.com/oliknight1/retail-isa-investment/investment-service/repository"
+	"github.com/oliknight1/retail-isa-investment/investment-service/service"
+)
+
+func TestTransferIn(t *testing.T) {
+	var published []string
+	mockPub := &mockPublisher{
+		publishFn: func(subject string, payload any) error {
+			published = append(published, subject)
+			return nil
+		},
+	}
+	logger := logger.NewMockLogger()
+	investments := repository.NewInvestmentClient()
+	allowance := newAllowanceService(logger)
+	ledger := newLedger(logger)
+	svc := service.NewTransferService(repository.NewTransferClient(), newAccountRepo(), investments, nil, allowance, ledger, service.NewLocks(), mockPub, logger)
+
+	transfer, err := svc.RequestTransfer(model.Transfer{
+		AccountId:         "acc-1",
+		Direction:         model.TransferIn,
+		Method:            model.TransferCash,
+		Provider:          "Other Bank",
+		CurrentYearAmount: money.Pounds(3000),
+		PriorYearAmount:   money.Pounds(10000),
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if transfer.Status != model.TransferRequested || transfer.CustomerId != "cust-1" {
+		t.Errorf("unexpected transfer: %+v", transfer)
+	}
+
+	for _, status := range []model.TransferStatus{model.TransferAwaitingProvider, model.TransferReceived, model.TransferCompleted} {
+		if transfer, err = svc.UpdateTransferStatus(transfer.Id, status, ""); err != nil {
+			t.Fatalf("unexpected error moving to %s: %v", status, err)
+		}
+	}
+	if transfer.CompletedAt == nil || len(transfer.History) != 4 {
+		t.Errorf("expected completed transfer with full history, got %+v", transfer)
+	}
+	expectedEvents := []string{
+		"isa.transfer.requested",
+		"isa.transfer.awaiting_ceding_provider",
+		"isa.transfer.received",
+		"investment.status.settled",
+		"isa.transfer.completed",
+	}
+	if diff := cmp.Diff(expectedEvents, published); diff != "" {
+		t.Errorf("unexpected events (-want +got):\n%s", diff)
+	}
+
+	actual, err := allowance.GetAllowance("cust-1")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !actual.Used.Equal(money.Pounds(3000)) || !actual.Products[model.StocksAndSharesISA].TransferredIn.Equal(money.Pounds(3000)) {
+		t.Errorf("expected only current year money to count towards allowance, got %+v", actual)
+	}
+
+	held, err := investments.GetInvestmentsByAccountId("acc-1")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(*held) != 1 || (*held)[0].Type != model.TransferredIn || !(*held)[0].Amount.Equal(money.Pounds(13000)) {
+		t.Errorf("expected a 13000 transfer_in investment, got %+v", *held)
+	}
+	if cash := cashIn(t, ledger, "acc-1"); !cash.Available.Equal(money.Pounds(13000)) {
+		t.Errorf("expected the transferred cash to be credited, got %+v", cash)
+	}
+}
+
+// slowTransfers gives concurrent status changes time to read the same transfer
+type slowTransfers struct {
+	*repository.TransferClient
+}
+
+func (s slowTransfers) GetTransferById(id string) (*model.Transfer, error) {
+	transfer, err := s.TransferClient.GetTransferById(id)
+	time.Sleep(time.Millisecond)
+	return transfer, err
+}
+
+func TestCompleteTransferConcurrently(t *testing.T) {
+	logger := logger.NewMockLogger()
+	investments := repository.NewInvestmentClient()
+	allowance := newAllowanceService(logger)
+	nothing := &mockPublisher{publishFn: func(string, any) error { return nil }}
+	svc := service.NewTransferService(slowTransfers{repository.NewTransferClient()}, newAccountRepo(), investments, nil, allowance, newLedger(logger), service.NewLocks(), nothing, logger)
+
+	transfer, err := svc.RequestTransfer(model.Transfer{
+		AccountId:         "acc-1",
+		Direction:         model.TransferIn,
+		Method:            model.TransferCash,
+		Provider:          "Other Bank",
+		CurrentYearAmount: money.Pounds(3000),
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	for _, status := range []model.TransferStatus{model.TransferAwaitingProvider, model.TransferReceived} {
+		if _, err := svc.UpdateTransferStatus(transfer.Id, status, ""); err != nil {
+			t.Fatalf("unexpected error moving to %s: %v", status, err)
+		}
+	}
+
+	var wg sync.WaitGroup
+	for range 10 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			svc.UpdateTransferStatus(transfer.Id, model.TransferCompleted, "")
+		}()
+	}
+	wg.Wait()
+
+	held, err := investments.GetInvestmentsByAccountId("acc-1")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(*held) != 1 {
+		t.Errorf("expected the transfer to be completed once, got %d investments", len(*held))
+	}
+	actual, err := allowance.GetAllowance("cust-1")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !actual.Used.Equal(money.Pounds(3000)) {
+		t.Errorf("expected 3000 of allowance used once, got %s", actual.Used)
+	}
+}
+
+func TestStockTransferOutLimitedToHolding(t *testing.T) {
+	mockPub := &mockPublisher{
+		publishFn: func(subject string, payload any) error {
+			return nil
+		},
+	}
+	logger := logger.NewMockLogger()
+	investments := repository.NewInvestmentClient()
+	investments.CreateInvestment(model.Investment{
+		Id: "inv-1", AccountId: "acc-1", FundId: "fund-1", Type: model.Purchase, Amount: money.Pounds(1000), Status: model.InvestmentSettled,
+		Dealing: &model.Dealing{Price: 1_000_000, Units: 10_000_000},
+	})
+	svc := service.NewTransferService(repository.NewTransferClient(), newAccountRepo(), investments, fundPriced("1.50"), newAllowanceService(logger), newLedger(logger), service.NewLocks(), mockPub, logger)
+
+	request := model.Transfer{
+		AccountId:       "acc-1",
+		Direction:       model.TransferOut,
+		Method:          model.TransferStock,
+		Provider:        "Other Bank",
+		PriorYearAmount: money.Pounds(1000),
+		Holdings:        []model.TransferHolding{{FundId: "fund-1", Units: 10_000_001}},
+	}
+	if _, err := svc.RequestTransfer(request); !errors.Is(err, internal.ErrInsufficientHolding) {
+		t.Fatalf("expected insufficient holding error, got: %v", err)
+	}
+
+	request.Holdings[0].Units = 6_000_000
+	transfer, err := svc.RequestTransfer(request)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if _, err := svc.RequestTransfer(request); !errors.Is(err, internal.ErrInsufficientHolding) {
+		t.Fatalf("expected the units to be set aside for the first transfer, got: %v", err)
+	}
+	if _, err := svc.UpdateTransferStatus(transfer.Id, model.TransferCompleted, ""); !errors.Is(err, internal.ErrInvalidTransition) {
+		t.Errorf("expected a requested transfer not to complete straight away, got: %v", err)
+	}
+
+	rejected, err := svc.UpdateTransferStatus(transfer.Id, model.TransferRejected, "account details do not match")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if rejected.RejectionReason == nil || *rejected.RejectionReason != "account details do not match" {
+		t.Errorf("expected rejection reason to be kept, got %+v", rejected)
+	}
+	if _, err := svc.UpdateTransferStatus(transfer.Id, model.TransferAwaitingProvider, ""); !errors.Is(err, internal.ErrInvalidTransition) {
+		t.Errorf("expected a rejected transfer to be final, got: %v", err)
+	}
+
+	request.Holdings[0].Units = 10_000_000
+	transfer, err = svc.RequestTransfer(request)
+	if err != nil {
+		t.Fatalf("expected a rejected transfer to free its units, got: %v", err)
+	}
+	for _, status := range []model.TransferStatus{model.TransferAwaitingProvider, model.TransferReceived, model.TransferCompleted} {
+		if _, err := svc.UpdateTransferStatus(transfer.Id, status, ""); err != nil {
+			t.Fatalf("unexpected error moving to %s: %v", status, err)
+		}
+	}
+	held, err := investments.GetInvestmentsByAccountId("acc-1")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	var moved []model.Investment
+	for _, investment := range *held {
+		if investment.TransferId != nil && *investment.TransferId == transfer.Id {
+			moved = append(moved, investment)
+		}
+	}
+	if len(moved) != 1 || moved[0].Status != model.InvestmentSettled || moved[0].Dealing == nil ||
+		moved[0].Dealing.Units != 10_000_000 || !moved[0].Amount.Equal(money.Pounds(1500)) {
+		t.Errorf("expected 1000 units worth 1500 transferred out, got %+v", moved)
+	}
+	if _, err := svc.RequestTransfer(request); !errors.Is(err, internal.ErrInsufficientHolding) {
+		t.Errorf("expected nothing left to transfer, got: %v", err)
+	}
+}
+
+func TestStockTransferIn(t *testing.T) {
+	logger := logger.NewMockLogger()
+	investments := repository.NewInvestmentClient()
+	nothing := &mockPublisher{publishFn: func(string, any) error { return nil }}
+	ledger := newLedger(logger)
+	svc := service.NewTransferService(repository.NewTransferClient(), newAccountRepo(), investments, fundPriced("2.00"), newAllowanceService(logger), ledger, service.NewLocks(), nothing, logger)
+
+	transfer, err := svc.RequestTransfer(model.Transfer{
+		AccountId:         "acc-1",
+		Direction:         model.TransferIn,
+		Method:            model.TransferStock,
+		Provider:          "Other Bank",
+		CurrentYearAmount: money.Pounds(500),
+		PriorYearAmount:   money.Pounds(1500),
+		Holdings: []model.TransferHolding{
+			{FundId: "fund-1", Units: 5_000_000, BookCost: money.Pounds(600)},
+			{FundId: "fund-2", Units: 2_500_000, BookCost: money.Pounds(400)},
+		},
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	for _, status := range []model.TransferStatus{model.TransferAwaitingProvider, model.TransferReceived, model.TransferCompleted} {
+		if _, err := svc.UpdateTransferStatus(transfer.Id, status, ""); err != nil {
+			t.Fatalf("unexpected error moving to %s: %v", status, err)
+		}
+	}
+
+	held, err := investments.GetInvestmentsByAccountId("acc-1")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	units := make(map[string]model.Units)
+	var subscribed money.Money
+	for _, investment := range *held {
+		if investment.Type != model.TransferredIn || investment.Status != model.InvestmentSettled || investment.Dealing == nil {
+			t.Fatalf("expected settled transfer_in investments with units, got %+v", investment)
+		}
+		units[investment.FundId] += investment.Dealing.Units
+		subscribed = subscribed.Add(investment.AllowanceUse.Subscribed)
+	}
+	if diff := cmp.Diff(map[string]model.Units{"fund-1": 5_000_000, "fund-2": 2_500_000}, units); diff != "" {
+		t.Errorf("unexpected units transferred in (-want +got):\n%s", diff)
+	}
+	if !subscribed.Equal(money.Pounds(500)) {
+		t.Errorf("expected the current year subscription to be recorded once, got %s", subscribed)
+	}
+
+	balances, err := ledger.GetBalances("acc-1")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	posted := make(map[string]string)
+	for _, balance := range *balances {
+		posted[balance.Code] = balance.Balance.String()
+	}
+	expected := map[string]string{"external:acc-1": "-1000.00", "holdings:acc-1:fund-1": "600.00", "holdings:acc-1:fund-2": "400.00"}
+	if diff := cmp.Diff(expected, posted); diff != "" {
+		t.Errorf("expected the holdings to be posted to the ledger at their book cost (-want +got):\n%s", diff)
+	}
+}
+
+// flakyInvestments fails to save the first investment in failFund, then recovers
+type flakyInvestments struct {
+	*repository.InvestmentClient
+	failFund string
+	failed   bool
+}
+
+func (f *flakyInvestments) CreateInvestment(investment model.Investment) error {
+	if investment.FundId == f.failFund && !f.failed {
+		f.failed = true
+		return errors.New("database unavailable")
+	}
+	return f.InvestmentClient.CreateInvestment(investment)
+}
+
+func TestRetryStockTransferIn(t *testing.T) {
+	logger := logger.NewMockLogger()
+	investments := &flakyInvestments{InvestmentClient: repository.NewInvestmentClient(), failFund: "fund-2"}
+	allowance := newAllowanceService(logger)
+	nothing := &mockPublisher{publishFn: func(string, any) error { return nil }}
+	svc := service.NewTransferService(repository.NewTransferClient(), newAccountRepo(), investments, fundPriced("2.00"), allowance, newLedger(logger), service.NewLocks(), nothing, logger)
+
+	transfer, err := svc.RequestTransfer(model.Transfer{
+		AccountId:         "acc-1",
+		Direction:         model.TransferIn,
+		Method:            model.TransferStock,
+		Provider:          "Other Bank",
+		CurrentYearAmount: money.Pounds(500),
+		Holdings: []model.TransferHolding{
+			{FundId: "fund-1", Units: 5_000_000, BookCost: money.Pounds(300)},
+			{FundId: "fund-2", Units: 2_500_000, BookCost: money.Pounds(200)},
+		},
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	for _, status := range []model.TransferStatus{model.TransferAwaitingProvider, model.TransferReceived} {
+		if _, err := svc.UpdateTransferStatus(transfer.Id, status, ""); err != nil {
+			t.Fatalf("unexpected error moving to %s: %v", status, err)
+		}
+	}
+	if _, err := svc.UpdateTransferStatus(transfer.Id, model.TransferCompleted, ""); err == nil {
+		t.Fatal("expected the first completion to fail")
+	}
+	actual, err := allowance.GetAllowance("cust-1")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !actual.Used.IsZero() {
+		t.Errorf("expected no allowance used before every holding is recorded, got %s", actual.Used)
+	}
+
+	if _, err := svc.UpdateTransferStatus(transfer.Id, model.TransferCompleted, ""); err != nil {
+		t.Fatalf("unexpected error retrying completion: %v", err)
+	}
+	held, err := investments.GetInvestmentsByAccountId("acc-1")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	units := make(map[string]model.Units)
+	var subscribed money.Money
+	for _, investment := range *held {
+		units[investment.FundId] += investment.Dealing.Units
+		subscribed = subscribed.Add(investment.AllowanceUse.Subscribed)
+	}
+	if diff := cmp.Diff(map[string]model.Units{"fund-1": 5_000_000, "fund-2": 2_500_000}, units); diff != "" {
+		t.Errorf("expected each holding to be credited once (-want +got):\n%s", diff)
+	}
+	if !subscribed.Equal(money.Pounds(500)) {
+		t.Errorf("expected the current year subscription on one holding, got %s", subscribed)
+	}
+	if actual, err = allowance.GetAllowance("cust-1"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !actual.Used.Equal(money.Pounds(500)) {
+		t.Errorf("expected 500 of allowance used once, got %s", actual.Used)
+	}
+}
+
+func TestRequestTransferFailures(t *testing.T) {
+	valid := model.Transfer{
+		AccountId:       "acc-1",
+		Direction:       model.TransferIn,
+		Method:          model.TransferCash,
+		Provider:        "Other Bank",
+		PriorYearAmount: money.Pounds(100),
+	}
+	tests := []struct {
+		name        string
+		modify      func(*model.Transfer)
+		expectedErr error
+	}{
+		{"missing accountId", func(tr *model.Transfer) { tr.AccountId = "" }, internal.ErrMissingAccountId},
+		{"unknown direction", func(tr *model.Transfer) { tr.Direction = "sideways" }, internal.ErrInvalidTransfer},
+		{"unknown method", func(tr *model.Transfer) { tr.Method = "cheque" }, internal.ErrInvalidTransfer},
+		{"missing provider", func(tr *model.Transfer) { tr.Provider = "" }, internal.ErrInvalidTransfer},
+		{"negative amount", func(tr *model.Transfer) { tr.CurrentYearAmount = money.Pounds(-1) }, internal.ErrInvalidTransfer},
+		{"zero amount", func(tr *model.Transfer) { tr.PriorYearAmount = money.Pounds(0) }, internal.ErrZeroTransactionAmount},
+		{"cash transfer with holdings", func(tr *model.Transfer) { tr.Holdings = []model.TransferHolding{{FundId: "fund-1", Units: 1}} }, internal.ErrInvalidTransfer},
+		{"stock transfer without holdings", func(tr *model.Transfer) { tr.Method = model.TransferStock }, internal.ErrInvalidTransfer},
+		{"unknown account", func(tr *model.Transfer) { tr.AccountId = "acc-unknown" }, internal.ErrAccountNotFound},
+		{"closed account", func(tr *model.Transfer) { tr.AccountId = "acc-closed" }, internal.ErrAccountClosed},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			logger := logger.NewMockLogger()
+			svc := service.NewTransferService(repository.NewTransferClient(), newAccountRepo(), repository.NewInvestmentClient(), nil, newAllowanceService(logger), newLedger(logger), service.NewLocks(), nil, logger)
+
+			request := valid
+			tt.modify(&request)
+			transfer, err := svc.RequestTransfer(request)
+			if !errors.Is(err, tt.expectedErr) {
+				t.Fatalf("expected %v, got: %v", tt.expectedErr, err)
+			}
+			if transfer != nil {
+				t.Errorf("expected nil transfer, got: %+v", transfer)
+			}
+		})
+	}
+}
+
+func TestCashTransferOutReservesCash(t *testing.T) {
+	logger := logger.NewMockLogger()
+	ledger := newLedger(logger)
+	payIn(ledger, "acc-1", money.Pounds(1000))
+	nothing := &mockPublisher{publishFn: func(string, any) error { return nil }}
+	svc := service.NewTransferService(repository.NewTransferClient(), newAccountRepo(), repository.NewInvestmentClient(), nil, newAllowanceService(logger), ledger, service.NewLocks(), nothing, logger)
+
+	request := model.Transfer{
+		AccountId:       "acc-1",
+		Direction:       model.TransferOut,
+		Method:          model.TransferCash,
+		Provider:        "Other Bank",
+		PriorYearAmount: money.Pounds(600),
+	}
+	rejected, err := svc.RequestTransfer(request)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if _, err := svc.RequestTransfer(request); !errors.Is(err, internal.ErrInsufficientCash) {
+		t.Fatalf("expected the cash to be reserved for the first transfer, got: %v", err)
+	}
+	if _, err := svc.UpdateTransferStatus(rejected.Id, model.TransferRejected, "account details do not match"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if cash := cashIn(t, ledger, "acc-1"); !cash.Available.Equal(money.Pounds(1000)) || !cash.Reserved.IsZero() {
+		t.Errorf("expected a rejected transfer to free its cash, got %+v", cash)
+	}
+
+	transfer, err := svc.RequestTransfer(request)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	for _, status := range []model.TransferStatus{model.TransferAwaitingProvider, model.TransferReceived, model.TransferCompleted} {
+		if _, err := svc.UpdateTransferStatus(transfer.Id, status, ""); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}
+	if cash := cashIn(t, ledger, "acc-1"); !cash.Balance.Equal(money.Pounds(400)) || !cash.Reserved.IsZero() {
+		t.Errorf("expected 600 paid out of cash once the transfer completes, got %+v", cash)
+	}
+}
@@ -0,0 +1,128 @@
+// Package taxyear models the UK tax year, which runs from 6 April to 5 April in UK local time
+package taxyear
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"time"
+	_ "time/tzdata"
+
+	"github.com/oliknight1/retail-isa-investment/investment-service/money"
+)
+
+var london = mustLoadLocation("Europe/London")
+
+func mustLoadLocation(name string) *time.Location {
+	loc, err := time.LoadLocation(name)
+	if err != nil {
+		panic(fmt.Sprintf("failed to load %s timezone: %v", name, err))
+	}
+	return loc
+}
+
+// InLondon returns t in UK local time, which tax years and other calendar periods are judged by
+func InLondon(t time.Time) time.Time {
+	return t.In(london)
+}
+
+// TaxYear is identified by the calendar year it starts in, so TaxYear(2025) is 6 April 2025 to 5 April 2026
+type TaxYear int
+
+// For returns the tax year t falls in, judged by the time in London rather than t's own location
+func For(t time.Time) TaxYear {
+	local := InLondon(t)
+	year := local.Year()
+	if local.Month() < time.April || (local.Month() == time.April && local.Day() < 6) {
+		year--
+	}
+	return TaxYear(year)
+}
+
+// Start is the first instant of the tax year, midnight on 6 April in London
+func (y TaxYear) Start() time.Time {
+	return time.Date(int(y), time.April, 6, 0, 0, 0, 0, london)
+}
+
+// End is the first instant after the tax year, which is also the start of the next one
+func (y TaxYear) End() time.Time {
+	return y.Next().Start()
+}
+
+func (y TaxYear) Contains(t time.Time) bool {
+	return For(t) == y
+}
+
+func (y TaxYear) Next() TaxYear {
+	return y + 1
+}
+
+func (y TaxYear) Previous() TaxYear {
+	return y - 1
+}
+
+// String formats the tax year the way HMRC does e.g. "2025-26"
+func (y TaxYear) String() string {
+	return fmt.Sprintf("%04d-%02d", int(y), (int(y)+1)%100)
+}
+
+func Parse(s string) (TaxYear, error) {
+	var start, end int
+	if _, err := fmt.Sscanf(s, "%4d-%2d", &start, &end); err != nil || len(s) != 7 {
+		return 0, fmt.Errorf("invalid tax year %q: expected format YYYY-YY", s)
+	}
+	if (start+1)%100 != end {
+		return 0, fmt.Errorf("invalid tax year %q: years must be consecutive", s)
+	}
+	return TaxYear(start), nil
+}
+
+func (y TaxYear) MarshalText() ([]byte, error) {
+	return []byte(y.String()), nil
+}
+
+func (y *TaxYear) UnmarshalText(text []byte) error {
+	parsed, err := Parse(string(text))
+	if err != nil {
+		return err
+	}
+	*y = parsed
+	return nil
+}
+
+// Limits is a table of the overall annual ISA allowance, keyed by the tax year each limit took
+// effect. A limit applies to every following year until a later entry replaces it
+type Limits map[TaxYear]money.Money
+
+// DefaultLimits is the allowance set by HMRC, unchanged at £20,000 since 2017-18
+func DefaultLimits() Limits {
+	return Limits{
+		2017: money.Pounds(20000),
+	}
+}
+
+// LoadLimits reads a limit table from a JSON file of the form {"2017-18": 20000}
+func LoadLimits(path string) (Limits, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	var limits Limits
+	if err := json.NewDecoder(file).Decode(&limits); err != nil {
+		return nil, fmt.Errorf("error decoding allowance limits: %w", err)
+	}
+	return limits, nil
+}
+
+// For returns the allowance in force for y, or zero if the table has no entry on or before it
+func (l Limits) For(y TaxYear) money.Money {
+	effective := TaxYear(-1)
+	for from := range l {
+		if from <= y && from > effective {
+			effective = from
+		}
+	}
+	return l[effective]
+}
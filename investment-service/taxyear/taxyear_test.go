@@ -0,0 +1,134 @@
+package taxyear_test
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/oliknight1/retail-isa-investment/investment-service/money"
+	"github.com/oliknight1/retail-isa-investment/investment-service/taxyear"
+)
+
+func TestForAroundAprilBoundary(t *testing.T) {
+	london, err := time.LoadLocation("Europe/London")
+	if err != nil {
+		t.Fatalf("failed to load location: %v", err)
+	}
+
+	tests := []struct {
+		name     string
+		at       time.Time
+		expected taxyear.TaxYear
+	}{
+		{
+			name:     "last second of 5 April in London",
+			at:       time.Date(2025, time.April, 5, 23, 59, 59, 0, london),
+			expected: 2024,
+		},
+		{
+			name:     "midnight on 6 April in London",
+			at:       time.Date(2025, time.April, 6, 0, 0, 0, 0, london),
+			expected: 2025,
+		},
+		{
+			name:     "UTC evening of 5 April is already 6 April during BST",
+			at:       time.Date(2025, time.April, 5, 23, 30, 0, 0, time.UTC),
+			expected: 2025,
+		},
+		{
+			name:     "UTC evening of 5 April before BST starts",
+			at:       time.Date(2025, time.April, 5, 22, 59, 59, 0, time.UTC),
+			expected: 2024,
+		},
+		{
+			name:     "January belongs to the year that started the previous April",
+			at:       time.Date(2026, time.January, 15, 12, 0, 0, 0, london),
+			expected: 2025,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if actual := taxyear.For(tt.at); actual != tt.expected {
+				t.Errorf("expected %s, got %s", tt.expected, actual)
+			}
+		})
+	}
+}
+
+func TestStartAndEnd(t *testing.T) {
+	y := taxyear.TaxYear(2025)
+
+	if !y.Contains(y.Start()) {
+		t.Errorf("expected %s to contain its start %v", y, y.Start())
+	}
+	if y.Contains(y.End()) {
+		t.Errorf("expected %s not to contain its end %v", y, y.End())
+	}
+	if !y.Contains(y.End().Add(-time.Nanosecond)) {
+		t.Errorf("expected %s to contain the instant before its end", y)
+	}
+	if y.End() != y.Next().Start() {
+		t.Errorf("expected end of %s to be the start of %s", y, y.Next())
+	}
+}
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		input     string
+		expected  taxyear.TaxYear
+		expectErr bool
+	}{
+		{input: "2025-26", expected: 2025},
+		{input: "1999-00", expected: 1999},
+		{input: "2025-27", expectErr: true},
+		{input: "2025", expectErr: true},
+		{input: "2025-26x", expectErr: true},
+		{input: "twenty", expectErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			actual, err := taxyear.Parse(tt.input)
+			if tt.expectErr {
+				if err == nil {
+					t.Errorf("expected error, got %s", actual)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if actual != tt.expected || actual.String() != tt.input {
+				t.Errorf("expected %s, got %s", tt.input, actual)
+			}
+		})
+	}
+}
+
+func TestLimitsFor(t *testing.T) {
+	var limits taxyear.Limits
+	if err := json.Unmarshal([]byte(`{"2014-15": 15000, "2017-18": 20000, "2027-28": 25000}`), &limits); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	tests := []struct {
+		year     taxyear.TaxYear
+		expected money.Money
+	}{
+		{year: 2013, expected: money.Money{}},
+		{year: 2014, expected: money.Pounds(15000)},
+		{year: 2016, expected: money.Pounds(15000)},
+		{year: 2017, expected: money.Pounds(20000)},
+		{year: 2026, expected: money.Pounds(20000)},
+		{year: 2030, expected: money.Pounds(25000)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.year.String(), func(t *testing.T) {
+			if actual := limits.For(tt.year); !actual.Equal(tt.expected) {
+				t.Errorf("expected %s, got %s", tt.expected, actual)
+			}
+		})
+	}
+}